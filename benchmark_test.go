@@ -0,0 +1,72 @@
+package passepartout_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// benchmarkFS has a page using the given number of partials, and a layout with a partial of its own.
+func benchmarkFS(partials int) fstest.MapFS {
+	fsys := fstest.MapFS{
+		"layouts/default.tmpl":      {Data: []byte(`<html>{{ template "layouts/default/_nav.tmpl" }}{{ block "content" . }}{{ end }}</html>`)},
+		"layouts/default/_nav.tmpl": {Data: []byte(`<nav>home</nav>`)},
+	}
+	page := "<h1>{{ .Title }}</h1>"
+	for i := range partials {
+		name := fmt.Sprintf("reviews/index/_item%03d.tmpl", i)
+		fsys[name] = &fstest.MapFile{Data: []byte(`<li>{{ .Title }}</li>`)}
+		page += fmt.Sprintf(`{{ template %q . }}`, name)
+	}
+	fsys["reviews/index.tmpl"] = &fstest.MapFile{Data: []byte(page)}
+
+	return fsys
+}
+
+func BenchmarkRender(b *testing.B) {
+	data := map[string]any{"Title": "Reviews"}
+
+	for _, partials := range []int{1, 100} {
+		fsys := benchmarkFS(partials)
+		for _, tc := range []struct {
+			name string
+			pp   *passepartout.Passepartout
+		}{
+			{name: "without cache", pp: passepartout.New(ppdefaults.NewLoaderBuilder().WithDefaults(fsys).Build())},
+			{
+				name: "with cache",
+				pp: passepartout.New(ppdefaults.NewLoaderBuilder().WithDefaults(fsys).
+					TemplateLoader(ppdefaults.NewCachedLoader(&ppdefaults.TemplateByNameLoader{FS: fsys})).
+					Build()),
+			},
+			{
+				name: "with template cache",
+				pp:   passepartout.New(ppdefaults.NewTemplateCache(ppdefaults.NewLoaderBuilder().WithDefaults(fsys).Build())),
+			},
+		} {
+			pp := tc.pp
+
+			b.Run(fmt.Sprintf("Standalone %s with %d partials", tc.name, partials), func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					if err := pp.Render(io.Discard, "reviews/index.tmpl", data); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+
+			b.Run(fmt.Sprintf("InLayout %s with %d partials", tc.name, partials), func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					if err := pp.RenderInLayout(io.Discard, "layouts/default.tmpl", "reviews/index.tmpl", data); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package passepartout
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// bundleVersion is bumped when the format written by [WriteBundle] changes.
+const bundleVersion = 1
+
+// bundle is the format written by [WriteBundle].
+type bundle struct {
+	Version   int               `json:"version"`
+	Templates map[string]string `json:"templates"`
+}
+
+// WriteBundle writes all the templates in fsys ending with ext to w, so they can be loaded with [LoadBundle] without a
+// filesystem at runtime. Run it at build time, for example with "passepartout bundle", and embed the result.
+func WriteBundle(w io.Writer, fsys fs.FS, ext string) error {
+	b := bundle{Version: bundleVersion, Templates: make(map[string]string)}
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.HasSuffix(name, ext) {
+			return nil
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		b.Templates[name] = string(content)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to collect templates for bundle: %w", err)
+	}
+
+	if err := json.NewEncoder(w).Encode(b); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	return nil
+}
+
+// LoadBundle loads the templates written by [WriteBundle] from r into memory, and renders them following the same
+// conventions as [LoadFrom] without touching a filesystem. [Passepartout.Templates] isn't available for a bundle.
+func LoadBundle(r io.Reader, opts ...Option) (*Passepartout, error) {
+	var b bundle
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	if b.Version != bundleVersion {
+		return nil, fmt.Errorf("failed to read bundle: unsupported version %d, expected %d", b.Version, bundleVersion)
+	}
+
+	p := New(nil, opts...)
+	loader, err := p.buildLoader(ppdefaults.NewLoaderBuilder().WithMap(b.Templates))
+	if err != nil {
+		return nil, err
+	}
+	p.loader.Store(loader)
+
+	return p, nil
+}
@@ -0,0 +1,64 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+func TestLoadBundle(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl":   {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":             {Data: []byte(`{{ template "index/_greeting.tmpl" . }}`)},
+		"index/_greeting.tmpl":   {Data: []byte(`Hello, {{ . }}!`)},
+		"index.tmpl.data/a.json": {Data: []byte(`"fixture"`)},
+	}
+	bundled := new(bytes.Buffer)
+	require.NoError(t, passepartout.WriteBundle(bundled, fs, ".tmpl"))
+
+	t.Run("renders the bundled templates like LoadFrom", func(t *testing.T) {
+		pp, err := passepartout.LoadBundle(bytes.NewReader(bundled.Bytes()))
+		require.NoError(t, err)
+		out := new(bytes.Buffer)
+
+		require.NoError(t, pp.RenderInLayout(out, "layouts/default.tmpl", "index.tmpl", "bundle"))
+		require.Equal(t, "<main>Hello, bundle!</main>", out.String())
+	})
+
+	t.Run("only bundles files with the extension", func(t *testing.T) {
+		require.NotContains(t, bundled.String(), "fixture")
+	})
+
+	t.Run("applies the options", func(t *testing.T) {
+		pp, err := passepartout.LoadBundle(bytes.NewReader(bundled.Bytes()), passepartout.WithExtensions(".tmpl"))
+		require.NoError(t, err)
+		out := new(bytes.Buffer)
+
+		require.NoError(t, pp.Render(out, "index", "options"))
+		require.Equal(t, "Hello, options!", out.String())
+	})
+
+	t.Run("returns an error for a missing template", func(t *testing.T) {
+		pp, err := passepartout.LoadBundle(bytes.NewReader(bundled.Bytes()))
+		require.NoError(t, err)
+
+		require.ErrorContains(t, pp.Render(new(bytes.Buffer), "missing.tmpl", nil), "open missing.tmpl")
+	})
+
+	t.Run("returns an error for an unsupported version", func(t *testing.T) {
+		_, err := passepartout.LoadBundle(strings.NewReader(`{"version": 99, "templates": {}}`))
+
+		require.ErrorContains(t, err, "failed to read bundle: unsupported version 99")
+	})
+
+	t.Run("returns an error when it's not a bundle", func(t *testing.T) {
+		_, err := passepartout.LoadBundle(strings.NewReader(`nope`))
+
+		require.ErrorContains(t, err, "failed to read bundle")
+	})
+}
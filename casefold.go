@@ -0,0 +1,91 @@
+package passepartout
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ambiguousCaseError is returned when [WithCaseInsensitiveLookup] finds more than one file for a name.
+type ambiguousCaseError struct {
+	name    string
+	matches []string
+}
+
+func (e *ambiguousCaseError) Error() string {
+	return fmt.Sprintf("failed to find %q ignoring case: %q differ only by case", e.name, e.matches)
+}
+
+// foldCase returns the name of the file name refers to ignoring case, when configured with
+// [WithCaseInsensitiveLookup]. A name that exists as it is, or that can't be found, is returned as it is.
+func (p *Passepartout) foldCase(name string) string {
+	if !p.caseInsensitive || p.fs == nil {
+		return name
+	}
+	if _, err := fs.Stat(p.fs, name); err == nil {
+		return name
+	}
+
+	folded, err := foldName(p.fs, name)
+	if err != nil {
+		return name
+	}
+
+	return folded
+}
+
+// explainAmbiguousCase replaces the error of loading a template with why it wasn't found ignoring case, when it's
+// because a name matches several files.
+func (p *Passepartout) explainAmbiguousCase(err error, names ...string) error {
+	if err == nil || !p.caseInsensitive || p.fs == nil {
+		return err
+	}
+
+	for _, name := range names {
+		var ambiguous *ambiguousCaseError
+		if _, foldErr := foldName(p.fs, name); errors.As(foldErr, &ambiguous) {
+			return ambiguous
+		}
+	}
+
+	return err
+}
+
+// foldName finds the file name refers to in fsys ignoring case, one folder at a time. When a folder has several
+// entries matching ignoring case the one matching exactly is used, and otherwise it's ambiguous.
+func foldName(fsys fs.FS, name string) (string, error) {
+	found := "."
+	for _, part := range strings.Split(name, "/") {
+		entries, err := fs.ReadDir(fsys, found)
+		if err != nil {
+			return "", err
+		}
+
+		var matches []string
+		for _, entry := range entries {
+			if entry.Name() == part {
+				matches = []string{part}
+				break
+			}
+			if strings.EqualFold(entry.Name(), part) {
+				matches = append(matches, entry.Name())
+			}
+		}
+
+		switch len(matches) {
+		case 0:
+			return "", &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		case 1:
+			found = path.Join(found, matches[0])
+		default:
+			for i, match := range matches {
+				matches[i] = path.Join(found, match)
+			}
+			return "", &ambiguousCaseError{name: name, matches: matches}
+		}
+	}
+
+	return found, nil
+}
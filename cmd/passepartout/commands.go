@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/internal/ppparse"
+	"github.com/gaqzi/passepartout/ppdefaults"
+	"github.com/gaqzi/passepartout/pplint"
+	"github.com/gaqzi/passepartout/ppowners"
+	"github.com/gaqzi/passepartout/pptest"
+)
+
+func validate(args []string, stdout, stderr io.Writer) int {
+	flags, opts := newFlagSet("validate", stderr)
+	strict := flags.Bool("strict", false, "also report partials that are never used and templates that are used but never defined")
+	if _, err := parseArgs(flags, args); err != nil {
+		return 2
+	}
+
+	fsys := os.DirFS(opts.templates)
+	names, err := templateNames(fsys, opts.ext)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	failed := 0
+	for _, name := range names {
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+
+		if _, err := ppparse.Parse(name, string(content)); err != nil {
+			failed++
+			_, _ = fmt.Fprintln(stdout, prefixError(opts.templates, err))
+		}
+	}
+
+	if failed > 0 {
+		_, _ = fmt.Fprintf(stderr, "%d of %d templates failed to parse\n", failed, len(names))
+		return 1
+	}
+
+	if *strict {
+		loader := ppdefaults.NewLoaderBuilder().WithDefaults(fsys).Build()
+		findings := pplint.Strict(loader, names)
+		for _, finding := range findings {
+			_, _ = fmt.Fprintln(stdout, finding)
+		}
+
+		if len(findings) > 0 {
+			_, _ = fmt.Fprintf(stderr, "all %d templates parsed, with %d strict findings\n", len(names), len(findings))
+			return 1
+		}
+	}
+
+	_, _ = fmt.Fprintf(stderr, "all %d templates parsed\n", len(names))
+	return 0
+}
+
+func lint(args []string, stdout, stderr io.Writer) int {
+	flags, opts := newFlagSet("lint", stderr)
+	rulesFlag := flags.String("rules", "", "a comma separated list of the rules to run, all when empty")
+	if _, err := parseArgs(flags, args); err != nil {
+		return 2
+	}
+	rules, err := pplint.ParseRules(*rulesFlag)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	fsys := os.DirFS(opts.templates)
+	names, err := templateNames(fsys, opts.ext)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	loader := ppdefaults.NewLoaderBuilder().WithDefaults(fsys).Build()
+	findings := pplint.Lint(loader, names, rules...)
+	for _, finding := range findings {
+		_, _ = fmt.Fprintf(stdout, "%s\t%s\n", finding.Rule, finding)
+	}
+
+	if len(findings) > 0 {
+		_, _ = fmt.Fprintf(stderr, "%d findings in %d templates\n", len(findings), len(names))
+		return 1
+	}
+
+	_, _ = fmt.Fprintf(stderr, "no findings in %d templates\n", len(names))
+	return 0
+}
+
+// prefixError includes the templates folder in the file name so the output points to the file on disk,
+// followed by the excerpt of where it failed.
+func prefixError(dir string, err error) string {
+	if parseErr, ok := err.(*ppparse.Error); ok {
+		withDir := *parseErr
+		withDir.Name = filepath.Join(dir, filepath.FromSlash(parseErr.Name))
+		if withDir.Excerpt != "" {
+			return withDir.Error() + "\n" + withDir.Excerpt
+		}
+		return withDir.Error()
+	}
+
+	return err.Error()
+}
+
+func list(args []string, stdout, stderr io.Writer) int {
+	flags, opts := newFlagSet("list", stderr)
+	if _, err := parseArgs(flags, args); err != nil {
+		return 2
+	}
+
+	names, err := templateNames(os.DirFS(opts.templates), opts.ext)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	for _, name := range names {
+		_, _ = fmt.Fprintf(stdout, "%s\t%s\n", ppdefaults.KindOf(name), name)
+	}
+
+	return 0
+}
+
+func owners(args []string, stdout, stderr io.Writer) int {
+	flags, opts := newFlagSet("owners", stderr)
+	ownersFile := flags.String("owners", "OWNERS", "a CODEOWNERS-style file within the templates folder, ignored when missing")
+	if _, err := parseArgs(flags, args); err != nil {
+		return 2
+	}
+
+	fsys := os.DirFS(opts.templates)
+	var rules ppowners.Rules
+	content, err := fs.ReadFile(fsys, *ownersFile)
+	switch {
+	case err == nil:
+		rules, err = ppowners.ParseRules(string(content))
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		_, _ = fmt.Fprintf(stderr, "failed to read owners: %s\n", err)
+		return 1
+	}
+
+	entries, err := ppowners.Report(fsys, opts.ext, rules)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	for _, entry := range entries {
+		owned := strings.Join(entry.Owners, " ")
+		if owned == "" {
+			owned = "-"
+		}
+		_, _ = fmt.Fprintf(stdout, "%s\t%s\t%s\n", entry.Kind, entry.Template, owned)
+	}
+
+	return 0
+}
+
+func render(args []string, stdout, stderr io.Writer) int {
+	flags, opts := newFlagSet("render", stderr)
+	layout := flags.String("layout", "", "render the page within this layout")
+	dataFile := flags.String("data", "", "a JSON file with the data to render the page with")
+	fixture := flags.String("fixture", "", "render the page with one of its fixtures, e.g. \"empty\" for <page>.data/empty.json")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 1 {
+		_, _ = fmt.Fprintln(stderr, "usage: passepartout render [flags] <page>")
+		flags.PrintDefaults()
+		return 2
+	}
+	page := positional[0]
+
+	var data any
+	switch {
+	case *dataFile != "" && *fixture != "":
+		_, _ = fmt.Fprintln(stderr, "only one of -data and -fixture can be used")
+		return 2
+	case *fixture != "":
+		data, err = pptest.LoadFixture(os.DirFS(opts.templates), page, *fixture)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+	case *dataFile != "":
+		content, err := os.ReadFile(*dataFile)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "failed to read data: %s\n", err)
+			return 1
+		}
+
+		if err := json.Unmarshal(content, &data); err != nil {
+			_, _ = fmt.Fprintf(stderr, "failed to parse data %q: %s\n", *dataFile, err)
+			return 1
+		}
+	}
+
+	pp, err := passepartout.LoadFrom(os.DirFS(opts.templates))
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if *layout != "" {
+		err = pp.RenderInLayout(stdout, *layout, page, data)
+	} else {
+		err = pp.Render(stdout, page, data)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "failed to render %q: %s\n", page, err)
+		return 1
+	}
+
+	return 0
+}
+
+func golden(args []string, stdout, stderr io.Writer) int {
+	flags, opts := newFlagSet("golden", stderr)
+	layout := flags.String("layout", "", "render the pages within this layout")
+	goldenDir := flags.String("golden", filepath.Join("testdata", "golden"), "the folder with the golden files")
+	dataDir := flags.String("data", "testdata", "the folder with <page>.json data for pages without fixtures")
+	update := flags.Bool("update", false, "write the rendered output as the new golden files")
+	if _, err := parseArgs(flags, args); err != nil {
+		return 2
+	}
+
+	fsys := os.DirFS(opts.templates)
+	cases, err := pptest.Cases(fsys, opts.ext, os.DirFS(*dataDir))
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	pp, err := passepartout.LoadFrom(fsys)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	failed := 0
+	for _, c := range cases {
+		buf := new(bytes.Buffer)
+		if *layout != "" {
+			err = pp.RenderInLayout(buf, *layout, c.Page, c.Data)
+		} else {
+			err = pp.Render(buf, c.Page, c.Data)
+		}
+		if err != nil {
+			failed++
+			_, _ = fmt.Fprintf(stdout, "%s: failed to render: %s\n", c.Name, err)
+			continue
+		}
+
+		file := filepath.Join(*goldenDir, filepath.FromSlash(c.Name)+".golden")
+		if *update {
+			if err := writeFile(file, buf.Bytes()); err != nil {
+				_, _ = fmt.Fprintln(stderr, err)
+				return 1
+			}
+			continue
+		}
+
+		expected, err := os.ReadFile(file)
+		if err != nil {
+			failed++
+			_, _ = fmt.Fprintf(stdout, "%s: missing golden file %s, run with -update to create it\n", c.Name, file)
+			continue
+		}
+
+		if string(expected) != buf.String() {
+			failed++
+			diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				A:        difflib.SplitLines(string(expected)),
+				B:        difflib.SplitLines(buf.String()),
+				FromFile: file,
+				ToFile:   c.Name,
+				Context:  3,
+			})
+			_, _ = fmt.Fprint(stdout, diff)
+		}
+	}
+
+	if failed > 0 {
+		_, _ = fmt.Fprintf(stderr, "%d of %d pages differ from their golden files\n", failed, len(cases))
+		return 1
+	}
+
+	if *update {
+		_, _ = fmt.Fprintf(stderr, "wrote %d golden files\n", len(cases))
+	} else {
+		_, _ = fmt.Fprintf(stderr, "all %d pages match their golden files\n", len(cases))
+	}
+	return 0
+}
+
+func bundle(args []string, stdout, stderr io.Writer) int {
+	flags, opts := newFlagSet("bundle", stderr)
+	output := flags.String("o", "", "the file to write the bundle to, stdout when empty")
+	if _, err := parseArgs(flags, args); err != nil {
+		return 2
+	}
+
+	buf := new(bytes.Buffer)
+	if err := passepartout.WriteBundle(buf, os.DirFS(opts.templates), opts.ext); err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if *output == "" {
+		_, _ = buf.WriteTo(stdout)
+		return 0
+	}
+	if err := writeFile(*output, buf.Bytes()); err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func writeFile(name string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return fmt.Errorf("failed to write %q: %w", name, err)
+	}
+
+	if err := os.WriteFile(name, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write %q: %w", name, err)
+	}
+
+	return nil
+}
+
+func templateNames(fsys fs.FS, ext string) ([]string, error) {
+	var names []string
+	err := fs.WalkDir(fsys, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() && strings.HasSuffix(filePath, ext) {
+			names = append(names, filePath)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find templates: %w", err)
+	}
+
+	return names, nil
+}
@@ -0,0 +1,101 @@
+// Command passepartout validates, lists, and renders templates using passepartout's conventions,
+// so CI and designers can check templates without writing a Go program.
+//
+// Usage:
+//
+//	passepartout validate [-templates dir] [-ext .tmpl] [-strict]
+//	passepartout lint [-templates dir] [-ext .tmpl] [-rules unused-define,raw-html]
+//	passepartout list [-templates dir] [-ext .tmpl]
+//	passepartout owners [-templates dir] [-ext .tmpl] [-owners OWNERS]
+//	passepartout golden [-templates dir] [-ext .tmpl] [-layout layouts/default.tmpl] [-golden testdata/golden] [-data testdata] [-update]
+//	passepartout render [-templates dir] [-layout layouts/default.tmpl] [-data data.json | -fixture name] <page>
+//	passepartout bundle [-templates dir] [-ext .tmpl] [-o bundle.json]
+//	passepartout serve [-templates dir] [-ext .tmpl] [-layout layouts/default.tmpl] [-data fixtures] [-addr localhost:8080]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage: passepartout <command> [flags]
+
+commands:
+  validate  parse every page, layout, and partial and report errors with file:line
+  lint      report unused defines and partials, layouts without content, raw HTML funcs, and inconsistent names
+  list      list every template with its kind
+  owners    list every template with its kind and owners
+  golden    render every page and compare it with its golden file
+  render    render a page to stdout, optionally within a layout and with JSON data
+  bundle    write every template into one file to load with passepartout.LoadBundle
+  serve     serve every page at its path with fixture data, reloading the browser when a template changes
+
+run "passepartout <command> -h" for the flags of a command
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run returns the exit code: 0 on success, 1 when the command failed, and 2 when used incorrectly.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		_, _ = fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	commands := map[string]func(args []string, stdout, stderr io.Writer) int{
+		"validate": validate,
+		"lint":     lint,
+		"list":     list,
+		"owners":   owners,
+		"golden":   golden,
+		"render":   render,
+		"bundle":   bundle,
+		"serve":    serve,
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	return cmd(args[1:], stdout, stderr)
+}
+
+// options are the flags shared by all commands.
+type options struct {
+	templates string
+	ext       string
+}
+
+func newFlagSet(name string, stderr io.Writer) (*flag.FlagSet, *options) {
+	opts := new(options)
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&opts.templates, "templates", "templates", "the folder with the templates")
+	flags.StringVar(&opts.ext, "ext", ".tmpl", "the extension of template files")
+
+	return flags, opts
+}
+
+// parseArgs allows positional arguments before the flags, like "render index.tmpl -data data.json".
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for len(args) > 0 {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = flags.Args()
+		if len(args) > 0 {
+			positional = append(positional, args[0])
+			args = args[1:]
+		}
+	}
+
+	return positional, nil
+}
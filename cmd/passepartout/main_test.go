@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+func writeTemplates(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0o755))
+		require.NoError(t, os.WriteFile(filePath, []byte(content), 0o644))
+	}
+
+	return dir
+}
+
+func runCommand(args ...string) (int, string, string) {
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	code := run(args, stdout, stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	t.Run("prints usage without a command", func(t *testing.T) {
+		code, _, stderr := runCommand()
+
+		require.Equal(t, 2, code)
+		require.Contains(t, stderr, "usage: passepartout")
+	})
+
+	t.Run("fails on unknown commands", func(t *testing.T) {
+		code, _, stderr := runCommand("nope")
+
+		require.Equal(t, 2, code)
+		require.Contains(t, stderr, `unknown command "nope"`)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("succeeds when all templates parse", func(t *testing.T) {
+		dir := writeTemplates(t, map[string]string{
+			"index.tmpl":       `{{ template "index/_item.tmpl" . }}`,
+			"index/_item.tmpl": `{{ customFunc . }}`,
+		})
+
+		code, stdout, stderr := runCommand("validate", "-templates", dir)
+
+		require.Equal(t, 0, code)
+		require.Empty(t, stdout)
+		require.Contains(t, stderr, "all 2 templates parsed")
+	})
+
+	t.Run("reports each broken template with its file, line, and an excerpt", func(t *testing.T) {
+		dir := writeTemplates(t, map[string]string{
+			"index.tmpl":  "ok",
+			"broken.tmpl": "line 1\n{{ if .Missing }}",
+		})
+
+		code, stdout, stderr := runCommand("validate", "-templates", dir)
+
+		require.Equal(t, 1, code)
+		require.Equal(t, filepath.Join(dir, "broken.tmpl")+":2: unexpected EOF\n  1 | line 1\n> 2 | {{ if .Missing }}\n", stdout)
+		require.Contains(t, stderr, "1 of 2 templates failed to parse")
+	})
+
+	t.Run("reports unused partials and undefined templates with -strict", func(t *testing.T) {
+		dir := writeTemplates(t, map[string]string{
+			"index.tmpl":         `{{ template "index/_missing.tmpl" . }}`,
+			"index/_unused.tmpl": "unused",
+		})
+
+		code, stdout, stderr := runCommand("validate", "-strict", "-templates", dir)
+
+		require.Equal(t, 1, code)
+		require.Equal(t, "index.tmpl: template \"index/_missing.tmpl\" is used but never defined\nindex/_unused.tmpl: partial is never used by a page or layout\n", stdout)
+		require.Contains(t, stderr, "with 2 strict findings")
+	})
+}
+
+func TestLint(t *testing.T) {
+	t.Run("succeeds without findings", func(t *testing.T) {
+		dir := writeTemplates(t, map[string]string{
+			"index.tmpl":           `{{ template "index/_item.tmpl" . }}`,
+			"index/_item.tmpl":     `item`,
+			"layouts/default.tmpl": `{{ block "content" . }}{{ end }}`,
+		})
+
+		code, stdout, stderr := runCommand("lint", "-templates", dir)
+
+		require.Equal(t, 0, code, stdout)
+		require.Contains(t, stderr, "no findings in 3 templates")
+	})
+
+	t.Run("reports the findings of the rules given with their rule", func(t *testing.T) {
+		dir := writeTemplates(t, map[string]string{
+			"index.tmpl":           "{{ .Title }}\n{{ safeHTML .Body }}",
+			"layouts/default.tmpl": `layout`,
+		})
+
+		code, stdout, stderr := runCommand("lint", "-templates", dir, "-rules", "raw-html,missing-content")
+
+		require.Equal(t, 1, code)
+		require.Equal(t, "missing-content\tlayouts/default.tmpl: layout never renders the \"content\" block the pages are rendered as\n"+
+			"raw-html\tindex.tmpl:2: safeHTML marks its input as safe so it isn't escaped, make sure the input can be trusted\n", stdout)
+		require.Contains(t, stderr, "2 findings in 2 templates")
+	})
+
+	t.Run("fails on unknown rules", func(t *testing.T) {
+		code, _, stderr := runCommand("lint", "-rules", "nope")
+
+		require.Equal(t, 2, code)
+		require.Contains(t, stderr, `unknown rule "nope"`)
+	})
+}
+
+func TestList(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"layouts/default.tmpl": "",
+		"index.tmpl":           "",
+		"index/_item.tmpl":     "",
+		"style.css":            "",
+	})
+
+	code, stdout, _ := runCommand("list", "-templates", dir)
+
+	require.Equal(t, 0, code)
+	require.Equal(t, "partial\tindex/_item.tmpl\npage\tindex.tmpl\nlayout\tlayouts/default.tmpl\n", stdout)
+}
+
+func TestOwners(t *testing.T) {
+	t.Run("lists the owners from directives and the owners file", func(t *testing.T) {
+		dir := writeTemplates(t, map[string]string{
+			"OWNERS":            "reviews/ @reviews-team\n",
+			"index.tmpl":        `{{/* owner: @home */}}`,
+			"reviews/show.tmpl": "",
+			"about.tmpl":        "",
+		})
+
+		code, stdout, stderr := runCommand("owners", "-templates", dir)
+
+		require.Equal(t, 0, code, stderr)
+		require.Equal(t, "page\tabout.tmpl\t-\npage\tindex.tmpl\t@home\npage\treviews/show.tmpl\t@reviews-team\n", stdout)
+	})
+
+	t.Run("fails on an invalid owners file", func(t *testing.T) {
+		dir := writeTemplates(t, map[string]string{"OWNERS": "[ @broken\n"})
+
+		code, _, stderr := runCommand("owners", "-templates", dir)
+
+		require.Equal(t, 1, code)
+		require.Contains(t, stderr, "failed to parse owners line 1")
+	})
+}
+
+func TestGolden(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"templates/index.tmpl":                `{{ range .Items }}{{ . }}{{ end }}`,
+		"templates/index.tmpl.data/full.json": `{"Items": ["a", "b"]}`,
+		"templates/about.tmpl":                "about {{ .Name }}\n",
+		"testdata/about.tmpl.json":            `{"Name": "us"}`,
+	})
+	t.Chdir(dir)
+
+	code, _, stderr := runCommand("golden", "-update")
+	require.Equal(t, 0, code, stderr)
+	require.Contains(t, stderr, "wrote 2 golden files")
+
+	code, stdout, stderr := runCommand("golden")
+	require.Equal(t, 0, code, stdout)
+	require.Contains(t, stderr, "all 2 pages match their golden files")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "templates", "about.tmpl"), []byte("about {{ .Name }}!\n"), 0o644))
+	code, stdout, stderr = runCommand("golden")
+	require.Equal(t, 1, code)
+	require.Contains(t, stdout, "-about us\n+about us!\n")
+	require.Contains(t, stderr, "1 of 2 pages differ from their golden files")
+}
+
+func TestRender(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"layouts/default.tmpl":           `<main>{{ block "content" . }}{{ end }}</main>`,
+		"index.tmpl":                     `Hello, {{ .Name }}!`,
+		"index.tmpl.data/long-name.json": `{"Name": "Wolfeschlegelsteinhausenbergerdorff"}`,
+	})
+	dataFile := filepath.Join(t.TempDir(), "data.json")
+	require.NoError(t, os.WriteFile(dataFile, []byte(`{"Name": "World"}`), 0o644))
+
+	t.Run("renders the page with the data", func(t *testing.T) {
+		code, stdout, stderr := runCommand("render", "index.tmpl", "-templates", dir, "-data", dataFile)
+
+		require.Equal(t, 0, code, stderr)
+		require.Equal(t, "Hello, World!", stdout)
+	})
+
+	t.Run("renders the page within a layout", func(t *testing.T) {
+		code, stdout, stderr := runCommand("render", "-templates", dir, "-layout", "layouts/default.tmpl", "-data", dataFile, "index.tmpl")
+
+		require.Equal(t, 0, code, stderr)
+		require.Equal(t, "<main>Hello, World!</main>", stdout)
+	})
+
+	t.Run("renders the page with one of its fixtures", func(t *testing.T) {
+		code, stdout, stderr := runCommand("render", "-templates", dir, "-fixture", "long-name", "index.tmpl")
+
+		require.Equal(t, 0, code, stderr)
+		require.Equal(t, "Hello, Wolfeschlegelsteinhausenbergerdorff!", stdout)
+	})
+
+	t.Run("fails when the fixture doesn't exist", func(t *testing.T) {
+		code, _, stderr := runCommand("render", "-templates", dir, "-fixture", "missing", "index.tmpl")
+
+		require.Equal(t, 1, code)
+		require.Contains(t, stderr, `failed to read fixture "missing" for "index.tmpl"`)
+	})
+
+	t.Run("fails when the page doesn't exist", func(t *testing.T) {
+		code, _, stderr := runCommand("render", "-templates", dir, "missing.tmpl")
+
+		require.Equal(t, 1, code)
+		require.Contains(t, stderr, `failed to render "missing.tmpl"`)
+	})
+
+	t.Run("requires a page", func(t *testing.T) {
+		code, _, stderr := runCommand("render", "-templates", dir)
+
+		require.Equal(t, 2, code)
+		require.Contains(t, stderr, "usage: passepartout render")
+	})
+}
+
+func TestBundle(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"index.tmpl":           `{{ template "index/_greeting.tmpl" . }}`,
+		"index/_greeting.tmpl": `Hello, {{ . }}!`,
+	})
+	output := filepath.Join(t.TempDir(), "bundle.json")
+
+	code, _, stderr := runCommand("bundle", "-templates", dir, "-o", output)
+
+	require.Equal(t, 0, code, stderr)
+	f, err := os.Open(output)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	pp, err := passepartout.LoadBundle(f)
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	require.NoError(t, pp.Render(buf, "index.tmpl", "bundle"))
+	require.Equal(t, "Hello, bundle!", buf.String())
+}
+
+func TestServe(t *testing.T) {
+	serve := func(t *testing.T, dir string, data string) *httptest.Server {
+		t.Helper()
+		var dataFS fs.FS
+		if data != "" {
+			dataFS = os.DirFS(data)
+		}
+		srv, err := newDevServer(os.DirFS(dir), ".tmpl", "", dataFS, 10*time.Millisecond)
+		require.NoError(t, err)
+		ts := httptest.NewServer(srv)
+		t.Cleanup(ts.Close)
+
+		return ts
+	}
+	get := func(t *testing.T, url string) (int, string) {
+		t.Helper()
+		res, err := http.Get(url)
+		require.NoError(t, err)
+		defer func() { _ = res.Body.Close() }()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		return res.StatusCode, string(body)
+	}
+
+	dir := writeTemplates(t, map[string]string{
+		"index.tmpl":                         `<body>Hello, {{ .name }}!</body>`,
+		"reviews/show.tmpl":                  `Review {{ .id }}`,
+		"reviews/show.tmpl.data/second.json": `{"id": 2}`,
+		"reviews/show/_stars.tmpl":           `stars`,
+	})
+	data := writeTemplates(t, map[string]string{"index.tmpl.json": `{"name": "data"}`})
+
+	t.Run("serves the pages at their paths with their data and the reload script", func(t *testing.T) {
+		ts := serve(t, dir, data)
+
+		status, body := get(t, ts.URL+"/")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, `<body>Hello, data!`+passepartout.LiveReloadScript+`</body>`, body)
+
+		status, body = get(t, ts.URL+"/reviews/show")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, `Review `+passepartout.LiveReloadScript, body)
+	})
+
+	t.Run("renders with the fixture picked in the query", func(t *testing.T) {
+		status, body := get(t, serve(t, dir, "").URL+"/reviews/show?fixture=second")
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, `Review 2`+passepartout.LiveReloadScript, body)
+	})
+
+	t.Run("lists the pages when there's no page at the path", func(t *testing.T) {
+		ts := serve(t, dir, "")
+
+		for _, path := range []string{"/missing", "/reviews/show/_stars"} {
+			status, body := get(t, ts.URL+path)
+			require.Equal(t, http.StatusNotFound, status)
+			require.Contains(t, body, `<a href="/reviews/show">reviews/show.tmpl</a> <a href="/reviews/show?fixture=second">second</a>`)
+			require.NotContains(t, body, `href="/reviews/show/_stars`)
+		}
+	})
+
+	t.Run("sends a reload event when a template changes", func(t *testing.T) {
+		dir := writeTemplates(t, map[string]string{"index.tmpl": `before`})
+		ts := serve(t, dir, "")
+
+		res, err := http.Get(ts.URL + passepartout.LiveReloadPath)
+		require.NoError(t, err)
+		defer func() { _ = res.Body.Close() }()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "index.tmpl"), []byte(`after`), 0o644))
+
+		event, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, "data: reload\n\n", string(event))
+
+		_, body := get(t, ts.URL+"/")
+		require.Equal(t, "after"+passepartout.LiveReloadScript, body)
+	})
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/ppdefaults"
+	"github.com/gaqzi/passepartout/pptest"
+)
+
+func serve(args []string, stdout, stderr io.Writer) int {
+	flags, opts := newFlagSet("serve", stderr)
+	addr := flags.String("addr", "localhost:8080", "the address to listen on")
+	layout := flags.String("layout", "", "render the pages within this layout")
+	dataDir := flags.String("data", "", "a folder with <page>.json data for the pages, used when no fixture is picked")
+	poll := flags.Duration("poll", 500*time.Millisecond, "how often to check the templates for changes to reload the browser")
+	if _, err := parseArgs(flags, args); err != nil {
+		return 2
+	}
+
+	var data fs.FS
+	if *dataDir != "" {
+		data = os.DirFS(*dataDir)
+	}
+	srv, err := newDevServer(os.DirFS(opts.templates), opts.ext, *layout, data, *poll)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	_, _ = fmt.Fprintf(stderr, "serving %s at http://%s\n", opts.templates, *addr)
+	if err := http.ListenAndServe(*addr, srv); err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+// devServer serves every page at its path, "/reviews/show" for "reviews/show.tmpl" and "/" for "index.tmpl",
+// rendered with the fixture picked with "?fixture=name" or its data file. The templates are read on every request
+// and the pages reload when the templates change.
+type devServer struct {
+	templates fs.FS
+	ext       string
+	layout    string
+	data      fs.FS
+	pp        *passepartout.Passepartout
+	reload    http.Handler
+}
+
+func newDevServer(templates fs.FS, ext, layout string, data fs.FS, poll time.Duration) (*devServer, error) {
+	pp, err := passepartout.LoadFrom(templates, passepartout.WithLiveReload(poll))
+	if err != nil {
+		return nil, err
+	}
+
+	return &devServer{templates: templates, ext: ext, layout: layout, data: data, pp: pp, reload: pp.LiveReload()}, nil
+}
+
+func (s *devServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == passepartout.LiveReloadPath {
+		s.reload.ServeHTTP(w, r)
+		return
+	}
+
+	page := s.page(r.URL.Path)
+	if ppdefaults.KindOf(page) != ppdefaults.KindPage || !s.pp.Has(page) {
+		s.notFound(w, page)
+		return
+	}
+
+	data, err := s.pageData(page, r.URL.Query().Get("fixture"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	if s.layout != "" {
+		err = s.pp.RenderInLayoutContext(r.Context(), buf, s.layout, page, data)
+	} else {
+		err = s.pp.RenderContext(r.Context(), buf, page, data)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to render %q: %s", page, err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = buf.WriteTo(w)
+}
+
+// page is the template for urlPath, index.tmpl for folders.
+func (s *devServer) page(urlPath string) string {
+	name := strings.TrimPrefix(urlPath, "/")
+	if name == "" || strings.HasSuffix(name, "/") {
+		name += "index"
+	}
+	if path.Ext(name) == "" {
+		name += s.ext
+	}
+
+	return name
+}
+
+func (s *devServer) pageData(page, fixture string) (any, error) {
+	if fixture != "" {
+		return pptest.LoadFixture(s.templates, page, fixture)
+	}
+	if s.data == nil {
+		return nil, nil
+	}
+
+	name := page + pptest.FixtureExt
+	content, err := fs.ReadFile(s.data, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read data for %q: %w", page, err)
+	}
+
+	var data any
+	if err := json.Unmarshal(content, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse data %q: %w", name, err)
+	}
+
+	return data, nil
+}
+
+var notFoundTemplate = template.Must(template.New("not-found").Parse(`<!doctype html>
+<title>Not found</title>
+<p>No page {{ .Page }}, the pages are:</p>
+<ul>
+{{- range $page := .Pages }}
+<li><a href="/{{ .Path }}">{{ .Page }}</a>{{ range .Fixtures }} <a href="/{{ $page.Path }}?fixture={{ . }}">{{ . }}</a>{{ end }}</li>
+{{- end }}
+</ul>
+`))
+
+type listedPage struct {
+	Page     string
+	Path     string
+	Fixtures []string
+}
+
+// notFound lists every page with its fixtures.
+func (s *devServer) notFound(w http.ResponseWriter, page string) {
+	names, err := templateNames(s.templates, s.ext)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var pages []listedPage
+	for _, name := range names {
+		if ppdefaults.KindOf(name) != ppdefaults.KindPage {
+			continue
+		}
+
+		fixtures, err := pptest.Fixtures(s.templates, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		listed := listedPage{Page: name, Path: strings.TrimSuffix(name, s.ext)}
+		for _, f := range fixtures {
+			listed.Fixtures = append(listed.Fixtures, f.Name)
+		}
+		pages = append(pages, listed)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := notFoundTemplate.Execute(buf, map[string]any{"Page": page, "Pages": pages}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+	_, _ = buf.WriteTo(w)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"sort"
+	"strings"
+	"text/template/parse"
+	"unicode"
+
+	"github.com/gaqzi/passepartout/internal/ppparse"
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// page is a page template and what's generated for it.
+type page struct {
+	Template string
+	Name     string
+	Fields   []string
+}
+
+func generate(fsys fs.FS, ext, pkg string) ([]byte, error) {
+	var pages []page
+	seen := make(map[string]string)
+	err := fs.WalkDir(fsys, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || !strings.HasSuffix(filePath, ext) || ppdefaults.KindOf(filePath) != ppdefaults.KindPage {
+			return nil
+		}
+
+		content, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return err
+		}
+
+		file, err := ppparse.Parse(filePath, string(content))
+		if err != nil {
+			return err
+		}
+
+		name := identifier(strings.TrimSuffix(filePath, ext))
+		if other, ok := seen[name]; ok {
+			return fmt.Errorf("both %q and %q would generate %s", other, filePath, name)
+		}
+		seen[name] = filePath
+
+		pages = append(pages, page{Template: filePath, Name: name, Fields: dataFields(file.Trees[filePath])})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pages: %w", err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := source.Execute(buf, map[string]any{"Package": pkg, "Pages": pages}); err != nil {
+		return nil, fmt.Errorf("failed to generate: %w", err)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+
+	return src, nil
+}
+
+// identifier turns a template path like "reviews/show-item" into an exported Go identifier like "ReviewsShowItem".
+func identifier(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	id := b.String()
+	if id == "" || !unicode.IsLetter(rune(id[0])) {
+		id = "P" + id
+	}
+
+	return id
+}
+
+// dataFields returns the fields read from the data the template is rendered with, sorted.
+// Only fields of the top-level dot or "$" are found, not fields read within range or with where dot has changed.
+func dataFields(tree *parse.Tree) []string {
+	seen := make(map[string]struct{})
+	collectFields(tree.Root, true, seen)
+
+	fields := make([]string, 0, len(seen))
+	for field := range seen {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+// collectFields walks the bodies of range and with on its own, as dot isn't the data within them.
+func collectFields(node parse.Node, topLevel bool, seen map[string]struct{}) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		for _, child := range n.Nodes {
+			collectFields(child, topLevel, seen)
+		}
+	case *parse.IfNode:
+		collectBranch(&n.BranchNode, topLevel, topLevel, seen)
+	case *parse.RangeNode:
+		collectBranch(&n.BranchNode, topLevel, false, seen)
+	case *parse.WithNode:
+		collectBranch(&n.BranchNode, topLevel, false, seen)
+	default:
+		ppparse.Walk(node, func(n parse.Node) {
+			switch n := n.(type) {
+			case *parse.FieldNode:
+				if topLevel {
+					seen[n.Ident[0]] = struct{}{}
+				}
+			case *parse.VariableNode:
+				if n.Ident[0] == "$" && len(n.Ident) > 1 {
+					seen[n.Ident[1]] = struct{}{}
+				}
+			}
+		})
+	}
+}
+
+func collectBranch(n *parse.BranchNode, topLevel, inBody bool, seen map[string]struct{}) {
+	collectFields(n.Pipe, topLevel, seen)
+	if n.List != nil {
+		collectFields(n.List, inBody, seen)
+	}
+	if n.ElseList != nil {
+		collectFields(n.ElseList, topLevel, seen)
+	}
+}
@@ -0,0 +1,59 @@
+// Command ppgen generates type-safe render functions for the pages in a templates folder,
+// so template names and the fields a page uses are checked at compile time.
+//
+// Each page gets a data struct with the fields the page reads from its data, and a render method:
+//
+//	func (t *Templates) RenderReviewsIndex(w io.Writer, data ReviewsIndexData) error
+//
+// The fields are typed any, as templates don't declare what type they expect.
+//
+// Usage:
+//
+//	ppgen [-templates templates] [-ext .tmpl] [-package templates] [-o templates_gen.go]
+//
+// Or from a go:generate directive:
+//
+//	//go:generate go run github.com/gaqzi/passepartout/cmd/ppgen -templates templates -o templates_gen.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run returns the exit code: 0 on success, 1 when generating failed, and 2 when used incorrectly.
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("ppgen", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	templates := flags.String("templates", "templates", "the folder with the templates")
+	ext := flags.String("ext", ".tmpl", "the extension of template files")
+	pkg := flags.String("package", "templates", "the package name of the generated file")
+	out := flags.String("o", "", "the file to write, writes to stdout when empty")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	src, err := generate(os.DirFS(*templates), *ext, *pkg)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if *out == "" {
+		_, _ = stdout.Write(src)
+		return 0
+	}
+
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		_, _ = fmt.Fprintf(stderr, "failed to write %q: %s\n", *out, err)
+		return 1
+	}
+
+	return 0
+}
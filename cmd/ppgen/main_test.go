@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Run("generates a data struct and render methods for each page", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"layouts/default.tmpl":    {Data: []byte(`{{ .LayoutOnly }}`)},
+			"reviews/index.tmpl":      {Data: []byte(`{{ .Title }}{{ range .Reviews }}{{ .Body }}{{ $.Author }}{{ else }}{{ .Empty }}{{ end }}{{ if .Draft }}{{ .Note }}{{ end }}`)},
+			"reviews/index/_row.tmpl": {Data: []byte(`{{ .PartialOnly }}`)},
+			"404.tmpl":                {Data: []byte(`not found`)},
+			"style.css":               {Data: []byte(`body {}`)},
+		}
+
+		src, err := generate(fsys, ".tmpl", "views")
+
+		require.NoError(t, err)
+		require.Equal(t, `// Code generated by ppgen. DO NOT EDIT.
+
+package views
+
+import (
+	"io"
+
+	"github.com/gaqzi/passepartout"
+)
+
+// Templates renders the pages with type-safe data.
+type Templates struct {
+	pp *passepartout.Passepartout
+}
+
+// New returns Templates rendering with pp.
+func New(pp *passepartout.Passepartout) *Templates {
+	return &Templates{pp: pp}
+}
+
+// P404Data is the data used by "404.tmpl".
+type P404Data struct {
+}
+
+// RenderP404 renders "404.tmpl".
+func (t *Templates) RenderP404(w io.Writer, data P404Data) error {
+	return t.pp.Render(w, "404.tmpl", data)
+}
+
+// RenderP404InLayout renders "404.tmpl" within layout.
+func (t *Templates) RenderP404InLayout(w io.Writer, layout string, data P404Data) error {
+	return t.pp.RenderInLayout(w, layout, "404.tmpl", data)
+}
+
+// ReviewsIndexData is the data used by "reviews/index.tmpl".
+type ReviewsIndexData struct {
+	Author  any
+	Draft   any
+	Empty   any
+	Note    any
+	Reviews any
+	Title   any
+}
+
+// RenderReviewsIndex renders "reviews/index.tmpl".
+func (t *Templates) RenderReviewsIndex(w io.Writer, data ReviewsIndexData) error {
+	return t.pp.Render(w, "reviews/index.tmpl", data)
+}
+
+// RenderReviewsIndexInLayout renders "reviews/index.tmpl" within layout.
+func (t *Templates) RenderReviewsIndexInLayout(w io.Writer, layout string, data ReviewsIndexData) error {
+	return t.pp.RenderInLayout(w, layout, "reviews/index.tmpl", data)
+}
+`, string(src))
+	})
+
+	t.Run("fails when two pages would generate the same name", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"show-item.tmpl": {Data: []byte(``)},
+			"show_item.tmpl": {Data: []byte(``)},
+		}
+
+		_, err := generate(fsys, ".tmpl", "views")
+
+		require.ErrorContains(t, err, `both "show-item.tmpl" and "show_item.tmpl" would generate ShowItem`)
+	})
+
+	t.Run("fails on a page that doesn't parse", func(t *testing.T) {
+		_, err := generate(fstest.MapFS{"index.tmpl": {Data: []byte(`{{ if }}`)}}, ".tmpl", "views")
+
+		require.ErrorContains(t, err, "index.tmpl:1")
+	})
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.tmpl"), []byte(`{{ .Name }}`), 0o644))
+	out := filepath.Join(t.TempDir(), "templates_gen.go")
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+
+	code := run([]string{"-templates", dir, "-package", "views", "-o", out}, stdout, stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	src, err := os.ReadFile(out)
+	require.NoError(t, err)
+	require.Contains(t, string(src), "func (t *Templates) RenderIndex(w io.Writer, data IndexData) error")
+}
@@ -0,0 +1,41 @@
+package main
+
+import "text/template"
+
+var source = template.Must(template.New("source").Parse(`// Code generated by ppgen. DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"io"
+
+	"github.com/gaqzi/passepartout"
+)
+
+// Templates renders the pages with type-safe data.
+type Templates struct {
+	pp *passepartout.Passepartout
+}
+
+// New returns Templates rendering with pp.
+func New(pp *passepartout.Passepartout) *Templates {
+	return &Templates{pp: pp}
+}
+{{ range .Pages }}
+// {{ .Name }}Data is the data used by {{ printf "%q" .Template }}.
+type {{ .Name }}Data struct {
+{{- range .Fields }}
+	{{ . }} any
+{{- end }}
+}
+
+// Render{{ .Name }} renders {{ printf "%q" .Template }}.
+func (t *Templates) Render{{ .Name }}(w io.Writer, data {{ .Name }}Data) error {
+	return t.pp.Render(w, {{ printf "%q" .Template }}, data)
+}
+
+// Render{{ .Name }}InLayout renders {{ printf "%q" .Template }} within layout.
+func (t *Templates) Render{{ .Name }}InLayout(w io.Writer, layout string, data {{ .Name }}Data) error {
+	return t.pp.RenderInLayout(w, layout, {{ printf "%q" .Template }}, data)
+}
+{{ end }}`))
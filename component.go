@@ -0,0 +1,201 @@
+package passepartout
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// ComponentData is what a component is rendered with by [Passepartout.RenderComponent].
+type ComponentData struct {
+	// Props are the props passed to RenderComponent.
+	Props any
+	// Children is the content passed with [Children], rendered with {{ .Children }}.
+	Children template.HTML
+	// Slots is the content passed with [Slot] by name, rendered with {{ .Slots.footer }}.
+	Slots map[string]template.HTML
+}
+
+// ComponentOption passes content to a component's slots.
+type ComponentOption func(data *ComponentData)
+
+// Children passes already rendered content to the component, like the body of a card.
+func Children(content template.HTML) ComponentOption {
+	return func(data *ComponentData) {
+		data.Children = content
+	}
+}
+
+// Slot passes already rendered content to the component's slot called name.
+func Slot(name string, content template.HTML) ComponentOption {
+	return func(data *ComponentData) {
+		if data.Slots == nil {
+			data.Slots = make(map[string]template.HTML)
+		}
+		data.Slots[name] = content
+	}
+}
+
+// RenderComponent renders the component name, by convention in "components/", with [ComponentData] holding props
+// and the content of its slots. A component is loaded like a page, with the partials in the folder named after it.
+// The error template isn't rendered for components, since they're usually part of a larger page.
+func (p *Passepartout) RenderComponent(out io.Writer, name string, props any, opts ...ComponentOption) error {
+	return p.RenderComponentContext(context.Background(), out, name, props, opts...)
+}
+
+// RenderComponentContext is [Passepartout.RenderComponent] with ctx passed on to the loader like
+// [Passepartout.RenderContext].
+func (p *Passepartout) RenderComponentContext(ctx context.Context, out io.Writer, name string, props any, opts ...ComponentOption) error {
+	data := ComponentData{Props: props}
+	for _, opt := range opts {
+		opt(&data)
+	}
+
+	return p.render(ctx, out, name, data)
+}
+
+// componentAction matches {{ component "name" props }} and {{ end_component }}, with their trim markers.
+var componentAction = regexp.MustCompile(`\{\{(- )?\s*(component|end_component)\b(.*?)( -)?\}\}`)
+
+// createTemplate is [ppdefaults.CreateTemplate] with the partials of the extensions added to files, see
+// [Extender.Partials], the components rewritten by rewriteComponents and the component funcs bound to the created
+// template, see [WithComponents], the fragments rewritten by rewriteFragmentCaches, see [WithFragmentCache], and the
+// field chains rewritten by rewriteNilSafe, see [WithNilSafeFields].
+func (p *Passepartout) createTemplate(base *template.Template, files []ppdefaults.FileWithContent) (*template.Template, error) {
+	files, err := p.withPartials(files)
+	if err != nil {
+		return nil, err
+	}
+
+	rewritten := make([]ppdefaults.FileWithContent, len(files))
+	for i, file := range files {
+		rewritten[i] = file
+		if p.components {
+			if rewritten[i], err = rewriteComponents(rewritten[i]); err != nil {
+				return nil, err
+			}
+		}
+		if p.fragmentCache != nil {
+			if rewritten[i], err = rewriteFragmentCaches(rewritten[i]); err != nil {
+				return nil, err
+			}
+		}
+		if p.nilSafe {
+			if rewritten[i], err = rewriteNilSafe(rewritten[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	tmplt, err := ppdefaults.CreateTemplate(base, rewritten)
+	if err != nil {
+		return nil, err
+	}
+	if p.fragmentCache != nil {
+		tmplt = tmplt.Funcs(recoverFuncs(fragmentCacheFuncs(p.fragmentCache, tmplt)))
+	}
+	if !p.components {
+		return tmplt, nil
+	}
+
+	var ext string
+	if len(files) > 0 {
+		ext = path.Ext(files[len(files)-1].Name)
+	}
+
+	return tmplt.Funcs(recoverFuncs(p.componentFuncs(tmplt, ext))), nil
+}
+
+// rewriteComponents turns every {{ component }}, which is always ended by {{ end_component }}, into a block that's only
+// defined and a call to componentWithChildren that renders the block with the same data as the children.
+// Only the actions are replaced, so the lines don't move and parse errors point at where the template was written.
+func rewriteComponents(file ppdefaults.FileWithContent) (ppdefaults.FileWithContent, error) {
+	matches := componentAction.FindAllStringSubmatchIndex(file.Content, -1)
+	if matches == nil {
+		return file, nil
+	}
+
+	group := func(match []int, n int) string {
+		if match[2*n] < 0 {
+			return ""
+		}
+		return file.Content[match[2*n]:match[2*n+1]]
+	}
+
+	var b strings.Builder
+	var opened []int
+	last := 0
+	for i, match := range matches {
+		b.WriteString(file.Content[last:match[0]])
+		last = match[1]
+
+		if group(match, 2) == "component" {
+			opened = append(opened, i)
+			fmt.Fprintf(&b, `{{%sif false }}{{ block %q .%s}}`, group(match, 1), componentSlot(file.Name, i), group(match, 4))
+			continue
+		}
+		if len(opened) == 0 {
+			return file, fmt.Errorf("failed to parse template %q: end_component without a component", file.Name)
+		}
+
+		open := opened[len(opened)-1]
+		opened = opened[:len(opened)-1]
+		fmt.Fprintf(&b, `{{%send }}{{ end }}{{ componentWithChildren %q . %s%s}}`,
+			group(match, 1), componentSlot(file.Name, open), strings.TrimSpace(group(matches[open], 3)), group(match, 4))
+	}
+	if len(opened) > 0 {
+		return file, fmt.Errorf("failed to parse template %q: component without an end_component", file.Name)
+	}
+	b.WriteString(file.Content[last:])
+
+	file.Content = b.String()
+
+	return file, nil
+}
+
+// componentSlot is the name of the block rewriteComponents defines for the children of the nth component action.
+func componentSlot(name string, n int) string {
+	return fmt.Sprintf("%s:component%d", name, n)
+}
+
+// componentFuncs renders components with [Passepartout.RenderComponent] from templates, where the children are
+// rendered from the block rewriteComponents defined in tmplt. Names without an extension get ext.
+func (p *Passepartout) componentFuncs(tmplt *template.Template, ext string) template.FuncMap {
+	return template.FuncMap{
+		"componentWithChildren": func(slot string, data any, name string, props ...any) (template.HTML, error) {
+			if len(props) > 1 {
+				return "", fmt.Errorf("failed to render component %q: takes one props argument, got %d", name, len(props))
+			}
+			var componentProps any
+			if len(props) == 1 {
+				componentProps = props[0]
+			}
+
+			children := new(bytes.Buffer)
+			if err := tmplt.ExecuteTemplate(children, slot, data); err != nil {
+				return "", fmt.Errorf("failed to render children of component %q: %w", name, err)
+			}
+
+			if !strings.HasPrefix(name, "components/") {
+				name = "components/" + name
+			}
+			if len(p.extensions) == 0 && path.Ext(name) == "" {
+				name += ext
+			}
+
+			out := new(bytes.Buffer)
+			if err := p.RenderComponent(out, name, componentProps, Children(template.HTML(children.String()))); err != nil {
+				return "", err
+			}
+
+			return template.HTML(out.String()), nil
+		},
+	}
+}
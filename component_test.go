@@ -0,0 +1,135 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+func TestPassepartout_RenderComponent(t *testing.T) {
+	pp, err := passepartout.LoadFrom(fstest.MapFS{
+		"components/button.tmpl":        {Data: []byte(`<button class="{{ template "components/button/_class.tmpl" .Props }}">{{ .Props.Label }}</button>`)},
+		"components/button/_class.tmpl": {Data: []byte(`btn-{{ .Kind }}`)},
+		"components/card.tmpl":          {Data: []byte(`<div>{{ .Children }}<footer>{{ .Slots.footer }}</footer></div>`)},
+	})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		render   func(out *bytes.Buffer) error
+		expected string
+	}{
+		{
+			name: "renders the component with its props and its partials",
+			render: func(out *bytes.Buffer) error {
+				return pp.RenderComponent(out, "components/button.tmpl", map[string]string{"Label": "Save", "Kind": "primary"})
+			},
+			expected: `<button class="btn-primary">Save</button>`,
+		},
+		{
+			name: "renders the children and slots without escaping them",
+			render: func(out *bytes.Buffer) error {
+				return pp.RenderComponent(out, "components/card.tmpl", nil,
+					passepartout.Children("<p>Body</p>"),
+					passepartout.Slot("footer", "<a>More</a>"),
+				)
+			},
+			expected: `<div><p>Body</p><footer><a>More</a></footer></div>`,
+		},
+		{
+			name:     "renders empty slots when no content is passed",
+			render:   func(out *bytes.Buffer) error { return pp.RenderComponent(out, "components/card.tmpl", nil) },
+			expected: `<div><footer></footer></div>`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+
+			require.NoError(t, tc.render(out))
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func TestWithComponents(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl":   {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"components/button.tmpl": {Data: []byte(`<button>{{ .Props }}</button>`)},
+		"components/card.tmpl":   {Data: []byte(`<div class="card">{{ .Children }}</div>`)},
+		"index.tmpl": {Data: []byte(`{{ range .Items -}}
+{{ component "card" . -}}
+  <h2>{{ . }}</h2>
+  {{- component "button" "Open" }}{{ end_component -}}
+{{ end_component }}
+{{ end }}`)},
+		"nested.tmpl":   {Data: []byte(`{{ component "card" }}{{ component "card" }}{{ . }}{{ end_component }}{{ end_component }}`)},
+		"escaped.tmpl":  {Data: []byte(`{{ component "card" }}<b>{{ . }}</b>{{ end_component }}`)},
+		"broken.tmpl":   {Data: []byte(`{{ end_component }}`)},
+		"unclosed.tmpl": {Data: []byte(`{{ component "button" }}`)},
+		"many.tmpl":     {Data: []byte(`{{ component "button" "a" "b" }}{{ end_component }}`)},
+	}
+	pp, err := passepartout.LoadFrom(fs, passepartout.WithComponents())
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		render   func(out *bytes.Buffer) error
+		expected string
+	}{
+		{
+			name: "renders the body as the children with the data where it's used",
+			render: func(out *bytes.Buffer) error {
+				return pp.Render(out, "index.tmpl", map[string]any{"Items": []string{"One", "Two"}})
+			},
+			expected: `<div class="card"><h2>One</h2><button>Open</button></div>
+<div class="card"><h2>Two</h2><button>Open</button></div>
+`,
+		},
+		{
+			name:     "renders components nested in the children",
+			render:   func(out *bytes.Buffer) error { return pp.Render(out, "nested.tmpl", "x") },
+			expected: `<div class="card"><div class="card">x</div></div>`,
+		},
+		{
+			name:     "escapes the data in the children",
+			render:   func(out *bytes.Buffer) error { return pp.Render(out, "escaped.tmpl", "<i>") },
+			expected: `<div class="card"><b>&lt;i&gt;</b></div>`,
+		},
+		{
+			name: "renders components in pages within a layout",
+			render: func(out *bytes.Buffer) error {
+				return pp.RenderInLayout(out, "layouts/default.tmpl", "nested.tmpl", "x")
+			},
+			expected: `<main><div class="card"><div class="card">x</div></div></main>`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+
+			require.NoError(t, tc.render(out))
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+
+	t.Run("returns an error for an end_component without a component", func(t *testing.T) {
+		err := pp.Render(new(bytes.Buffer), "broken.tmpl", nil)
+
+		require.ErrorContains(t, err, `failed to parse template "broken.tmpl": end_component without a component`)
+	})
+
+	t.Run("returns an error for a component without an end_component", func(t *testing.T) {
+		err := pp.Render(new(bytes.Buffer), "unclosed.tmpl", nil)
+
+		require.ErrorContains(t, err, `failed to parse template "unclosed.tmpl": component without an end_component`)
+	})
+
+	t.Run("returns an error for more than one props argument", func(t *testing.T) {
+		err := pp.Render(new(bytes.Buffer), "many.tmpl", nil)
+
+		require.ErrorContains(t, err, `failed to render component "button": takes one props argument, got 2`)
+	})
+}
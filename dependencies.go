@@ -0,0 +1,159 @@
+package passepartout
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/gaqzi/passepartout/internal/ppparse"
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// fileLoader is implemented by loaders that can tell which files a template is created from, like [ppdefaults.Loader].
+type fileLoader interface {
+	StandaloneFiles(name string) ([]ppdefaults.FileWithContent, error)
+	InLayoutFiles(page string, layout string) ([]ppdefaults.FileWithContent, error)
+}
+
+// Dependencies returns the files, partials or layouts, that rendering name pulls in, sorted.
+// The files are the ones found with the loader's conventions that define a template that name ends up using,
+// so a partial that is loaded but never used is not a dependency.
+func (p *Passepartout) Dependencies(name string) ([]string, error) {
+	l, ok := p.loader.Load().(fileLoader)
+	if !ok {
+		return nil, fmt.Errorf("failed to find dependencies for %q: loader %T doesn't expose its files", name, p.loader.Load())
+	}
+
+	files, err := l.StandaloneFiles(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find dependencies for %q: %w", name, err)
+	}
+
+	deps, err := dependencies(files, name, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find dependencies for %q: %w", name, err)
+	}
+
+	return deps, nil
+}
+
+// DependenciesInLayout returns the files that rendering page within layout pulls in, including the layout, sorted.
+func (p *Passepartout) DependenciesInLayout(page string, layout string) ([]string, error) {
+	l, ok := p.loader.Load().(fileLoader)
+	if !ok {
+		return nil, fmt.Errorf("failed to find dependencies for %q in layout %q: loader %T doesn't expose its files", page, layout, p.loader.Load())
+	}
+
+	files, err := l.InLayoutFiles(page, layout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find dependencies for %q in layout %q: %w", page, layout, err)
+	}
+
+	deps, err := dependencies(files, layout, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find dependencies for %q in layout %q: %w", page, layout, err)
+	}
+
+	return deps, nil
+}
+
+// dependencies returns the files defining the templates reachable from entry, except for the file self.
+func dependencies(files []ppdefaults.FileWithContent, entry string, self string) ([]string, error) {
+	parsed := make([]*ppparse.File, 0, len(files))
+	definedIn := make(map[string]string)
+	for _, f := range files {
+		file, err := ppparse.Parse(f.Name, f.Content)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, file)
+
+		// Later files override the defines of earlier ones, the same as when the template is created.
+		for tmpl := range file.Trees {
+			definedIn[tmpl] = file.Name
+		}
+	}
+
+	seen := make(map[string]struct{})
+	if entry != self {
+		seen[entry] = struct{}{}
+	}
+	for _, tmpl := range ppparse.NewSet(parsed...).Reachable(entry) {
+		if file, ok := definedIn[tmpl]; ok && file != self {
+			seen[file] = struct{}{}
+		}
+	}
+
+	deps := make([]string, 0, len(seen))
+	for file := range seen {
+		deps = append(deps, file)
+	}
+	sort.Strings(deps)
+
+	return deps, nil
+}
+
+// Graph maps a template to the files it depends on, see [Passepartout.Dependencies].
+// It marshals to JSON as an object of template names to lists of files.
+type Graph map[string][]string
+
+// DependencyGraph returns the dependencies of all the names.
+func (p *Passepartout) DependencyGraph(names ...string) (Graph, error) {
+	g := make(Graph, len(names))
+	for _, name := range names {
+		deps, err := p.Dependencies(name)
+		if err != nil {
+			return nil, err
+		}
+		g[name] = deps
+	}
+
+	return g, nil
+}
+
+// Dependents returns the templates in the graph that depend on file, sorted.
+// Use it to find the pages impacted when a partial changes.
+func (g Graph) Dependents(file string) []string {
+	var names []string
+	for name, deps := range g {
+		for _, dep := range deps {
+			if dep == file {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// WriteDOT writes the graph in the Graphviz DOT format, with an edge from each template to the files it depends on.
+func (g Graph) WriteDOT(w io.Writer) error {
+	names := make([]string, 0, len(g))
+	for name := range g {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if _, err := fmt.Fprintln(w, "digraph dependencies {"); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if len(g[name]) == 0 {
+			if _, err := fmt.Fprintf(w, "\t%q;\n", name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, dep := range g[name] {
+			if _, err := fmt.Fprintf(w, "\t%q -> %q;\n", name, dep); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+
+	return err
+}
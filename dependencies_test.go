@@ -0,0 +1,125 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+func dependenciesFS() fstest.MapFS {
+	return fstest.MapFS{
+		"index.tmpl":                {Data: []byte(`{{ template "index/_list.tmpl" . }}`)},
+		"index/_list.tmpl":          {Data: []byte(`{{ range . }}{{ template "item" . }}{{ end }}`)},
+		"index/_item.tmpl":          {Data: []byte(`{{ define "item" }}{{ . }}{{ end }}`)},
+		"index/_unused.tmpl":        {Data: []byte(`unused`)},
+		"about.tmpl":                {Data: []byte(`about`)},
+		"broken.tmpl":               {Data: []byte(`{{ if }}`)},
+		"layouts/default.tmpl":      {Data: []byte(`{{ template "layouts/default/_nav.tmpl" }}{{ block "content" . }}{{ end }}`)},
+		"layouts/default/_nav.tmpl": {Data: []byte(`nav`)},
+	}
+}
+
+func TestPassepartout_Dependencies(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		template      string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "returns the partials used directly and through other partials",
+			template: "index.tmpl",
+			expected: []string{"index/_item.tmpl", "index/_list.tmpl"},
+		},
+		{
+			name:     "returns nothing for a template without dependencies",
+			template: "about.tmpl",
+			expected: []string{},
+		},
+		{
+			name:          "returns an error when a file fails to parse",
+			template:      "broken.tmpl",
+			expectedError: `failed to find dependencies for "broken.tmpl"`,
+		},
+		{
+			name:          "returns an error when the template doesn't exist",
+			template:      "missing.tmpl",
+			expectedError: `failed to find dependencies for "missing.tmpl"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pp, err := passepartout.LoadFrom(dependenciesFS())
+			require.NoError(t, err)
+
+			actual, err := pp.Dependencies(tc.template)
+
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestPassepartout_DependenciesInLayout(t *testing.T) {
+	pp, err := passepartout.LoadFrom(dependenciesFS())
+	require.NoError(t, err)
+
+	t.Run("returns the layout, its partials, and the page's partials", func(t *testing.T) {
+		actual, err := pp.DependenciesInLayout("index.tmpl", "layouts/default.tmpl")
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"index/_item.tmpl", "index/_list.tmpl", "layouts/default.tmpl", "layouts/default/_nav.tmpl"}, actual)
+	})
+
+	t.Run("returns an error when the layout doesn't exist", func(t *testing.T) {
+		_, err := pp.DependenciesInLayout("index.tmpl", "layouts/missing.tmpl")
+
+		require.ErrorContains(t, err, `failed to find dependencies for "index.tmpl" in layout "layouts/missing.tmpl"`)
+	})
+}
+
+func TestPassepartout_DependencyGraph(t *testing.T) {
+	pp, err := passepartout.LoadFrom(dependenciesFS())
+	require.NoError(t, err)
+
+	graph, err := pp.DependencyGraph("index.tmpl", "about.tmpl")
+	require.NoError(t, err)
+
+	t.Run("lists the templates impacted by a change to a file", func(t *testing.T) {
+		require.Equal(t, []string{"index.tmpl"}, graph.Dependents("index/_item.tmpl"))
+		require.Empty(t, graph.Dependents("index/_unused.tmpl"))
+	})
+
+	t.Run("exports as JSON", func(t *testing.T) {
+		actual, err := json.Marshal(graph)
+
+		require.NoError(t, err)
+		require.JSONEq(t, `{"about.tmpl": [], "index.tmpl": ["index/_item.tmpl", "index/_list.tmpl"]}`, string(actual))
+	})
+
+	t.Run("exports as DOT", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+
+		require.NoError(t, graph.WriteDOT(buf))
+		require.Equal(t, `digraph dependencies {
+	"about.tmpl";
+	"index.tmpl" -> "index/_item.tmpl";
+	"index.tmpl" -> "index/_list.tmpl";
+}
+`, buf.String())
+	})
+
+	t.Run("returns an error when any of the templates fail", func(t *testing.T) {
+		_, err := pp.DependencyGraph("index.tmpl", "missing.tmpl")
+
+		require.ErrorContains(t, err, `"missing.tmpl"`)
+	})
+}
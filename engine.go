@@ -0,0 +1,108 @@
+package passepartout
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// Executable is a compiled template, the templates of both html/template and text/template are Executables.
+type Executable interface {
+	ExecuteTemplate(w io.Writer, name string, data any) error
+}
+
+// Engine compiles the files collected for a template into an [Executable], so template languages other than
+// html/template, like jet or pongo2, can be used with passepartout's file conventions, partial discovery, and caching.
+// See [WithEngine].
+type Engine interface {
+	// Compile compiles files, the partials followed by the layout when there is one and then the page, as they're
+	// written. The result is executed with the name of the layout, or of page when layout is empty. In a layout the
+	// page's Block is the block of the layout it's defined as, empty when the page defines the blocks itself.
+	Compile(page, layout string, files []ppdefaults.FileWithContent) (Executable, error)
+}
+
+// EngineFunc is a func implementing [Engine].
+type EngineFunc func(page, layout string, files []ppdefaults.FileWithContent) (Executable, error)
+
+func (f EngineFunc) Compile(page, layout string, files []ppdefaults.FileWithContent) (Executable, error) {
+	return f(page, layout, files)
+}
+
+// fileLoaderContext is implemented by file loaders that can pass a context on to remote backends.
+type fileLoaderContext interface {
+	StandaloneFilesContext(ctx context.Context, name string) ([]ppdefaults.FileWithContent, error)
+	InLayoutFilesContext(ctx context.Context, page string, layout string) ([]ppdefaults.FileWithContent, error)
+}
+
+// engineFor returns the engine that renders name, the first [WithEngineFor] engine for its extension, the [WithEngine]
+// engine, or nil when it's rendered with html/template.
+func (p *Passepartout) engineFor(name string) Engine {
+	for _, e := range p.engines {
+		if strings.HasSuffix(name, e.ext) {
+			return e.engine
+		}
+	}
+
+	return p.engine
+}
+
+// compile loads the files for page from l, in layout unless it's empty, and compiles them with engine.
+func (p *Passepartout) compile(ctx context.Context, l loader, engine Engine, page, layout string) (Executable, error) {
+	files, err := engineFiles(ctx, l, page, layout)
+	if err != nil {
+		return nil, err
+	}
+
+	executable, err := engine.Compile(page, layout, unwrapPage(files, page, layout))
+	if err != nil {
+		if layout == "" {
+			return nil, fmt.Errorf("failed to compile template for %q: %w", page, err)
+		}
+		return nil, fmt.Errorf("failed to compile template for %q in layout %q: %w", page, layout, err)
+	}
+
+	return executable, nil
+}
+
+// unwrapPage returns files with page as it's written, without the define wrapping it in layout, and everything else,
+// like the partials' own defines, left as it is. files itself is never changed since it can be cached.
+func unwrapPage(files []ppdefaults.FileWithContent, page, layout string) []ppdefaults.FileWithContent {
+	if layout == "" {
+		return files
+	}
+
+	unwrapped := slices.Clone(files)
+	for i, file := range unwrapped {
+		if file.Name == page {
+			unwrapped[i].Content = file.Unwrapped()
+		}
+	}
+
+	return unwrapped
+}
+
+func engineFiles(ctx context.Context, l loader, page, layout string) ([]ppdefaults.FileWithContent, error) {
+	if l, ok := l.(fileLoaderContext); ok {
+		if layout == "" {
+			return l.StandaloneFilesContext(ctx, page)
+		}
+		return l.InLayoutFilesContext(ctx, page, layout)
+	}
+
+	files, ok := l.(fileLoader)
+	if !ok {
+		return nil, fmt.Errorf("failed to compile %q: loader %T doesn't expose its files", page, l)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if layout == "" {
+		return files.StandaloneFiles(page)
+	}
+
+	return files.InLayoutFiles(page, layout)
+}
@@ -0,0 +1,114 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"testing"
+	"testing/fstest"
+	"text/template"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// textEngine compiles with text/template, which doesn't escape, defining the page as its block of the layout.
+func textEngine(page, layout string, files []ppdefaults.FileWithContent) (passepartout.Executable, error) {
+	tmpl := template.New("")
+	for _, file := range files {
+		content := file.Content
+		if layout != "" && file.Name == page && file.Block != "" {
+			content = fmt.Sprintf("{{ define %q }}", file.Block) + content + `{{ end }}`
+		}
+		if _, err := tmpl.New(file.Name).Parse(content); err != nil {
+			return nil, err
+		}
+	}
+
+	return tmpl, nil
+}
+
+// templateOnlyLoader only creates templates, without exposing the files they're created from.
+type templateOnlyLoader struct{}
+
+func (*templateOnlyLoader) Standalone(string) (*htmltemplate.Template, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (*templateOnlyLoader) InLayout(string, string) (*htmltemplate.Template, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestWithEngine(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":           {Data: []byte(`{{ . }} {{ template "index/_item.tmpl" }}`)},
+		"index/_item.tmpl":     {Data: []byte(`item`)},
+	}
+
+	t.Run("renders with the engine, with the partials and layout", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithEngine(passepartout.EngineFunc(textEngine)))
+		require.NoError(t, err)
+		out := new(bytes.Buffer)
+
+		require.NoError(t, pp.Render(out, "index.tmpl", "<b>"))
+		require.NoError(t, pp.RenderInLayout(out, "layouts/default.tmpl", "index.tmpl", "<b>"))
+		require.Equal(t, "<b> item<main><b> item</main>", out.String(), "expected text/template to not escape")
+	})
+
+	t.Run("compiles the files as they're written, in the order they're loaded", func(t *testing.T) {
+		var compiled []ppdefaults.FileWithContent
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithEngine(passepartout.EngineFunc(
+			func(page, layout string, files []ppdefaults.FileWithContent) (passepartout.Executable, error) {
+				compiled = files
+				return textEngine(page, layout, files)
+			},
+		)))
+		require.NoError(t, err)
+
+		require.NoError(t, pp.RenderInLayout(new(bytes.Buffer), "layouts/default.tmpl", "index.tmpl", nil))
+		require.Equal(t, []ppdefaults.FileWithContent{
+			{Name: "index/_item.tmpl", Content: `item`},
+			{Name: "layouts/default.tmpl", Content: `<main>{{ block "content" . }}{{ end }}</main>`},
+			{Name: "index.tmpl", Content: `{{ . }} {{ template "index/_item.tmpl" }}`, Block: "content"},
+		}, compiled)
+	})
+
+	t.Run("leaves the defines of the partials as they're written", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fstest.MapFS{
+			"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+			"index.tmpl":           {Data: []byte(`{{ template "item" }}`)},
+			"index/_item.tmpl":     {Data: []byte(`{{ define "item" }}<item/>{{ end }}`)},
+		}, passepartout.WithEngine(passepartout.EngineFunc(textEngine)))
+		require.NoError(t, err)
+		out := new(bytes.Buffer)
+
+		require.NoError(t, pp.Render(out, "index.tmpl", nil))
+		require.NoError(t, pp.RenderInLayout(out, "layouts/default.tmpl", "index.tmpl", nil))
+		require.Equal(t, "<item/><main><item/></main>", out.String())
+	})
+
+	t.Run("returns the error from the engine", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithEngine(passepartout.EngineFunc(
+			func(string, string, []ppdefaults.FileWithContent) (passepartout.Executable, error) {
+				return nil, errors.New("syntax error")
+			},
+		)))
+		require.NoError(t, err)
+
+		err = pp.RenderInLayout(new(bytes.Buffer), "layouts/default.tmpl", "index.tmpl", nil)
+
+		require.EqualError(t, err, `failed to compile template for "index.tmpl" in layout "layouts/default.tmpl": syntax error`)
+	})
+
+	t.Run("returns an error when the loader doesn't expose its files", func(t *testing.T) {
+		pp := passepartout.New(&templateOnlyLoader{}, passepartout.WithEngine(passepartout.EngineFunc(textEngine)))
+
+		err := pp.Render(new(bytes.Buffer), "index.tmpl", nil)
+
+		require.ErrorContains(t, err, `failed to compile "index.tmpl": loader *passepartout_test.templateOnlyLoader doesn't expose its files`)
+	})
+}
@@ -0,0 +1,92 @@
+package passepartout
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"slices"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// Extension packages templates together with the funcs and partials they need, like pagination components or the
+// pages of an auth UI, so a feature can be added to an instance with [Use].
+type Extension interface {
+	Extend(e *Extender)
+}
+
+// ExtensionFunc is a func implementing [Extension].
+type ExtensionFunc func(e *Extender)
+
+func (f ExtensionFunc) Extend(e *Extender) {
+	f(e)
+}
+
+// Extender is what an [Extension] adds its templates, funcs, and hooks to.
+type Extender struct {
+	p *Passepartout
+}
+
+// Use adds the extensions, in order, when creating a [Passepartout].
+func Use(exts ...Extension) Option {
+	return func(p *Passepartout) {
+		for _, ext := range exts {
+			ext.Extend(&Extender{p: p})
+		}
+	}
+}
+
+// Funcs adds funcs every template can use, later funcs with the same name replace earlier ones.
+// Only used by [LoadFrom] and [LoadBundle].
+func (e *Extender) Funcs(funcs template.FuncMap) {
+	e.p.funcs = append(e.p.funcs, funcs)
+}
+
+// Mount adds the templates in fsys under prefix like [Passepartout.Mount], an invalid prefix fails [LoadFrom].
+// Only used by [LoadFrom].
+func (e *Extender) Mount(prefix string, fsys fs.FS) {
+	e.p.mounts = append(e.p.mounts, mounted{prefix: prefix, fs: ppdefaults.AsFS(fsys)})
+}
+
+// Partials adds the partials returned by load, called with the name of the page, to every template so all pages and
+// layouts can use them. They're added before the files of the template, so the templates those define are used over
+// the extension's, and files of the template with the same name are used instead.
+// Only used by [LoadFrom] and [LoadBundle].
+func (e *Extender) Partials(load ppdefaults.PartialLoader) {
+	e.p.partials = append(e.p.partials, load)
+}
+
+// PostRender changes the output of every successful render like [WithPostRender].
+func (e *Extender) PostRender(postRender func(name string, html []byte) ([]byte, error)) {
+	e.p.postRenders = append(e.p.postRenders, postRender)
+}
+
+// withPartials returns files with the partials of the extensions, see [Extender.Partials], that files doesn't have
+// before them.
+func (p *Passepartout) withPartials(files []ppdefaults.FileWithContent) ([]ppdefaults.FileWithContent, error) {
+	if len(p.partials) == 0 || len(files) == 0 {
+		return files, nil
+	}
+
+	names := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		names[file.Name] = struct{}{}
+	}
+
+	page := files[len(files)-1].Name
+	var partials []ppdefaults.FileWithContent
+	for _, load := range p.partials {
+		loaded, err := load(page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the partials of the extensions for %q: %w", page, err)
+		}
+		for _, file := range loaded {
+			if _, ok := names[file.Name]; !ok {
+				names[file.Name] = struct{}{}
+				partials = append(partials, file)
+			}
+		}
+	}
+
+	return slices.Concat(partials, files), nil
+}
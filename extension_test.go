@@ -0,0 +1,93 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// shoutExtension is an extension as a feature package would write it, with funcs, partials, templates, and a hook.
+var shoutExtension = passepartout.ExtensionFunc(func(e *passepartout.Extender) {
+	e.Funcs(template.FuncMap{"shout": strings.ToUpper})
+	e.Partials(func(page string) ([]ppdefaults.FileWithContent, error) {
+		return []ppdefaults.FileWithContent{{Name: "shout/_banner.tmpl", Content: `{{ define "banner" }}{{ shout . }}{{ end }}`}}, nil
+	})
+	e.Mount("shout", fstest.MapFS{"index.tmpl": {Data: []byte(`{{ template "banner" "from the extension" }}`)}})
+	e.PostRender(func(name string, html []byte) ([]byte, error) {
+		return append(html, "!"...), nil
+	})
+})
+
+func TestUse(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":           {Data: []byte(`{{ template "banner" . }}`)},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		render   func(pp *passepartout.Passepartout, out *bytes.Buffer) error
+		expected string
+	}{
+		{
+			name: "renders the pages with the funcs and partials of the extension",
+			render: func(pp *passepartout.Passepartout, out *bytes.Buffer) error {
+				return pp.Render(out, "index.tmpl", "hello")
+			},
+			expected: "HELLO!",
+		},
+		{
+			name: "renders the templates of the extension within the layouts",
+			render: func(pp *passepartout.Passepartout, out *bytes.Buffer) error {
+				return pp.RenderInLayout(out, "layouts/default.tmpl", "shout/index.tmpl", nil)
+			},
+			expected: "<main>FROM THE EXTENSION</main>!",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pp, err := passepartout.LoadFrom(fs, passepartout.Use(shoutExtension))
+			require.NoError(t, err)
+			out := new(bytes.Buffer)
+
+			require.NoError(t, tc.render(pp, out))
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+
+	t.Run("uses the templates defined by the partials of the page over the extension's", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fstest.MapFS{
+			"greeting.tmpl":         {Data: []byte(`{{ template "banner" . }}`)},
+			"greeting/_banner.tmpl": {Data: []byte(`{{ define "banner" }}quiet {{ . }}{{ end }}`)},
+		}, passepartout.Use(shoutExtension))
+		require.NoError(t, err)
+		out := new(bytes.Buffer)
+
+		require.NoError(t, pp.Render(out, "greeting.tmpl", "hi"))
+		require.Equal(t, "quiet hi!", out.String())
+	})
+
+	t.Run("fails to render when the partials of an extension fail to load", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs, passepartout.Use(passepartout.ExtensionFunc(func(e *passepartout.Extender) {
+			e.Partials(func(page string) ([]ppdefaults.FileWithContent, error) { return nil, errors.New("boom") })
+		})))
+		require.NoError(t, err)
+
+		require.ErrorContains(t, pp.Render(new(bytes.Buffer), "index.tmpl", nil), `failed to load the partials of the extensions for "index.tmpl": boom`)
+	})
+
+	t.Run("fails to load when an extension mounts an invalid prefix", func(t *testing.T) {
+		_, err := passepartout.LoadFrom(fs, passepartout.Use(passepartout.ExtensionFunc(func(e *passepartout.Extender) {
+			e.Mount("../up", fstest.MapFS{})
+		})))
+
+		require.EqualError(t, err, `failed to mount "../up": not a valid directory name`)
+	})
+}
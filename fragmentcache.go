@@ -0,0 +1,119 @@
+package passepartout
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// cacheAction matches {{ cache "key" ttl }} and {{ endcache }}, with their trim markers.
+var cacheAction = regexp.MustCompile(`\{\{(- )?\s*(cache|endcache)\b(.*?)( -)?\}\}`)
+
+// rewriteFragmentCaches turns every {{ cache }}, which is always ended by {{ endcache }}, into a block that's only
+// defined and a call to cachedFragment that renders the block with the same data when it isn't cached, like
+// rewriteComponents.
+func rewriteFragmentCaches(file ppdefaults.FileWithContent) (ppdefaults.FileWithContent, error) {
+	matches := cacheAction.FindAllStringSubmatchIndex(file.Content, -1)
+	if matches == nil {
+		return file, nil
+	}
+
+	group := func(match []int, n int) string {
+		if match[2*n] < 0 {
+			return ""
+		}
+		return file.Content[match[2*n]:match[2*n+1]]
+	}
+
+	var b strings.Builder
+	var opened []int
+	last := 0
+	for i, match := range matches {
+		b.WriteString(file.Content[last:match[0]])
+		last = match[1]
+
+		if group(match, 2) == "cache" {
+			opened = append(opened, i)
+			fmt.Fprintf(&b, `{{%sif false }}{{ block %q .%s}}`, group(match, 1), fragmentSlot(file.Name, i), group(match, 4))
+			continue
+		}
+		if len(opened) == 0 {
+			return file, fmt.Errorf("failed to parse template %q: endcache without a cache", file.Name)
+		}
+
+		open := opened[len(opened)-1]
+		opened = opened[:len(opened)-1]
+		fmt.Fprintf(&b, `{{%send }}{{ end }}{{ cachedFragment %q . %s%s}}`,
+			group(match, 1), fragmentSlot(file.Name, open), strings.TrimSpace(group(matches[open], 3)), group(match, 4))
+	}
+	if len(opened) > 0 {
+		return file, fmt.Errorf("failed to parse template %q: cache without an endcache", file.Name)
+	}
+	b.WriteString(file.Content[last:])
+
+	file.Content = b.String()
+
+	return file, nil
+}
+
+// fragmentSlot is the name of the block rewriteFragmentCaches defines for the content of the nth cache action.
+func fragmentSlot(name string, n int) string {
+	return fmt.Sprintf("%s:cache%d", name, n)
+}
+
+// fragmentCacheFuncs renders the blocks rewriteFragmentCaches defined in tmplt, keeping their output in store.
+// The key of a fragment is the key and the rest of the arguments after the ttl, like {{ cache "post" "1h" .ID }}.
+func fragmentCacheFuncs(store OutputStore, tmplt *template.Template) template.FuncMap {
+	return template.FuncMap{
+		"cachedFragment": func(slot string, data any, key string, ttl any, vary ...any) (template.HTML, error) {
+			duration, err := fragmentTTL(ttl)
+			if err != nil {
+				return "", fmt.Errorf("failed to cache fragment %q: %w", key, err)
+			}
+
+			parts := []string{"fragment", key}
+			for _, v := range vary {
+				parts = append(parts, fmt.Sprint(v))
+			}
+			storeKey := strings.Join(parts, "|")
+
+			if output, ok, err := store.Get(context.Background(), storeKey); err == nil && ok {
+				return template.HTML(output), nil
+			}
+
+			buf := new(bytes.Buffer)
+			if err := tmplt.ExecuteTemplate(buf, slot, data); err != nil {
+				return "", fmt.Errorf("failed to render fragment %q: %w", key, err)
+			}
+			_ = store.Set(context.Background(), storeKey, buf.Bytes(), duration)
+
+			return template.HTML(buf.String()), nil
+		},
+	}
+}
+
+func fragmentTTL(ttl any) (time.Duration, error) {
+	var duration time.Duration
+	switch v := ttl.(type) {
+	case time.Duration:
+		duration = v
+	case string:
+		var err error
+		if duration, err = time.ParseDuration(v); err != nil {
+			return 0, fmt.Errorf("failed to parse ttl: %w", err)
+		}
+	default:
+		return 0, fmt.Errorf("expected the ttl as a duration like \"5m\", got %T", ttl)
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("a positive ttl is required")
+	}
+
+	return duration, nil
+}
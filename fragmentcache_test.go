@@ -0,0 +1,85 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+type fragmentReview struct {
+	ID    int
+	Title string
+	Votes int
+}
+
+func TestWithFragmentCache(t *testing.T) {
+	render := func(t *testing.T, pp *passepartout.Passepartout, name string, data any) string {
+		t.Helper()
+		buf := new(bytes.Buffer)
+		require.NoError(t, pp.Render(buf, name, data))
+
+		return buf.String()
+	}
+
+	t.Run("renders the cached fragment until its key changes", func(t *testing.T) {
+		fs := fstest.MapFS{"index.tmpl": {Data: []byte(`{{ range . }}{{ cache "review" "1h" .ID }}<p>{{ .Title }}</p>{{ endcache }}{{ .Votes }} {{ end }}`)}}
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithFragmentCache(passepartout.NewMemoryOutputStore()))
+		require.NoError(t, err)
+
+		require.Equal(t, "<p>Dune</p>1 <p>Emma</p>2 ", render(t, pp, "index.tmpl", []fragmentReview{{1, "Dune", 1}, {2, "Emma", 2}}))
+		require.Equal(t, "<p>Dune</p>3 <p>Solaris</p>4 ", render(t, pp, "index.tmpl", []fragmentReview{{1, "Dune 2", 3}, {3, "Solaris", 4}}),
+			"expected the cached fragment for the same key, and the fragment rendered for a new key")
+	})
+
+	t.Run("renders an outer cache from the inner caches", func(t *testing.T) {
+		fs := fstest.MapFS{"index.tmpl": {Data: []byte(`{{ cache "list" "1h" .Version }}<ul>{{ range .Reviews }}{{ cache "item" "1h" .ID }}<li>{{ .Title }}</li>{{ endcache }}{{ end }}</ul>{{ endcache }}`)}}
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithFragmentCache(passepartout.NewMemoryOutputStore()))
+		require.NoError(t, err)
+		require.Equal(t, "<ul><li>Dune</li></ul>", render(t, pp, "index.tmpl", map[string]any{"Version": 1, "Reviews": []fragmentReview{{ID: 1, Title: "Dune"}}}))
+
+		output := render(t, pp, "index.tmpl", map[string]any{"Version": 2, "Reviews": []fragmentReview{{ID: 1, Title: "Dune 2"}, {ID: 2, Title: "Emma"}}})
+
+		require.Equal(t, "<ul><li>Dune</li><li>Emma</li></ul>", output)
+	})
+
+	t.Run("escapes the fragment like the rest of the template", func(t *testing.T) {
+		fs := fstest.MapFS{"index.tmpl": {Data: []byte(`{{ cache "title" "1h" }}<p>{{ . }}</p>{{ endcache }}`)}}
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithFragmentCache(passepartout.NewMemoryOutputStore()))
+		require.NoError(t, err)
+
+		require.Equal(t, "<p>&lt;b&gt;</p>", render(t, pp, "index.tmpl", "<b>"))
+	})
+
+	for _, tc := range []struct {
+		name        string
+		template    string
+		expectedErr string
+	}{
+		{
+			name:        "fails to load a cache without an endcache",
+			template:    `{{ cache "title" "1h" }}`,
+			expectedErr: `cache without an endcache`,
+		},
+		{
+			name:        "fails to load an endcache without a cache",
+			template:    `{{ endcache }}`,
+			expectedErr: `endcache without a cache`,
+		},
+		{
+			name:        "fails to render a cache with an invalid ttl",
+			template:    `{{ cache "title" "soon" }}{{ endcache }}`,
+			expectedErr: `failed to cache fragment "title": failed to parse ttl`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pp, err := passepartout.LoadFrom(fstest.MapFS{"index.tmpl": {Data: []byte(tc.template)}}, passepartout.WithFragmentCache(passepartout.NewMemoryOutputStore()))
+			require.NoError(t, err)
+
+			require.ErrorContains(t, pp.Render(new(bytes.Buffer), "index.tmpl", nil), tc.expectedErr)
+		})
+	}
+}
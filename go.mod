@@ -2,7 +2,10 @@ module github.com/gaqzi/passepartout
 
 go 1.24.1
 
-require github.com/stretchr/testify v1.10.0
+require (
+	github.com/pmezard/go-difflib v1.0.0
+	github.com/stretchr/testify v1.10.0
+)
 
 require (
 	dario.cat/mergo v1.0.1 // indirect
@@ -26,7 +29,6 @@ require (
 	github.com/mitchellh/copystructure v1.2.0 // indirect
 	github.com/mitchellh/reflectwalk v1.0.2 // indirect
 	github.com/muesli/termenv v0.16.0 // indirect
-	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
 	github.com/samber/lo v1.49.1 // indirect
 	github.com/shopspring/decimal v1.4.0 // indirect
@@ -0,0 +1,22 @@
+package passepartout
+
+import (
+	"fmt"
+)
+
+// guard returns the error of the first resolve guard, see [WithResolveGuard], that rejects one of names.
+// Empty names, like the layout of a standalone render, aren't checked.
+func (p *Passepartout) guard(names ...string) error {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		for _, guard := range p.resolveGuards {
+			if err := guard(name); err != nil {
+				return fmt.Errorf("template %q isn't allowed: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+func TestWithResolveGuard(t *testing.T) {
+	errNotPublic := errors.New("not a public page")
+	var checked []string
+	pp, err := passepartout.LoadFrom(fstest.MapFS{
+		"layouts/app.tmpl":       {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"pages/about.tmpl":       {Data: []byte(`{{/* cache: ttl=1h */}}about {{ template "pages/about/_team.tmpl" }}`)},
+		"pages/about/_team.tmpl": {Data: []byte(`team`)},
+		"admin/users.tmpl":       {Data: []byte(`users`)},
+	},
+		passepartout.WithExtensions(".tmpl"),
+		passepartout.WithOutputCache(),
+		passepartout.WithResolveGuard(func(name string) error {
+			checked = append(checked, name)
+			return nil
+		}),
+		passepartout.WithResolveGuard(func(name string) error {
+			if name == "layouts/app.tmpl" || (strings.HasPrefix(name, "pages/") && !strings.Contains(name, "/_")) {
+				return nil
+			}
+			return errNotPublic
+		}),
+	)
+	require.NoError(t, err)
+
+	t.Run("renders the names the guards allow, after resolving them", func(t *testing.T) {
+		checked = nil
+		out := new(bytes.Buffer)
+
+		require.NoError(t, pp.RenderInLayout(out, "layouts/app", "pages/about", nil))
+		require.Equal(t, `<main>about team</main>`, out.String())
+		require.Equal(t, []string{"pages/about.tmpl", "layouts/app.tmpl"}, checked)
+	})
+
+	t.Run("checks the names before using the output cache", func(t *testing.T) {
+		checked = nil
+		out := new(bytes.Buffer)
+
+		require.NoError(t, pp.Render(out, "pages/about.tmpl", nil))
+		require.NoError(t, pp.Render(out, "pages/about.tmpl", nil))
+		require.Equal(t, `about teamabout team`, out.String())
+		require.Equal(t, []string{"pages/about.tmpl", "pages/about.tmpl"}, checked, "checked on the cached render too")
+	})
+
+	for _, tc := range []struct {
+		name     string
+		render   func(out *bytes.Buffer) error
+		expected string
+	}{
+		{
+			name:     "fails the render of a page a guard rejects",
+			render:   func(out *bytes.Buffer) error { return pp.Render(out, "admin/users", nil) },
+			expected: `template "admin/users.tmpl" isn't allowed: not a public page`,
+		},
+		{
+			name:     "fails the render in a layout a guard rejects",
+			render:   func(out *bytes.Buffer) error { return pp.RenderInLayout(out, "admin/users", "pages/about", nil) },
+			expected: `template "admin/users.tmpl" isn't allowed: not a public page`,
+		},
+		{
+			name:     "fails the render of a partial by name",
+			render:   func(out *bytes.Buffer) error { return pp.RenderPartial(out, "pages/about/_team.tmpl", nil) },
+			expected: `template "pages/about/_team.tmpl" isn't allowed: not a public page`,
+		},
+		{
+			name: "fails to get the template",
+			render: func(*bytes.Buffer) error {
+				_, err := pp.Template("admin/users")
+				return err
+			},
+			expected: `failed to get template "admin/users": template "admin/users.tmpl" isn't allowed: not a public page`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+
+			err := tc.render(out)
+
+			require.EqualError(t, err, tc.expected)
+			require.ErrorIs(t, err, errNotPublic)
+			require.Empty(t, out.String())
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package passepartout
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// Hash returns a hash of the content of name and every file loaded with it, like its partials, which only changes
+// when one of them does, for building ETags and cache keys that are invalidated on deploys.
+func (p *Passepartout) Hash(name string) (string, error) {
+	l, ok := p.loader.Load().(fileLoader)
+	if !ok {
+		return "", fmt.Errorf("failed to hash %q: loader %T doesn't expose its files", name, p.loader.Load())
+	}
+
+	if err := p.guard(p.resolve(name)); err != nil {
+		return "", fmt.Errorf("failed to hash %q: %w", name, err)
+	}
+
+	files, err := l.StandaloneFiles(p.resolve(name))
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %q: %w", name, err)
+	}
+
+	return hashFiles(files), nil
+}
+
+// HashInLayout returns the hash of page rendered within layout, like [Passepartout.Hash], including the layout.
+func (p *Passepartout) HashInLayout(page string, layout string) (string, error) {
+	l, ok := p.loader.Load().(fileLoader)
+	if !ok {
+		return "", fmt.Errorf("failed to hash %q in layout %q: loader %T doesn't expose its files", page, layout, p.loader.Load())
+	}
+
+	if err := p.guard(p.resolve(page), p.resolve(layout)); err != nil {
+		return "", fmt.Errorf("failed to hash %q in layout %q: %w", page, layout, err)
+	}
+
+	files, err := l.InLayoutFiles(p.resolve(page), p.resolve(layout))
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %q in layout %q: %w", page, layout, err)
+	}
+
+	return hashFiles(files), nil
+}
+
+// hashFiles hashes the names and content of files in the order they're loaded, since it decides which defines win.
+func hashFiles(files []ppdefaults.FileWithContent) string {
+	h := sha256.New()
+	for _, f := range files {
+		_, _ = io.WriteString(h, f.Name+"\x00"+f.Content+"\x00")
+	}
+
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
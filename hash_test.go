@@ -0,0 +1,89 @@
+package passepartout_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+func TestPassepartout_Hash(t *testing.T) {
+	templates := func() fstest.MapFS {
+		return fstest.MapFS{
+			"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+			"index.tmpl":           {Data: []byte(`{{ template "index/_item.tmpl" . }}`)},
+			"index/_item.tmpl":     {Data: []byte(`item`)},
+			"show.tmpl":            {Data: []byte(`show`)},
+		}
+	}
+	hashes := func(t *testing.T, fsys fstest.MapFS) (standalone, inLayout string) {
+		t.Helper()
+		pp, err := passepartout.LoadFrom(fsys)
+		require.NoError(t, err)
+
+		standalone, err = pp.Hash("index.tmpl")
+		require.NoError(t, err)
+		inLayout, err = pp.HashInLayout("index.tmpl", "layouts/default.tmpl")
+		require.NoError(t, err)
+
+		return standalone, inLayout
+	}
+	standalone, inLayout := hashes(t, templates())
+
+	t.Run("is the same for the same files", func(t *testing.T) {
+		again, againInLayout := hashes(t, templates())
+
+		require.Regexp(t, `^[0-9a-f]{32}$`, standalone)
+		require.Equal(t, standalone, again)
+		require.Equal(t, inLayout, againInLayout)
+		require.NotEqual(t, standalone, inLayout, "expected the layout to be part of the hash")
+	})
+
+	t.Run("changes when a partial changes", func(t *testing.T) {
+		fsys := templates()
+		fsys["index/_item.tmpl"] = &fstest.MapFile{Data: []byte(`item 2`)}
+
+		changed, changedInLayout := hashes(t, fsys)
+
+		require.NotEqual(t, standalone, changed)
+		require.NotEqual(t, inLayout, changedInLayout)
+	})
+
+	t.Run("changes in the layout only when the layout changes", func(t *testing.T) {
+		fsys := templates()
+		fsys["layouts/default.tmpl"] = &fstest.MapFile{Data: []byte(`<div>{{ block "content" . }}{{ end }}</div>`)}
+
+		unchanged, changedInLayout := hashes(t, fsys)
+
+		require.Equal(t, standalone, unchanged)
+		require.NotEqual(t, inLayout, changedInLayout)
+	})
+
+	t.Run("doesn't change with other pages", func(t *testing.T) {
+		fsys := templates()
+		fsys["show.tmpl"] = &fstest.MapFile{Data: []byte(`show 2`)}
+
+		unchanged, _ := hashes(t, fsys)
+
+		require.Equal(t, standalone, unchanged)
+	})
+
+	t.Run("fails for a template that doesn't exist", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(templates())
+		require.NoError(t, err)
+
+		_, err = pp.Hash("missing.tmpl")
+
+		require.ErrorContains(t, err, `failed to hash "missing.tmpl"`)
+	})
+
+	t.Run("fails when the loader doesn't expose its files", func(t *testing.T) {
+		pp := passepartout.New(&templateOnlyLoader{})
+
+		_, err := pp.Hash("index.tmpl")
+
+		require.EqualError(t, err, `failed to hash "index.tmpl": loader *passepartout_test.templateOnlyLoader doesn't expose its files`)
+	})
+}
@@ -0,0 +1,82 @@
+package passepartout
+
+import (
+	"io"
+	"time"
+)
+
+// HookInfo describes a load or render to [Hooks]. Duration, Bytes, and Err are only set once it has ended,
+// and Bytes only for renders.
+type HookInfo struct {
+	Template string
+	// Layout is empty when the template is loaded or rendered standalone.
+	Layout   string
+	Duration time.Duration
+	// Bytes is the number of bytes written by the render, including what was written before it failed.
+	Bytes int64
+	Err   error
+}
+
+// Hooks are called around every load and render, so metrics and logging can be wired in one place.
+// All hooks are optional, and are called synchronously so they should be quick.
+// A render includes loading its templates, so the load hooks are called between OnRenderStart and OnRenderEnd,
+// and the load hooks aren't called when the output is served by [WithOutputCache].
+type Hooks struct {
+	OnLoadStart   func(info HookInfo)
+	OnLoadEnd     func(info HookInfo)
+	OnRenderStart func(info HookInfo)
+	OnRenderEnd   func(info HookInfo)
+}
+
+// observeLoad calls the load hooks around load.
+func (p *Passepartout) observeLoad(layout, name string, load func() (Executable, error)) (Executable, error) {
+	if len(p.hooks) == 0 {
+		return load()
+	}
+
+	info := HookInfo{Template: name, Layout: layout}
+	p.callHooks(info, func(h Hooks) func(HookInfo) { return h.OnLoadStart })
+	start := time.Now()
+	t, err := load()
+	info.Duration, info.Err = time.Since(start), err
+	p.callHooks(info, func(h Hooks) func(HookInfo) { return h.OnLoadEnd })
+
+	return t, err
+}
+
+// observeRender calls the render hooks around render, counting what it writes to out.
+func (p *Passepartout) observeRender(out io.Writer, layout, name string, render func(out io.Writer) error) error {
+	if len(p.hooks) == 0 {
+		return render(out)
+	}
+
+	info := HookInfo{Template: name, Layout: layout}
+	p.callHooks(info, func(h Hooks) func(HookInfo) { return h.OnRenderStart })
+	start := time.Now()
+	cw := &countingWriter{w: out}
+	err := render(cw)
+	info.Duration, info.Bytes, info.Err = time.Since(start), cw.n, err
+	p.callHooks(info, func(h Hooks) func(HookInfo) { return h.OnRenderEnd })
+
+	return err
+}
+
+func (p *Passepartout) callHooks(info HookInfo, hook func(h Hooks) func(HookInfo)) {
+	for _, h := range p.hooks {
+		if fn := hook(h); fn != nil {
+			fn(info)
+		}
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+
+	return n, err
+}
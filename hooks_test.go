@@ -0,0 +1,83 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+type hookRecorder struct {
+	events []string
+	ends   []passepartout.HookInfo
+}
+
+func (r *hookRecorder) hooks() passepartout.Hooks {
+	return passepartout.Hooks{
+		OnLoadStart: func(info passepartout.HookInfo) { r.events = append(r.events, "load start "+info.Template) },
+		OnLoadEnd: func(info passepartout.HookInfo) {
+			r.events = append(r.events, "load end "+info.Template)
+			r.ends = append(r.ends, info)
+		},
+		OnRenderStart: func(info passepartout.HookInfo) { r.events = append(r.events, "render start "+info.Template) },
+		OnRenderEnd: func(info passepartout.HookInfo) {
+			r.events = append(r.events, "render end "+info.Template)
+			r.ends = append(r.ends, info)
+		},
+	}
+}
+
+func TestWithHooks(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":           {Data: []byte(`Hello, {{ . }}!`)},
+		"broken.tmpl":          {Data: []byte(`half {{ index . 1 }}`)},
+	}
+
+	t.Run("calls the hooks around loading and rendering", func(t *testing.T) {
+		rec := new(hookRecorder)
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithHooks(rec.hooks()))
+		require.NoError(t, err)
+
+		require.NoError(t, pp.RenderInLayout(new(bytes.Buffer), "layouts/default.tmpl", "index.tmpl", "world"))
+
+		require.Equal(t, []string{"render start index.tmpl", "load start index.tmpl", "load end index.tmpl", "render end index.tmpl"}, rec.events)
+		load, render := rec.ends[0], rec.ends[1]
+		require.Equal(t, "layouts/default.tmpl", load.Layout)
+		require.Zero(t, load.Bytes, "expected no bytes for a load")
+		require.Equal(t, "layouts/default.tmpl", render.Layout)
+		require.Equal(t, int64(len("<main>Hello, world!</main>")), render.Bytes)
+		require.Positive(t, render.Duration)
+		require.NoError(t, render.Err)
+	})
+
+	t.Run("passes on the error and what was written before it failed", func(t *testing.T) {
+		rec := new(hookRecorder)
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithHooks(rec.hooks()))
+		require.NoError(t, err)
+
+		renderErr := pp.Render(new(bytes.Buffer), "broken.tmpl", nil)
+
+		require.Error(t, renderErr)
+		render := rec.ends[1]
+		require.Equal(t, renderErr, render.Err)
+		require.Equal(t, "", render.Layout)
+		require.Equal(t, int64(len("half ")), render.Bytes)
+	})
+
+	t.Run("calls every added hook and skips the ones not set", func(t *testing.T) {
+		var renders int
+		pp, err := passepartout.LoadFrom(fs,
+			passepartout.WithHooks(passepartout.Hooks{OnRenderEnd: func(passepartout.HookInfo) { renders++ }}),
+			passepartout.WithHooks(passepartout.Hooks{OnRenderEnd: func(passepartout.HookInfo) { renders++ }}),
+		)
+		require.NoError(t, err)
+
+		require.NoError(t, pp.Render(new(bytes.Buffer), "index.tmpl", "world"))
+
+		require.Equal(t, 2, renders)
+	})
+}
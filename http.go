@@ -0,0 +1,80 @@
+package passepartout
+
+import (
+	"bytes"
+	"context"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// RenderHTTP renders the template into a buffer before writing it to w with status and a Content-Type based on the
+// extension of name, so a failing render never sends a half-rendered page.
+// When rendering fails the error template configured with [WithErrorTemplate] is sent with a 500 status instead,
+// or a plain text error if there's no error template or it fails too, and the original error is returned.
+func (p *Passepartout) RenderHTTP(w http.ResponseWriter, status int, name string, data any) error {
+	buf := new(bytes.Buffer)
+	err := p.render(context.Background(), buf, name, data)
+	if err == nil {
+		err = p.postRender(buf, name)
+	}
+	if err != nil {
+		p.writeHTTPError(w, name, err)
+		return err
+	}
+
+	p.storeCapture(w, "", name, buf.Bytes(), nil)
+	return writeHTTP(w, status, contentType(name), buf)
+}
+
+func (p *Passepartout) writeHTTPError(w http.ResponseWriter, name string, renderErr error) {
+	const status = http.StatusInternalServerError
+
+	if p.errorTemplate != "" {
+		buf := new(bytes.Buffer)
+		if err := p.renderErrorTemplate(buf, name, renderErr); err == nil {
+			p.storeCapture(w, "", name, buf.Bytes(), renderErr)
+			_ = writeHTTP(w, status, contentType(p.errorTemplate), buf)
+			return
+		}
+	}
+
+	p.storeCapture(w, "", name, nil, renderErr)
+	http.Error(w, http.StatusText(status), status)
+}
+
+func writeHTTP(w http.ResponseWriter, status int, contentType string, buf *bytes.Buffer) error {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, err := buf.WriteTo(w)
+
+	return err
+}
+
+// feedTypes are the types of feeds, which aren't known by [mime.TypeByExtension] on every system.
+var feedTypes = map[string]string{
+	".rss":  "application/rss+xml; charset=utf-8",
+	".atom": "application/atom+xml; charset=utf-8",
+}
+
+// contentType guesses the content type from the extension before the template's own extension,
+// so "index.html.tmpl" is HTML and "robots.txt.tmpl" is plain text,
+// and falls back on HTML because templates are rendered with html/template by default.
+func contentType(name string) string {
+	ext := path.Ext(name)
+	for _, e := range []string{path.Ext(strings.TrimSuffix(name, ext)), ext} {
+		if e == "" {
+			continue
+		}
+
+		if t, ok := feedTypes[e]; ok {
+			return t
+		}
+		if t := mime.TypeByExtension(e); t != "" {
+			return t
+		}
+	}
+
+	return "text/html; charset=utf-8"
+}
@@ -0,0 +1,100 @@
+package passepartout_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+func TestPassepartout_RenderHTTP(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		fs              fstest.MapFS
+		opts            []passepartout.Option
+		template        string
+		status          int
+		expectedStatus  int
+		expectedType    string
+		expectedBody    string
+		expectedFailure bool
+	}{
+		{
+			name:           "writes the rendered template with the status and an HTML content type",
+			fs:             fstest.MapFS{"index.tmpl": {Data: []byte(`<h1>{{ . }}</h1>`)}},
+			template:       "index.tmpl",
+			status:         http.StatusCreated,
+			expectedStatus: http.StatusCreated,
+			expectedType:   "text/html; charset=utf-8",
+			expectedBody:   "<h1>hello</h1>",
+		},
+		{
+			name:           "uses the extension before the template extension for the content type",
+			fs:             fstest.MapFS{"robots.txt.tmpl": {Data: []byte(`User-agent: {{ . }}`)}},
+			template:       "robots.txt.tmpl",
+			status:         http.StatusOK,
+			expectedStatus: http.StatusOK,
+			expectedType:   "text/plain; charset=utf-8",
+			expectedBody:   "User-agent: hello",
+		},
+		{
+			name:            "when rendering fails nothing of the failed render is sent",
+			fs:              fstest.MapFS{"index.tmpl": {Data: []byte(`partial output {{ template "missing" }}`)}},
+			template:        "index.tmpl",
+			status:          http.StatusOK,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedType:    "text/plain; charset=utf-8",
+			expectedBody:    "Internal Server Error\n",
+			expectedFailure: true,
+		},
+		{
+			name: "when rendering fails the error template is sent",
+			fs: fstest.MapFS{
+				"index.tmpl":      {Data: []byte(`partial output {{ template "missing" }}`)},
+				"errors/500.tmpl": {Data: []byte(`<h1>{{ .Status }}: {{ .Template }} broke</h1>`)},
+			},
+			opts:            []passepartout.Option{passepartout.WithErrorTemplate("errors/500.tmpl")},
+			template:        "index.tmpl",
+			status:          http.StatusOK,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedType:    "text/html; charset=utf-8",
+			expectedBody:    "<h1>500: index.tmpl broke</h1>",
+			expectedFailure: true,
+		},
+		{
+			name: "when the error template fails too a plain error is sent",
+			fs: fstest.MapFS{
+				"index.tmpl":      {Data: []byte(`{{ template "missing" }}`)},
+				"errors/500.tmpl": {Data: []byte(`{{ template "also-missing" }}`)},
+			},
+			opts:            []passepartout.Option{passepartout.WithErrorTemplate("errors/500.tmpl")},
+			template:        "index.tmpl",
+			status:          http.StatusOK,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedType:    "text/plain; charset=utf-8",
+			expectedBody:    "Internal Server Error\n",
+			expectedFailure: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pp, err := passepartout.LoadFrom(tc.fs, tc.opts...)
+			require.NoError(t, err)
+			rec := httptest.NewRecorder()
+
+			err = pp.RenderHTTP(rec, tc.status, tc.template, "hello")
+
+			if tc.expectedFailure {
+				require.ErrorContains(t, err, `no such template "missing"`, "expected the original error to be returned")
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.expectedStatus, rec.Code)
+			require.Equal(t, tc.expectedType, rec.Header().Get("Content-Type"))
+			require.Equal(t, tc.expectedBody, rec.Body.String())
+		})
+	}
+}
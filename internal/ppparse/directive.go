@@ -0,0 +1,21 @@
+package ppparse
+
+import (
+	"regexp"
+	"strings"
+)
+
+var directive = regexp.MustCompile(`\{\{-?\s*/\*\s*([a-z][a-z0-9-]*):(.*?)\*/\s*-?}}`)
+
+// Directive returns the value of the first comment directive named name in content, like "ttl=5m" for
+// `{{/* cache: ttl=5m */}}`, and reports whether there was one.
+// Comments are used so the directives are ignored when the template is rendered.
+func Directive(content string, name string) (string, bool) {
+	for _, m := range directive.FindAllStringSubmatch(content, -1) {
+		if m[1] == name {
+			return strings.TrimSpace(m[2]), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,48 @@
+package ppparse_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/internal/ppparse"
+)
+
+func TestDirective(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		content       string
+		expected      string
+		expectedFound bool
+	}{
+		{
+			name:          "returns the value of the directive",
+			content:       "{{/* cache: ttl=5m */}}<h1>Hi</h1>",
+			expected:      "ttl=5m",
+			expectedFound: true,
+		},
+		{
+			name:          "allows trim markers",
+			content:       "{{- /* cache: ttl=1m */ -}}",
+			expected:      "ttl=1m",
+			expectedFound: true,
+		},
+		{
+			name:          "finds the directive among others",
+			content:       "{{/* owner: @reviews */}}\n{{/* cache: ttl=1h */}}",
+			expected:      "ttl=1h",
+			expectedFound: true,
+		},
+		{
+			name:    "ignores other comments",
+			content: "{{/* cache is handled elsewhere */}}",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, found := ppparse.Directive(tc.content, "cache")
+
+			require.Equal(t, tc.expectedFound, found)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
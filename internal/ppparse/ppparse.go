@@ -0,0 +1,254 @@
+// Package ppparse parses template files without executing them, to find out what they define and reference.
+package ppparse
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// Error is a template that failed to parse, with the line the parser stopped at.
+type Error struct {
+	Name string
+	// Line is 0 when the parser didn't say which line.
+	Line    int
+	Message string
+	// Excerpt is the source around Line, numbered and with Line marked, empty when Line is 0.
+	Excerpt string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Name, e.Message)
+	}
+
+	return fmt.Sprintf("%s:%d: %s", e.Name, e.Line, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// parseErrorFormat is how [parse.Tree] formats its errors: "template: <name>:<line>: <message>".
+var parseErrorFormat = regexp.MustCompile(`^template: (.*?):(\d+): (.*)$`)
+
+// NewError creates an [*Error] from err returned by the parser when parsing content as name.
+func NewError(name, content string, err error) *Error {
+	e := &Error{Name: name, Message: err.Error(), Err: err}
+	if m := parseErrorFormat.FindStringSubmatch(err.Error()); m != nil && m[1] == name {
+		e.Line, _ = strconv.Atoi(m[2])
+		e.Message = m[3]
+		e.Excerpt = excerpt(content, e.Line)
+	}
+
+	return e
+}
+
+// excerpt returns line of content with the lines around it:
+//
+//	  2 | <h1>
+//	> 3 | {{ .Missing
+//	  4 | </h1>
+func excerpt(content string, line int) string {
+	lines := strings.Split(content, "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	last := min(line+1, len(lines))
+	width := len(strconv.Itoa(last))
+	numbered := make([]string, 0, 3)
+	for n := max(line-1, 1); n <= last; n++ {
+		marker := " "
+		if n == line {
+			marker = ">"
+		}
+		numbered = append(numbered, fmt.Sprintf("%s %*d | %s", marker, width, n, lines[n-1]))
+	}
+
+	return strings.Join(numbered, "\n")
+}
+
+// File is a parsed template file.
+type File struct {
+	Name string
+	// Trees are all templates in the file, including the file itself under Name.
+	Trees map[string]*parse.Tree
+}
+
+// Parse parses content as a template named name, errors are returned as an [*Error].
+// Functions are not checked, so templates using funcs from a [template.FuncMap] can be parsed without them.
+func Parse(name, content string) (*File, error) {
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck | parse.ParseComments
+	trees := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(content, "", "", trees); err != nil {
+		return nil, NewError(name, content, err)
+	}
+	if _, ok := trees[name]; !ok {
+		// Make sure the file itself is always available, even if a define in it happens to share its name.
+		trees[name] = tree
+	}
+
+	return &File{Name: name, Trees: trees}, nil
+}
+
+// Defines returns the names of all templates defined inside the file with define or block, sorted.
+func (f *File) Defines() []string {
+	var names []string
+	for name := range f.Trees {
+		if name != f.Name {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// References returns the names of all templates used by the file with template or block, sorted and deduplicated.
+func (f *File) References() []string {
+	seen := make(map[string]struct{})
+	for _, tree := range f.Trees {
+		for _, name := range TreeReferences(tree) {
+			seen[name] = struct{}{}
+		}
+	}
+
+	return sortedKeys(seen)
+}
+
+// TreeReferences returns the names of the templates used by a single tree, in the order they appear.
+func TreeReferences(tree *parse.Tree) []string {
+	var names []string
+	Walk(tree.Root, func(node parse.Node) {
+		if tmpl, ok := node.(*parse.TemplateNode); ok {
+			names = append(names, tmpl.Name)
+		}
+	})
+
+	return names
+}
+
+// Walk calls fn for node and every node below it.
+func Walk(node parse.Node, fn func(parse.Node)) {
+	if node == nil {
+		return
+	}
+	fn(node)
+
+	switch n := node.(type) {
+	case *parse.ListNode:
+		for _, child := range n.Nodes {
+			Walk(child, fn)
+		}
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.ActionNode:
+		Walk(n.Pipe, fn)
+	case *parse.TemplateNode:
+		if n.Pipe != nil {
+			Walk(n.Pipe, fn)
+		}
+	case *parse.PipeNode:
+		for _, cmd := range n.Cmds {
+			Walk(cmd, fn)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			Walk(arg, fn)
+		}
+	}
+}
+
+func walkBranch(n *parse.BranchNode, fn func(parse.Node)) {
+	Walk(n.Pipe, fn)
+	if n.List != nil {
+		Walk(n.List, fn)
+	}
+	if n.ElseList != nil {
+		Walk(n.ElseList, fn)
+	}
+}
+
+// Set is a collection of parsed files that are used together, like the files making up a page in a layout.
+type Set struct {
+	Files []*File
+	trees map[string]*parse.Tree
+}
+
+// NewSet combines files in the order given, so a later file's define will override an earlier file's.
+func NewSet(files ...*File) *Set {
+	s := &Set{Files: files, trees: make(map[string]*parse.Tree)}
+	for _, f := range files {
+		for name, tree := range f.Trees {
+			s.trees[name] = tree
+		}
+	}
+
+	return s
+}
+
+// File returns the file with name, or nil if it's not in the set.
+func (s *Set) File(name string) *File {
+	for _, f := range s.Files {
+		if f.Name == name {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// Defined reports whether there's a template named name in the set.
+func (s *Set) Defined(name string) bool {
+	_, ok := s.trees[name]
+	return ok
+}
+
+// Reachable returns the names of all templates that executing the template name can end up using, sorted.
+func (s *Set) Reachable(name string) []string {
+	seen := make(map[string]struct{})
+	queue := []string{name}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		tree, ok := s.trees[current]
+		if !ok {
+			continue
+		}
+
+		for _, ref := range TreeReferences(tree) {
+			if _, ok := seen[ref]; ok {
+				continue
+			}
+			seen[ref] = struct{}{}
+			queue = append(queue, ref)
+		}
+	}
+
+	return sortedKeys(seen)
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	if len(m) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,83 @@
+package ppparse_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/internal/ppparse"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("returns an error with the line when the template can't be parsed", func(t *testing.T) {
+		_, err := ppparse.Parse("broken.tmpl", "line 1\nline 2 {{ .Missing")
+
+		var parseErr *ppparse.Error
+		require.ErrorAs(t, err, &parseErr)
+		require.Equal(t, "broken.tmpl", parseErr.Name)
+		require.Equal(t, 2, parseErr.Line)
+		require.Equal(t, "broken.tmpl:2: unclosed action", err.Error())
+		require.Equal(t, "  1 | line 1\n> 2 | line 2 {{ .Missing", parseErr.Excerpt)
+	})
+
+	t.Run("the excerpt is the lines around the error", func(t *testing.T) {
+		var content []string
+		for i := 1; i <= 12; i++ {
+			content = append(content, fmt.Sprintf("line %d", i))
+		}
+		content[9] += " {{ if }}"
+
+		_, err := ppparse.Parse("broken.tmpl", strings.Join(content, "\n"))
+
+		var parseErr *ppparse.Error
+		require.ErrorAs(t, err, &parseErr)
+		require.Equal(t, "   9 | line 9\n> 10 | line 10 {{ if }}\n  11 | line 11", parseErr.Excerpt)
+	})
+
+	t.Run("parses templates using funcs that aren't known", func(t *testing.T) {
+		_, err := ppparse.Parse("funcs.tmpl", `{{ unknownFunc . }}`)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("finds the defines and references in the file", func(t *testing.T) {
+		f, err := ppparse.Parse("page.tmpl", `
+{{ define "title" }}{{ template "_title.tmpl" . }}{{ end }}
+{{ block "content" . }}
+  {{ range .Items }}{{ template "_item.tmpl" . }}{{ else }}{{ template "_empty.tmpl" }}{{ end }}
+  {{ if .Footer }}{{ with .Footer }}{{ template "_footer.tmpl" . }}{{ end }}{{ end }}
+{{ end }}`)
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"content", "title"}, f.Defines())
+		require.Equal(t, []string{"_empty.tmpl", "_footer.tmpl", "_item.tmpl", "_title.tmpl", "content"}, f.References())
+	})
+}
+
+func TestSet(t *testing.T) {
+	layout, err := ppparse.Parse("layout.tmpl", `{{ template "_nav.tmpl" }}{{ block "content" . }}{{ end }}`)
+	require.NoError(t, err)
+	nav, err := ppparse.Parse("_nav.tmpl", `{{ template "_link.tmpl" }}`)
+	require.NoError(t, err)
+	page, err := ppparse.Parse("page.tmpl", `{{ define "content" }}{{ template "_item.tmpl" }}{{ end }}`)
+	require.NoError(t, err)
+
+	set := ppparse.NewSet(layout, nav, page)
+
+	t.Run("Reachable follows references through all files", func(t *testing.T) {
+		require.Equal(t, []string{"_item.tmpl", "_link.tmpl", "_nav.tmpl", "content"}, set.Reachable("layout.tmpl"))
+	})
+
+	t.Run("Defined knows about files and their defines", func(t *testing.T) {
+		require.True(t, set.Defined("_nav.tmpl"))
+		require.True(t, set.Defined("content"))
+		require.False(t, set.Defined("_link.tmpl"))
+	})
+
+	t.Run("File returns the file by name", func(t *testing.T) {
+		require.Equal(t, page, set.File("page.tmpl"))
+		require.Nil(t, set.File("missing.tmpl"))
+	})
+}
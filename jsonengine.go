@@ -0,0 +1,60 @@
+package passepartout
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// JSONEngine renders JSON templates with text/template, since the escaping of html/template is wrong for JSON, and
+// fails a render whose output doesn't parse as JSON so a broken response is never sent. Values are written as JSON
+// with the json func, like {"name": {{ json .Name }}}. Use it for the JSON templates with [WithEngineFor]:
+//
+//	passepartout.LoadFrom(fsys, passepartout.WithEngineFor(".json.tmpl", passepartout.JSONEngine))
+//
+// A page is rendered in a layout as the block the template loader defined it as, like with html/template.
+var JSONEngine Engine = EngineFunc(compileJSON)
+
+func compileJSON(page, layout string, files []ppdefaults.FileWithContent) (Executable, error) {
+	tmpl, err := compileText(template.FuncMap{"json": marshalJSON}, page, layout, files)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonTemplate{tmpl: tmpl}, nil
+}
+
+func marshalJSON(v any) (string, error) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to write JSON: %w", err)
+	}
+
+	return string(out), nil
+}
+
+// jsonTemplate only writes the output of the template when it's valid JSON.
+type jsonTemplate struct {
+	tmpl *template.Template
+}
+
+func (t *jsonTemplate) ExecuteTemplate(w io.Writer, name string, data any) error {
+	buf := new(bytes.Buffer)
+	if err := t.tmpl.ExecuteTemplate(buf, name, data); err != nil {
+		return err
+	}
+
+	if !json.Valid(buf.Bytes()) {
+		// Unmarshal to say where the output is invalid.
+		var v any
+		err := json.Unmarshal(buf.Bytes(), &v)
+		return fmt.Errorf("failed to render %q: the output isn't valid JSON: %w", name, err)
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
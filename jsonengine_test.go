@@ -0,0 +1,44 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+func TestJSONEngine(t *testing.T) {
+	pp, err := passepartout.LoadFrom(fstest.MapFS{
+		"reviews/show.json.tmpl":   {Data: []byte(`{"title": {{ json .Title }}, "stars": {{ .Stars }}}`)},
+		"reviews/broken.json.tmpl": {Data: []byte(`{"title": {{ .Title }}}`)},
+		"reviews/show.tmpl":        {Data: []byte(`<h1>{{ .Title }}</h1>`)},
+	}, passepartout.WithEngineFor(".json.tmpl", passepartout.JSONEngine))
+	require.NoError(t, err)
+	data := map[string]any{"Title": `<Great> "food"`, "Stars": 5}
+
+	t.Run("renders JSON without escaping it for HTML", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+
+		require.NoError(t, pp.Render(buf, "reviews/show.json.tmpl", data))
+		require.JSONEq(t, `{"title": "<Great> \"food\"", "stars": 5}`, buf.String())
+	})
+
+	t.Run("renders the other templates with html/template", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+
+		require.NoError(t, pp.Render(buf, "reviews/show.tmpl", data))
+		require.Equal(t, `<h1>&lt;Great&gt; &#34;food&#34;</h1>`, buf.String())
+	})
+
+	t.Run("fails and writes nothing when the output isn't valid JSON", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+
+		err := pp.Render(buf, "reviews/broken.json.tmpl", data)
+
+		require.ErrorContains(t, err, `failed to render "reviews/broken.json.tmpl": the output isn't valid JSON`)
+		require.Empty(t, buf.String())
+	})
+}
@@ -0,0 +1,84 @@
+package passepartout
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// LiveReloadPath is where the script added by [WithLiveReload] listens for changes to the templates.
+const LiveReloadPath = "/_passepartout/reload"
+
+// LiveReloadScript is the script added by [WithLiveReload].
+const LiveReloadScript = `<script>new EventSource("` + LiveReloadPath + `").onmessage = () => location.reload();</script>`
+
+// LiveReload serves server-sent events to the script added by [WithLiveReload], sending one when a template changes.
+// Only instances created with [LoadFrom] know their filesystem, others respond with an error.
+func (p *Passepartout) LiveReload() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := p.serveLiveReload(w, r); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func (p *Passepartout) serveLiveReload(w http.ResponseWriter, r *http.Request) error {
+	if p.fs == nil {
+		return errors.New("failed to watch templates: no filesystem, create passepartout with LoadFrom")
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return errors.New("failed to watch templates: the response can't be streamed")
+	}
+
+	old, err := ppdefaults.NewManifest(p.fs)
+	if err != nil {
+		return fmt.Errorf("failed to watch templates: %w", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	poll := p.liveReload
+	if poll <= 0 {
+		poll = 500 * time.Millisecond
+	}
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-ticker.C:
+			// A template that's being written can fail to read, check again on the next tick.
+			newer, err := ppdefaults.NewManifest(p.fs)
+			if err != nil || old.Changes(newer).Empty() {
+				continue
+			}
+
+			_, _ = fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+			return nil
+		}
+	}
+}
+
+func injectLiveReload(name string, output []byte) ([]byte, error) {
+	if mediaType, _, _ := mime.ParseMediaType(contentType(name)); mediaType != "text/html" {
+		return output, nil
+	}
+
+	i := bytes.LastIndex(output, []byte("</body>"))
+	if i < 0 {
+		return append(output, LiveReloadScript...), nil
+	}
+
+	return append(output[:i:i], append([]byte(LiveReloadScript), output[i:]...)...), nil
+}
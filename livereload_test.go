@@ -0,0 +1,85 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+func TestWithLiveReload(t *testing.T) {
+	fs := fstest.MapFS{
+		"index.tmpl":     {Data: []byte(`<body>{{ . }}</body>`)},
+		"fragment.tmpl":  {Data: []byte(`{{ . }}`)},
+		"feed.json.tmpl": {Data: []byte(`{"name": "{{ . }}"}`)},
+	}
+	pp, err := passepartout.LoadFrom(fs, passepartout.WithLiveReload(0))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{
+			name:     "adds the script before the closing body tag",
+			template: "index.tmpl",
+			expected: `<body>hi` + passepartout.LiveReloadScript + `</body>`,
+		},
+		{
+			name:     "adds the script at the end without a body",
+			template: "fragment.tmpl",
+			expected: `hi` + passepartout.LiveReloadScript,
+		},
+		{
+			name:     "leaves output that isn't HTML alone",
+			template: "feed.json.tmpl",
+			expected: `{"name": "hi"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			require.NoError(t, pp.Render(buf, tc.template, "hi"))
+
+			require.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestPassepartout_LiveReload(t *testing.T) {
+	t.Run("sends an event when a template changes", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "index.tmpl"), []byte(`before`), 0o644))
+		pp, err := passepartout.LoadFrom(os.DirFS(dir).(passepartout.FS), passepartout.WithLiveReload(10*time.Millisecond))
+		require.NoError(t, err)
+		ts := httptest.NewServer(pp.LiveReload())
+		t.Cleanup(ts.Close)
+
+		res, err := http.Get(ts.URL)
+		require.NoError(t, err)
+		defer func() { _ = res.Body.Close() }()
+		require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "index.tmpl"), []byte(`after`), 0o644))
+
+		event, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, "data: reload\n\n", string(event))
+	})
+
+	t.Run("fails without a filesystem to watch", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		passepartout.New(nil).LiveReload().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, passepartout.LiveReloadPath, nil))
+
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+		require.Contains(t, rec.Body.String(), "no filesystem")
+	})
+}
@@ -0,0 +1,97 @@
+package passepartout
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// Meta is what a page says about itself in the <head> of the layout: its title, description, and how it's shown when
+// shared, with OpenGraph. Render it in the layout with the meta func of [WithMeta].
+type Meta struct {
+	Title       string
+	Description string
+	// Image is the URL of the image shown when the page is shared, og:image.
+	Image string
+	// URL is the canonical URL of the page, og:url.
+	URL string
+	// Type is the og:type of the page, "website" when empty.
+	Type     string
+	SiteName string
+}
+
+// merge returns m with the empty fields set from defaults.
+func (m Meta) merge(defaults Meta) Meta {
+	for _, f := range []struct {
+		field    *string
+		fallback string
+	}{
+		{&m.Title, defaults.Title},
+		{&m.Description, defaults.Description},
+		{&m.Image, defaults.Image},
+		{&m.URL, defaults.URL},
+		{&m.Type, defaults.Type},
+		{&m.SiteName, defaults.SiteName},
+	} {
+		if *f.field == "" {
+			*f.field = f.fallback
+		}
+	}
+
+	return m
+}
+
+// HTML renders the title and meta tags of m, leaving out the tags of the empty fields.
+func (m Meta) HTML() template.HTML {
+	if m.Type == "" {
+		m.Type = "website"
+	}
+
+	var b strings.Builder
+	if m.Title != "" {
+		fmt.Fprintf(&b, "<title>%s</title>\n", template.HTMLEscapeString(m.Title))
+	}
+	if m.Description != "" {
+		fmt.Fprintf(&b, "<meta name=\"description\" content=\"%s\">\n", template.HTMLEscapeString(m.Description))
+	}
+	for _, tag := range [][2]string{
+		{"og:title", m.Title},
+		{"og:description", m.Description},
+		{"og:image", m.Image},
+		{"og:url", m.URL},
+		{"og:type", m.Type},
+		{"og:site_name", m.SiteName},
+	} {
+		if tag[1] != "" {
+			fmt.Fprintf(&b, "<meta property=\"%s\" content=\"%s\">\n", tag[0], template.HTMLEscapeString(tag[1]))
+		}
+	}
+
+	return template.HTML(strings.TrimSuffix(b.String(), "\n"))
+}
+
+// metaFunc renders the Meta of a page, when given, merged over defaults.
+func metaFunc(defaults Meta) func(page ...any) (template.HTML, error) {
+	return func(page ...any) (template.HTML, error) {
+		if len(page) > 1 {
+			return "", fmt.Errorf("failed to render meta: takes the Meta of the page, got %d arguments", len(page))
+		}
+
+		var m Meta
+		if len(page) == 1 {
+			switch v := page[0].(type) {
+			case nil:
+			case Meta:
+				m = v
+			case *Meta:
+				if v != nil {
+					m = *v
+				}
+			default:
+				return "", fmt.Errorf("failed to render meta: expected a passepartout.Meta, got %T", page[0])
+			}
+		}
+
+		return m.merge(defaults).HTML(), nil
+	}
+}
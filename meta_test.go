@@ -0,0 +1,68 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+func TestWithMeta(t *testing.T) {
+	defaults := passepartout.Meta{Title: "Reviews", Description: "All the reviews", SiteName: "Reviews"}
+
+	for _, tc := range []struct {
+		name        string
+		data        any
+		expected    string
+		expectedErr string
+	}{
+		{
+			name: "renders the Meta of the page over the defaults",
+			data: map[string]any{"Meta": passepartout.Meta{Title: "Dune <review>", Image: "https://example.com/dune.png", Type: "article"}},
+			expected: `<head><title>Dune &lt;review&gt;</title>
+<meta name="description" content="All the reviews">
+<meta property="og:title" content="Dune &lt;review&gt;">
+<meta property="og:description" content="All the reviews">
+<meta property="og:image" content="https://example.com/dune.png">
+<meta property="og:type" content="article">
+<meta property="og:site_name" content="Reviews"></head><main>page</main>`,
+		},
+		{
+			name: "renders the defaults when the page has no Meta",
+			data: map[string]any{},
+			expected: `<head><title>Reviews</title>
+<meta name="description" content="All the reviews">
+<meta property="og:title" content="Reviews">
+<meta property="og:description" content="All the reviews">
+<meta property="og:type" content="website">
+<meta property="og:site_name" content="Reviews"></head><main>page</main>`,
+		},
+		{
+			name:        "fails when the Meta of the page isn't a Meta",
+			data:        map[string]any{"Meta": "Dune"},
+			expectedErr: "failed to render meta: expected a passepartout.Meta, got string",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"layouts/base.tmpl": {Data: []byte(`<head>{{ meta .Meta }}</head><main>{{ block "content" . }}{{ end }}</main>`)},
+				"page.tmpl":         {Data: []byte(`page`)},
+			}
+			pp, err := passepartout.LoadFrom(fsys, passepartout.WithMeta(defaults))
+			require.NoError(t, err)
+			buf := new(bytes.Buffer)
+
+			err = pp.RenderInLayout(buf, "layouts/base.tmpl", "page.tmpl", tc.data)
+
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
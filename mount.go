@@ -0,0 +1,209 @@
+package passepartout
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// Mount adds the templates in fsys under prefix, like "admin/", so plugins and internal packages can contribute their
+// own templates to be rendered as "admin/users/index.tmpl" alongside the other templates, with the same layouts.
+// A mounted filesystem hides whatever is under prefix in the filesystems mounted before it.
+// Only instances created with [LoadFrom] know their filesystem, others return an error.
+func (p *Passepartout) Mount(prefix string, fsys fs.FS) error {
+	m, ok := p.fs.(*mountFS)
+	if !ok {
+		return fmt.Errorf("failed to mount %q: no filesystem, create passepartout with LoadFrom", prefix)
+	}
+
+	return m.mount(prefix, fsys)
+}
+
+// MountFS combines the filesystems in mounts into one, with each under its prefix, like "emails/" for one embedded
+// filesystem and "pages/" for another, so a single instance can load the templates of several packages.
+// A prefix within another prefix, like "pages/admin", hides that folder of the filesystem it's in.
+func MountFS(mounts map[string]fs.FS) (FS, error) {
+	prefixes := make([]string, 0, len(mounts))
+	for prefix := range mounts {
+		prefixes = append(prefixes, prefix)
+	}
+	// Mount the outer prefixes before the prefixes within them, which are sorted after.
+	sort.Strings(prefixes)
+
+	m := &mountFS{base: emptyFS{}}
+	for _, prefix := range prefixes {
+		if err := m.mount(prefix, mounts[prefix]); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// mountFS is the filesystem of [LoadFrom], with the filesystems of [Passepartout.Mount] under their prefixes.
+type mountFS struct {
+	base FS
+
+	mu sync.RWMutex
+	// mounts are in the order they were mounted.
+	mounts []mounted
+}
+
+type mounted struct {
+	prefix string
+	fs     FS
+}
+
+func (m *mountFS) mount(prefix string, fsys fs.FS) error {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "." || !fs.ValidPath(prefix) {
+		return fmt.Errorf("failed to mount %q: not a valid directory name", prefix)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mounts = append(m.mounts, mounted{prefix: prefix, fs: ppdefaults.AsFS(fsys)})
+
+	return nil
+}
+
+// route returns the filesystem name is in, and its name within that filesystem.
+func (m *mountFS) route(name string) (FS, string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for i := len(m.mounts) - 1; i >= 0; i-- {
+		prefix := m.mounts[i].prefix
+		if name == prefix {
+			return m.mounts[i].fs, "."
+		}
+		if strings.HasPrefix(name, prefix+"/") {
+			return m.mounts[i].fs, strings.TrimPrefix(name, prefix+"/")
+		}
+	}
+
+	return m.base, name
+}
+
+// mountDirs returns the directories in dir that are, or lead to, a mount.
+func (m *mountFS) mountDirs(dir string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var dirs []string
+	for _, mount := range m.mounts {
+		rest := mount.prefix
+		if dir != "." {
+			if !strings.HasPrefix(rest, dir+"/") {
+				continue
+			}
+			rest = strings.TrimPrefix(rest, dir+"/")
+		}
+		dirs = append(dirs, strings.SplitN(rest, "/", 2)[0])
+	}
+
+	return dirs
+}
+
+func (m *mountFS) Open(name string) (fs.File, error) {
+	fsys, rel := m.route(name)
+	f, err := fsys.Open(rel)
+	if err != nil && errors.Is(err, fs.ErrNotExist) && len(m.mountDirs(name)) > 0 {
+		return &mountDir{name: path.Base(name)}, nil
+	}
+
+	return f, err
+}
+
+func (m *mountFS) ReadFile(name string) ([]byte, error) {
+	fsys, rel := m.route(name)
+
+	return fsys.ReadFile(rel)
+}
+
+// ReadDir lists the entries of name, with the mounts in it as directories.
+func (m *mountFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	fsys, rel := m.route(name)
+	entries, err := fsys.ReadDir(rel)
+	dirs := m.mountDirs(name)
+	if err != nil && (!errors.Is(err, fs.ErrNotExist) || len(dirs) == 0) {
+		return nil, err
+	}
+	if len(dirs) == 0 {
+		return entries, nil
+	}
+
+	byName := make(map[string]fs.DirEntry, len(entries)+len(dirs))
+	for _, entry := range entries {
+		byName[entry.Name()] = entry
+	}
+	for _, dir := range dirs {
+		if entry, ok := byName[dir]; !ok || !entry.IsDir() {
+			byName[dir] = fs.FileInfoToDirEntry(&mountDir{name: dir})
+		}
+	}
+
+	merged := make([]fs.DirEntry, 0, len(byName))
+	for _, entry := range byName {
+		merged = append(merged, entry)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Name() < merged[j].Name() })
+
+	return merged, nil
+}
+
+// Glob is the Glob of the filesystem passed to [LoadFrom] while nothing is mounted, so it's as fast as without mounts.
+func (m *mountFS) Glob(pattern string) ([]string, error) {
+	m.mu.RLock()
+	hasMounts := len(m.mounts) > 0
+	m.mu.RUnlock()
+	if g, ok := m.base.(fs.GlobFS); ok && !hasMounts {
+		return g.Glob(pattern)
+	}
+
+	return fs.Glob(withoutGlob{m}, pattern)
+}
+
+// mountDir is a directory that only exists because a mount is in it, as its own [fs.File] and [fs.FileInfo].
+type mountDir struct {
+	name string
+}
+
+func (d *mountDir) Stat() (fs.FileInfo, error) { return d, nil }
+func (d *mountDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+func (d *mountDir) Close() error       { return nil }
+func (d *mountDir) Name() string       { return d.name }
+func (d *mountDir) Size() int64        { return 0 }
+func (d *mountDir) Mode() fs.FileMode  { return fs.ModeDir | 0o555 }
+func (d *mountDir) ModTime() time.Time { return time.Time{} }
+func (d *mountDir) IsDir() bool        { return true }
+func (d *mountDir) Sys() any           { return nil }
+
+// emptyFS has no files, for a [mountFS] that only has mounts.
+type emptyFS struct{}
+
+func (emptyFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (emptyFS) ReadFile(name string) ([]byte, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (emptyFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// withoutGlob hides Glob, so [fs.Glob] reads the directories instead of calling it.
+type withoutGlob struct {
+	fs.ReadDirFS
+}
@@ -0,0 +1,149 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+func TestPassepartout_Mount(t *testing.T) {
+	base := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":           {Data: []byte(`Home`)},
+	}
+	admin := fstest.MapFS{
+		"users/index.tmpl":      {Data: []byte(`<ul>{{ range . }}{{ template "admin/users/index/_row.tmpl" . }}{{ end }}</ul>`)},
+		"users/index/_row.tmpl": {Data: []byte(`<li>{{ . }}</li>`)},
+		"settings/profile.tmpl": {Data: []byte(`Profile`)},
+	}
+
+	t.Run("renders the templates of a mounted filesystem with its partials and the layouts", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(base)
+		require.NoError(t, err)
+		require.NoError(t, pp.Mount("admin/", admin))
+		buf := new(bytes.Buffer)
+
+		require.NoError(t, pp.RenderInLayout(buf, "layouts/default.tmpl", "admin/users/index.tmpl", []string{"Ada"}))
+		require.Equal(t, `<main><ul><li>Ada</li></ul></main>`, buf.String())
+	})
+
+	t.Run("still renders the templates of the filesystem passed to LoadFrom", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(base)
+		require.NoError(t, err)
+		require.NoError(t, pp.Mount("plugins/admin", admin))
+		buf := new(bytes.Buffer)
+
+		require.NoError(t, pp.Render(buf, "index.tmpl", nil))
+		require.Equal(t, `Home`, buf.String())
+	})
+
+	t.Run("lists the mounted templates", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(base)
+		require.NoError(t, err)
+		require.NoError(t, pp.Mount("plugins/admin", admin))
+
+		templates, err := pp.Templates()
+		require.NoError(t, err)
+
+		var names []string
+		for _, tmpl := range templates {
+			names = append(names, tmpl.Name)
+		}
+		require.Equal(t, []string{
+			"index.tmpl",
+			"layouts/default.tmpl",
+			"plugins/admin/settings/profile.tmpl",
+			"plugins/admin/users/index/_row.tmpl",
+			"plugins/admin/users/index.tmpl",
+		}, names)
+	})
+
+	t.Run("hides what is under the prefix in the filesystems mounted before it", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fstest.MapFS{"admin/settings/profile.tmpl": {Data: []byte(`Old`)}})
+		require.NoError(t, err)
+		require.NoError(t, pp.Mount("admin", admin))
+		buf := new(bytes.Buffer)
+
+		require.NoError(t, pp.Render(buf, "admin/settings/profile.tmpl", nil))
+		require.Equal(t, `Profile`, buf.String())
+	})
+
+	t.Run("fails on an invalid prefix", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(base)
+		require.NoError(t, err)
+
+		require.EqualError(t, pp.Mount("../admin", admin), `failed to mount "../admin": not a valid directory name`)
+	})
+
+	t.Run("fails without a filesystem", func(t *testing.T) {
+		pp := passepartout.New(ppdefaults.NewLoaderBuilder().WithDefaults(base).Build())
+
+		require.EqualError(t, pp.Mount("admin", admin), `failed to mount "admin": no filesystem, create passepartout with LoadFrom`)
+	})
+}
+
+func TestMountFS(t *testing.T) {
+	emails := fstest.MapFS{"welcome.tmpl": {Data: []byte(`Welcome {{ . }}`)}}
+	pages := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":           {Data: []byte(`{{ template "pages/index/_item.tmpl" . }}`)},
+		"index/_item.tmpl":     {Data: []byte(`Home {{ . }}`)},
+		"admin/index.tmpl":     {Data: []byte(`Hidden`)},
+	}
+	admin := fstest.MapFS{"index.tmpl": {Data: []byte(`Admin {{ . }}`)}}
+
+	fsys, err := passepartout.MountFS(map[string]fs.FS{"emails/": emails, "pages": pages, "pages/admin": admin})
+	require.NoError(t, err)
+	pp, err := passepartout.LoadFrom(fsys)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		render   func(buf *bytes.Buffer) error
+		expected string
+	}{
+		{
+			name:     "renders the templates of each filesystem under its prefix",
+			render:   func(buf *bytes.Buffer) error { return pp.Render(buf, "emails/welcome.tmpl", "Ada") },
+			expected: "Welcome Ada",
+		},
+		{
+			name: "renders with the partials and layouts of the filesystem",
+			render: func(buf *bytes.Buffer) error {
+				return pp.RenderInLayout(buf, "pages/layouts/default.tmpl", "pages/index.tmpl", "Ada")
+			},
+			expected: "<main>Home Ada</main>",
+		},
+		{
+			name:     "renders from a prefix within another prefix",
+			render:   func(buf *bytes.Buffer) error { return pp.Render(buf, "pages/admin/index.tmpl", "Ada") },
+			expected: "Admin Ada",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+
+			require.NoError(t, tc.render(buf))
+			require.Equal(t, tc.expected, buf.String())
+		})
+	}
+
+	t.Run("lists the prefixes as folders", func(t *testing.T) {
+		matches, err := fs.Glob(fsys, "*/*.tmpl")
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"emails/welcome.tmpl", "pages/index.tmpl"}, matches)
+	})
+
+	t.Run("fails with a prefix that isn't a folder name", func(t *testing.T) {
+		_, err := passepartout.MountFS(map[string]fs.FS{"../emails": emails})
+
+		require.EqualError(t, err, `failed to mount "../emails": not a valid directory name`)
+	})
+}
@@ -0,0 +1,221 @@
+package passepartout
+
+import (
+	"fmt"
+	"html/template"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// Funcs are the funcs the templates created by [LoadFrom] and [LoadBundle] can use:
+//   - get returns the field at a dotted path from data, like {{ get . "User.Address.City" "unknown" }}, or the
+//     fallback, nil when there is none, when a value on the way is nil or a map doesn't have the key. Fields are
+//     looked up like in a template, so methods are called, and a struct without the field is an error.
+func Funcs() template.FuncMap {
+	return template.FuncMap{"get": get}
+}
+
+func get(data any, path string, fallback ...any) (any, error) {
+	if len(fallback) > 1 {
+		return nil, fmt.Errorf("failed to get %q: takes one fallback, got %d", path, len(fallback))
+	}
+
+	value, err := lookupPath(data, strings.Split(strings.TrimPrefix(path, "."), "."))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %q: %w", path, err)
+	}
+	if value == nil && len(fallback) == 1 {
+		return fallback[0], nil
+	}
+
+	return value, nil
+}
+
+// nilSafe is what the field chains are rewritten to call with [WithNilSafeFields].
+func nilSafe(data any, path string) (any, error) {
+	return lookupPath(data, strings.Split(path, "."))
+}
+
+// lookupPath follows fields from data like a template does, and returns nil as soon as a value is nil or a map
+// doesn't have the key.
+func lookupPath(data any, fields []string) (any, error) {
+	v := reflect.ValueOf(data)
+	for _, field := range fields {
+		v = indirectInterface(v)
+		if !v.IsValid() {
+			return nil, nil
+		}
+
+		ptr := v
+		if ptr.Kind() != reflect.Pointer && ptr.CanAddr() {
+			ptr = ptr.Addr()
+		}
+		if m := ptr.MethodByName(field); m.IsValid() {
+			var err error
+			if v, err = call(m, field); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return nil, nil
+			}
+			v = v.Elem()
+		}
+
+		switch {
+		case v.Kind() == reflect.Struct:
+			f, ok := v.Type().FieldByName(field)
+			if !ok || !f.IsExported() {
+				return nil, fmt.Errorf("can't evaluate field %s in type %s", field, v.Type())
+			}
+			v = v.FieldByIndex(f.Index)
+		case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+			v = v.MapIndex(reflect.ValueOf(field).Convert(v.Type().Key()))
+		default:
+			return nil, fmt.Errorf("can't evaluate field %s in type %s", field, v.Type())
+		}
+	}
+
+	v = indirectInterface(v)
+	if !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return nil, nil
+	}
+
+	return v.Interface(), nil
+}
+
+func indirectInterface(v reflect.Value) reflect.Value {
+	for v.IsValid() && v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
+
+func call(m reflect.Value, name string) (reflect.Value, error) {
+	t := m.Type()
+	if t.NumIn() != 0 || t.NumOut() == 0 || t.NumOut() > 2 || (t.NumOut() == 2 && t.Out(1) != reflect.TypeFor[error]()) {
+		return reflect.Value{}, fmt.Errorf("can't call method %s: it must take no arguments and return a value and optionally an error", name)
+	}
+
+	out := m.Call(nil)
+	if len(out) == 2 && !out[1].IsNil() {
+		return reflect.Value{}, out[1].Interface().(error)
+	}
+
+	return out[0], nil
+}
+
+// rewriteNilSafe turns the field chains of file, like .User.Address.City or $.User.Name, into calls to nilSafe that
+// return nil instead of failing when a value on the way is nil. Chains with arguments calling a method are left as
+// they are. Only the chains are replaced, so the lines don't move and parse errors point at where the template was
+// written.
+func rewriteNilSafe(file ppdefaults.FileWithContent) (ppdefaults.FileWithContent, error) {
+	tree := parse.New(file.Name)
+	tree.Mode = parse.SkipFuncCheck
+	trees := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(file.Content, "", "", trees); err != nil {
+		// Let creating the template report the error.
+		return file, nil
+	}
+
+	type replacement struct {
+		pos      int
+		text     string
+		receiver string
+		path     []string
+	}
+	var replacements []replacement
+	for _, t := range trees {
+		walkCommands(t.Root, func(cmd *parse.CommandNode) {
+			for i, arg := range cmd.Args {
+				// The first argument of a command with arguments is called with them, like a method with arguments.
+				if i == 0 && len(cmd.Args) > 1 {
+					continue
+				}
+
+				// The position of a chain is where its second part is, so it's moved back to where the chain starts.
+				switch a := arg.(type) {
+				case *parse.FieldNode:
+					if len(a.Ident) > 1 {
+						replacements = append(replacements, replacement{pos: int(a.Pos) - len(a.Ident[0]) - 1, text: a.String(), receiver: ".", path: a.Ident})
+					}
+				case *parse.VariableNode:
+					if len(a.Ident) > 1 {
+						replacements = append(replacements, replacement{pos: int(a.Pos) - len(a.Ident[0]), text: a.String(), receiver: a.Ident[0], path: a.Ident[1:]})
+					}
+				}
+			}
+		})
+	}
+	if len(replacements) == 0 {
+		return file, nil
+	}
+	sort.Slice(replacements, func(i, j int) bool { return replacements[i].pos < replacements[j].pos })
+
+	var b strings.Builder
+	last := 0
+	for _, r := range replacements {
+		// A chain that isn't written where it was parsed, which shouldn't happen, is left alone.
+		if r.pos < last || r.pos < 0 || !strings.HasPrefix(file.Content[r.pos:], r.text) {
+			continue
+		}
+		b.WriteString(file.Content[last:r.pos])
+		fmt.Fprintf(&b, "(nilSafe %s %q)", r.receiver, strings.Join(r.path, "."))
+		last = r.pos + len(r.text)
+	}
+	b.WriteString(file.Content[last:])
+
+	file.Content = b.String()
+
+	return file, nil
+}
+
+// walkCommands calls fn for every command in node and below it.
+func walkCommands(node parse.Node, fn func(cmd *parse.CommandNode)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkCommands(child, fn)
+		}
+	case *parse.ActionNode:
+		walkCommands(n.Pipe, fn)
+	case *parse.IfNode:
+		walkBranchCommands(&n.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranchCommands(&n.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranchCommands(&n.BranchNode, fn)
+	case *parse.TemplateNode:
+		walkCommands(n.Pipe, fn)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			fn(cmd)
+			for _, arg := range cmd.Args {
+				walkCommands(arg, fn)
+			}
+		}
+	}
+}
+
+func walkBranchCommands(n *parse.BranchNode, fn func(cmd *parse.CommandNode)) {
+	walkCommands(n.Pipe, fn)
+	walkCommands(n.List, fn)
+	walkCommands(n.ElseList, fn)
+}
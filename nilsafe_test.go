@@ -0,0 +1,164 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+type nilSafeAddress struct {
+	City string
+}
+
+type nilSafeUser struct {
+	Name    string
+	Address *nilSafeAddress
+}
+
+func (u nilSafeUser) Greeting() string {
+	return "Hello " + u.Name
+}
+
+type nilSafeData struct {
+	User *nilSafeUser
+}
+
+func TestFuncs_get(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		template    string
+		data        any
+		expected    string
+		expectedErr string
+	}{
+		{
+			name:     "returns the field at the path",
+			template: `{{ get . "User.Address.City" "unknown" }}`,
+			data:     nilSafeData{User: &nilSafeUser{Address: &nilSafeAddress{City: "Stockholm"}}},
+			expected: "Stockholm",
+		},
+		{
+			name:     "returns the fallback when a value on the way is nil",
+			template: `{{ get . "User.Address.City" "unknown" }}`,
+			data:     nilSafeData{User: &nilSafeUser{}},
+			expected: "unknown",
+		},
+		{
+			name:     "returns nothing when a value on the way is nil without a fallback",
+			template: `{{ get . ".User.Address.City" }}`,
+			data:     nilSafeData{},
+			expected: "",
+		},
+		{
+			name:     "returns the fallback when a map doesn't have the key",
+			template: `{{ get . "User.Name" "anonymous" }}`,
+			data:     map[string]any{"User": map[string]any{}},
+			expected: "anonymous",
+		},
+		{
+			name:     "calls methods",
+			template: `{{ get . "User.Greeting" }}`,
+			data:     nilSafeData{User: &nilSafeUser{Name: "Ada"}},
+			expected: "Hello Ada",
+		},
+		{
+			name:        "fails when a struct doesn't have the field",
+			template:    `{{ get . "User.Email" }}`,
+			data:        nilSafeData{User: &nilSafeUser{}},
+			expectedErr: `failed to get "User.Email": can't evaluate field Email in type passepartout_test.nilSafeUser`,
+		},
+		{
+			name:        "fails with more than one fallback",
+			template:    `{{ get . "User.Name" "a" "b" }}`,
+			data:        nilSafeData{},
+			expectedErr: `failed to get "User.Name": takes one fallback, got 2`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pp, err := passepartout.LoadFrom(fstest.MapFS{"index.tmpl": {Data: []byte(tc.template)}})
+			require.NoError(t, err)
+			out := new(bytes.Buffer)
+
+			err = pp.Render(out, "index.tmpl", tc.data)
+
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func TestWithNilSafeFields(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		template string
+		data     any
+		expected string
+	}{
+		{
+			name:     "renders nil pointers in a field chain as empty",
+			template: `<p>{{ .User.Address.City }}</p>`,
+			data:     nilSafeData{User: &nilSafeUser{}},
+			expected: `<p></p>`,
+		},
+		{
+			name:     "renders the field when every value is set",
+			template: `<p>{{ .User.Address.City }}</p>`,
+			data:     nilSafeData{User: &nilSafeUser{Address: &nilSafeAddress{City: "Stockholm"}}},
+			expected: `<p>Stockholm</p>`,
+		},
+		{
+			name:     "rewrites chains from variables and in conditions",
+			template: `{{ $user := .User }}{{ if .User.Address }}set{{ else }}unset{{ end }} [{{ $user.Address.City }}{{ $.User.Name }}]`,
+			data:     nilSafeData{},
+			expected: `unset []`,
+		},
+		{
+			name:     "calls methods in the chain",
+			template: `{{ with .User }}{{ .Greeting }}{{ end }} {{ .User.Greeting }}`,
+			data:     nilSafeData{User: &nilSafeUser{Name: "Ada"}},
+			expected: `Hello Ada Hello Ada`,
+		},
+		{
+			name:     "renders a chain with a nil pointer passed to a template as empty",
+			template: `{{ define "city" }}[{{ . }}]{{ end }}{{ template "city" .User.Address.City }}`,
+			data:     nilSafeData{},
+			expected: `[]`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pp, err := passepartout.LoadFrom(fstest.MapFS{"index.tmpl": {Data: []byte(tc.template)}}, passepartout.WithNilSafeFields())
+			require.NoError(t, err)
+			out := new(bytes.Buffer)
+
+			require.NoError(t, pp.Render(out, "index.tmpl", tc.data))
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+
+	t.Run("fails on nil pointers in a field chain without the option", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fstest.MapFS{"index.tmpl": {Data: []byte(`{{ .User.Address.City }}`)}})
+		require.NoError(t, err)
+
+		require.ErrorContains(t, pp.Render(new(bytes.Buffer), "index.tmpl", nilSafeData{User: &nilSafeUser{}}), "nil pointer evaluating")
+	})
+
+	t.Run("works together with components", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fstest.MapFS{
+			"components/card.tmpl": {Data: []byte(`<div>{{ .Props.Title }}{{ .Children }}</div>`)},
+			"index.tmpl":           {Data: []byte(`{{ component "card" .User }}{{ .User.Address.City }}{{ end_component }}`)},
+		}, passepartout.WithComponents(), passepartout.WithNilSafeFields())
+		require.NoError(t, err)
+		out := new(bytes.Buffer)
+
+		require.NoError(t, pp.Render(out, "index.tmpl", map[string]any{"User": map[string]any{"Title": "Ada"}}))
+		require.Equal(t, `<div>Ada</div>`, out.String())
+	})
+}
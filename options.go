@@ -0,0 +1,408 @@
+package passepartout
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"maps"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gaqzi/passepartout/ppcapture"
+	"github.com/gaqzi/passepartout/ppmetrics"
+)
+
+// Option configures optional behavior when creating a [Passepartout] with [LoadFrom] or [New].
+type Option func(p *Passepartout)
+
+// WithErrorTemplate renders name instead of the requested template when rendering fails, for example a branded
+// "errors/500.tmpl" page instead of a broken half-page.
+// The error template is rendered standalone with [ErrorData] as its data, and to be able to replace the failed output
+// all rendering is buffered before being written out.
+func WithErrorTemplate(name string) Option {
+	return func(p *Passepartout) {
+		p.errorTemplate = name
+	}
+}
+
+// WithTemplateOption sets [template.Template.Option] options, like "missingkey=error" for strict rendering where
+// missing map keys are errors instead of "<no value>". Only used by [LoadFrom], configure the loader passed to [New]
+// with [ppdefaults.LoaderBuilder.WithTemplateOption] instead.
+func WithTemplateOption(opt ...string) Option {
+	return func(p *Passepartout) {
+		p.templateOptions = append(p.templateOptions, opt...)
+	}
+}
+
+// validTemplateOptions checks the options up front, as [template.Template.Option] panics on unknown options.
+func validTemplateOptions(opts []string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to set template options: %v", r)
+		}
+	}()
+	template.New("").Option(opts...)
+
+	return nil
+}
+
+// WithCapture stores a copy of everything rendered in store, keyed by template, time, and request ID,
+// to make it possible to review exactly what users saw. Meant for non-production environments.
+// The request ID is read from the X-Request-Id header of the response when rendering into an [http.ResponseWriter].
+func WithCapture(store ppcapture.Store) Option {
+	return func(p *Passepartout) {
+		p.capture = store
+	}
+}
+
+// WithOutputCache caches the rendered output of the templates that declare a [CachePolicy], in memory.
+// Templates without a policy are always rendered, and failed renders are never cached.
+// The policy of a template is read once, so changes to it are picked up on restart.
+func WithOutputCache() Option {
+	return WithOutputCacheStore(NewMemoryOutputStore(), nil, 0)
+}
+
+// WithOutputCacheStore caches the rendered output in store, like [WithOutputCache], and also the output of the
+// templates without a [CachePolicy] that key returns a key for, for ttl. The key must tell apart every output of the
+// template, like the layout, name, and the ID and version of the data, and an empty key renders without caching.
+// key can be nil to only cache the templates with a policy.
+func WithOutputCacheStore(store OutputStore, key func(layout, name string, data any) string, ttl time.Duration) Option {
+	return func(p *Passepartout) {
+		p.outputCache = &outputCache{store: store, key: key, ttl: ttl, policies: make(map[string]*CachePolicy)}
+	}
+}
+
+// WithExtensions lets templates be rendered without their extension, like "reviews/index" for "reviews/index.tmpl",
+// by trying each of exts in order and rendering the first template that exists. Names ending in one of exts are used
+// as they are, and if none exists the name is rendered as is so the error is about the name asked for.
+func WithExtensions(exts ...string) Option {
+	return func(p *Passepartout) {
+		p.extensions = append(p.extensions, exts...)
+	}
+}
+
+// WithNormalizedNames normalizes the names of the templates rendered with [ppdefaults.NormalizeName] before they're
+// loaded, so names built with the separators of Windows, [path/filepath], or with a leading "./" find the template.
+// Only the names passed to passepartout are normalized, not the names of the templates executed from templates.
+func WithNormalizedNames() Option {
+	return func(p *Passepartout) {
+		p.normalizeNames = true
+	}
+}
+
+// WithCaseInsensitiveLookup finds the templates rendered ignoring the case of their names, like on the filesystems of
+// macOS and Windows, so "Reviews/Index.tmpl" renders "reviews/index.tmpl". A name that exists as it is is always used,
+// and a name matching several files that differ only by case fails to render. Only used by [LoadFrom].
+func WithCaseInsensitiveLookup() Option {
+	return func(p *Passepartout) {
+		p.caseInsensitive = true
+	}
+}
+
+// WithTemplateCache creates every template once and renders the same template every time after that, so the hot path
+// never loads or parses files. Changes to the templates aren't picked up until restarted. Only used by [LoadFrom] and
+// [LoadBundle], wrap the loader passed to [New] with [ppdefaults.NewTemplateCache] instead.
+func WithTemplateCache() Option {
+	return func(p *Passepartout) {
+		p.templateCache = true
+	}
+}
+
+// WithComponents lets templates render components with [Passepartout.RenderComponent], so
+// {{ component "card" .Props }}...{{ end_component }} renders "components/card" with the extension of the template
+// using it. The content up to {{ end_component }}, which every component needs, is rendered with the data at that
+// point and passed as the children. Variables from outside the component aren't available in the children.
+// Only used by [LoadFrom] and [LoadBundle].
+func WithComponents() Option {
+	return func(p *Passepartout) {
+		p.components = true
+	}
+}
+
+// WithFragmentCache caches the output of the parts of templates between {{ cache "key" ttl }} and {{ endcache }} in
+// store, so expensive partials aren't rendered on every request. The arguments after ttl are added to the key, like
+// {{ cache "review" "1h" .ID .UpdatedAt }}, and caches can be nested so an outer cache is rendered from the inner
+// caches that are still fresh. The content of a cache has the same data as where it's written, but not its variables.
+// Only used by [LoadFrom] and [LoadBundle].
+func WithFragmentCache(store OutputStore) Option {
+	return func(p *Passepartout) {
+		p.fragmentCache = store
+	}
+}
+
+// WithNilSafeFields renders a field chain like .User.Address.City as empty when a value on the way is nil, or a map
+// doesn't have the key, instead of failing the render, like wrapping every chain in [Funcs] get.
+// Only used by [LoadFrom] and [LoadBundle].
+func WithNilSafeFields() Option {
+	return func(p *Passepartout) {
+		p.nilSafe = true
+	}
+}
+
+// WithMeta adds the meta func, which renders the title and meta tags of a [Meta] in the <head> of a layout, with
+// defaults for the fields a page leaves empty. A page sets its own Meta in the data it's rendered with:
+//
+//	<head>{{ meta .Meta }}</head>
+//
+// Without a Meta, like {{ meta }}, the defaults are rendered. Only used by [LoadFrom] and [LoadBundle].
+func WithMeta(defaults Meta) Option {
+	return func(p *Passepartout) {
+		p.funcs = append(p.funcs, template.FuncMap{"meta": metaFunc(defaults)})
+	}
+}
+
+// WithSanitizer adds the sanitize func, which renders user-generated HTML with only what s allows, as the default
+// policy:
+//
+//	<div class="comment">{{ sanitize .Comment.Body }}</div>
+//	<p>{{ sanitize .User.Bio "strict" }}</p>
+//
+// Other policies are added by name with [WithSanitizePolicy]. Only used by [LoadFrom] and [LoadBundle].
+func WithSanitizer(s Sanitizer) Option {
+	return func(p *Passepartout) {
+		p.sanitizePolicy("", s)
+	}
+}
+
+// WithSanitizePolicy adds the policy name to the sanitize func of [WithSanitizer], sanitizing with s.
+func WithSanitizePolicy(name string, s Sanitizer) Option {
+	return func(p *Passepartout) {
+		p.sanitizePolicy(name, s)
+	}
+}
+
+// WithEngine renders with engine instead of html/template, the loader collects the files for each template by its
+// conventions and engine compiles them on every render. The loader must expose its files, like [ppdefaults.Loader].
+// Options changing how templates are parsed, like [WithTemplateOption] and [WithComponents], don't apply to engine.
+func WithEngine(engine Engine) Option {
+	return func(p *Passepartout) {
+		p.engine = engine
+	}
+}
+
+// WithEngineFor renders the templates whose name ends with ext, like ".json.tmpl", with engine like [WithEngine], and
+// the other templates as before. Can be used multiple times, and the first engine added for a matching ext is used.
+// A page is rendered in a layout with the engine of the page.
+func WithEngineFor(ext string, engine Engine) Option {
+	return func(p *Passepartout) {
+		p.engines = append(p.engines, extEngine{ext: ext, engine: engine})
+	}
+}
+
+type extEngine struct {
+	ext    string
+	engine Engine
+}
+
+// WithPostRender changes the output of every successful render before it's written, to plug in a CSS inliner for
+// emails or an HTML minifier. Can be used multiple times, and they run in the order they were added.
+// A failing post-render is handled like a failed render, so the error template is rendered when configured.
+// The output is buffered, and streamed renders, components, and the error template aren't post-rendered.
+func WithPostRender(postRender func(name string, html []byte) ([]byte, error)) Option {
+	return func(p *Passepartout) {
+		p.postRenders = append(p.postRenders, postRender)
+	}
+}
+
+// Minifier minifies output of the media type, like "text/html". The M of github.com/tdewolff/minify implements it,
+// and the ppminify module configures one for HTML, CSS, JS, SVG, JSON, and XML.
+type Minifier interface {
+	Minify(mediaType string, w io.Writer, r io.Reader) error
+}
+
+// WithMinify minifies the output of every render with m, by the media type from the template's extension like
+// [Passepartout.RenderHTTP], in the same way as [WithPostRender].
+func WithMinify(m Minifier) Option {
+	return WithPostRender(func(name string, output []byte) ([]byte, error) {
+		mediaType, _, err := mime.ParseMediaType(contentType(name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to minify: %w", err)
+		}
+
+		buf := bytes.NewBuffer(make([]byte, 0, len(output)))
+		if err := m.Minify(mediaType, buf, bytes.NewReader(output)); err != nil {
+			return nil, fmt.Errorf("failed to minify: %w", err)
+		}
+
+		return buf.Bytes(), nil
+	})
+}
+
+// postRender replaces the output of name in buf with the result of the [WithPostRender] funcs.
+func (p *Passepartout) postRender(buf *bytes.Buffer, name string) error {
+	if len(p.postRenders) == 0 {
+		return nil
+	}
+
+	output := buf.Bytes()
+	for _, postRender := range p.postRenders {
+		var err error
+		if output, err = postRender(name, output); err != nil {
+			return fmt.Errorf("failed to post-render %q: %w", name, err)
+		}
+	}
+	buf.Reset()
+	buf.Write(output)
+
+	return nil
+}
+
+// WithLiveReload adds a script to the HTML output of every render, before "</body>" or at the end, that reloads the
+// page when a template changes, for development. Serve [Passepartout.LiveReload] at [LiveReloadPath] for the script to
+// connect to, it checks the templates for changes every poll, or every 500ms when poll is 0.
+// The script is added in the same way as [WithPostRender].
+func WithLiveReload(poll time.Duration) Option {
+	return func(p *Passepartout) {
+		p.liveReload = poll
+		if p.liveReload <= 0 {
+			p.liveReload = 500 * time.Millisecond
+		}
+		p.postRenders = append(p.postRenders, injectLiveReload)
+	}
+}
+
+// WithBuffering buffers the output of every render and writes it at once, instead of with the many small writes of
+// an executing template. Failed renders are written as they were rendered, the same as without buffering.
+func WithBuffering() Option {
+	return func(p *Passepartout) {
+		p.buffering = true
+	}
+}
+
+// WithDebugComments adds an HTML comment to the end of the HTML output of every successful render naming the
+// template, the layout, and the files they were loaded from, for development.
+func WithDebugComments() Option {
+	return func(p *Passepartout) {
+		p.debugComments = true
+	}
+}
+
+// WithDataValidation checks the data of every render against the fields the template reads, in every branch and not
+// only the ones executed, and fails the render with a [*DataError] listing the fields the data doesn't have, for
+// development so a missing map key is found before it renders "<no value>" in production.
+// Fields guarded by {{ if .Field }} or {{ with .Field }} may be missing, methods aren't called so the fields of what
+// they return are only checked against its type, and only the first element is checked within a range.
+func WithDataValidation() Option {
+	return func(p *Passepartout) {
+		p.dataValidation = true
+	}
+}
+
+// WithRenderTimeout stops a render that takes longer than timeout and returns a [*TimeoutError] naming the template.
+// An executing template can only be stopped when it writes, so a template is stopped at the first write after the
+// timeout. Renders with a context are also stopped when the context is done, with or without a timeout.
+func WithRenderTimeout(timeout time.Duration) Option {
+	return func(p *Passepartout) {
+		p.renderTimeout = timeout
+	}
+}
+
+// WithRepanic panics with the [*PanicError] a panicking render returns instead, for development where a crash with
+// the stack trace is more useful than an error page.
+func WithRepanic() Option {
+	return func(p *Passepartout) {
+		p.repanic = true
+	}
+}
+
+// WithResolveGuard calls guard with the name of every template before it's loaded, pages and layouts alike, and fails
+// the render with its error when it returns one, for apps that render templates named by user input and want to only
+// allow some of them. Partials are loaded with the page that uses them, so they're only checked when loaded by name.
+// Can be used multiple times, and every guard has to allow the name.
+//
+// The names are checked after [WithExtensions] and the other name options have resolved them, and the error template
+// is checked too.
+func WithResolveGuard(guard func(name string) error) Option {
+	return func(p *Passepartout) {
+		p.resolveGuards = append(p.resolveGuards, guard)
+	}
+}
+
+// WithHooks calls hooks around every load and render, see [Hooks]. Can be used multiple times, and the hooks are
+// called in the order they were added.
+func WithHooks(hooks Hooks) Option {
+	return func(p *Passepartout) {
+		p.hooks = append(p.hooks, hooks)
+	}
+}
+
+// WithMetrics records how long each render takes and if it failed with m. With [LoadFrom] the time it takes to load
+// and parse the templates is recorded as well, configure the loader passed to [New] with
+// [ppdefaults.LoaderBuilder.Metrics] and [ppdefaults.CachedLoader.WithMetrics] instead.
+func WithMetrics(m ppmetrics.Recorder) Option {
+	return func(p *Passepartout) {
+		p.metrics = m
+		p.hooks = append(p.hooks, Hooks{OnRenderEnd: func(info HookInfo) {
+			m.Render(info.Template, info.Duration, info.Err)
+		}})
+	}
+}
+
+// WithLogger logs every file resolved for a template at debug level with logger, to find out which files a page was
+// rendered from. Only used by [LoadFrom], configure the loader passed to [New] with [ppdefaults.LoaderBuilder.Logger].
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Passepartout) {
+		p.logger = logger
+	}
+}
+
+// WithGlobalData merges the data returned by global, like the CurrentYear or a CSPNonce used by the layouts, into the
+// data of every render. Keys already in the render's data are kept, and the render's map isn't modified.
+// Only nil data and data of the type map[string]any are merged, other data is rendered as it is.
+// Renders without a context, like [Passepartout.Render], pass a background context to global.
+func WithGlobalData(global func(ctx context.Context) map[string]any) Option {
+	return func(p *Passepartout) {
+		p.globalData = global
+	}
+}
+
+// withGlobalData returns data with the keys from [WithGlobalData] it doesn't have.
+func (p *Passepartout) withGlobalData(ctx context.Context, data any) any {
+	if p.globalData == nil {
+		return data
+	}
+
+	var local map[string]any
+	switch d := data.(type) {
+	case nil:
+	case map[string]any:
+		local = d
+	default:
+		return data
+	}
+
+	merged := maps.Clone(p.globalData(ctx))
+	if merged == nil {
+		merged = make(map[string]any, len(local))
+	}
+	maps.Copy(merged, local)
+
+	return merged
+}
+
+// storeCapture is best-effort, a capture that fails to be stored doesn't fail the render.
+func (p *Passepartout) storeCapture(out io.Writer, layout, name string, output []byte, renderErr error) {
+	if p.capture == nil {
+		return
+	}
+
+	c := ppcapture.Capture{
+		Template:    name,
+		Layout:      layout,
+		Time:        time.Now(),
+		ContentType: contentType(name),
+		Output:      append([]byte(nil), output...),
+	}
+	if w, ok := out.(http.ResponseWriter); ok {
+		c.RequestID = w.Header().Get("X-Request-Id")
+	}
+	if renderErr != nil {
+		c.Err = renderErr.Error()
+	}
+
+	_ = p.capture.Store(c)
+}
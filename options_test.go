@@ -0,0 +1,435 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/ppcapture"
+	"github.com/gaqzi/passepartout/ppmetrics"
+)
+
+func TestWithCapture(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":           {Data: []byte(`Hello, {{ . }}!`)},
+		"broken.tmpl":          {Data: []byte(`half {{ index . 1 }}`)},
+	}
+
+	t.Run("stores a copy of what Render and RenderInLayout wrote", func(t *testing.T) {
+		store := ppcapture.NewMemoryStore(10)
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithCapture(store))
+		require.NoError(t, err)
+		output := new(bytes.Buffer)
+
+		require.NoError(t, pp.Render(output, "index.tmpl", "world"))
+		require.NoError(t, pp.RenderInLayout(output, "layouts/default.tmpl", "index.tmpl", "layout"))
+
+		captures, err := store.List()
+		require.NoError(t, err)
+		require.Len(t, captures, 2)
+		require.Equal(t, "<main>Hello, layout!</main>", string(captures[0].Output))
+		require.Equal(t, "layouts/default.tmpl", captures[0].Layout)
+		require.Equal(t, "Hello, world!", string(captures[1].Output))
+		require.Equal(t, "index.tmpl", captures[1].Template)
+		require.False(t, captures[1].Time.IsZero())
+		require.Equal(t, "Hello, world!<main>Hello, layout!</main>", output.String(), "expected the output to be unchanged")
+	})
+
+	t.Run("failed renders are stored with their error and still write what they rendered", func(t *testing.T) {
+		store := ppcapture.NewMemoryStore(10)
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithCapture(store))
+		require.NoError(t, err)
+		output := new(bytes.Buffer)
+
+		err = pp.Render(output, "broken.tmpl", nil)
+
+		require.Error(t, err)
+		captures, err := store.List()
+		require.NoError(t, err)
+		require.Contains(t, captures[0].Err, `error calling index`)
+		require.Equal(t, "half ", output.String())
+	})
+
+	t.Run("RenderHTTP stores the request ID from the response", func(t *testing.T) {
+		store := ppcapture.NewMemoryStore(10)
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithCapture(store))
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		rec.Header().Set("X-Request-Id", "req-123")
+
+		require.NoError(t, pp.RenderHTTP(rec, http.StatusOK, "index.tmpl", "http"))
+
+		captures, err := store.List()
+		require.NoError(t, err)
+		require.Equal(t, "req-123", captures[0].RequestID)
+		require.Equal(t, "Hello, http!", string(captures[0].Output))
+		require.Equal(t, "text/html; charset=utf-8", captures[0].ContentType)
+	})
+}
+
+func TestWithTemplateOption(t *testing.T) {
+	fs := fstest.MapFS{"index.tmpl": {Data: []byte(`{{ .missing }}`)}}
+
+	t.Run("applies the option to all templates", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithTemplateOption("missingkey=error"))
+		require.NoError(t, err)
+
+		err = pp.Render(new(bytes.Buffer), "index.tmpl", map[string]any{})
+
+		require.ErrorContains(t, err, `map has no entry for key "missing"`)
+	})
+
+	t.Run("renders missing keys as no value by default", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs)
+		require.NoError(t, err)
+		buf := new(bytes.Buffer)
+
+		require.NoError(t, pp.Render(buf, "index.tmpl", map[string]any{}))
+		require.Equal(t, "", buf.String(), "html/template renders <no value> as empty")
+	})
+
+	t.Run("returns an error for an unknown option", func(t *testing.T) {
+		_, err := passepartout.LoadFrom(fs, passepartout.WithTemplateOption("nope"))
+
+		require.ErrorContains(t, err, "failed to set template options")
+	})
+}
+
+func TestWithMetrics(t *testing.T) {
+	fs := fstest.MapFS{
+		"index.tmpl":  {Data: []byte(`Hello, {{ . }}!`)},
+		"broken.tmpl": {Data: []byte(`{{ index . 1 }}`)},
+	}
+	metrics := ppmetrics.NewExpvar()
+	pp, err := passepartout.LoadFrom(fs, passepartout.WithMetrics(metrics))
+	require.NoError(t, err)
+
+	require.NoError(t, pp.Render(new(bytes.Buffer), "index.tmpl", "world"))
+	require.Error(t, pp.Render(new(bytes.Buffer), "broken.tmpl", nil))
+
+	var recorded struct {
+		Load         map[string]struct{ Count int }
+		Parse        map[string]struct{ Count int }
+		Render       map[string]struct{ Count int }
+		RenderErrors map[string]int `json:"render_errors"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(metrics.String()), &recorded))
+	require.Equal(t, 1, recorded.Load["index.tmpl"].Count)
+	require.Equal(t, 1, recorded.Parse["broken.tmpl"].Count)
+	require.Equal(t, 1, recorded.Render["index.tmpl"].Count)
+	require.Equal(t, map[string]int{"broken.tmpl": 1}, recorded.RenderErrors)
+}
+
+func TestWithLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	pp, err := passepartout.LoadFrom(fstest.MapFS{"index.tmpl": {Data: []byte(`Hello`)}}, passepartout.WithLogger(logger))
+	require.NoError(t, err)
+
+	require.NoError(t, pp.Render(new(bytes.Buffer), "index.tmpl", nil))
+
+	require.Contains(t, buf.String(), `msg="resolved template file" template=index.tmpl layout="" file=index.tmpl kind=page order=0`)
+}
+
+func TestWithTemplateCache(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":           {Data: []byte(`Hello`)},
+	}
+	pp, err := passepartout.LoadFrom(fs, passepartout.WithTemplateCache())
+	require.NoError(t, err)
+	out := new(bytes.Buffer)
+	require.NoError(t, pp.Render(out, "index.tmpl", nil))
+	require.NoError(t, pp.RenderInLayout(out, "layouts/default.tmpl", "index.tmpl", nil))
+
+	fs["index.tmpl"] = &fstest.MapFile{Data: []byte(`Changed`)}
+	out.Reset()
+	require.NoError(t, pp.Render(out, "index.tmpl", nil))
+	require.NoError(t, pp.RenderInLayout(out, "layouts/default.tmpl", "index.tmpl", nil))
+
+	require.Equal(t, "Hello<main>Hello</main>", out.String(), "expected the templates created by the first renders")
+}
+
+func TestWithExtensions(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.gohtml":   {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"reviews/index.gohtml":     {Data: []byte(`{{ template "reviews/index/_item.tmpl" }}`)},
+		"reviews/index/_item.tmpl": {Data: []byte(`item`)},
+		"robots.txt.tmpl":          {Data: []byte(`User-agent: *`)},
+		"about.tmpl":               {Data: []byte(`tmpl`)},
+		"about.gohtml":             {Data: []byte(`gohtml`)},
+	}
+	pp, err := passepartout.LoadFrom(fs, passepartout.WithExtensions(".tmpl", ".gohtml"))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		render   func(out *bytes.Buffer) error
+		expected string
+	}{
+		{
+			name:     "renders the template with the extension added, with its partials",
+			render:   func(out *bytes.Buffer) error { return pp.Render(out, "reviews/index", nil) },
+			expected: "item",
+		},
+		{
+			name:     "tries the extensions in order",
+			render:   func(out *bytes.Buffer) error { return pp.Render(out, "about", nil) },
+			expected: "tmpl",
+		},
+		{
+			name:     "uses names with one of the extensions as they are",
+			render:   func(out *bytes.Buffer) error { return pp.Render(out, "about.gohtml", nil) },
+			expected: "gohtml",
+		},
+		{
+			name:     "adds the extension after other dots in the name",
+			render:   func(out *bytes.Buffer) error { return pp.Render(out, "robots.txt", nil) },
+			expected: "User-agent: *",
+		},
+		{
+			name: "resolves both the layout and the page",
+			render: func(out *bytes.Buffer) error {
+				return pp.RenderInLayout(out, "layouts/default", "reviews/index", nil)
+			},
+			expected: "<main>item</main>",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+
+			require.NoError(t, tc.render(out))
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+
+	t.Run("returns the error for the name asked for when no template exists", func(t *testing.T) {
+		err := pp.Render(new(bytes.Buffer), "missing", nil)
+
+		require.ErrorContains(t, err, "open missing:")
+	})
+}
+
+func TestWithNormalizedNames(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl":     {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"reviews/index.tmpl":       {Data: []byte(`{{ template "reviews/index/_item.tmpl" }}`)},
+		"reviews/index/_item.tmpl": {Data: []byte(`item`)},
+	}
+
+	t.Run("renders the template with the name normalized", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithNormalizedNames(), passepartout.WithExtensions(".tmpl"))
+		require.NoError(t, err)
+
+		for _, name := range []string{`reviews\index.tmpl`, "./reviews/index.tmpl", "/reviews//index", `.\reviews\index`} {
+			out := new(bytes.Buffer)
+
+			require.NoError(t, pp.RenderInLayout(out, `.\layouts\default.tmpl`, name, nil), name)
+			require.Equal(t, "<main>item</main>", out.String(), name)
+		}
+		require.True(t, pp.Has(`reviews\index.tmpl`))
+	})
+
+	t.Run("uses the names as they are without the option", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs)
+		require.NoError(t, err)
+
+		require.Error(t, pp.Render(new(bytes.Buffer), "./reviews/index.tmpl", nil))
+	})
+}
+
+func TestWithCaseInsensitiveLookup(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl":     {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"reviews/index.tmpl":       {Data: []byte(`{{ template "reviews/index/_item.tmpl" }}`)},
+		"reviews/index/_item.tmpl": {Data: []byte(`item`)},
+		"about.tmpl":               {Data: []byte(`about`)},
+		"About.tmpl":               {Data: []byte(`About`)},
+		"help/faq.tmpl":            {Data: []byte(`faq`)},
+		"Help/faq.tmpl":            {Data: []byte(`Faq`)},
+	}
+	pp, err := passepartout.LoadFrom(fs, passepartout.WithCaseInsensitiveLookup(), passepartout.WithExtensions(".tmpl"))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		render   func(out *bytes.Buffer) error
+		expected string
+	}{
+		{
+			name: "renders the template and its partials ignoring case",
+			render: func(out *bytes.Buffer) error {
+				return pp.RenderInLayout(out, "Layouts/Default.tmpl", "Reviews/INDEX.tmpl", nil)
+			},
+			expected: "<main>item</main>",
+		},
+		{
+			name:     "adds the extension ignoring case",
+			render:   func(out *bytes.Buffer) error { return pp.Render(out, "reviews/Index", nil) },
+			expected: "item",
+		},
+		{
+			name:     "uses the name that exists as it is",
+			render:   func(out *bytes.Buffer) error { return pp.Render(out, "About.tmpl", nil) },
+			expected: "About",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+
+			require.NoError(t, tc.render(out))
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+
+	t.Run("fails when the name matches files that differ only by case", func(t *testing.T) {
+		err := pp.Render(new(bytes.Buffer), "HELP/faq.tmpl", nil)
+
+		require.EqualError(t, err, `failed to find "HELP/faq.tmpl" ignoring case: ["Help" "help"] differ only by case`)
+	})
+
+	t.Run("reports templates that exist ignoring case", func(t *testing.T) {
+		require.True(t, pp.Has("REVIEWS/index.tmpl"))
+		require.False(t, pp.Has("reviews/missing.tmpl"))
+	})
+}
+
+func TestWithGlobalData(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<footer>{{ .Year }}</footer>{{ block "content" . }}{{ end }}`)},
+		"index.tmpl":           {Data: []byte(`{{ .Title }}`)},
+	}
+	pp, err := passepartout.LoadFrom(fs, passepartout.WithGlobalData(func(ctx context.Context) map[string]any {
+		return map[string]any{"Year": 2026, "Title": ctx.Value(contextKey{})}
+	}))
+	require.NoError(t, err)
+	ctx := context.WithValue(context.Background(), contextKey{}, "Global")
+
+	for _, tc := range []struct {
+		name     string
+		data     any
+		expected string
+	}{
+		{name: "renders nil data with the global data", data: nil, expected: "<footer>2026</footer>Global"},
+		{name: "keeps the keys in the render's data", data: map[string]any{"Title": "Local"}, expected: "<footer>2026</footer>Local"},
+		{name: "renders other data as it is", data: struct{ Title, Year string }{"Struct", "1999"}, expected: "<footer>1999</footer>Struct"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+
+			require.NoError(t, pp.RenderInLayoutContext(ctx, out, "layouts/default.tmpl", "index.tmpl", tc.data))
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+
+	t.Run("doesn't modify the render's data", func(t *testing.T) {
+		data := map[string]any{"Title": "Local"}
+
+		require.NoError(t, pp.Render(new(bytes.Buffer), "index.tmpl", data))
+		require.Equal(t, map[string]any{"Title": "Local"}, data)
+	})
+}
+
+func TestWithPostRender(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":           {Data: []byte(`<p>  Hello  </p>`)},
+		"broken.tmpl":          {Data: []byte(`{{ template "missing" }}`)},
+	}
+	collapse := func(name string, html []byte) ([]byte, error) {
+		return bytes.ReplaceAll(html, []byte("  "), nil), nil
+	}
+	var names []string
+	record := func(name string, html []byte) ([]byte, error) {
+		names = append(names, name)
+		return append(html, "<!-- "+name+" -->"...), nil
+	}
+	pp, err := passepartout.LoadFrom(fs, passepartout.WithPostRender(collapse), passepartout.WithPostRender(record))
+	require.NoError(t, err)
+
+	t.Run("changes the output with the post-renders in order", func(t *testing.T) {
+		names = nil
+		out := new(bytes.Buffer)
+
+		require.NoError(t, pp.Render(out, "index.tmpl", nil))
+		require.NoError(t, pp.RenderInLayout(out, "layouts/default.tmpl", "index.tmpl", nil))
+		require.Equal(t, "<p>Hello</p><!-- index.tmpl --><main><p>Hello</p></main><!-- index.tmpl -->", out.String())
+		require.Equal(t, []string{"index.tmpl", "index.tmpl"}, names)
+	})
+
+	t.Run("post-renders RenderHTTP", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		require.NoError(t, pp.RenderHTTP(rec, http.StatusOK, "index.tmpl", nil))
+		require.Equal(t, "<p>Hello</p><!-- index.tmpl -->", rec.Body.String())
+	})
+
+	t.Run("doesn't post-render failed renders", func(t *testing.T) {
+		names = nil
+
+		require.Error(t, pp.Render(new(bytes.Buffer), "broken.tmpl", nil))
+		require.Empty(t, names)
+	})
+
+	t.Run("returns the error from a post-render and writes the output as it was rendered", func(t *testing.T) {
+		failing, err := passepartout.LoadFrom(fs, passepartout.WithPostRender(func(string, []byte) ([]byte, error) {
+			return nil, errors.New("invalid CSS")
+		}))
+		require.NoError(t, err)
+		out := new(bytes.Buffer)
+
+		err = failing.Render(out, "index.tmpl", nil)
+
+		require.EqualError(t, err, `failed to post-render "index.tmpl": invalid CSS`)
+		require.Equal(t, "<p>  Hello  </p>", out.String())
+	})
+}
+
+// spaceMinifier removes the spaces from HTML, and fails for other media types.
+type spaceMinifier struct{}
+
+func (spaceMinifier) Minify(mediaType string, w io.Writer, r io.Reader) error {
+	if mediaType != "text/html" {
+		return fmt.Errorf("no minifier for %s", mediaType)
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(bytes.ReplaceAll(content, []byte(" "), nil))
+	return err
+}
+
+func TestWithMinify(t *testing.T) {
+	pp, err := passepartout.LoadFrom(fstest.MapFS{
+		"index.tmpl":      {Data: []byte(`<p> Hello </p>`)},
+		"robots.txt.tmpl": {Data: []byte(`User-agent: *`)},
+	}, passepartout.WithMinify(spaceMinifier{}))
+	require.NoError(t, err)
+
+	t.Run("minifies the output by its media type", func(t *testing.T) {
+		out := new(bytes.Buffer)
+
+		require.NoError(t, pp.Render(out, "index.tmpl", nil))
+		require.Equal(t, "<p>Hello</p>", out.String())
+	})
+
+	t.Run("returns the error from the minifier", func(t *testing.T) {
+		err := pp.Render(new(bytes.Buffer), "robots.txt.tmpl", nil)
+
+		require.EqualError(t, err, `failed to post-render "robots.txt.tmpl": failed to minify: no minifier for text/plain`)
+	})
+}
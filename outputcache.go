@@ -0,0 +1,282 @@
+package passepartout
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaqzi/passepartout/internal/ppparse"
+)
+
+// CachePolicy is how long a template's output can be cached, declared by the template itself with a directive:
+//
+//	{{/* cache: ttl=5m vary=locale,user */}}
+//
+// Vary names the fields or map keys of the data that the output depends on, each combination is cached separately.
+type CachePolicy struct {
+	TTL  time.Duration
+	Vary []string
+}
+
+// ParseCachePolicy finds the cache directive in content, and reports whether there was one.
+func ParseCachePolicy(content string) (CachePolicy, bool, error) {
+	value, ok := ppparse.Directive(content, "cache")
+	if !ok {
+		return CachePolicy{}, false, nil
+	}
+
+	var policy CachePolicy
+	for _, field := range strings.Fields(value) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return CachePolicy{}, false, fmt.Errorf("failed to parse cache directive: expected key=value, got %q", field)
+		}
+
+		switch key {
+		case "ttl":
+			ttl, err := time.ParseDuration(value)
+			if err != nil {
+				return CachePolicy{}, false, fmt.Errorf("failed to parse cache directive ttl: %w", err)
+			}
+			policy.TTL = ttl
+		case "vary":
+			policy.Vary = strings.Split(value, ",")
+		default:
+			return CachePolicy{}, false, fmt.Errorf("failed to parse cache directive: unknown key %q", key)
+		}
+	}
+	if policy.TTL <= 0 {
+		return CachePolicy{}, false, fmt.Errorf("failed to parse cache directive: a positive ttl is required")
+	}
+
+	return policy, true, nil
+}
+
+// CachePolicy returns the cache policy declared by the template name, and reports whether it declared one.
+func (p *Passepartout) CachePolicy(name string) (CachePolicy, bool, error) {
+	l, ok := p.loader.Load().(fileLoader)
+	if !ok {
+		return CachePolicy{}, false, nil
+	}
+
+	files, err := l.StandaloneFiles(name)
+	if err != nil {
+		return CachePolicy{}, false, fmt.Errorf("failed to find cache policy for %q: %w", name, err)
+	}
+
+	for _, f := range files {
+		if f.Name != name {
+			continue
+		}
+
+		policy, ok, err := ParseCachePolicy(f.Content)
+		if err != nil {
+			return CachePolicy{}, false, fmt.Errorf("failed to find cache policy for %q: %w", name, err)
+		}
+
+		return policy, ok, nil
+	}
+
+	return CachePolicy{}, false, nil
+}
+
+// OutputStore keeps rendered output for the output cache, see [WithOutputCacheStore]. Implement it to share the
+// cache between instances, like with Redis.
+type OutputStore interface {
+	// Get returns the output kept for key, and reports whether it was found and hasn't expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set keeps output for key until ttl has passed.
+	Set(ctx context.Context, key string, output []byte, ttl time.Duration) error
+}
+
+// MemoryOutputStore keeps output in memory, it's the store used by [WithOutputCache].
+// Expired output is removed when it's next asked for.
+type MemoryOutputStore struct {
+	mu      sync.Mutex
+	entries map[string]outputEntry
+}
+
+type outputEntry struct {
+	output  []byte
+	expires time.Time
+}
+
+// NewMemoryOutputStore returns an empty store.
+func NewMemoryOutputStore() *MemoryOutputStore {
+	return &MemoryOutputStore{entries: make(map[string]outputEntry)}
+}
+
+func (m *MemoryOutputStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !time.Now().Before(entry.expires) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+
+	return entry.output, true, nil
+}
+
+func (m *MemoryOutputStore) Set(_ context.Context, key string, output []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[key] = outputEntry{output: bytes.Clone(output), expires: time.Now().Add(ttl)}
+	return nil
+}
+
+// Stats returns the number and size of the outputs held, including expired outputs that haven't been removed yet.
+func (m *MemoryOutputStore) Stats() OutputStoreStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := OutputStoreStats{Entries: len(m.entries)}
+	for _, entry := range m.entries {
+		stats.Bytes += len(entry.output)
+	}
+
+	return stats
+}
+
+func (m *MemoryOutputStore) clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clear(m.entries)
+}
+
+type outputCache struct {
+	store OutputStore
+	// key and ttl cache the templates without a policy, when key is set.
+	key func(layout, name string, data any) string
+	ttl time.Duration
+
+	mu       sync.Mutex
+	policies map[string]*CachePolicy // nil when the template has no policy
+}
+
+func (c *outputCache) policy(p *Passepartout, name string) (*CachePolicy, error) {
+	c.mu.Lock()
+	policy, ok := c.policies[name]
+	c.mu.Unlock()
+	if ok {
+		return policy, nil
+	}
+
+	found, ok, err := p.CachePolicy(name)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		policy = &found
+	}
+
+	c.mu.Lock()
+	c.policies[name] = policy
+	c.mu.Unlock()
+
+	return policy, nil
+}
+
+// clearPolicies forgets the policies found so far, so they're found again in templates that have changed.
+func (c *outputCache) clearPolicies() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.policies)
+}
+
+// entry returns the key and ttl to cache the output of name with, and an empty key when it isn't cached.
+func (c *outputCache) entry(p *Passepartout, layout, name string, data any) (string, time.Duration) {
+	policy, err := c.policy(p, name)
+	if err != nil {
+		// Let the render report any problems with loading the template.
+		return "", 0
+	}
+	if policy != nil {
+		return cacheKey(layout, name, policy.Vary, data), policy.TTL
+	}
+	if c.key != nil {
+		return c.key(layout, name, data), c.ttl
+	}
+
+	return "", 0
+}
+
+// cached writes the cached output for name when there is one, otherwise it renders and caches the output
+// if name declares a cache policy or has a key. The store failing is handled like the output not being cached.
+func (p *Passepartout) cached(ctx context.Context, out io.Writer, layout, name string, data any, render func(out io.Writer) error) error {
+	if p.outputCache == nil {
+		return render(out)
+	}
+
+	key, ttl := p.outputCache.entry(p, layout, name, data)
+	if key == "" {
+		return render(out)
+	}
+
+	if output, ok, err := p.outputCache.store.Get(ctx, key); err == nil && ok {
+		if trace, ok := ctx.Value(traceKey{}).(*RenderTrace); ok {
+			trace.CacheHit = true
+		}
+		_, err := out.Write(output)
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := render(buf); err != nil {
+		_, _ = buf.WriteTo(out)
+		return err
+	}
+
+	_ = p.outputCache.store.Set(ctx, key, buf.Bytes(), ttl)
+
+	_, err := buf.WriteTo(out)
+	return err
+}
+
+func cacheKey(layout, name string, vary []string, data any) string {
+	parts := []string{layout, name}
+	for _, field := range vary {
+		parts = append(parts, field+"="+varyValue(data, field))
+	}
+
+	return strings.Join(parts, "|")
+}
+
+// varyValue looks up field in data when it's a map with string keys or a struct, ignoring case for struct fields.
+func varyValue(data any, field string) string {
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return ""
+		}
+		if value := v.MapIndex(reflect.ValueOf(field).Convert(v.Type().Key())); value.IsValid() {
+			return fmt.Sprint(value.Interface())
+		}
+	case reflect.Struct:
+		if value := v.FieldByNameFunc(func(name string) bool { return strings.EqualFold(name, field) }); value.IsValid() && value.CanInterface() {
+			return fmt.Sprint(value.Interface())
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,221 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+func TestParseCachePolicy(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		content       string
+		expected      passepartout.CachePolicy
+		expectedFound bool
+		expectedError string
+	}{
+		{
+			name:          "parses the ttl and the vary fields",
+			content:       "{{/* cache: ttl=5m vary=locale,user */}}\n<h1>Hi</h1>",
+			expected:      passepartout.CachePolicy{TTL: 5 * time.Minute, Vary: []string{"locale", "user"}},
+			expectedFound: true,
+		},
+		{
+			name:          "allows trimming whitespace around the directive",
+			content:       "{{- /* cache: ttl=1h */ -}}",
+			expected:      passepartout.CachePolicy{TTL: time.Hour},
+			expectedFound: true,
+		},
+		{
+			name:    "reports no policy when there's no directive",
+			content: "{{/* just a comment */}}",
+		},
+		{
+			name:          "returns an error for an unknown key",
+			content:       "{{/* cache: ttl=1m private=yes */}}",
+			expectedError: `unknown key "private"`,
+		},
+		{
+			name:          "returns an error without a ttl",
+			content:       "{{/* cache: vary=locale */}}",
+			expectedError: "a positive ttl is required",
+		},
+		{
+			name:          "returns an error for an invalid ttl",
+			content:       "{{/* cache: ttl=soon */}}",
+			expectedError: "failed to parse cache directive ttl",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, found, err := passepartout.ParseCachePolicy(tc.content)
+
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedFound, found)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestWithOutputCache(t *testing.T) {
+	render := func(t *testing.T, pp *passepartout.Passepartout, name string, data any) string {
+		t.Helper()
+		buf := new(bytes.Buffer)
+		require.NoError(t, pp.Render(buf, name, data))
+
+		return buf.String()
+	}
+
+	t.Run("caches the output of templates with a policy per vary value", func(t *testing.T) {
+		fs := fstest.MapFS{"index.tmpl": {Data: []byte(`{{/* cache: ttl=1h vary=locale */}}{{ .locale }} {{ .count }}`)}}
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithOutputCache())
+		require.NoError(t, err)
+
+		require.Equal(t, "en 1", render(t, pp, "index.tmpl", map[string]any{"locale": "en", "count": 1}))
+		require.Equal(t, "en 1", render(t, pp, "index.tmpl", map[string]any{"locale": "en", "count": 2}), "expected the cached output")
+		require.Equal(t, "sv 3", render(t, pp, "index.tmpl", map[string]any{"locale": "sv", "count": 3}), "expected a different vary value to render")
+	})
+
+	t.Run("looks up vary fields on structs ignoring case", func(t *testing.T) {
+		type data struct {
+			Locale string
+			Count  int
+		}
+		fs := fstest.MapFS{"index.tmpl": {Data: []byte(`{{/* cache: ttl=1h vary=locale */}}{{ .Locale }} {{ .Count }}`)}}
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithOutputCache())
+		require.NoError(t, err)
+
+		require.Equal(t, "en 1", render(t, pp, "index.tmpl", data{"en", 1}))
+		require.Equal(t, "en 1", render(t, pp, "index.tmpl", &data{"en", 2}))
+		require.Equal(t, "sv 3", render(t, pp, "index.tmpl", data{"sv", 3}))
+	})
+
+	t.Run("renders again once the ttl has passed", func(t *testing.T) {
+		fs := fstest.MapFS{"index.tmpl": {Data: []byte(`{{/* cache: ttl=1ms */}}{{ . }}`)}}
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithOutputCache())
+		require.NoError(t, err)
+
+		require.Equal(t, "1", render(t, pp, "index.tmpl", 1))
+		time.Sleep(5 * time.Millisecond)
+		require.Equal(t, "2", render(t, pp, "index.tmpl", 2))
+	})
+
+	t.Run("always renders templates without a policy", func(t *testing.T) {
+		fs := fstest.MapFS{"index.tmpl": {Data: []byte(`{{ . }}`)}}
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithOutputCache())
+		require.NoError(t, err)
+
+		require.Equal(t, "1", render(t, pp, "index.tmpl", 1))
+		require.Equal(t, "2", render(t, pp, "index.tmpl", 2))
+	})
+
+	t.Run("caches pages in a layout separately from standalone", func(t *testing.T) {
+		fs := fstest.MapFS{
+			"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+			"index.tmpl":           {Data: []byte(`{{/* cache: ttl=1h */}}{{ . }}`)},
+		}
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithOutputCache())
+		require.NoError(t, err)
+		require.Equal(t, "1", render(t, pp, "index.tmpl", 1))
+		buf := new(bytes.Buffer)
+
+		require.NoError(t, pp.RenderInLayout(buf, "layouts/default.tmpl", "index.tmpl", 2))
+		require.Equal(t, "<main>2</main>", buf.String())
+	})
+
+	t.Run("never caches a failed render", func(t *testing.T) {
+		fs := fstest.MapFS{"index.tmpl": {Data: []byte(`{{/* cache: ttl=1h */}}{{ index . 1 }}`)}}
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithOutputCache())
+		require.NoError(t, err)
+
+		require.Error(t, pp.Render(new(bytes.Buffer), "index.tmpl", []int{}))
+		require.Equal(t, "2", render(t, pp, "index.tmpl", []int{1, 2}))
+	})
+}
+
+type failingOutputStore struct{}
+
+func (failingOutputStore) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("store is down")
+}
+
+func (failingOutputStore) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("store is down")
+}
+
+func TestWithOutputCacheStore(t *testing.T) {
+	render := func(t *testing.T, pp *passepartout.Passepartout, name string, data any) string {
+		t.Helper()
+		buf := new(bytes.Buffer)
+		require.NoError(t, pp.Render(buf, name, data))
+
+		return buf.String()
+	}
+	byID := func(layout, name string, data any) string {
+		m, ok := data.(map[string]any)
+		if !ok {
+			return ""
+		}
+
+		return fmt.Sprintf("%s|%s|%v", layout, name, m["id"])
+	}
+	fs := fstest.MapFS{"index.tmpl": {Data: []byte(`{{ .id }} {{ .count }}`)}}
+
+	t.Run("caches templates without a policy by the key returned for them", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithOutputCacheStore(passepartout.NewMemoryOutputStore(), byID, time.Hour))
+		require.NoError(t, err)
+
+		require.Equal(t, "1 1", render(t, pp, "index.tmpl", map[string]any{"id": 1, "count": 1}))
+		require.Equal(t, "1 1", render(t, pp, "index.tmpl", map[string]any{"id": 1, "count": 2}), "expected the cached output")
+		require.Equal(t, "2 3", render(t, pp, "index.tmpl", map[string]any{"id": 2, "count": 3}))
+	})
+
+	t.Run("renders without caching when the key is empty", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fstest.MapFS{"index.tmpl": {Data: []byte(`{{ . }}`)}}, passepartout.WithOutputCacheStore(passepartout.NewMemoryOutputStore(), byID, time.Hour))
+		require.NoError(t, err)
+
+		require.Equal(t, "1", render(t, pp, "index.tmpl", 1))
+		require.Equal(t, "2", render(t, pp, "index.tmpl", 2))
+	})
+
+	t.Run("renders when the store fails", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithOutputCacheStore(failingOutputStore{}, byID, time.Hour))
+		require.NoError(t, err)
+
+		require.Equal(t, "1 1", render(t, pp, "index.tmpl", map[string]any{"id": 1, "count": 1}))
+		require.Equal(t, "1 2", render(t, pp, "index.tmpl", map[string]any{"id": 1, "count": 2}))
+	})
+}
+
+func TestMemoryOutputStore(t *testing.T) {
+	ctx := context.Background()
+	store := passepartout.NewMemoryOutputStore()
+
+	require.NoError(t, store.Set(ctx, "fresh", []byte("output"), time.Hour))
+	require.NoError(t, store.Set(ctx, "expired", []byte("output"), time.Nanosecond))
+	time.Sleep(time.Millisecond)
+
+	output, ok, err := store.Get(ctx, "fresh")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "output", string(output))
+
+	_, ok, err = store.Get(ctx, "expired")
+	require.NoError(t, err)
+	require.False(t, ok, "expected expired output to not be found")
+
+	_, ok, err = store.Get(ctx, "missing")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
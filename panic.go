@@ -0,0 +1,105 @@
+package passepartout
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"reflect"
+	"runtime/debug"
+)
+
+// PanicError is returned when a render panics, instead of taking down the request with it, including when a func
+// registered by passepartout, like with [Extender.Funcs], panics. Panics in methods called by a template are
+// returned as errors by text/template without the stack.
+type PanicError struct {
+	Template string
+	// Layout is empty when the template was rendered standalone.
+	Layout string
+	// DataType is the type of the data the template was rendered with, formatted with %T.
+	DataType string
+	// Value is what was passed to panic.
+	Value any
+	// Stack is the stack trace of the goroutine where it panicked.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	if e.Layout == "" {
+		return fmt.Sprintf("panic rendering %q with data of type %s: %v", e.Template, e.DataType, e.Value)
+	}
+
+	return fmt.Sprintf("panic rendering %q in layout %q with data of type %s: %v", e.Template, e.Layout, e.DataType, e.Value)
+}
+
+// Unwrap returns Value when it's an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// execute executes tmplt as execName, recovering a panic as a [*PanicError], which is panicked with again when
+// configured with [WithRepanic]. With [WithDataValidation] data is validated against the template first.
+func (p *Passepartout) execute(tmplt Executable, out io.Writer, execName, layout, name string, data any) (err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		panicErr, ok := r.(*PanicError)
+		if !ok {
+			panicErr = &PanicError{Template: name, Layout: layout, DataType: fmt.Sprintf("%T", data), Value: r, Stack: debug.Stack()}
+		}
+		if p.repanic {
+			panic(panicErr)
+		}
+		err = panicErr
+	}()
+
+	if p.dataValidation {
+		if err := p.validateData(tmplt, execName, layout, name, data); err != nil {
+			return err
+		}
+	}
+
+	err = tmplt.ExecuteTemplate(out, execName, data)
+	// text/template returns a panicking func as an error, so a panic recovered by recoverFuncs is completed here.
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) && panicErr.Template == "" {
+		panicErr.Template, panicErr.Layout, panicErr.DataType = name, layout, fmt.Sprintf("%T", data)
+		if p.repanic {
+			panic(panicErr)
+		}
+	}
+
+	return err
+}
+
+// recoverFuncs returns funcs with every func recovering a panic as a [*PanicError] with the stack of where it
+// panicked, since text/template would otherwise turn it into an error without it.
+func recoverFuncs(funcs template.FuncMap) template.FuncMap {
+	recovering := make(template.FuncMap, len(funcs))
+	for name, fn := range funcs {
+		v := reflect.ValueOf(fn)
+		if v.Kind() != reflect.Func {
+			recovering[name] = fn
+			continue
+		}
+
+		recovering[name] = reflect.MakeFunc(v.Type(), func(args []reflect.Value) []reflect.Value {
+			defer func() {
+				if r := recover(); r != nil {
+					panic(&PanicError{Value: r, Stack: debug.Stack()})
+				}
+			}()
+
+			if v.Type().IsVariadic() {
+				return v.CallSlice(args)
+			}
+			return v.Call(args)
+		}).Interface()
+	}
+
+	return recovering
+}
@@ -0,0 +1,80 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+// panickingWriter panics on every write.
+type panickingWriter struct{}
+
+func (panickingWriter) Write([]byte) (int, error) {
+	panic(errors.New("connection reset"))
+}
+
+func TestPassepartout_RenderPanics(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":           {Data: []byte(`Hello`)},
+	}
+
+	t.Run("returns a panicking render as an error with the template and data type", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs)
+		require.NoError(t, err)
+
+		err = pp.RenderInLayout(panickingWriter{}, "layouts/default.tmpl", "index.tmpl", map[string]any{})
+
+		var panicErr *passepartout.PanicError
+		require.ErrorAs(t, err, &panicErr)
+		require.Equal(t, "index.tmpl", panicErr.Template)
+		require.Equal(t, "layouts/default.tmpl", panicErr.Layout)
+		require.Equal(t, "map[string]interface {}", panicErr.DataType)
+		require.NotEmpty(t, panicErr.Stack)
+		require.EqualError(t, err, `panic rendering "index.tmpl" in layout "layouts/default.tmpl" with data of type map[string]interface {}: connection reset`)
+		require.EqualError(t, errors.Unwrap(panicErr), "connection reset")
+	})
+
+	t.Run("returns a panicking func as a panic error with the template, data type, and stack", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fstest.MapFS{"index.tmpl": {Data: []byte(`{{ boom }}`)}}, boomFuncs)
+		require.NoError(t, err)
+
+		err = pp.Render(new(bytes.Buffer), "index.tmpl", map[string]any{})
+
+		var panicErr *passepartout.PanicError
+		require.ErrorAs(t, err, &panicErr)
+		require.Equal(t, "index.tmpl", panicErr.Template)
+		require.Empty(t, panicErr.Layout)
+		require.Equal(t, "map[string]interface {}", panicErr.DataType)
+		require.Equal(t, "boom", panicErr.Value)
+		require.Contains(t, string(panicErr.Stack), "panic_test.go", "the stack is of where it panicked")
+	})
+
+	t.Run("panics again with WithRepanic when a func panics", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fstest.MapFS{"index.tmpl": {Data: []byte(`{{ boom }}`)}}, boomFuncs, passepartout.WithRepanic())
+		require.NoError(t, err)
+
+		require.PanicsWithError(t, `panic rendering "index.tmpl" with data of type <nil>: boom`, func() {
+			_ = pp.Render(new(bytes.Buffer), "index.tmpl", nil)
+		})
+	})
+
+	t.Run("panics again with WithRepanic", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithRepanic())
+		require.NoError(t, err)
+
+		require.PanicsWithError(t, `panic rendering "index.tmpl" with data of type <nil>: connection reset`, func() {
+			_ = pp.Render(panickingWriter{}, "index.tmpl", nil)
+		})
+	})
+}
+
+var boomFuncs = passepartout.Use(passepartout.ExtensionFunc(func(e *passepartout.Extender) {
+	e.Funcs(template.FuncMap{"boom": func() string { panic("boom") }})
+}))
@@ -0,0 +1,26 @@
+package passepartout
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// RenderPartial renders just the partial name, like "reviews/index/_item.tmpl", with the partials in its own folder,
+// "reviews/index/_item/", for endpoints that return a single part of a page such as a list item.
+// The error template isn't rendered for partials, since they're usually part of a larger page.
+func (p *Passepartout) RenderPartial(out io.Writer, name string, data any) error {
+	return p.RenderPartialContext(context.Background(), out, name, data)
+}
+
+// RenderPartialContext is [Passepartout.RenderPartial] with ctx passed on to the loader like
+// [Passepartout.RenderContext].
+func (p *Passepartout) RenderPartialContext(ctx context.Context, out io.Writer, name string, data any) error {
+	if !strings.HasPrefix(path.Base(name), "_") {
+		return fmt.Errorf("failed to render partial %q: partials are named starting with \"_\"", name)
+	}
+
+	return p.render(ctx, out, name, data)
+}
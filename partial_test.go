@@ -0,0 +1,38 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+func TestPassepartout_RenderPartial(t *testing.T) {
+	pp, err := passepartout.LoadFrom(fstest.MapFS{
+		"reviews/index.tmpl":              {Data: []byte(`<ul>{{ range . }}{{ template "reviews/index/_item.tmpl" . }}{{ end }}</ul>`)},
+		"reviews/index/_item.tmpl":        {Data: []byte(`<li>{{ .Title }} {{ template "reviews/index/_item/_stars.tmpl" .Stars }}</li>`)},
+		"reviews/index/_item/_stars.tmpl": {Data: []byte(`{{ . }} stars`)},
+	})
+	require.NoError(t, err)
+
+	t.Run("renders the partial with the partials in its own folder", func(t *testing.T) {
+		out := new(bytes.Buffer)
+
+		err := pp.RenderPartial(out, "reviews/index/_item.tmpl", map[string]any{"Title": "Great", "Stars": 5})
+
+		require.NoError(t, err)
+		require.Equal(t, `<li>Great 5 stars</li>`, out.String())
+	})
+
+	t.Run("returns an error for templates that aren't partials", func(t *testing.T) {
+		out := new(bytes.Buffer)
+
+		err := pp.RenderPartial(out, "reviews/index.tmpl", nil)
+
+		require.EqualError(t, err, `failed to render partial "reviews/index.tmpl": partials are named starting with "_"`)
+		require.Empty(t, out.String())
+	})
+}
@@ -0,0 +1,57 @@
+package passepartout
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"path"
+	"strings"
+	"text/template/parse"
+)
+
+// MissingPartialError is returned when a template uses a partial, a template whose name starts with "_", that wasn't
+// loaded. Partials are only loaded from the folder named after the page, and the layout, it's rendered with.
+type MissingPartialError struct {
+	Partial  string
+	Template string
+	// Searched are the folders partials were loaded from, when known by the loader.
+	Searched []string
+	Err      error
+}
+
+func (e *MissingPartialError) Error() string {
+	msg := fmt.Sprintf("partial %q used by %q wasn't loaded, partials are only loaded from the folder named after the page or layout (and the common folder if configured)", e.Partial, e.Template)
+	if len(e.Searched) > 0 {
+		msg += fmt.Sprintf(", searched: %s/", strings.Join(e.Searched, "/, "))
+	}
+
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *MissingPartialError) Unwrap() error {
+	return e.Err
+}
+
+// searchedDirsLoader is implemented by loaders that know where they look for partials, like [ppdefaults.Loader].
+type searchedDirsLoader interface {
+	SearchedDirs(page, layout string) []string
+}
+
+// explainMissingPartial returns a [MissingPartialError] when err is html/template failing to find a partial.
+func (p *Passepartout) explainMissingPartial(err error, layout, name string) error {
+	var tmplErr *template.Error
+	if !errors.As(err, &tmplErr) || tmplErr.ErrorCode != template.ErrNoSuchTemplate {
+		return err
+	}
+	node, ok := tmplErr.Node.(*parse.TemplateNode)
+	if !ok || !strings.HasPrefix(path.Base(node.Name), "_") {
+		return err
+	}
+
+	missing := &MissingPartialError{Partial: node.Name, Template: name, Err: err}
+	if l, ok := p.loader.Load().(searchedDirsLoader); ok {
+		missing.Searched = l.SearchedDirs(name, layout)
+	}
+
+	return missing
+}
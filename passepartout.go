@@ -1,14 +1,25 @@
 package passepartout
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"html/template"
 	"io"
 	"io/fs"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
 
+	"github.com/gaqzi/passepartout/ppcapture"
 	"github.com/gaqzi/passepartout/ppdefaults"
+	"github.com/gaqzi/passepartout/ppmetrics"
 )
 
+// FS is a filesystem that can read files and directories directly, any [fs.FS] can be used with [LoadFrom] and the
+// other functions taking a filesystem, which use [ppdefaults.AsFS] for filesystems that only open files.
 type FS interface {
 	fs.ReadDirFS
 	fs.ReadFileFS
@@ -19,28 +30,78 @@ type loader interface {
 	InLayout(page string, layout string) (*template.Template, error)
 }
 
+// contextLoader is implemented by loaders that can pass a context on to remote backends, like [ppdefaults.Loader].
+type contextLoader interface {
+	StandaloneContext(ctx context.Context, name string) (*template.Template, error)
+	InLayoutContext(ctx context.Context, page string, layout string) (*template.Template, error)
+}
+
 // FSWithoutPrefix will take a passed in filesystem and strip away "prefix" when using the filesystem.
 // It uses [fs.Sub] under the hood, and it's a wrapper to ensure the returned filesystem can be used by passepartout.
 // The usecase is that you store all your templates in `templates/` and don't want to actually use your templates as
 // `templates/page/index.tmpl` and instead just say `page/index.html`.
-func FSWithoutPrefix(fsys FS, prefix string) (FS, error) {
+func FSWithoutPrefix(fsys fs.FS, prefix string) (FS, error) {
 	sub, err := fs.Sub(fsys, prefix)
 	if err != nil {
 		return nil, err
 	}
 
-	fsys, ok := sub.(FS)
-	if !ok {
-		return nil, errors.New("[fs.Sub] returned a filesystem that doesn't implement FS, this is probably a bug in passepartout")
-	}
-
-	return fsys, nil
+	return ppdefaults.AsFS(sub), nil
 }
 
 type Passepartout struct {
-	loader loader
+	// loader is swapped by Reload while renders are using it.
+	loader         currentLoader
+	fs             fs.FS
+	errorTemplate  string
+	capture        ppcapture.Store
+	outputCache    *outputCache
+	hooks          []Hooks
+	extensions     []string
+	normalizeNames bool
+	// caseInsensitive is only used by instances created with LoadFrom, which know their filesystem.
+	caseInsensitive bool
+	globalData      func(ctx context.Context) map[string]any
+	engine          Engine
+	engines         []extEngine
+	postRenders     []func(name string, html []byte) ([]byte, error)
+	renderTimeout   time.Duration
+	repanic         bool
+	liveReload      time.Duration
+	buffering       bool
+	debugComments   bool
+	dataValidation  bool
+	resolveGuards   []func(name string) error
+	// providers has the [Provider] of each template by its resolved name.
+	providers sync.Map
+	// templateOptions, metrics, logger, templateCache, components, nilSafe, fragmentCache, funcs, mounts, partials, and
+	// sanitizers are only used by LoadFrom and LoadBundle when building the loader.
+	templateOptions []string
+	templateCache   bool
+	components      bool
+	nilSafe         bool
+	fragmentCache   OutputStore
+	funcs           []template.FuncMap
+	mounts          []mounted
+	partials        []ppdefaults.PartialLoader
+	metrics         ppmetrics.Recorder
+	logger          *slog.Logger
+	sanitizers      map[string]Sanitizer
+}
+
+// ErrorData is the data an error template, see [WithErrorTemplate], is rendered with.
+type ErrorData struct {
+	// Status is the HTTP status code of the response, always 500 outside of [Passepartout.RenderHTTP] as well.
+	Status int
+	// Template is the name of the template that failed to render.
+	Template string
+	Err      error
 }
 
+// ParseError is returned when a template fails to parse, with the file, line, and an excerpt of where it failed.
+// Find it with [errors.As].
+type ParseError = ppdefaults.ParseError
+
 // LoadFrom initializes a template manager to load and render templates within a passed in filesystem.
 // Passepartout manages the loading of Go templates.
 // It does this by relying on a hierarchy in a folder that is:
@@ -70,34 +131,255 @@ type Passepartout struct {
 //
 //	passepartout := passepartout.LoadFrom(os.DirFS("templates/")) // the path to the base folder, removes the first part so all templates are referenced out of this folder
 //	str, err := passepartout.Render("index/main.tmpl", map[string]any{"Items": []string{"Hello", "World"}})  // renders the index/main.tmpl using the index/_main/_item.tmpl partial and returns the result as a string
-func LoadFrom(fs_ FS) (*Passepartout, error) {
-	return &Passepartout{
-		loader: ppdefaults.NewLoaderBuilder().
-			WithDefaults(fs_).
-			Build(),
-	}, nil
+func LoadFrom(fs_ fs.FS, opts ...Option) (*Passepartout, error) {
+	p := New(nil, opts...)
+
+	fsys := &mountFS{base: ppdefaults.AsFS(fs_)}
+	for _, m := range p.mounts {
+		if err := fsys.mount(m.prefix, m.fs); err != nil {
+			return nil, err
+		}
+	}
+	loader, err := p.buildLoader(ppdefaults.NewLoaderBuilder().WithDefaults(fsys))
+	if err != nil {
+		return nil, err
+	}
+	p.loader.Store(loader)
+	p.fs = fsys
+
+	return p, nil
+}
+
+// buildLoader configures builder with the options only used when passepartout creates the loader.
+func (p *Passepartout) buildLoader(builder *ppdefaults.LoaderBuilder) (loader, error) {
+	if len(p.templateOptions) > 0 {
+		if err := validTemplateOptions(p.templateOptions); err != nil {
+			return nil, err
+		}
+		builder.WithTemplateOption(p.templateOptions...)
+	}
+	if p.metrics != nil {
+		builder.Metrics(p.metrics)
+	}
+	if p.logger != nil {
+		builder.Logger(p.logger)
+	}
+	builder.WithFuncs(recoverFuncs(Funcs()))
+	for _, funcs := range p.funcs {
+		builder.WithFuncs(recoverFuncs(funcs))
+	}
+	if p.components {
+		builder.WithFuncs(recoverFuncs(p.componentFuncs(nil, "")))
+	}
+	if p.nilSafe {
+		builder.WithFuncs(recoverFuncs(template.FuncMap{"nilSafe": nilSafe}))
+	}
+	if p.fragmentCache != nil {
+		builder.WithFuncs(recoverFuncs(fragmentCacheFuncs(p.fragmentCache, nil)))
+	}
+	if p.components || p.nilSafe || p.fragmentCache != nil || len(p.partials) > 0 {
+		builder.CreateTemplate(p.createTemplate)
+	}
+	if p.templateCache {
+		return ppdefaults.NewTemplateCache(builder.Build()), nil
+	}
+
+	return builder.Build(), nil
 }
 
 // New instantiates a passepartout instance matching with the given loader.
 // [ppdefaults.Loader] can be instantiated with [ppdefaults.NewLoaderBuilder()] and configured.
-func New(loader loader) *Passepartout {
-	return &Passepartout{loader: loader}
+func New(loader loader, opts ...Option) *Passepartout {
+	p := &Passepartout{}
+	p.loader.Store(loader)
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
 }
 
+// Render renders the template name, and if it fails the error template is rendered instead when configured with
+// [WithErrorTemplate]. The error from the failed render is always returned, so it can be logged.
 func (p *Passepartout) Render(out io.Writer, name string, data any) error {
-	t, err := p.loader.Standalone(name)
-	if err != nil {
+	return p.RenderContext(context.Background(), out, name, data)
+}
+
+// RenderContext is [Passepartout.Render] with ctx passed on to the loader, so remote loaders can respect timeouts
+// and cancellation. Loaders that don't accept a context are only called when ctx isn't done, and the render is stopped
+// at its next write once ctx is done.
+func (p *Passepartout) RenderContext(ctx context.Context, out io.Writer, name string, data any) error {
+	return p.buffered(out, "", name, func(out io.Writer) error {
+		return p.render(ctx, out, name, data)
+	})
+}
+
+// RenderInLayout renders the template name within layout, and falls back on the error template like [Passepartout.Render].
+func (p *Passepartout) RenderInLayout(out io.Writer, layout string, name string, data any) error {
+	return p.RenderInLayoutContext(context.Background(), out, layout, name, data)
+}
+
+// RenderInLayoutContext is [Passepartout.RenderInLayout] with ctx passed on to the loader like [Passepartout.RenderContext].
+func (p *Passepartout) RenderInLayoutContext(ctx context.Context, out io.Writer, layout string, name string, data any) error {
+	return p.buffered(out, layout, name, func(out io.Writer) error {
+		return p.renderInLayout(ctx, out, layout, name, data)
+	})
+}
+
+// Template returns the template name as it would be rendered by [Passepartout.Render], for callers that want to
+// execute another of its templates or pass it on to another library. With [WithTemplateCache] the same template is
+// returned every time, so it must not be changed.
+func (p *Passepartout) Template(name string) (*template.Template, error) {
+	if p.engineFor(p.resolve(name)) != nil {
+		return nil, fmt.Errorf("failed to get template %q: rendered with an engine, not html/template", name)
+	}
+	if err := p.guard(p.resolve(name)); err != nil {
+		return nil, fmt.Errorf("failed to get template %q: %w", name, err)
+	}
+
+	return p.loadStandalone(context.Background(), p.loader.Load(), p.resolve(name))
+}
+
+// TemplateInLayout returns the template name within layout as it would be rendered by [Passepartout.RenderInLayout],
+// like [Passepartout.Template].
+func (p *Passepartout) TemplateInLayout(layout string, name string) (*template.Template, error) {
+	if p.engineFor(p.resolve(name)) != nil {
+		return nil, fmt.Errorf("failed to get template %q in layout %q: rendered with an engine, not html/template", name, layout)
+	}
+	if err := p.guard(p.resolve(name), p.resolve(layout)); err != nil {
+		return nil, fmt.Errorf("failed to get template %q in layout %q: %w", name, layout, err)
+	}
+
+	return p.loadInLayout(context.Background(), p.loader.Load(), p.resolve(name), p.resolve(layout))
+}
+
+func (p *Passepartout) standalone(ctx context.Context, name string) (Executable, error) {
+	return p.standaloneFrom(ctx, p.loader.Load(), name)
+}
+
+// standaloneFrom is standalone loading from l, which is the current loader except when a new template set is
+// checked or a variant is rendered.
+func (p *Passepartout) standaloneFrom(ctx context.Context, l loader, name string) (Executable, error) {
+	return p.observeLoad("", name, func() (Executable, error) {
+		if engine := p.engineFor(name); engine != nil {
+			return p.compile(ctx, l, engine, name, "")
+		}
+		t, err := p.loadStandalone(ctx, l, name)
+		return t, p.explainAmbiguousCase(err, name)
+	})
+}
+
+func (p *Passepartout) loadStandalone(ctx context.Context, l loader, name string) (*template.Template, error) {
+	if l, ok := l.(contextLoader); ok {
+		return l.StandaloneContext(ctx, name)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return l.Standalone(name)
+}
+
+func (p *Passepartout) inLayout(ctx context.Context, page string, layout string) (Executable, error) {
+	return p.inLayoutFrom(ctx, p.loader.Load(), page, layout)
+}
+
+// inLayoutFrom is inLayout loading from l, like standaloneFrom.
+func (p *Passepartout) inLayoutFrom(ctx context.Context, l loader, page string, layout string) (Executable, error) {
+	return p.observeLoad(layout, page, func() (Executable, error) {
+		if engine := p.engineFor(page); engine != nil {
+			return p.compile(ctx, l, engine, page, layout)
+		}
+		t, err := p.loadInLayout(ctx, l, page, layout)
+		return t, p.explainAmbiguousCase(err, page, layout)
+	})
+}
+
+func (p *Passepartout) loadInLayout(ctx context.Context, l loader, page string, layout string) (*template.Template, error) {
+	if l, ok := l.(contextLoader); ok {
+		return l.InLayoutContext(ctx, page, layout)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return l.InLayout(page, layout)
+}
+
+func (p *Passepartout) render(ctx context.Context, out io.Writer, name string, data any) error {
+	name, data = p.resolve(name), p.withGlobalData(ctx, data)
+	if err := p.guard(name); err != nil {
 		return err
 	}
 
-	return t.ExecuteTemplate(out, name, data)
+	return p.observeRender(out, "", name, func(out io.Writer) error {
+		return p.cached(ctx, out, "", name, data, func(out io.Writer) error {
+			t, err := p.standalone(ctx, name)
+			if err != nil {
+				return err
+			}
+
+			return p.explainMissingPartial(p.execute(t, p.limitRender(ctx, out, "", name), name, "", name, data), "", name)
+		})
+	})
 }
 
-func (p *Passepartout) RenderInLayout(out io.Writer, layout string, name string, data any) error {
-	t, err := p.loader.InLayout(name, layout)
-	if err != nil {
+func (p *Passepartout) renderInLayout(ctx context.Context, out io.Writer, layout string, name string, data any) error {
+	layout, name, data = p.resolve(layout), p.resolve(name), p.withGlobalData(ctx, data)
+	if err := p.guard(name, layout); err != nil {
 		return err
 	}
 
-	return t.ExecuteTemplate(out, layout, data)
+	return p.observeRender(out, layout, name, func(out io.Writer) error {
+		return p.cached(ctx, out, layout, name, data, func(out io.Writer) error {
+			t, err := p.inLayout(ctx, name, layout)
+			if err != nil {
+				return err
+			}
+
+			return p.explainMissingPartial(p.execute(t, p.limitRender(ctx, out, layout, name), layout, layout, name, data), layout, name)
+		})
+	})
+}
+
+// buffered buffers the output of render when it's needed by the configured options: so the output can be replaced by
+// the error template if it fails, so that what was written can be captured, so it can be post-rendered or commented,
+// and when asked to with [WithBuffering].
+func (p *Passepartout) buffered(out io.Writer, layout, name string, render func(out io.Writer) error) error {
+	if p.errorTemplate == "" && p.capture == nil && len(p.postRenders) == 0 && !p.buffering && !p.debugComments {
+		return render(out)
+	}
+
+	buf := new(bytes.Buffer)
+	renderErr := render(buf)
+	if renderErr == nil {
+		renderErr = p.postRender(buf, name)
+	}
+	if renderErr == nil && p.debugComments {
+		p.debugComment(buf, layout, name)
+	}
+	if renderErr != nil && p.errorTemplate != "" {
+		buf.Reset()
+		if err := p.renderErrorTemplate(buf, name, renderErr); err != nil {
+			renderErr = errors.Join(renderErr, err)
+		}
+	}
+	p.storeCapture(out, layout, name, buf.Bytes(), renderErr)
+
+	if _, err := buf.WriteTo(out); err != nil {
+		return errors.Join(renderErr, err)
+	}
+
+	return renderErr
+}
+
+func (p *Passepartout) renderErrorTemplate(out io.Writer, name string, renderErr error) error {
+	buf := new(bytes.Buffer)
+	err := p.render(context.Background(), buf, p.errorTemplate, ErrorData{Status: http.StatusInternalServerError, Template: name, Err: renderErr})
+	if err != nil {
+		return fmt.Errorf("failed to render error template %q: %w", p.errorTemplate, err)
+	}
+
+	_, err = buf.WriteTo(out)
+	return err
 }
@@ -2,6 +2,8 @@ package passepartout_test
 
 import (
 	"bytes"
+	"context"
+	"io/fs"
 	"testing"
 	"testing/fstest"
 
@@ -56,8 +58,12 @@ func TestPassepartout_Render(t *testing.T) {
 			render:   call{`templates/index.tmpl`, nil},
 			expected: "",
 			expectError: func(t *testing.T, err error) {
-				// XXX: should there be a custom error message when the filename starts with "_" to say that we only look for partials in the current folder or sub folder named the same as the template minus extension?
 				require.ErrorContains(t, err, `no such template "_item.tmpl"`, "expected a warning that the partial was not found")
+				var missing *passepartout.MissingPartialError
+				require.ErrorAs(t, err, &missing, "expected an explanation of where partials are loaded from")
+				require.Equal(t, "_item.tmpl", missing.Partial)
+				require.Equal(t, []string{"templates/index"}, missing.Searched)
+				require.ErrorContains(t, err, `partial "_item.tmpl" used by "templates/index.tmpl" wasn't loaded, partials are only loaded from the folder named after the page or layout (and the common folder if configured), searched: templates/index/`)
 			},
 		},
 		{
@@ -122,6 +128,31 @@ func TestPassepartout_RenderInLayout(t *testing.T) {
 			expected:    "HEADER\n body\n item partial \nFOOTER",
 			expectError: noError,
 		},
+		{
+			name: "When the layout has a partial folder, then its partials are available to the layout",
+			fs: fstest.MapFS{
+				"templates/layouts/default.tmpl":      {Data: []byte("{{ template \"templates/layouts/default/_nav.tmpl\" . }}\n {{ block \"content\" . }}DEFAULT CONTENT{{ end }}")},
+				"templates/layouts/default/_nav.tmpl": {Data: []byte("NAV")},
+				"templates/index.tmpl":                {Data: []byte("body")},
+			},
+			render:      layoutCall{`templates/layouts/default.tmpl`, `templates/index.tmpl`, nil},
+			expected:    "NAV\n body",
+			expectError: noError,
+		},
+		{
+			name: "When a partial isn't in the page's or layout's folder, then the error says where partials were searched for",
+			fs: fstest.MapFS{
+				"templates/layouts/default.tmpl": {Data: []byte(`{{ block "content" . }}{{ end }}`)},
+				"templates/index.tmpl":           {Data: []byte(`{{ template "templates/shared/_item.tmpl" . }}`)},
+				"templates/shared/_item.tmpl":    {Data: []byte("item partial")},
+			},
+			render:   layoutCall{`templates/layouts/default.tmpl`, `templates/index.tmpl`, nil},
+			expected: "",
+			expectError: func(t *testing.T, err error) {
+				require.ErrorContains(t, err, `partial "templates/shared/_item.tmpl" used by "templates/index.tmpl" wasn't loaded`)
+				require.ErrorContains(t, err, `searched: templates/layouts/default/, templates/index/`)
+			},
+		},
 		{
 			name:     "When the template doesn't exist we get an error",
 			fs:       fstest.MapFS{},
@@ -146,3 +177,178 @@ func TestPassepartout_RenderInLayout(t *testing.T) {
 		})
 	}
 }
+
+func TestWithErrorTemplate(t *testing.T) {
+	fs := fstest.MapFS{
+		"templates/layouts/default.tmpl": {Data: []byte(`HEAD {{ block "content" . }}{{ end }} FOOT`)},
+		"templates/index.tmpl":           {Data: []byte(`half a page {{ template "missing" }}`)},
+		"templates/ok.tmpl":              {Data: []byte(`a whole page`)},
+		"templates/errors/500.tmpl":      {Data: []byte(`Sorry! {{ .Template }} failed: {{ .Err }}`)},
+	}
+
+	t.Run("Render renders the error template with the error instead of the failed output", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithErrorTemplate("templates/errors/500.tmpl"))
+		require.NoError(t, err)
+		output := bytes.NewBuffer(nil)
+
+		err = pp.Render(output, "templates/index.tmpl", nil)
+
+		require.ErrorContains(t, err, `no such template "missing"`, "expected the original error to be returned")
+		require.Equal(t, `Sorry! templates/index.tmpl failed: html/template:templates/index.tmpl:1:24: no such template &#34;missing&#34;`, output.String())
+	})
+
+	t.Run("RenderInLayout renders the error template instead of the failed output", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithErrorTemplate("templates/errors/500.tmpl"))
+		require.NoError(t, err)
+		output := bytes.NewBuffer(nil)
+
+		err = pp.RenderInLayout(output, "templates/layouts/default.tmpl", "templates/index.tmpl", nil)
+
+		require.Error(t, err)
+		require.Contains(t, output.String(), "Sorry! templates/index.tmpl failed")
+		require.NotContains(t, output.String(), "HEAD", "expected nothing of the failed render to be written")
+	})
+
+	t.Run("successful renders are written as normal", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithErrorTemplate("templates/errors/500.tmpl"))
+		require.NoError(t, err)
+		output := bytes.NewBuffer(nil)
+
+		err = pp.Render(output, "templates/ok.tmpl", nil)
+
+		require.NoError(t, err)
+		require.Equal(t, "a whole page", output.String())
+	})
+
+	t.Run("when the error template fails both errors are returned", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithErrorTemplate("templates/errors/missing.tmpl"))
+		require.NoError(t, err)
+		output := bytes.NewBuffer(nil)
+
+		err = pp.Render(output, "templates/index.tmpl", nil)
+
+		require.ErrorContains(t, err, `no such template "missing"`)
+		require.ErrorContains(t, err, `failed to render error template "templates/errors/missing.tmpl"`)
+		require.Empty(t, output.String())
+	})
+}
+
+func TestPassepartout_RenderContext(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":           {Data: []byte(`Hello, {{ . }}!`)},
+	}
+	pp, err := passepartout.LoadFrom(fs)
+	require.NoError(t, err)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	t.Run("renders with a live context", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+
+		require.NoError(t, pp.RenderContext(context.Background(), buf, "index.tmpl", "World"))
+		require.Equal(t, "Hello, World!", buf.String())
+	})
+
+	t.Run("doesn't load templates when the context is done", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+
+		require.ErrorIs(t, pp.RenderContext(canceled, buf, "index.tmpl", "World"), context.Canceled)
+		require.ErrorIs(t, pp.RenderInLayoutContext(canceled, buf, "layouts/default.tmpl", "index.tmpl", "World"), context.Canceled)
+		require.Empty(t, buf.String())
+	})
+}
+
+func TestPassepartout_Template(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"about.tmpl":           {Data: []byte(`{{ define "title" }}About{{ end }}`)},
+		"index.tmpl":           {Data: []byte(`Hello, {{ . }}!`)},
+	}
+	pp, err := passepartout.LoadFrom(fs, passepartout.WithExtensions(".tmpl"))
+	require.NoError(t, err)
+
+	t.Run("returns the template to execute any of its templates", func(t *testing.T) {
+		tmpl, err := pp.Template("about")
+		require.NoError(t, err)
+		buf := new(bytes.Buffer)
+
+		require.NoError(t, tmpl.ExecuteTemplate(buf, "title", nil))
+		require.Equal(t, "About", buf.String())
+	})
+
+	t.Run("returns the template within the layout", func(t *testing.T) {
+		tmpl, err := pp.TemplateInLayout("layouts/default", "index")
+		require.NoError(t, err)
+		buf := new(bytes.Buffer)
+
+		require.NoError(t, tmpl.ExecuteTemplate(buf, "layouts/default.tmpl", "World"))
+		require.Equal(t, "<main>Hello, World!</main>", buf.String())
+	})
+
+	t.Run("returns the error when the template doesn't exist", func(t *testing.T) {
+		_, err := pp.Template("missing")
+
+		require.ErrorContains(t, err, `failed to collect all files for "missing"`)
+	})
+
+	t.Run("fails when rendering with an engine", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithEngine(passepartout.EngineFunc(textEngine)))
+		require.NoError(t, err)
+
+		_, err = pp.Template("index.tmpl")
+
+		require.EqualError(t, err, `failed to get template "index.tmpl": rendered with an engine, not html/template`)
+	})
+}
+
+func TestParseError(t *testing.T) {
+	pp, err := passepartout.LoadFrom(fstest.MapFS{"reviews/show.tmpl": {Data: []byte("<h1>\n{{ .Title }\n</h1>")}})
+	require.NoError(t, err)
+
+	err = pp.Render(new(bytes.Buffer), "reviews/show.tmpl", nil)
+
+	var parseErr *passepartout.ParseError
+	require.ErrorAs(t, err, &parseErr)
+	require.Equal(t, "reviews/show.tmpl", parseErr.Path)
+	require.Equal(t, 2, parseErr.Line)
+	require.Contains(t, parseErr.Excerpt, "> 2 | {{ .Title }")
+}
+
+// openOnlyFS only implements [fs.FS], like many filesystems outside the standard library.
+type openOnlyFS struct {
+	fsys fs.FS
+}
+
+func (o openOnlyFS) Open(name string) (fs.File, error) {
+	return o.fsys.Open(name)
+}
+
+func TestLoadFrom_openOnlyFS(t *testing.T) {
+	fsys := openOnlyFS{fsys: fstest.MapFS{
+		"templates/layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"templates/index.tmpl":           {Data: []byte(`{{ template "index/_item.tmpl" . }}`)},
+		"templates/index/_item.tmpl":     {Data: []byte(`Hello, {{ . }}!`)},
+	}}
+
+	t.Run("loads the templates and their partials", func(t *testing.T) {
+		sub, err := passepartout.FSWithoutPrefix(fsys, "templates")
+		require.NoError(t, err)
+		pp, err := passepartout.LoadFrom(sub)
+		require.NoError(t, err)
+		buf := new(bytes.Buffer)
+
+		require.NoError(t, pp.RenderInLayout(buf, "layouts/default.tmpl", "index.tmpl", "world"))
+		require.Equal(t, "<main>Hello, world!</main>", buf.String())
+	})
+
+	t.Run("loads the templates of a mounted filesystem", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fstest.MapFS{})
+		require.NoError(t, err)
+		require.NoError(t, pp.Mount("admin", openOnlyFS{fsys: fstest.MapFS{"users.tmpl": {Data: []byte(`Users of {{ . }}`)}}}))
+		buf := new(bytes.Buffer)
+
+		require.NoError(t, pp.Render(buf, "admin/users.tmpl", "admin"))
+		require.Equal(t, "Users of admin", buf.String())
+	})
+}
@@ -0,0 +1,308 @@
+// Package ppassets resolves asset names to the fingerprinted paths in a manifest.json written by Vite, webpack, esbuild,
+// or [github.com/gaqzi/passepartout/ppssg], through the "asset", "assetPreload", "scriptTag", and "styleTag" funcs.
+//
+// The funcs are meant to be registered on the base template, see [ppdefaults.Loader.TemplateConfig]:
+//
+//	assets, err := ppassets.Load(os.DirFS("public"), "manifest.json")
+//	loader := ppdefaults.NewLoaderBuilder().
+//		WithDefaults(fsys).
+//		WithFuncs(assets.WithPrefix("/static/").FuncMap()).
+//		Build()
+//
+// And then used in templates as:
+//
+//	{{ assetPreload "app.js" }}<script type="module" src="{{ asset "app.js" }}"></script>
+//
+// Or with the tags written by the funcs, with Subresource Integrity hashes when [Manifest.WithIntegrity] is used:
+//
+//	{{ styleTag "app.css" }}{{ scriptTag "app.js" }}
+package ppassets
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a single asset in the manifest, in the format of Vite's manifest.json.
+// Manifests mapping names straight to files, like webpack's, only have File set.
+type Entry struct {
+	File string `json:"file"`
+	// CSS are the stylesheets imported by the asset.
+	CSS []string `json:"css"`
+	// Imports are the names of the other entries the asset imports.
+	Imports []string `json:"imports"`
+	// Integrity is the Subresource Integrity hash of File, like "sha384-...", added by bundler plugins such as
+	// vite-plugin-manifest-sri.
+	Integrity string `json:"integrity"`
+}
+
+// Manifest is a concurrency safe manifest of assets loaded from a file.
+type Manifest struct {
+	fsys   fs.FS
+	name   string
+	prefix string
+	reload bool
+	// assets are the files hashed for their integrity, see [Manifest.WithIntegrity].
+	assets fs.FS
+
+	mu      sync.RWMutex
+	entries map[string]Entry
+	modTime time.Time
+	// hashes are the integrity hashes computed by the name of their file.
+	hashes map[string]string
+}
+
+// Load reads the manifest name from fsys.
+func Load(fsys fs.FS, name string) (*Manifest, error) {
+	m := &Manifest{fsys: fsys, name: name}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// WithPrefix adds prefix to the paths of the assets, like "/static/" or "https://cdn.example.com".
+func (m *Manifest) WithPrefix(prefix string) *Manifest {
+	m.prefix = prefix
+	return m
+}
+
+// WithIntegrity adds the Subresource Integrity hash of each file to the tags written by the funcs, from the manifest
+// when it has one and otherwise computed from the file in assets, like os.DirFS("public"), by its path without the
+// prefix. Tags with a hash are written with crossorigin="anonymous", as browsers require for assets on a CDN.
+func (m *Manifest) WithIntegrity(assets fs.FS) *Manifest {
+	m.assets = assets
+	return m
+}
+
+// WithReload reads the manifest again when it has been modified since it was last read, checked every time an
+// asset is looked up, for dev mode where the bundler rebuilds the assets while the server runs.
+func (m *Manifest) WithReload() *Manifest {
+	m.reload = true
+	return m
+}
+
+func (m *Manifest) load() error {
+	info, err := fs.Stat(m.fsys, m.name)
+	if err != nil {
+		return fmt.Errorf("failed to read asset manifest: %w", err)
+	}
+	content, err := fs.ReadFile(m.fsys, m.name)
+	if err != nil {
+		return fmt.Errorf("failed to read asset manifest: %w", err)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return fmt.Errorf("failed to parse asset manifest %q: %w", m.name, err)
+	}
+
+	entries := make(map[string]Entry, len(raw))
+	for name, value := range raw {
+		var entry Entry
+		if bytes.HasPrefix(bytes.TrimSpace(value), []byte(`"`)) {
+			err = json.Unmarshal(value, &entry.File)
+		} else {
+			err = json.Unmarshal(value, &entry)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse asset manifest %q entry %q: %w", m.name, name, err)
+		}
+		entries[name] = entry
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries, m.modTime, m.hashes = entries, info.ModTime(), make(map[string]string)
+
+	return nil
+}
+
+// reloadIfModified reads the manifest again when [Manifest.WithReload] is used and it has been modified.
+func (m *Manifest) reloadIfModified() error {
+	if !m.reload {
+		return nil
+	}
+
+	info, err := fs.Stat(m.fsys, m.name)
+	if err != nil {
+		return fmt.Errorf("failed to read asset manifest: %w", err)
+	}
+	m.mu.RLock()
+	modified := !info.ModTime().Equal(m.modTime)
+	m.mu.RUnlock()
+	if !modified {
+		return nil
+	}
+
+	return m.load()
+}
+
+func (m *Manifest) entry(name string) (Entry, error) {
+	if err := m.reloadIfModified(); err != nil {
+		return Entry{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.entries[name]
+	if !ok {
+		return Entry{}, fmt.Errorf("asset %q isn't in the manifest %q", name, m.name)
+	}
+
+	return entry, nil
+}
+
+// Path returns the path of the fingerprinted file for the asset name, with the prefix.
+func (m *Manifest) Path(name string) (string, error) {
+	entry, err := m.entry(name)
+	if err != nil {
+		return "", err
+	}
+
+	return m.path(entry.File), nil
+}
+
+func (m *Manifest) path(file string) string {
+	if m.prefix == "" {
+		return file
+	}
+
+	return strings.TrimSuffix(m.prefix, "/") + "/" + strings.TrimPrefix(file, "/")
+}
+
+// Preload returns the tags loading what the asset name imports before it: a stylesheet link for its CSS and a
+// modulepreload link for each import, and their imports.
+func (m *Manifest) Preload(name string) (template.HTML, error) {
+	var b bytes.Buffer
+	seen := map[string]bool{name: true}
+	if err := m.preload(&b, name, seen, true); err != nil {
+		return "", err
+	}
+
+	return template.HTML(b.String()), nil
+}
+
+func (m *Manifest) preload(b *bytes.Buffer, name string, seen map[string]bool, root bool) error {
+	entry, err := m.entry(name)
+	if err != nil {
+		return err
+	}
+
+	if !root {
+		if err := m.tag(b, `<link rel="modulepreload" href="%s"%s>`, entry.File, entry.Integrity); err != nil {
+			return err
+		}
+	}
+	for _, css := range entry.CSS {
+		if err := m.tag(b, `<link rel="stylesheet" href="%s"%s>`, css, ""); err != nil {
+			return err
+		}
+	}
+	for _, imported := range entry.Imports {
+		if seen[imported] {
+			continue
+		}
+		seen[imported] = true
+
+		if err := m.preload(b, imported, seen, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ScriptTag returns the module script tag of the asset name, after the tags of [Manifest.Preload].
+func (m *Manifest) ScriptTag(name string) (template.HTML, error) {
+	preload, err := m.Preload(name)
+	if err != nil {
+		return "", err
+	}
+	entry, err := m.entry(name)
+	if err != nil {
+		return "", err
+	}
+
+	b := bytes.NewBufferString(string(preload))
+	if err := m.tag(b, `<script type="module" src="%s"%s></script>`, entry.File, entry.Integrity); err != nil {
+		return "", err
+	}
+
+	return template.HTML(b.String()), nil
+}
+
+// StyleTag returns the stylesheet link of the asset name, like "app.css".
+func (m *Manifest) StyleTag(name string) (template.HTML, error) {
+	entry, err := m.entry(name)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := m.tag(&b, `<link rel="stylesheet" href="%s"%s>`, entry.File, entry.Integrity); err != nil {
+		return "", err
+	}
+
+	return template.HTML(b.String()), nil
+}
+
+// tag writes format with the path of file and, when it's known, its integrity.
+func (m *Manifest) tag(b *bytes.Buffer, format, file, integrity string) error {
+	integrity, err := m.integrity(file, integrity)
+	if err != nil {
+		return err
+	}
+
+	var attrs string
+	if integrity != "" {
+		attrs = fmt.Sprintf(` integrity="%s" crossorigin="anonymous"`, template.HTMLEscapeString(integrity))
+	}
+	fmt.Fprintf(b, format, template.HTMLEscapeString(m.path(file)), attrs)
+
+	return nil
+}
+
+// integrity returns known, or the hash of file computed from the assets of [Manifest.WithIntegrity], and is empty
+// without either.
+func (m *Manifest) integrity(file, known string) (string, error) {
+	if known != "" || m.assets == nil {
+		return known, nil
+	}
+
+	m.mu.RLock()
+	hash, ok := m.hashes[file]
+	m.mu.RUnlock()
+	if ok {
+		return hash, nil
+	}
+
+	content, err := fs.ReadFile(m.assets, strings.TrimPrefix(file, "/"))
+	if err != nil {
+		return "", fmt.Errorf("failed to hash asset %q: %w", file, err)
+	}
+	sum := sha512.Sum384(content)
+	hash = "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+
+	m.mu.Lock()
+	m.hashes[file] = hash
+	m.mu.Unlock()
+
+	return hash, nil
+}
+
+// FuncMap returns the "asset", "assetPreload", "scriptTag", and "styleTag" funcs for use with
+// [template.Template.Funcs].
+func (m *Manifest) FuncMap() template.FuncMap {
+	return template.FuncMap{"asset": m.Path, "assetPreload": m.Preload, "scriptTag": m.ScriptTag, "styleTag": m.StyleTag}
+}
@@ -0,0 +1,171 @@
+package ppassets_test
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/base64"
+	"html/template"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppassets"
+)
+
+const viteManifest = `{
+  "main.js": {"file": "assets/main.4889e940.js", "css": ["assets/main.b82dbe22.css"], "imports": ["_vendor.js"], "isEntry": true},
+  "_vendor.js": {"file": "assets/vendor.cd0b5b89.js", "imports": ["_shared.js"]},
+  "_shared.js": {"file": "assets/shared.83a7c8a4.js", "imports": ["_vendor.js"]}
+}`
+
+func TestManifest(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		manifest string
+		asset    string
+		prefix   string
+		expected string
+	}{
+		{name: "returns the file of a Vite entry", manifest: viteManifest, asset: "main.js", expected: "assets/main.4889e940.js"},
+		{name: "returns the file from a flat manifest", manifest: `{"app.css": "app.1a2b3c4d.css"}`, asset: "app.css", expected: "app.1a2b3c4d.css"},
+		{name: "adds the prefix", manifest: `{"app.css": "app.1a2b3c4d.css"}`, asset: "app.css", prefix: "/static/", expected: "/static/app.1a2b3c4d.css"},
+		{name: "keeps the scheme and host of the prefix", manifest: `{"app.css": "app.1a2b3c4d.css"}`, asset: "app.css", prefix: "https://cdn.example.com", expected: "https://cdn.example.com/app.1a2b3c4d.css"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := ppassets.Load(fstest.MapFS{"manifest.json": {Data: []byte(tc.manifest)}}, "manifest.json")
+			require.NoError(t, err)
+
+			assetPath, err := m.WithPrefix(tc.prefix).Path(tc.asset)
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, assetPath)
+		})
+	}
+
+	t.Run("returns an error for an asset that isn't in the manifest", func(t *testing.T) {
+		m, err := ppassets.Load(fstest.MapFS{"manifest.json": {Data: []byte(`{}`)}}, "manifest.json")
+		require.NoError(t, err)
+
+		_, err = m.Path("missing.js")
+
+		require.EqualError(t, err, `asset "missing.js" isn't in the manifest "manifest.json"`)
+	})
+
+	t.Run("returns an error for an invalid manifest", func(t *testing.T) {
+		_, err := ppassets.Load(fstest.MapFS{"manifest.json": {Data: []byte(`{"app.js": 1}`)}}, "manifest.json")
+
+		require.ErrorContains(t, err, `failed to parse asset manifest "manifest.json" entry "app.js"`)
+	})
+
+	t.Run("reloads the manifest when it's modified with WithReload", func(t *testing.T) {
+		fsys := fstest.MapFS{"manifest.json": {Data: []byte(`{"app.js": "app.1.js"}`), ModTime: time.Unix(1, 0)}}
+		m, err := ppassets.Load(fsys, "manifest.json")
+		require.NoError(t, err)
+		m.WithReload()
+
+		fsys["manifest.json"] = &fstest.MapFile{Data: []byte(`{"app.js": "app.2.js"}`), ModTime: time.Unix(2, 0)}
+		assetPath, err := m.Path("app.js")
+
+		require.NoError(t, err)
+		require.Equal(t, "app.2.js", assetPath)
+	})
+
+	t.Run("doesn't reload the manifest by default", func(t *testing.T) {
+		fsys := fstest.MapFS{"manifest.json": {Data: []byte(`{"app.js": "app.1.js"}`), ModTime: time.Unix(1, 0)}}
+		m, err := ppassets.Load(fsys, "manifest.json")
+		require.NoError(t, err)
+
+		fsys["manifest.json"] = &fstest.MapFile{Data: []byte(`{"app.js": "app.2.js"}`), ModTime: time.Unix(2, 0)}
+		assetPath, err := m.Path("app.js")
+
+		require.NoError(t, err)
+		require.Equal(t, "app.1.js", assetPath)
+	})
+}
+
+func TestManifest_FuncMap(t *testing.T) {
+	m, err := ppassets.Load(fstest.MapFS{"manifest.json": {Data: []byte(viteManifest)}}, "manifest.json")
+	require.NoError(t, err)
+	tmpl := template.Must(template.New("").Funcs(m.WithPrefix("/static").FuncMap()).
+		Parse(`{{ assetPreload "main.js" }}<script type="module" src="{{ asset "main.js" }}"></script>`))
+	out := new(bytes.Buffer)
+
+	require.NoError(t, tmpl.Execute(out, nil))
+	require.Equal(t, `<link rel="stylesheet" href="/static/assets/main.b82dbe22.css">`+
+		`<link rel="modulepreload" href="/static/assets/vendor.cd0b5b89.js">`+
+		`<link rel="modulepreload" href="/static/assets/shared.83a7c8a4.js">`+
+		`<script type="module" src="/static/assets/main.4889e940.js"></script>`, out.String())
+}
+
+func sri(content string) string {
+	sum := sha512.Sum384([]byte(content))
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func TestManifest_ScriptTag(t *testing.T) {
+	assets := fstest.MapFS{
+		"assets/main.4889e940.js":   {Data: []byte("main")},
+		"assets/main.b82dbe22.css":  {Data: []byte("css")},
+		"assets/vendor.cd0b5b89.js": {Data: []byte("vendor")},
+		"assets/shared.83a7c8a4.js": {Data: []byte("shared")},
+	}
+
+	t.Run("writes the preloads and the script", func(t *testing.T) {
+		m, err := ppassets.Load(fstest.MapFS{"manifest.json": {Data: []byte(viteManifest)}}, "manifest.json")
+		require.NoError(t, err)
+
+		tag, err := m.WithPrefix("/static").ScriptTag("main.js")
+
+		require.NoError(t, err)
+		require.Equal(t, template.HTML(`<link rel="stylesheet" href="/static/assets/main.b82dbe22.css">`+
+			`<link rel="modulepreload" href="/static/assets/vendor.cd0b5b89.js">`+
+			`<link rel="modulepreload" href="/static/assets/shared.83a7c8a4.js">`+
+			`<script type="module" src="/static/assets/main.4889e940.js"></script>`), tag)
+	})
+
+	t.Run("adds the integrity computed from the assets with WithIntegrity", func(t *testing.T) {
+		m, err := ppassets.Load(fstest.MapFS{"manifest.json": {Data: []byte(viteManifest)}}, "manifest.json")
+		require.NoError(t, err)
+
+		tag, err := m.WithPrefix("https://cdn.example.com").WithIntegrity(assets).ScriptTag("main.js")
+
+		require.NoError(t, err)
+		require.Equal(t, template.HTML(
+			`<link rel="stylesheet" href="https://cdn.example.com/assets/main.b82dbe22.css" integrity="`+sri("css")+`" crossorigin="anonymous">`+
+				`<link rel="modulepreload" href="https://cdn.example.com/assets/vendor.cd0b5b89.js" integrity="`+sri("vendor")+`" crossorigin="anonymous">`+
+				`<link rel="modulepreload" href="https://cdn.example.com/assets/shared.83a7c8a4.js" integrity="`+sri("shared")+`" crossorigin="anonymous">`+
+				`<script type="module" src="https://cdn.example.com/assets/main.4889e940.js" integrity="`+sri("main")+`" crossorigin="anonymous"></script>`), tag)
+	})
+
+	t.Run("uses the integrity of the manifest when it has one", func(t *testing.T) {
+		m, err := ppassets.Load(fstest.MapFS{"manifest.json": {Data: []byte(`{"app.js": {"file": "app.1.js", "integrity": "sha384-known"}}`)}}, "manifest.json")
+		require.NoError(t, err)
+
+		tag, err := m.ScriptTag("app.js")
+
+		require.NoError(t, err)
+		require.Equal(t, template.HTML(`<script type="module" src="app.1.js" integrity="sha384-known" crossorigin="anonymous"></script>`), tag)
+	})
+
+	t.Run("returns an error when an asset to hash is missing", func(t *testing.T) {
+		m, err := ppassets.Load(fstest.MapFS{"manifest.json": {Data: []byte(`{"app.js": "app.1.js"}`)}}, "manifest.json")
+		require.NoError(t, err)
+
+		_, err = m.WithIntegrity(assets).ScriptTag("app.js")
+
+		require.ErrorContains(t, err, `failed to hash asset "app.1.js"`)
+	})
+}
+
+func TestManifest_StyleTag(t *testing.T) {
+	m, err := ppassets.Load(fstest.MapFS{"manifest.json": {Data: []byte(`{"app.css": "app.1a2b3c4d.css"}`)}}, "manifest.json")
+	require.NoError(t, err)
+	tmpl := template.Must(template.New("").Funcs(m.WithIntegrity(fstest.MapFS{"app.1a2b3c4d.css": {Data: []byte("body{}")}}).FuncMap()).
+		Parse(`{{ styleTag "app.css" }}`))
+	out := new(bytes.Buffer)
+
+	require.NoError(t, tmpl.Execute(out, nil))
+	require.Equal(t, `<link rel="stylesheet" href="app.1a2b3c4d.css" integrity="`+sri("body{}")+`" crossorigin="anonymous">`, out.String())
+}
@@ -0,0 +1,88 @@
+// Package ppcapture stores copies of rendered templates so it's possible to review exactly what users saw when
+// triaging template bugs. It's meant for non-production environments, see [passepartout.WithCapture].
+package ppcapture
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when there's no capture with the requested ID.
+var ErrNotFound = errors.New("capture not found")
+
+// Capture is a copy of a single render.
+type Capture struct {
+	// ID is assigned by the store.
+	ID       string    `json:"id"`
+	Template string    `json:"template"`
+	Layout   string    `json:"layout,omitempty"`
+	Time     time.Time `json:"time"`
+	// RequestID identifies the request the render was for, when known.
+	RequestID   string `json:"requestId,omitempty"`
+	ContentType string `json:"contentType"`
+	// Err is the error the render failed with, if any.
+	Err    string `json:"error,omitempty"`
+	Output []byte `json:"output,omitempty"`
+}
+
+// Store keeps a bounded number of captures and allows inspecting them.
+type Store interface {
+	Store(c Capture) error
+	// List returns all captures, newest first.
+	List() ([]Capture, error)
+	Get(id string) (Capture, error)
+}
+
+// MemoryStore keeps the last Size captures in memory.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	size     int
+	nextID   int
+	captures []Capture
+}
+
+// NewMemoryStore keeps at most size captures, dropping the oldest when full.
+func NewMemoryStore(size int) *MemoryStore {
+	return &MemoryStore{size: size}
+}
+
+func (m *MemoryStore) Store(c Capture) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	c.ID = strconv.Itoa(m.nextID)
+	m.captures = append(m.captures, c)
+	if len(m.captures) > m.size {
+		m.captures = m.captures[len(m.captures)-m.size:]
+	}
+
+	return nil
+}
+
+func (m *MemoryStore) List() ([]Capture, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	captures := make([]Capture, 0, len(m.captures))
+	for i := len(m.captures) - 1; i >= 0; i-- {
+		captures = append(captures, m.captures[i])
+	}
+
+	return captures, nil
+}
+
+func (m *MemoryStore) Get(id string) (Capture, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, c := range m.captures {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+
+	return Capture{}, ErrNotFound
+}
@@ -0,0 +1,74 @@
+package ppcapture_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppcapture"
+)
+
+// testStore runs the same tests against all store implementations.
+func testStore(t *testing.T, newStore func(t *testing.T, size int) ppcapture.Store) {
+	t.Helper()
+
+	t.Run("lists stored captures newest first with IDs assigned", func(t *testing.T) {
+		store := newStore(t, 10)
+		now := time.Now()
+
+		require.NoError(t, store.Store(ppcapture.Capture{Template: "first.tmpl", Time: now, Output: []byte("first")}))
+		require.NoError(t, store.Store(ppcapture.Capture{Template: "second.tmpl", Time: now.Add(time.Second), RequestID: "req-1"}))
+
+		captures, err := store.List()
+		require.NoError(t, err)
+		require.Len(t, captures, 2)
+		require.Equal(t, "second.tmpl", captures[0].Template)
+		require.Equal(t, "req-1", captures[0].RequestID)
+		require.Equal(t, "first.tmpl", captures[1].Template)
+		require.NotEmpty(t, captures[0].ID)
+		require.NotEqual(t, captures[0].ID, captures[1].ID)
+	})
+
+	t.Run("drops the oldest captures when full", func(t *testing.T) {
+		store := newStore(t, 2)
+		now := time.Now()
+
+		for i, name := range []string{"first.tmpl", "second.tmpl", "third.tmpl"} {
+			require.NoError(t, store.Store(ppcapture.Capture{Template: name, Time: now.Add(time.Duration(i) * time.Second)}))
+		}
+
+		captures, err := store.List()
+		require.NoError(t, err)
+		require.Len(t, captures, 2)
+		require.Equal(t, "third.tmpl", captures[0].Template)
+		require.Equal(t, "second.tmpl", captures[1].Template)
+	})
+
+	t.Run("Get returns the capture with the ID", func(t *testing.T) {
+		store := newStore(t, 10)
+		require.NoError(t, store.Store(ppcapture.Capture{Template: "index.tmpl", Time: time.Now(), Output: []byte("hello")}))
+		captures, err := store.List()
+		require.NoError(t, err)
+
+		actual, err := store.Get(captures[0].ID)
+
+		require.NoError(t, err)
+		require.Equal(t, "index.tmpl", actual.Template)
+		require.Equal(t, []byte("hello"), actual.Output)
+	})
+
+	t.Run("Get returns ErrNotFound for unknown IDs", func(t *testing.T) {
+		store := newStore(t, 10)
+
+		_, err := store.Get("unknown")
+
+		require.ErrorIs(t, err, ppcapture.ErrNotFound)
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, func(t *testing.T, size int) ppcapture.Store {
+		return ppcapture.NewMemoryStore(size)
+	})
+}
@@ -0,0 +1,134 @@
+package ppcapture
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DirStore keeps the last Size captures as JSON files in a directory, so they survive restarts.
+type DirStore struct {
+	mu   sync.Mutex
+	dir  string
+	size int
+	seq  int
+}
+
+// NewDirStore keeps at most size captures in dir, deleting the oldest when full.
+// The directory is created if it doesn't exist.
+func NewDirStore(dir string, size int) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create capture dir: %w", err)
+	}
+
+	return &DirStore{dir: dir, size: size}, nil
+}
+
+func (d *DirStore) Store(c Capture) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Zero padded so sorting the file names sorts them by time.
+	d.seq++
+	c.ID = fmt.Sprintf("%020d-%06d", c.Time.UnixNano(), d.seq%1_000_000)
+
+	content, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode capture: %w", err)
+	}
+
+	if err := os.WriteFile(d.path(c.ID), content, 0o644); err != nil {
+		return fmt.Errorf("failed to write capture: %w", err)
+	}
+
+	return d.prune()
+}
+
+func (d *DirStore) List() ([]Capture, error) {
+	d.mu.Lock()
+	ids, err := d.ids()
+	d.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	captures := make([]Capture, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		c, err := d.Get(ids[i])
+		if errors.Is(err, ErrNotFound) {
+			continue // pruned since listing
+		}
+		if err != nil {
+			return nil, err
+		}
+		captures = append(captures, c)
+	}
+
+	return captures, nil
+}
+
+func (d *DirStore) Get(id string) (Capture, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return Capture{}, ErrNotFound
+	}
+
+	content, err := os.ReadFile(d.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Capture{}, ErrNotFound
+	}
+	if err != nil {
+		return Capture{}, fmt.Errorf("failed to read capture: %w", err)
+	}
+
+	var c Capture
+	if err := json.Unmarshal(content, &c); err != nil {
+		return Capture{}, fmt.Errorf("failed to decode capture %q: %w", id, err)
+	}
+
+	return c, nil
+}
+
+func (d *DirStore) path(id string) string {
+	return filepath.Join(d.dir, id+".json")
+}
+
+// ids returns the IDs of all captures in the directory, oldest first.
+func (d *DirStore) ids() ([]string, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list captures: %w", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+func (d *DirStore) prune() error {
+	ids, err := d.ids()
+	if err != nil {
+		return err
+	}
+
+	for len(ids) > d.size {
+		if err := os.Remove(d.path(ids[0])); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove old capture: %w", err)
+		}
+		ids = ids[1:]
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package ppcapture_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppcapture"
+)
+
+func TestDirStore(t *testing.T) {
+	testStore(t, func(t *testing.T, size int) ppcapture.Store {
+		store, err := ppcapture.NewDirStore(t.TempDir(), size)
+		require.NoError(t, err)
+
+		return store
+	})
+
+	t.Run("captures survive a new store being created for the same directory", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "captures")
+		store, err := ppcapture.NewDirStore(dir, 10)
+		require.NoError(t, err)
+		require.NoError(t, store.Store(ppcapture.Capture{Template: "index.tmpl", Time: time.Now()}))
+
+		again, err := ppcapture.NewDirStore(dir, 10)
+		require.NoError(t, err)
+		captures, err := again.List()
+
+		require.NoError(t, err)
+		require.Len(t, captures, 1)
+		require.Equal(t, "index.tmpl", captures[0].Template)
+	})
+
+	t.Run("Get doesn't read files outside the directory", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "..", "secret.json"), []byte(`{"template":"secret"}`), 0o644))
+		store, err := ppcapture.NewDirStore(dir, 10)
+		require.NoError(t, err)
+
+		_, err = store.Get("../secret")
+
+		require.ErrorIs(t, err, ppcapture.ErrNotFound)
+	})
+}
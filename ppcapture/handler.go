@@ -0,0 +1,44 @@
+package ppcapture
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Handler serves the captures in store for inspection:
+//   - without parameters it lists all captures, newest first, as JSON without their output
+//   - with ?id=<id> it serves the output of that capture exactly as it was rendered
+//
+// It should never be exposed publicly since captures contain whatever data the pages were rendered with.
+func Handler(store Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := r.URL.Query().Get("id"); id != "" {
+			c, err := store.Get(id)
+			if errors.Is(err, ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", c.ContentType)
+			_, _ = w.Write(c.Output)
+			return
+		}
+
+		captures, err := store.List()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for i := range captures {
+			captures[i].Output = nil
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(captures)
+	})
+}
@@ -0,0 +1,55 @@
+package ppcapture_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppcapture"
+)
+
+func TestHandler(t *testing.T) {
+	store := ppcapture.NewMemoryStore(10)
+	require.NoError(t, store.Store(ppcapture.Capture{
+		Template:    "index.tmpl",
+		Time:        time.Now(),
+		ContentType: "text/html; charset=utf-8",
+		Output:      []byte("<h1>Hello</h1>"),
+	}))
+	handler := ppcapture.Handler(store)
+
+	t.Run("lists all captures without their output", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var captures []ppcapture.Capture
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &captures))
+		require.Len(t, captures, 1)
+		require.Equal(t, "index.tmpl", captures[0].Template)
+		require.Nil(t, captures[0].Output)
+	})
+
+	t.Run("serves the output of a capture as it was rendered", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?id=1", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+		require.Equal(t, "<h1>Hello</h1>", rec.Body.String())
+	})
+
+	t.Run("returns not found for unknown captures", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?id=404", nil))
+
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,125 @@
+// Package paginate renders the links between the pages of a paginated list, through the "paginate" template and the
+// "pageURL" and "pageWindow" funcs.
+//
+// Add it to an instance with [passepartout.Use]:
+//
+//	p, err := passepartout.LoadFrom(fsys, passepartout.Use(paginate.Extension))
+//
+// And then render it from any page or layout with a [Pagination]:
+//
+//	{{ template "paginate" .Pagination }}
+//
+// The markup is a <nav class="pagination"> with links for the previous and next page, the first and last page, and
+// the pages around the current one, with a <span class="pagination-gap"> for the pages left out.
+package paginate
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/url"
+	"strconv"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// PartialName is the name of the partial defining the "paginate" template.
+const PartialName = "ppcomponents/paginate/_paginate.tmpl"
+
+//go:embed templates/_paginate.tmpl
+var templates embed.FS
+
+// Extension adds the "paginate" template to every page and layout, and the funcs it uses, see [Funcs].
+var Extension = passepartout.ExtensionFunc(func(e *passepartout.Extender) {
+	e.Funcs(Funcs())
+	e.Partials(Partials)
+})
+
+// Pagination is what the "paginate" template is rendered with.
+type Pagination struct {
+	// Current is the page being shown, starting at 1.
+	Current int
+	// Total is the number of pages, nothing is rendered when there's one page or less.
+	Total int
+	// URL is the URL of the list, the page is set in its "page" query parameter.
+	URL string
+}
+
+// Prev is the page before the current one.
+func (p Pagination) Prev() int {
+	return p.Current - 1
+}
+
+// Next is the page after the current one.
+func (p Pagination) Next() int {
+	return p.Current + 1
+}
+
+// Funcs are the funcs used by the "paginate" template, for markup of your own:
+//   - pageURL returns the URL with the page set, see [PageURL].
+//   - pageWindow returns the pages to link to, see [PageWindow].
+func Funcs() template.FuncMap {
+	return template.FuncMap{"pageURL": PageURL, "pageWindow": PageWindow}
+}
+
+// Partials returns the partial defining the "paginate" template, as a [ppdefaults.PartialLoader] for every page.
+func Partials(string) ([]ppdefaults.FileWithContent, error) {
+	content, err := fs.ReadFile(templates, "templates/_paginate.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the paginate partial: %w", err)
+	}
+
+	return []ppdefaults.FileWithContent{{Name: PartialName, Content: string(content)}}, nil
+}
+
+// PageURL returns rawURL with its "page" query parameter set to page, keeping the other parameters, and without the
+// parameter for the first page so it has the same URL as the list without a page.
+func PageURL(rawURL string, page int) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to create page URL: %w", err)
+	}
+
+	query := u.Query()
+	if page <= 1 {
+		query.Del("page")
+	} else {
+		query.Set("page", strconv.Itoa(page))
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// PageWindow returns the pages to link to out of total pages: the first and the last, and the pages within radius of
+// current, with a 0 for each gap of pages left out. A gap of a single page is shown as the page instead, so
+// PageWindow(5, 10, 1) is [1 0 4 5 6 0 10] and PageWindow(3, 10, 1) is [1 2 3 4 0 10].
+func PageWindow(current, total, radius int) []int {
+	if total < 1 {
+		return nil
+	}
+	current = min(max(current, 1), total)
+
+	start, end := max(current-radius, 1), min(current+radius, total)
+	if start <= 3 {
+		start = 1
+	}
+	if end >= total-2 {
+		end = total
+	}
+
+	var pages []int
+	if start > 1 {
+		pages = append(pages, 1, 0)
+	}
+	for page := start; page <= end; page++ {
+		pages = append(pages, page)
+	}
+	if end < total {
+		pages = append(pages, 0, total)
+	}
+
+	return pages
+}
@@ -0,0 +1,101 @@
+package paginate_test
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/ppcomponents/paginate"
+)
+
+func TestPageWindow(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		current  int
+		total    int
+		expected []int
+	}{
+		{name: "shows every page when there are few", current: 2, total: 4, expected: []int{1, 2, 3, 4}},
+		{name: "leaves out the pages far from the current one", current: 5, total: 10, expected: []int{1, 0, 4, 5, 6, 0, 10}},
+		{name: "shows a gap of a single page as the page", current: 3, total: 10, expected: []int{1, 2, 3, 4, 0, 10}},
+		{name: "shows the end of the pages on the last page", current: 10, total: 10, expected: []int{1, 0, 9, 10}},
+		{name: "keeps the current page within the pages", current: 20, total: 3, expected: []int{1, 2, 3}},
+		{name: "has no pages without any pages", current: 1, total: 0, expected: nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, paginate.PageWindow(tc.current, tc.total, 1))
+		})
+	}
+}
+
+func TestPageURL(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		url      string
+		page     int
+		expected string
+	}{
+		{name: "sets the page", url: "/reviews", page: 2, expected: "/reviews?page=2"},
+		{name: "keeps the other parameters", url: "/reviews?sort=new&page=3", page: 4, expected: "/reviews?page=4&sort=new"},
+		{name: "leaves out the first page", url: "/reviews?page=3", page: 1, expected: "/reviews"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := paginate.PageURL(tc.url, tc.page)
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestExtension(t *testing.T) {
+	pp, err := passepartout.LoadFrom(fstest.MapFS{
+		"reviews/index.tmpl": {Data: []byte(`{{ template "paginate" . }}`)},
+	}, passepartout.Use(paginate.Extension))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name       string
+		pagination paginate.Pagination
+		expected   string
+	}{
+		{
+			name:       "renders the links around the current page",
+			pagination: paginate.Pagination{Current: 2, Total: 3, URL: "/reviews?sort=new"},
+			expected: `<nav class="pagination" aria-label="Pagination">` +
+				`<a href="/reviews?sort=new" rel="prev">Previous</a>` +
+				`<a href="/reviews?sort=new">1</a>` +
+				`<span aria-current="page">2</span>` +
+				`<a href="/reviews?page=3&amp;sort=new">3</a>` +
+				`<a href="/reviews?page=3&amp;sort=new" rel="next">Next</a>` +
+				`</nav>`,
+		},
+		{
+			name:       "renders the gaps of the pages left out",
+			pagination: paginate.Pagination{Current: 1, Total: 9, URL: "/reviews"},
+			expected: `<nav class="pagination" aria-label="Pagination">` +
+				`<span aria-current="page">1</span>` +
+				`<a href="/reviews?page=2">2</a>` +
+				`<a href="/reviews?page=3">3</a>` +
+				`<span class="pagination-gap">&hellip;</span>` +
+				`<a href="/reviews?page=9">9</a>` +
+				`<a href="/reviews?page=2" rel="next">Next</a>` +
+				`</nav>`,
+		},
+		{
+			name:       "renders nothing for a single page",
+			pagination: paginate.Pagination{Current: 1, Total: 1, URL: "/reviews"},
+			expected:   ``,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+
+			require.NoError(t, pp.Render(out, "reviews/index.tmpl", tc.pagination))
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+}
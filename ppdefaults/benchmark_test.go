@@ -0,0 +1,110 @@
+package ppdefaults_test
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// benchmarkFS has a page using the given number of partials, and a layout with a partial of its own.
+func benchmarkFS(partials int) fstest.MapFS {
+	fsys := fstest.MapFS{
+		"layouts/default.tmpl":      {Data: []byte(`<html>{{ template "layouts/default/_nav.tmpl" }}{{ block "content" . }}{{ end }}</html>`)},
+		"layouts/default/_nav.tmpl": {Data: []byte(`<nav>home</nav>`)},
+		"reviews/index.tmpl":        {Data: []byte(`<h1>{{ .Title }}</h1>`)},
+	}
+	for i := range partials {
+		fsys[fmt.Sprintf("reviews/index/_item%03d.tmpl", i)] = &fstest.MapFile{Data: []byte(`<li>{{ .Title }}</li>`)}
+	}
+
+	return fsys
+}
+
+func BenchmarkLoader_Files(b *testing.B) {
+	for _, partials := range []int{1, 100} {
+		fsys := benchmarkFS(partials)
+		loader := ppdefaults.NewLoaderBuilder().WithDefaults(fsys).
+			TemplateLoader(ppdefaults.NewCachedLoader(&ppdefaults.TemplateByNameLoader{FS: fsys})).
+			Build()
+
+		b.Run(fmt.Sprintf("StandaloneFiles with %d partials", partials), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := loader.StandaloneFiles("reviews/index.tmpl"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("InLayoutFiles with %d partials", partials), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := loader.InLayoutFiles("reviews/index.tmpl", "layouts/default.tmpl"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkTemplateByNameLoader_InLayout(b *testing.B) {
+	loader := &ppdefaults.TemplateByNameLoader{FS: benchmarkFS(0)}
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if _, err := loader.InLayout("reviews/index.tmpl", "layouts/default.tmpl"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// raceEnabled is set when testing with -race.
+var raceEnabled = false
+
+// TestLoader_AllocationBudget guards the allocations of collecting the files for a render when the templates are
+// cached and the partials are in memory, which must not copy or rewrap the cached files.
+func TestLoader_AllocationBudget(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates on its own")
+	}
+
+	templates := make(map[string]string)
+	for name, f := range benchmarkFS(10) {
+		templates[name] = string(f.Data)
+	}
+	loader := ppdefaults.NewLoaderBuilder().WithMap(templates).
+		TemplateLoader(ppdefaults.NewCachedLoader(ppdefaults.MapLoader(templates))).
+		Build()
+
+	for _, tc := range []struct {
+		name   string
+		budget float64
+		load   func() ([]ppdefaults.FileWithContent, error)
+	}{
+		{
+			name:   "StandaloneFiles",
+			budget: 6,
+			load:   func() ([]ppdefaults.FileWithContent, error) { return loader.StandaloneFiles("reviews/index.tmpl") },
+		},
+		{
+			name:   "InLayoutFiles",
+			budget: 10,
+			load: func() ([]ppdefaults.FileWithContent, error) {
+				return loader.InLayoutFiles("reviews/index.tmpl", "layouts/default.tmpl")
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.load()
+			require.NoError(t, err)
+
+			allocs := testing.AllocsPerRun(100, func() { _, _ = tc.load() })
+
+			require.LessOrEqual(t, allocs, tc.budget, "expected to stay within the allocation budget")
+		})
+	}
+}
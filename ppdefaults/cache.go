@@ -1,6 +1,15 @@
 package ppdefaults
 
-import "sync"
+import (
+	"context"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gaqzi/passepartout/ppmetrics"
+)
 
 type loader interface {
 	Standalone(name string) ([]FileWithContent, error)
@@ -8,8 +17,41 @@ type loader interface {
 }
 
 type CachedLoader struct {
-	loader loader
-	data   *sync.Map
+	loader  loader
+	data    *sync.Map
+	metrics ppmetrics.Recorder
+	refresh refresher
+}
+
+// cacheKey is what is cached, a template and the layout it's loaded in, which is empty for standalone templates.
+type cacheKey struct {
+	name   string
+	layout string
+}
+
+// String is how the key is returned from [CachedLoader.PurgeChanged]: "name", or "name|layout".
+func (k cacheKey) String() string {
+	if k.layout == "" {
+		return k.name
+	}
+
+	return k.name + "|" + k.layout
+}
+
+// templates are the templates asked for with the key.
+func (k cacheKey) templates() []string {
+	if k.layout == "" {
+		return []string{k.name}
+	}
+
+	return []string{k.name, k.layout}
+}
+
+// cacheEntry remembers which templates were asked for alongside the files loaded for them,
+// so it's possible to tell which entries are affected when files change.
+type cacheEntry struct {
+	templates []string
+	files     []FileWithContent
 }
 
 // NewCachedLoader will cache successful calls to the passed in loader and return the result on repeated calls.
@@ -18,28 +60,169 @@ func NewCachedLoader(l loader) *CachedLoader {
 	return &CachedLoader{loader: l, data: new(sync.Map)}
 }
 
-func (c *CachedLoader) loadOrStore(cacheKey string, load func() ([]FileWithContent, error)) ([]FileWithContent, error) {
-	if v, ok := c.data.Load(cacheKey); ok {
-		return v.([]FileWithContent), nil
+// WithMetrics records the cache hits and misses of c with m, by the name of the requested template.
+func (c *CachedLoader) WithMetrics(m ppmetrics.Recorder) *CachedLoader {
+	c.metrics = m
+	return c
+}
+
+// loadOrStore returns the cached files for key without allocating, and only loads them when they aren't cached.
+func (c *CachedLoader) loadOrStore(key cacheKey, load func() ([]FileWithContent, error)) ([]FileWithContent, error) {
+	if v, ok := c.data.Load(key); ok {
+		if c.metrics != nil {
+			c.metrics.CacheHit(key.name)
+		}
+		return v.(cacheEntry).files, nil
+	}
+	if c.metrics != nil {
+		c.metrics.CacheMiss(key.name)
 	}
 
 	files, err := load()
 	if err != nil {
 		return nil, err
 	}
-	c.data.Store(cacheKey, files)
+	c.data.Store(key, cacheEntry{templates: key.templates(), files: files})
 
 	return files, nil
 }
 
 func (c *CachedLoader) Standalone(name string) ([]FileWithContent, error) {
-	return c.loadOrStore(name, func() ([]FileWithContent, error) {
+	return c.loadOrStore(cacheKey{name: name}, func() ([]FileWithContent, error) {
 		return c.loader.Standalone(name)
 	})
 }
 
 func (c *CachedLoader) InLayout(name, layout string) ([]FileWithContent, error) {
-	return c.loadOrStore(name+"|"+layout, func() ([]FileWithContent, error) {
+	return c.loadOrStore(cacheKey{name: name, layout: layout}, func() ([]FileWithContent, error) {
+		return c.loader.InLayout(name, layout)
+	})
+}
+
+// StandaloneContext implements [TemplateLoaderContext], passing ctx on when the underlying loader accepts a context.
+func (c *CachedLoader) StandaloneContext(ctx context.Context, name string) ([]FileWithContent, error) {
+	return c.loadOrStore(cacheKey{name: name}, func() ([]FileWithContent, error) {
+		if l, ok := c.loader.(TemplateLoaderContext); ok {
+			return l.StandaloneContext(ctx, name)
+		}
+		return c.loader.Standalone(name)
+	})
+}
+
+// InLayoutContext implements [TemplateLoaderContext], passing ctx on when the underlying loader accepts a context.
+func (c *CachedLoader) InLayoutContext(ctx context.Context, name, layout string) ([]FileWithContent, error) {
+	return c.loadOrStore(cacheKey{name: name, layout: layout}, func() ([]FileWithContent, error) {
+		if l, ok := c.loader.(TemplateLoaderContext); ok {
+			return l.InLayoutContext(ctx, name, layout)
+		}
 		return c.loader.InLayout(name, layout)
 	})
 }
+
+// PurgeChanged evicts only the cached entries affected by the changes between the old and new manifest,
+// instead of flushing the whole cache after a deploy. It returns the evicted cache keys, sorted.
+//
+// An entry is affected when it was loaded from a file that was modified or removed,
+// or when a file was added to a folder the entry loaded partials from or to the partial folder of one of its templates.
+func (c *CachedLoader) PurgeChanged(old, newer Manifest) []string {
+	changed, addedDirs, ok := manifestChanges(old, newer)
+	if !ok {
+		return nil
+	}
+
+	var purged []string
+	c.data.Range(func(key, value any) bool {
+		if value.(cacheEntry).affectedBy(changed, addedDirs) {
+			c.data.Delete(key)
+			purged = append(purged, key.(cacheKey).String())
+		}
+		return true
+	})
+	sort.Strings(purged)
+
+	return purged
+}
+
+// RefreshChanged reloads the cached entries affected by the changes between the old and new manifest in the
+// background, in the same way as [CachedLoader.PurgeChanged], while the stale files are returned until the reload
+// is done, so a change never makes a request wait for the files to load. It returns the refreshed cache keys, sorted.
+// An entry that fails to reload is evicted, so the error is returned when it's next loaded.
+func (c *CachedLoader) RefreshChanged(old, newer Manifest) []string {
+	changed, addedDirs, ok := manifestChanges(old, newer)
+	if !ok {
+		return nil
+	}
+
+	var refreshed []string
+	c.data.Range(func(key, value any) bool {
+		if !value.(cacheEntry).affectedBy(changed, addedDirs) {
+			return true
+		}
+
+		k := key.(cacheKey)
+		var files []FileWithContent
+		c.refresh.refresh(k, func() (err error) {
+			if k.layout == "" {
+				files, err = c.loader.Standalone(k.name)
+			} else {
+				files, err = c.loader.InLayout(k.name, k.layout)
+			}
+			return err
+		}, func(err error) {
+			if err != nil {
+				c.data.Delete(k)
+				return
+			}
+			c.data.Store(k, cacheEntry{templates: k.templates(), files: files})
+		})
+		refreshed = append(refreshed, k.String())
+		return true
+	})
+	sort.Strings(refreshed)
+
+	return refreshed
+}
+
+// manifestChanges returns the modified or removed files and the folders with added files, or false without changes.
+func manifestChanges(old, newer Manifest) (changed, addedDirs map[string]struct{}, ok bool) {
+	changes := old.Changes(newer)
+	if changes.Empty() {
+		return nil, nil, false
+	}
+
+	changed = make(map[string]struct{})
+	for _, name := range append(changes.Modified, changes.Removed...) {
+		changed[name] = struct{}{}
+	}
+	addedDirs = make(map[string]struct{})
+	for _, name := range changes.Added {
+		addedDirs[path.Dir(name)] = struct{}{}
+	}
+
+	return changed, addedDirs, true
+}
+
+func (e cacheEntry) affectedBy(changed, addedDirs map[string]struct{}) bool {
+	for _, f := range e.files {
+		if _, ok := changed[f.Name]; ok {
+			return true
+		}
+		if slices.Contains(e.templates, f.Name) {
+			continue // a new file next to a template isn't loaded by it, only new files next to its partials are
+		}
+		if _, ok := addedDirs[path.Dir(f.Name)]; ok {
+			return true
+		}
+	}
+
+	for _, name := range e.templates {
+		partialDir := strings.TrimSuffix(name, path.Ext(name))
+		for dir := range addedDirs {
+			if dir == partialDir || strings.HasPrefix(dir, partialDir+"/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -3,6 +3,8 @@ package ppdefaults_test
 import (
 	"errors"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -104,3 +106,135 @@ func TestCachedLoader(t *testing.T) {
 		})
 	}
 }
+
+func TestCachedLoader_WithMetrics(t *testing.T) {
+	metrics := new(metricsRecorder)
+	cache := ppdefaults.NewCachedLoader(&ppdefaults.TemplateByNameLoader{FS: fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`{{ block "content" . }}{{ end }}`)},
+		"index.tmpl":           {Data: []byte(`Hello`)},
+	}}).WithMetrics(metrics)
+
+	for range 2 {
+		_, err := cache.Standalone("index.tmpl")
+		require.NoError(t, err)
+		_, err = cache.InLayout("index.tmpl", "layouts/default.tmpl")
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, []string{"miss index.tmpl", "miss index.tmpl", "hit index.tmpl", "hit index.tmpl"}, metrics.calls)
+}
+
+func TestCachedLoader_PurgeChanged(t *testing.T) {
+	old := ppdefaults.Manifest{
+		"index.tmpl":           "1",
+		"show.tmpl":            "1",
+		"layouts/default.tmpl": "1",
+	}
+
+	for _, tc := range []struct {
+		name     string
+		newer    ppdefaults.Manifest
+		expected []string
+	}{
+		{
+			name:     "nothing is purged when nothing changed",
+			newer:    old,
+			expected: nil,
+		},
+		{
+			name:     "entries loaded from a modified file are purged",
+			newer:    ppdefaults.Manifest{"index.tmpl": "2", "show.tmpl": "1", "layouts/default.tmpl": "1"},
+			expected: []string{"index.tmpl", "index.tmpl|layouts/default.tmpl"},
+		},
+		{
+			name:     "entries loaded from a removed file are purged",
+			newer:    ppdefaults.Manifest{"index.tmpl": "1", "show.tmpl": "1"},
+			expected: []string{"index.tmpl|layouts/default.tmpl"},
+		},
+		{
+			name:     "entries whose partial folder got a new file are purged",
+			newer:    ppdefaults.Manifest{"index.tmpl": "1", "show.tmpl": "1", "layouts/default.tmpl": "1", "show/_new.tmpl": "1"},
+			expected: []string{"show.tmpl"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := ppdefaults.NewCachedLoader(&ppdefaults.TemplateByNameLoader{FS: fstest.MapFS{
+				"index.tmpl":           {Data: []byte("index")},
+				"show.tmpl":            {Data: []byte("show")},
+				"layouts/default.tmpl": {Data: []byte("layout")},
+			}})
+			for _, load := range []func() ([]ppdefaults.FileWithContent, error){
+				func() ([]ppdefaults.FileWithContent, error) { return cache.Standalone("index.tmpl") },
+				func() ([]ppdefaults.FileWithContent, error) { return cache.Standalone("show.tmpl") },
+				func() ([]ppdefaults.FileWithContent, error) {
+					return cache.InLayout("index.tmpl", "layouts/default.tmpl")
+				},
+			} {
+				_, err := load()
+				require.NoError(t, err)
+			}
+
+			purged := cache.PurgeChanged(old, tc.newer)
+
+			require.Equal(t, tc.expected, purged)
+			require.Empty(t, cache.PurgeChanged(old, tc.newer), "expected the purged entries to be gone from the cache")
+		})
+	}
+}
+
+// gatedLoader waits on gate before loading, when set, to control when a refresh in the background finishes.
+type gatedLoader struct {
+	ppdefaults.TemplateByNameLoader
+	gate chan struct{}
+}
+
+func (g *gatedLoader) Standalone(name string) ([]ppdefaults.FileWithContent, error) {
+	if g.gate != nil {
+		<-g.gate
+	}
+
+	return g.TemplateByNameLoader.Standalone(name)
+}
+
+func TestCachedLoader_RefreshChanged(t *testing.T) {
+	content := func(t *testing.T, cache *ppdefaults.CachedLoader) string {
+		t.Helper()
+		files, err := cache.Standalone("index.tmpl")
+		require.NoError(t, err)
+
+		return files[0].Content
+	}
+	old := ppdefaults.Manifest{"index.tmpl": "1", "show.tmpl": "1"}
+
+	t.Run("returns the stale files until the changed entries are reloaded", func(t *testing.T) {
+		fsys := fstest.MapFS{"index.tmpl": {Data: []byte("index")}, "show.tmpl": {Data: []byte("show")}}
+		loader := &gatedLoader{TemplateByNameLoader: ppdefaults.TemplateByNameLoader{FS: fsys}}
+		cache := ppdefaults.NewCachedLoader(loader)
+		require.Equal(t, "index", content(t, cache))
+		_, err := cache.Standalone("show.tmpl")
+		require.NoError(t, err)
+		fsys["index.tmpl"] = &fstest.MapFile{Data: []byte("index 2")}
+		loader.gate = make(chan struct{})
+
+		refreshed := cache.RefreshChanged(old, ppdefaults.Manifest{"index.tmpl": "2", "show.tmpl": "1"})
+
+		require.Equal(t, []string{"index.tmpl"}, refreshed)
+		require.Equal(t, "index", content(t, cache), "expected the stale files while reloading")
+		close(loader.gate)
+		require.Eventually(t, func() bool { return content(t, cache) == "index 2" }, time.Second, time.Millisecond)
+	})
+
+	t.Run("evicts an entry that fails to reload", func(t *testing.T) {
+		fsys := fstest.MapFS{"index.tmpl": {Data: []byte("index")}}
+		cache := ppdefaults.NewCachedLoader(&ppdefaults.TemplateByNameLoader{FS: fsys})
+		require.Equal(t, "index", content(t, cache))
+		delete(fsys, "index.tmpl")
+
+		require.Equal(t, []string{"index.tmpl"}, cache.RefreshChanged(old, ppdefaults.Manifest{"show.tmpl": "1"}))
+
+		require.Eventually(t, func() bool {
+			_, err := cache.Standalone("index.tmpl")
+			return err != nil
+		}, time.Second, time.Millisecond)
+	})
+}
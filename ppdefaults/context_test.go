@@ -0,0 +1,103 @@
+package ppdefaults_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+type ctxKey struct{}
+
+// remoteLoader is a template loader that needs a context, the files it returns are named after the context's value.
+type remoteLoader struct {
+	calls int
+}
+
+func (r *remoteLoader) Standalone(name string) ([]ppdefaults.FileWithContent, error) {
+	panic("expected the context variant to be used")
+}
+
+func (r *remoteLoader) InLayout(name, layout string) ([]ppdefaults.FileWithContent, error) {
+	panic("expected the context variant to be used")
+}
+
+func (r *remoteLoader) StandaloneContext(ctx context.Context, name string) ([]ppdefaults.FileWithContent, error) {
+	r.calls++
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return []ppdefaults.FileWithContent{{Name: name, Content: ctx.Value(ctxKey{}).(string)}}, nil
+}
+
+func (r *remoteLoader) InLayoutContext(ctx context.Context, name, layout string) ([]ppdefaults.FileWithContent, error) {
+	r.calls++
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return []ppdefaults.FileWithContent{{Name: layout, Content: ctx.Value(ctxKey{}).(string)}, {Name: name}}, nil
+}
+
+func TestLoader_Context(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ctxKey{}, "from context")
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	t.Run("passes the context to the template loader and partials that accept it", func(t *testing.T) {
+		loader := ppdefaults.NewLoaderBuilder().
+			TemplateLoader(&remoteLoader{}).
+			PartialsForContext(func(ctx context.Context, page string) ([]ppdefaults.FileWithContent, error) {
+				return []ppdefaults.FileWithContent{{Name: "_partial.tmpl", Content: ctx.Value(ctxKey{}).(string)}}, nil
+			}).
+			CreateTemplate(ppdefaults.CreateTemplate).
+			Build()
+
+		files, err := loader.StandaloneFilesContext(ctx, "index.tmpl")
+		require.NoError(t, err)
+		require.Equal(t, []ppdefaults.FileWithContent{
+			{Name: "_partial.tmpl", Content: "from context"},
+			{Name: "index.tmpl", Content: "from context"},
+		}, files)
+
+		files, err = loader.InLayoutFilesContext(ctx, "index.tmpl", "layouts/default.tmpl")
+		require.NoError(t, err)
+		require.Equal(t, "layouts/default.tmpl", files[1].Name)
+		require.Equal(t, "from context", files[1].Content)
+
+		_, err = loader.InLayoutContext(canceled, "index.tmpl", "layouts/default.tmpl")
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("doesn't call loaders without a context when the context is done", func(t *testing.T) {
+		loader := ppdefaults.NewLoaderBuilder().
+			TemplateLoader(&templateLoaderMock{}).
+			PartialsFor(func(page string) ([]ppdefaults.FileWithContent, error) {
+				panic("expected to not be called")
+			}).
+			Build()
+
+		_, err := loader.StandaloneContext(canceled, "index.tmpl")
+
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("CachedLoader passes the context on to the loader", func(t *testing.T) {
+		remote := &remoteLoader{}
+		cached := ppdefaults.NewCachedLoader(remote)
+
+		_, err := cached.StandaloneContext(canceled, "index.tmpl")
+		require.ErrorIs(t, err, context.Canceled, "expected a failed load to not be cached")
+
+		files, err := cached.StandaloneContext(ctx, "index.tmpl")
+		require.NoError(t, err)
+		require.Equal(t, "from context", files[0].Content)
+
+		_, err = cached.StandaloneContext(ctx, "index.tmpl")
+		require.NoError(t, err)
+		require.Equal(t, 2, remote.calls, "expected the successful load to be cached")
+	})
+}
@@ -0,0 +1,28 @@
+package ppdefaults
+
+import (
+	"io/fs"
+)
+
+// AsFS returns fsys as an [FS], reading its files and directories with [fs.ReadFile] and [fs.ReadDir] when it
+// doesn't implement them itself, so templates can be loaded from any [fs.FS].
+func AsFS(fsys fs.FS) FS {
+	if f, ok := fsys.(FS); ok {
+		return f
+	}
+
+	return openFS{FS: fsys}
+}
+
+// openFS reads files and directories by opening them.
+type openFS struct {
+	fs.FS
+}
+
+func (f openFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(f.FS, name)
+}
+
+func (f openFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(f.FS, name)
+}
@@ -0,0 +1,40 @@
+package ppdefaults_test
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+type openOnlyFS struct {
+	fsys fs.FS
+}
+
+func (o openOnlyFS) Open(name string) (fs.File, error) {
+	return o.fsys.Open(name)
+}
+
+func TestAsFS(t *testing.T) {
+	mapFS := fstest.MapFS{"index.tmpl": {Data: []byte("index")}, "index/_item.tmpl": {Data: []byte("item")}}
+
+	t.Run("returns a filesystem that already reads files and directories as it is", func(t *testing.T) {
+		require.Equal(t, mapFS, ppdefaults.AsFS(mapFS))
+	})
+
+	t.Run("reads the files and directories of a filesystem that only opens files", func(t *testing.T) {
+		fsys := ppdefaults.AsFS(openOnlyFS{fsys: mapFS})
+
+		content, err := fsys.ReadFile("index.tmpl")
+		require.NoError(t, err)
+		require.Equal(t, "index", string(content))
+
+		entries, err := fsys.ReadDir("index")
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, "_item.tmpl", entries[0].Name())
+	})
+}
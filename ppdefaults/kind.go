@@ -0,0 +1,32 @@
+package ppdefaults
+
+import (
+	"strings"
+)
+
+// Kind is what a template is used for by the default conventions.
+type Kind string
+
+const (
+	KindPage    Kind = "page"
+	KindLayout  Kind = "layout"
+	KindPartial Kind = "partial"
+	// KindComponent is a reusable template rendered with props, see passepartout's RenderComponent.
+	KindComponent Kind = "component"
+)
+
+// KindOf follows the default conventions: partials start with "_", wherever they are, as does everything in the
+// partial folder of a partial, like "reports/_table/row.tmpl", layouts live in "layouts/", components in
+// "components/", and everything else is a page.
+func KindOf(name string) Kind {
+	switch {
+	case strings.HasPrefix(name, "_") || strings.Contains(name, "/_"):
+		return KindPartial
+	case strings.HasPrefix(name, "layouts/"):
+		return KindLayout
+	case strings.HasPrefix(name, "components/"):
+		return KindComponent
+	default:
+		return KindPage
+	}
+}
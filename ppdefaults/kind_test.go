@@ -0,0 +1,30 @@
+package ppdefaults_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+func TestKindOf(t *testing.T) {
+	for name, expected := range map[string]ppdefaults.Kind{
+		"index.tmpl":                ppdefaults.KindPage,
+		"reviews/show.tmpl":         ppdefaults.KindPage,
+		"reviews/show/_item.tmpl":   ppdefaults.KindPartial,
+		"_root.tmpl":                ppdefaults.KindPartial,
+		"layouts/default.tmpl":      ppdefaults.KindLayout,
+		"layouts/default/_nav.tmpl": ppdefaults.KindPartial,
+		"reviews/layouts/x.tmpl":    ppdefaults.KindPage,
+		"components/button.tmpl":    ppdefaults.KindComponent,
+		"components/button/_x.tmpl": ppdefaults.KindPartial,
+		"reports/_table/_row.tmpl":  ppdefaults.KindPartial,
+		"reports/_table/row.tmpl":   ppdefaults.KindPartial,
+		"_shared/row.tmpl":          ppdefaults.KindPartial,
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, expected, ppdefaults.KindOf(name))
+		})
+	}
+}
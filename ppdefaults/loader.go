@@ -1,25 +1,69 @@
 package ppdefaults
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"html/template"
 	"io/fs"
+	"log/slog"
+	"slices"
+	"strings"
+	"text/template/parse"
+	"time"
+
+	"github.com/gaqzi/passepartout/internal/ppparse"
+	"github.com/gaqzi/passepartout/ppmetrics"
 )
 
 type FileWithContent struct {
 	Name    string
 	Content string
+	// Block is the block of the layout a page is defined as when the template loaders' InLayout wrapped it in a define,
+	// empty for every other file. Content includes the define, see [FileWithContent.Unwrapped].
+	Block string
+}
+
+// Unwrapped returns the content as it's written, without the define of Block.
+func (f FileWithContent) Unwrapped() string {
+	_, unwrapped, _ := f.split()
+	return unwrapped
+}
+
+// split returns the define of Block, the content as it's written, and the end of the define.
+// The define and end are empty when the file isn't wrapped.
+func (f FileWithContent) split() (define, unwrapped, end string) {
+	if f.Block == "" {
+		return "", f.Content, ""
+	}
+	define = blockDefine(f.Block)
+	if !strings.HasPrefix(f.Content, define) || !strings.HasSuffix(f.Content, contentEnd) || len(f.Content) < len(define)+len(contentEnd) {
+		return "", f.Content, ""
+	}
+
+	return define, f.Content[len(define) : len(f.Content)-len(contentEnd)], contentEnd
 }
 
 // PartialLoader loads all the partials for a template and returns a slice of FileWithContent.
 type PartialLoader func(page string) ([]FileWithContent, error)
 
+// PartialLoaderContext is a [PartialLoader] that respects the timeouts and cancellation of ctx,
+// for partials stored remotely like in a database or S3.
+type PartialLoaderContext func(ctx context.Context, page string) ([]FileWithContent, error)
+
 // TemplateLoader loads a template and knows how to templates for use in a layout.
 type TemplateLoader interface {
 	Standalone(name string) ([]FileWithContent, error)
 	InLayout(name string, layout string) ([]FileWithContent, error)
 }
 
+// TemplateLoaderContext is implemented by a [TemplateLoader] that respects the timeouts and cancellation of ctx,
+// it's used instead of the [TemplateLoader] methods when rendering with a context.
+type TemplateLoaderContext interface {
+	StandaloneContext(ctx context.Context, name string) ([]FileWithContent, error)
+	InLayoutContext(ctx context.Context, name string, layout string) ([]FileWithContent, error)
+}
+
 // Templater either creates a new template with name and content or adds that template to an existing collection of templates.
 type Templater func(base *template.Template, files []FileWithContent) (*template.Template, error)
 
@@ -29,28 +73,41 @@ type FS interface {
 	fs.ReadFileFS
 }
 
-func flatMap(name string, fns ...func(string) ([]FileWithContent, error)) ([]FileWithContent, error) {
-	var files []FileWithContent
+// appendLast appends the files of each group, keeping only the last of the files with the same name, for example when
+// both the layout and the page loads the same common partials, so the files are in the order they override each other.
+func appendLast(files []FileWithContent, groups ...[]FileWithContent) []FileWithContent {
+	last := make(map[string]int)
+	n := 0
+	for _, group := range groups {
+		for _, f := range group {
+			last[f.Name] = n
+			n++
+		}
+	}
 
-	for _, fn := range fns {
-		result, err := fn(name)
-		if err != nil {
-			return nil, err
+	n = 0
+	for _, group := range groups {
+		for _, f := range group {
+			if last[f.Name] == n {
+				files = append(files, f)
+			}
+			n++
 		}
-		files = append(files, result...)
 	}
 
-	return files, nil
+	return files
 }
 
-// WithDefaults sets the default Partial and Template loader together with the template creator using the passed in FS.
-// Uses:
+// WithDefaults sets the default Partial and Template loader together with the template creator using the passed in
+// filesystem, read with [AsFS]. Uses:
 //   - [PartialsInFolderOnly] for PartialsFor
 //   - [TemplateByNameLoader] for TemplateLoader
 //   - [CreateTemplate] for CreateTemplate
-func (b *LoaderBuilder) WithDefaults(fsys FS) *LoaderBuilder {
+func (b *LoaderBuilder) WithDefaults(fsys_ fs.FS) *LoaderBuilder {
+	fsys := AsFS(fsys_)
 	partials := PartialsInFolderOnly{FS: fsys}
 	b.build.PartialsFor = partials.Load
+	b.build.PartialDirs = partials.Dirs
 
 	b.build.TemplateLoader = &TemplateByNameLoader{FS: fsys}
 	b.build.CreateTemplate = CreateTemplate
@@ -58,56 +115,375 @@ func (b *LoaderBuilder) WithDefaults(fsys FS) *LoaderBuilder {
 	return b
 }
 
+// Partials loads the partials for a template from folders, like [PartialsInFolderOnly] and [PartialsWithCommon].
+type Partials interface {
+	Load(name string) ([]FileWithContent, error)
+	// Dirs returns the folders Load loads the partials for name from.
+	Dirs(name string) []string
+}
+
+// WithPartials sets PartialsFor and PartialDirs from p.
+func (b *LoaderBuilder) WithPartials(p Partials) *LoaderBuilder {
+	b.build.PartialsFor = p.Load
+	b.build.PartialDirs = p.Dirs
+
+	return b
+}
+
+// WithSharedLayoutPartials adds the partials in dir of fsys, and its subfolders, to every [Loader.InLayout],
+// for example "layouts/shared" with the nav, footer, and flash messages used by all layouts.
+func (b *LoaderBuilder) WithSharedLayoutPartials(fsys fs.ReadDirFS, dir string) *LoaderBuilder {
+	b.build.LayoutPartials = &PartialsInDir{FS: fsys, Dir: dir}
+
+	return b
+}
+
+// WithTemplateOption applies the [template.Template.Option] options, like "missingkey=error", to the TemplateConfig
+// so they're used by every template created from it. A TemplateConfig is created when none has been set yet.
+// Like [template.Template.Option] it panics on an unknown option.
+func (b *LoaderBuilder) WithTemplateOption(opt ...string) *LoaderBuilder {
+	if b.build.TemplateConfig == nil {
+		b.build.TemplateConfig = template.New("")
+	}
+	b.build.TemplateConfig.Option(opt...)
+
+	return b
+}
+
+// WithTransformer adds transform to the transformers of the files ending with ext, which run in the order they were
+// added.
+func (b *LoaderBuilder) WithTransformer(ext string, transform Transformer) *LoaderBuilder {
+	if b.build.Transformers == nil {
+		b.build.Transformers = make(map[string][]Transformer)
+	}
+	b.build.Transformers[ext] = append(b.build.Transformers[ext], transform)
+
+	return b
+}
+
+// WithFuncs adds funcs to the TemplateConfig, with [template.Template.Funcs], so every template created from it can
+// use them. A TemplateConfig is created when none has been set yet.
+func (b *LoaderBuilder) WithFuncs(funcs template.FuncMap) *LoaderBuilder {
+	if b.build.TemplateConfig == nil {
+		b.build.TemplateConfig = template.New("")
+	}
+	b.build.TemplateConfig.Funcs(funcs)
+
+	return b
+}
+
+// Validate returns an error naming the fields of the [Loader] that are required but haven't been set, see
+// [Loader.Validate].
+func (b *LoaderBuilder) Validate() error {
+	return b.build.Validate()
+}
+
+// MustBuild returns the built Loader like [LoaderBuilder.Build], and panics with the error of [LoaderBuilder.Validate]
+// when a required field hasn't been set, so a misconfigured loader fails at startup instead of when rendering.
+func (b *LoaderBuilder) MustBuild() *Loader {
+	if err := b.Validate(); err != nil {
+		panic(err)
+	}
+
+	return b.Build()
+}
+
 type Loader struct {
 	// TemplateConfig is used as a base when creating new templates from a collection of files.
 	// See [template.Template.Funcs] and [template.Template.Option] for what often is configured.
 	TemplateConfig *template.Template
 	PartialsFor    PartialLoader
+	// PartialsForContext is used instead of PartialsFor when set.
+	PartialsForContext PartialLoaderContext
+	// LayoutPartials are added to every [Loader.InLayout], for layout chrome shared by all layouts like the nav and
+	// footer in "layouts/shared/", see [LoaderBuilder.WithSharedLayoutPartials].
+	LayoutPartials Partials
+	// PartialDirs returns the folders partials are loaded from for a template, to explain where a missing partial
+	// was looked for.
+	PartialDirs func(name string) []string
+	// TemplateLoader is used through [TemplateLoaderContext] when it implements it.
 	TemplateLoader TemplateLoader
 	CreateTemplate Templater
+	// Transformers change the content of the files with an extension, like ".md", in order before they're created
+	// into a template, see [LoaderBuilder.WithTransformer].
+	Transformers map[string][]Transformer
+	// Metrics records how long loading and parsing the files for each template takes when set.
+	Metrics ppmetrics.Recorder
+	// Logger logs every file resolved for a template, in the order they're parsed, at debug level when set.
+	Logger *slog.Logger
+}
+
+// Validate returns an error naming the required fields that haven't been set: TemplateLoader, CreateTemplate, and
+// PartialsFor or PartialsForContext.
+func (l *Loader) Validate() error {
+	var missing []string
+	if l.TemplateLoader == nil {
+		missing = append(missing, "TemplateLoader")
+	}
+	if l.PartialsFor == nil && l.PartialsForContext == nil {
+		missing = append(missing, "PartialsFor")
+	}
+	if l.CreateTemplate == nil {
+		missing = append(missing, "CreateTemplate")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("failed to validate loader: %s not set, use LoaderBuilder.WithDefaults to set the defaults", strings.Join(missing, ", "))
+	}
+
+	return nil
 }
 
 func (l *Loader) Standalone(name string) (*template.Template, error) {
-	files, err := flatMap(name, l.PartialsFor, l.TemplateLoader.Standalone)
+	return l.StandaloneContext(context.Background(), name)
+}
+
+// StandaloneContext is [Loader.Standalone] passing ctx to the loaders that accept a context.
+func (l *Loader) StandaloneContext(ctx context.Context, name string) (*template.Template, error) {
+	tmplt, _, err := l.standalone(ctx, name)
+	return tmplt, err
+}
+
+// standalone creates the template for name, and returns the files it was created from.
+func (l *Loader) standalone(ctx context.Context, name string) (*template.Template, []FileWithContent, error) {
+	start := time.Now()
+	files, err := l.StandaloneFilesContext(ctx, name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to collect all files for %q: %w", name, err)
+		return nil, nil, err
 	}
+	start = l.observe(ppmetrics.Recorder.Load, name, start)
 
 	tmplt, err := l.CreateTemplate(l.TemplateConfig, files)
+	l.observe(ppmetrics.Recorder.Parse, name, start)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create template for %q: %w", name, err)
+		return nil, nil, fmt.Errorf("failed to create template for %q: %w", name, err)
 	}
 
-	return tmplt, nil
+	return tmplt, files, nil
 }
 
-func (l *Loader) InLayout(page string, layout string) (*template.Template, error) {
+// StandaloneFiles collects all the files [Loader.Standalone] creates the template from, without creating it.
+func (l *Loader) StandaloneFiles(name string) ([]FileWithContent, error) {
+	return l.StandaloneFilesContext(context.Background(), name)
+}
+
+// StandaloneFilesContext is [Loader.StandaloneFiles] passing ctx to the loaders that accept a context.
+func (l *Loader) StandaloneFilesContext(ctx context.Context, name string) ([]FileWithContent, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, fmt.Errorf("failed to collect all files: %w", err)
+	}
+
+	partials, err := l.partials(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect all files for %q: %w", name, err)
+	}
+
 	var files []FileWithContent
-	partials, err := l.PartialsFor(page)
+	if tl, ok := l.TemplateLoader.(TemplateLoaderContext); ok {
+		files, err = tl.StandaloneContext(ctx, name)
+	} else {
+		files, err = l.TemplateLoader.Standalone(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect all files for %q: %w", name, err)
+	}
+	// Concat instead of append so a cached partials slice with spare capacity is never written to.
+	files, err = l.transform(slices.Concat(partials, files))
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect all files for %q: %w", name, err)
+	}
+	l.logResolved(ctx, name, "", files)
+
+	return files, nil
+}
+
+func (l *Loader) InLayout(page string, layout string) (*template.Template, error) {
+	return l.InLayoutContext(context.Background(), page, layout)
+}
+
+// InLayoutContext is [Loader.InLayout] passing ctx to the loaders that accept a context.
+func (l *Loader) InLayoutContext(ctx context.Context, page string, layout string) (*template.Template, error) {
+	tmplt, _, err := l.inLayout(ctx, page, layout)
+	return tmplt, err
+}
+
+// inLayout creates the template for page within layout, and returns the files it was created from.
+func (l *Loader) inLayout(ctx context.Context, page string, layout string) (*template.Template, []FileWithContent, error) {
+	start := time.Now()
+	files, err := l.InLayoutFilesContext(ctx, page, layout)
+	if err != nil {
+		return nil, nil, err
+	}
+	start = l.observe(ppmetrics.Recorder.Load, page, start)
+
+	tmplt, err := l.CreateTemplate(l.TemplateConfig, files)
+	l.observe(ppmetrics.Recorder.Parse, page, start)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create template for %q in layout %q: %w", page, layout, err)
+	}
+
+	return tmplt, files, nil
+}
+
+// InLayoutFiles collects all the files [Loader.InLayout] creates the template from, without creating it.
+//
+// The partials for both the layout and the page are collected, so "layouts/default.tmpl" can use
+// "layouts/default/_nav.tmpl", before loading the page wrapped for use within the layout.
+// The LayoutPartials are collected first, then the layout's partials so the page's partials can override anything
+// defined by them.
+func (l *Loader) InLayoutFiles(page string, layout string) ([]FileWithContent, error) {
+	return l.InLayoutFilesContext(context.Background(), page, layout)
+}
+
+// InLayoutFilesContext is [Loader.InLayoutFiles] passing ctx to the loaders that accept a context.
+func (l *Loader) InLayoutFilesContext(ctx context.Context, page string, layout string) ([]FileWithContent, error) {
+	if err := errors.Join(ValidateName(page), ValidateName(layout)); err != nil {
+		return nil, fmt.Errorf("failed to collect all files: %w", err)
+	}
+
+	shared, err := l.SharedLayoutPartials(layout)
+	if err != nil {
+		return nil, err
+	}
+
+	layoutPartials, err := l.partials(ctx, layout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect partials for layout %q: %w", layout, err)
+	}
+
+	partials, err := l.partials(ctx, page)
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect partials for %q: %w", page, err)
 	}
-	files = append(files, partials...)
 
-	pageFiles, err := l.TemplateLoader.InLayout(page, layout)
+	var pageFiles []FileWithContent
+	if tl, ok := l.TemplateLoader.(TemplateLoaderContext); ok {
+		pageFiles, err = tl.InLayoutContext(ctx, page, layout)
+	} else {
+		pageFiles, err = l.TemplateLoader.InLayout(page, layout)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect all for %q in layout %q: %w", page, layout, err)
 	}
+
+	// Allocated once for all the files, the partials are deduplicated as the same common partials can be loaded for
+	// both the layout and the page.
+	files := make([]FileWithContent, 0, len(shared)+len(layoutPartials)+len(partials)+len(pageFiles))
+	files = appendLast(files, shared, layoutPartials, partials)
 	files = append(files, pageFiles...)
+	if files, err = l.transform(files); err != nil {
+		return nil, fmt.Errorf("failed to collect all for %q in layout %q: %w", page, layout, err)
+	}
+	l.logResolved(ctx, page, layout, files)
 
-	tmplt, err := l.CreateTemplate(l.TemplateConfig, files)
+	return files, nil
+}
+
+// SharedLayoutPartials returns the LayoutPartials added to every page rendered in layout, nil when LayoutPartials isn't
+// set.
+func (l *Loader) SharedLayoutPartials(layout string) ([]FileWithContent, error) {
+	if l.LayoutPartials == nil {
+		return nil, nil
+	}
+
+	shared, err := l.LayoutPartials.Load(layout)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create template for %q in layout %q: %w", page, layout, err)
+		return nil, fmt.Errorf("failed to collect shared partials for layout %q: %w", layout, err)
 	}
 
-	return tmplt, nil
+	return shared, nil
+}
+
+// ContentBlock returns the block of the layouts the TemplateLoader defines the pages as, like the ContentBlockName of
+// [TemplateByNameLoader], and "content" for loaders that don't say.
+func (l *Loader) ContentBlock() string {
+	if t, ok := l.TemplateLoader.(interface{ ContentBlock() string }); ok {
+		return t.ContentBlock()
+	}
+
+	return defaultContentBlock
+}
+
+// logResolved logs the files resolved for name, which are parsed in order so later files override earlier defines.
+func (l *Loader) logResolved(ctx context.Context, name, layout string, files []FileWithContent) {
+	if l.Logger == nil || !l.Logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	for i, f := range files {
+		l.Logger.DebugContext(ctx, "resolved template file", "template", name, "layout", layout, "file", f.Name, "kind", KindOf(f.Name), "order", i)
+	}
+}
+
+// SearchedDirs returns the folders searched for partials when loading page, within layout unless it's empty,
+// in the order they're loaded. It's nil when PartialDirs isn't set.
+func (l *Loader) SearchedDirs(page, layout string) []string {
+	if l.PartialDirs == nil {
+		return nil
+	}
+
+	var dirs []string
+	if l.LayoutPartials != nil && layout != "" {
+		dirs = append(dirs, l.LayoutPartials.Dirs(layout)...)
+	}
+	for _, name := range []string{layout, page} {
+		if name == "" {
+			continue
+		}
+		for _, dir := range l.PartialDirs(name) {
+			if !slices.Contains(dirs, dir) {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+
+	return dirs
+}
+
+// observe records the time since start with record when Metrics is set, and returns the current time so it can be
+// used as the start of the next observation.
+func (l *Loader) observe(record func(ppmetrics.Recorder, string, time.Duration), name string, start time.Time) time.Time {
+	now := time.Now()
+	if l.Metrics != nil {
+		record(l.Metrics, name, now.Sub(start))
+	}
+
+	return now
+}
+
+// partials uses PartialsForContext when set, and otherwise PartialsFor unless ctx is already done.
+func (l *Loader) partials(ctx context.Context, name string) ([]FileWithContent, error) {
+	if l.PartialsForContext != nil {
+		return l.PartialsForContext(ctx, name)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return l.PartialsFor(name)
 }
 
 type TemplateByNameLoader struct {
 	FS fs.ReadFileFS
+	// ContentBlockName is the name of the block in the layouts the pages are defined as, like "body", "main", or
+	// "yield", "content" when empty.
+	ContentBlockName string
+}
+
+// ContentBlock returns the block of the layouts the pages are defined as, ContentBlockName or "content" when it's empty.
+func (t *TemplateByNameLoader) ContentBlock() string {
+	if t.ContentBlockName == "" {
+		return defaultContentBlock
+	}
+
+	return t.ContentBlockName
 }
 
 func (t *TemplateByNameLoader) Standalone(name string) ([]FileWithContent, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
 	content, err := t.FS.ReadFile(name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read template: %w", err)
@@ -122,8 +498,8 @@ func (t *TemplateByNameLoader) InLayout(name, layout string) ([]FileWithContent,
 		return nil, err
 	}
 
-	for i := 0; i < len(pages); i++ {
-		pages[i].Content = `{{ define "content" }}` + pages[i].Content + `{{ end }}`
+	if err := ValidateName(layout); err != nil {
+		return nil, fmt.Errorf("failed to read layout template: %w", err)
 	}
 
 	layoutContent, err := t.FS.ReadFile(layout)
@@ -131,13 +507,62 @@ func (t *TemplateByNameLoader) InLayout(name, layout string) ([]FileWithContent,
 		return nil, fmt.Errorf("failed to read layout template: %w", err)
 	}
 
+	return wrapInLayout(pages, FileWithContent{Name: layout, Content: string(layoutContent)}, t.ContentBlock()), nil
+}
+
+const (
+	defaultContentBlock = "content"
+	contentEnd          = `{{ end }}`
+)
+
+// wrapInLayout defines the pages as the block of the layout, "content" when block is empty.
+func wrapInLayout(pages []FileWithContent, layout FileWithContent, block string) []FileWithContent {
+	if block == "" {
+		block = defaultContentBlock
+	}
+
 	// Intentionally prepend the layout so any declared definitions from it will be overridden by other templates,
 	// for example `{{ define "HEADER" }}` or similar blocks. If not, the default provided by the template will be the
 	// last one defined, and therefore used.
-	pages = append([]FileWithContent{{Name: layout, Content: string(layoutContent)}}, pages...)
-	return pages, nil
+	files := make([]FileWithContent, 0, len(pages)+1)
+	files = append(files, layout)
+	for _, page := range pages {
+		if !onlyDefines(page, block) {
+			page.Content = blockDefine(block) + page.Content + contentEnd
+			page.Block = block
+		}
+		files = append(files, page)
+	}
+
+	return files
+}
+
+// onlyDefines reports whether page is made of defines for the layout to use, either because it already defines block
+// itself, like pages written for another loader, or because it has no content outside its defines. Wrapping such
+// a page would nest the defines. Pages that fail to parse are wrapped, so the error is returned when they're created.
+func onlyDefines(page FileWithContent, block string) bool {
+	if !strings.Contains(page.Content, "define") {
+		return false
+	}
+	file, err := ppparse.Parse(page.Name, page.Content)
+	if err != nil || len(file.Defines()) == 0 {
+		return false
+	}
+	if _, ok := file.Trees[block]; ok {
+		// A block is also a define, but it's where the page renders the template, not its own content.
+		return !slices.Contains(file.References(), block)
+	}
+
+	return parse.IsEmptyTree(file.Trees[page.Name].Root)
+}
+
+// blockDefine is the start of the define wrapping a page as block.
+func blockDefine(block string) string {
+	return fmt.Sprintf("{{ define %q }}", block)
 }
 
+// CreateTemplate parses files into a copy of base, or a new template when base is nil.
+// A file that fails to parse is returned as a [*ParseError].
 func CreateTemplate(base *template.Template, files []FileWithContent) (*template.Template, error) {
 	var tmplt *template.Template
 	var err error
@@ -152,7 +577,7 @@ func CreateTemplate(base *template.Template, files []FileWithContent) (*template
 
 	for _, file := range files {
 		if _, err := tmplt.New(file.Name).Parse(file.Content); err != nil {
-			return nil, fmt.Errorf("failed to parse template: %w", err)
+			return nil, newParseError(file, err)
 		}
 	}
 
@@ -4,7 +4,12 @@
 
 package ppdefaults
 
-import "html/template"
+import (
+	"html/template"
+	"log/slog"
+
+	"github.com/gaqzi/passepartout/ppmetrics"
+)
 
 //go:generate go run github.com/kilianpaquier/go-builder-generator/cmd/go-builder-generator@latest generate -d . -f loader.go -s Loader
 
@@ -35,12 +40,42 @@ func (b *LoaderBuilder) CreateTemplate(createTemplate Templater) *LoaderBuilder
 	return b
 }
 
+// LayoutPartials sets Loader's LayoutPartials.
+func (b *LoaderBuilder) LayoutPartials(layoutPartials Partials) *LoaderBuilder {
+	b.build.LayoutPartials = layoutPartials
+	return b
+}
+
+// Logger sets Loader's Logger.
+func (b *LoaderBuilder) Logger(logger *slog.Logger) *LoaderBuilder {
+	b.build.Logger = logger
+	return b
+}
+
+// Metrics sets Loader's Metrics.
+func (b *LoaderBuilder) Metrics(metrics ppmetrics.Recorder) *LoaderBuilder {
+	b.build.Metrics = metrics
+	return b
+}
+
+// PartialDirs sets Loader's PartialDirs.
+func (b *LoaderBuilder) PartialDirs(partialDirs func(name string) []string) *LoaderBuilder {
+	b.build.PartialDirs = partialDirs
+	return b
+}
+
 // PartialsFor sets Loader's PartialsFor.
 func (b *LoaderBuilder) PartialsFor(partialsFor PartialLoader) *LoaderBuilder {
 	b.build.PartialsFor = partialsFor
 	return b
 }
 
+// PartialsForContext sets Loader's PartialsForContext.
+func (b *LoaderBuilder) PartialsForContext(partialsForContext PartialLoaderContext) *LoaderBuilder {
+	b.build.PartialsForContext = partialsForContext
+	return b
+}
+
 // TemplateConfig sets Loader's TemplateConfig.
 func (b *LoaderBuilder) TemplateConfig(templateConfig *template.Template) *LoaderBuilder {
 	b.build.TemplateConfig = templateConfig
@@ -52,3 +87,9 @@ func (b *LoaderBuilder) TemplateLoader(templateLoader TemplateLoader) *LoaderBui
 	b.build.TemplateLoader = templateLoader
 	return b
 }
+
+// Transformers sets Loader's Transformers.
+func (b *LoaderBuilder) Transformers(transformers map[string][]Transformer) *LoaderBuilder {
+	b.build.Transformers = transformers
+	return b
+}
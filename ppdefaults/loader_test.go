@@ -2,11 +2,15 @@ package ppdefaults_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"html/template"
+	"io/fs"
+	"log/slog"
 	"strings"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -51,6 +55,17 @@ func partialsFor(t *testing.T, name string, files ...ppdefaults.FileWithContent)
 	}
 }
 
+func partialsByName(t *testing.T, partials map[string][]ppdefaults.FileWithContent) func(string) ([]ppdefaults.FileWithContent, error) {
+	t.Helper()
+	return func(name string) ([]ppdefaults.FileWithContent, error) {
+		t.Helper()
+		files, ok := partials[name]
+		require.True(t, ok, "expected to not have called PartialsFor with %q", name)
+
+		return files, nil
+	}
+}
+
 func createTemplate(t *testing.T, base *template.Template, files []ppdefaults.FileWithContent, tmpl *template.Template) func(base *template.Template, files []ppdefaults.FileWithContent) (*template.Template, error) {
 	t.Helper()
 	return func(inBase *template.Template, inFiles []ppdefaults.FileWithContent) (*template.Template, error) {
@@ -162,10 +177,13 @@ func TestLoader_InLayout(t *testing.T) {
 		expect         func(t *testing.T, actual *template.Template, err error)
 	}{
 		{
-			name:        "with no errors and referencing a partial a useful template is returned",
-			pageName:    "test.tmpl",
-			layoutName:  "layouts/default.tmpl",
-			partialsFor: partialsFor(t, "test.tmpl", ppdefaults.FileWithContent{Name: "_example.tmpl", Content: "- an example partial!"}),
+			name:       "with no errors and referencing a partial a useful template is returned",
+			pageName:   "test.tmpl",
+			layoutName: "layouts/default.tmpl",
+			partialsFor: partialsByName(t, map[string][]ppdefaults.FileWithContent{
+				"layouts/default.tmpl": {{Name: "layouts/default/_nav.tmpl", Content: "- a layout partial!"}},
+				"test.tmpl":            {{Name: "_example.tmpl", Content: "- an example partial!"}},
+			}),
 			loadPage: func(tmplMock *templateLoaderMock) {
 				inLayout(
 					"test.tmpl",
@@ -179,6 +197,7 @@ func TestLoader_InLayout(t *testing.T) {
 				t,
 				nil,
 				[]ppdefaults.FileWithContent{
+					{Name: "layouts/default/_nav.tmpl", Content: "- a layout partial!"},
 					{Name: "_example.tmpl", Content: "- an example partial!"},
 					{Name: "layouts/default.tmpl", Content: `HEADER {% define "content" %}CONTENT{% end %} FOOTER`},
 					{Name: "test.tmpl", Content: `Hello, world!`},
@@ -193,7 +212,32 @@ func TestLoader_InLayout(t *testing.T) {
 			},
 		},
 		{
-			name:       "when loading partials fails, the error is returned",
+			name:       "partials loaded for both the layout and the page are only included once",
+			pageName:   "test.tmpl",
+			layoutName: "layouts/default.tmpl",
+			partialsFor: partialsByName(t, map[string][]ppdefaults.FileWithContent{
+				"layouts/default.tmpl": {{Name: "partials/_common.tmpl", Content: "common"}},
+				"test.tmpl":            {{Name: "partials/_common.tmpl", Content: "common"}, {Name: "test/_item.tmpl", Content: "item"}},
+			}),
+			loadPage: func(tmplMock *templateLoaderMock) {
+				inLayout("test.tmpl", "layouts/default.tmpl", tmplMock, ppdefaults.FileWithContent{Name: "test.tmpl", Content: "Hello, world!"})
+			},
+			createTemplate: createTemplate(
+				t,
+				nil,
+				[]ppdefaults.FileWithContent{
+					{Name: "partials/_common.tmpl", Content: "common"},
+					{Name: "test/_item.tmpl", Content: "item"},
+					{Name: "test.tmpl", Content: "Hello, world!"},
+				},
+				template.Must(template.New("test.tmpl").Parse("")),
+			),
+			expect: func(t *testing.T, actual *template.Template, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:       "when loading partials for the layout fails, the error is returned",
 			pageName:   "test.tmpl",
 			layoutName: "layouts/default.tmpl",
 			partialsFor: func(page string) ([]ppdefaults.FileWithContent, error) {
@@ -201,13 +245,27 @@ func TestLoader_InLayout(t *testing.T) {
 			},
 			loadPage:       func(tmplMock *templateLoaderMock) {},
 			createTemplate: noTemplate,
+			expect:         errContains(`failed to collect partials for layout "layouts/default.tmpl": uh-oh partial error`),
+		},
+		{
+			name:       "when loading partials for the page fails, the error is returned",
+			pageName:   "test.tmpl",
+			layoutName: "layouts/default.tmpl",
+			partialsFor: func(page string) ([]ppdefaults.FileWithContent, error) {
+				if page == "layouts/default.tmpl" {
+					return nil, nil
+				}
+				return nil, errors.New("uh-oh partial error")
+			},
+			loadPage:       func(tmplMock *templateLoaderMock) {},
+			createTemplate: noTemplate,
 			expect:         errContains(`failed to collect partials for "test.tmpl": uh-oh partial error`),
 		},
 		{
 			name:        "when loading the template fails, the error is returned",
 			pageName:    "test.tmpl",
 			layoutName:  "layouts/default.tmpl",
-			partialsFor: partialsFor(t, "test.tmpl"),
+			partialsFor: partialsByName(t, map[string][]ppdefaults.FileWithContent{"layouts/default.tmpl": nil, "test.tmpl": nil}),
 			loadPage: func(tmplMock *templateLoaderMock) {
 				tmplMock.On("InLayout", "test.tmpl", "layouts/default.tmpl").
 					Return([]ppdefaults.FileWithContent(nil), errors.New("uh-oh template error"))
@@ -219,7 +277,7 @@ func TestLoader_InLayout(t *testing.T) {
 			name:        "when creating the template fails, the error is returned",
 			pageName:    "test.tmpl",
 			layoutName:  "layouts/default.tmpl",
-			partialsFor: partialsFor(t, "test.tmpl"),
+			partialsFor: partialsByName(t, map[string][]ppdefaults.FileWithContent{"layouts/default.tmpl": nil, "test.tmpl": nil}),
 			loadPage: func(tmplMock *templateLoaderMock) {
 				inLayout(
 					"test.tmpl",
@@ -286,7 +344,7 @@ func TestLoader_TemplateConfig(t *testing.T) {
 		expectedTemplate := template.Must(template.New("template config").Parse("yohooo~!"))
 		loader := ppdefaults.Loader{
 			TemplateConfig: expectedTemplate,
-			PartialsFor:    partialsFor(t, "test.tmpl"),
+			PartialsFor:    partialsByName(t, map[string][]ppdefaults.FileWithContent{"layouts/default.tmpl": nil, "test.tmpl": nil}),
 			TemplateLoader: mockTmplt,
 			CreateTemplate: func(base *template.Template, files []ppdefaults.FileWithContent) (*template.Template, error) {
 				require.Equal(t, expectedTemplate, base, "expected to have received the configured expected template when creating templates")
@@ -300,6 +358,169 @@ func TestLoader_TemplateConfig(t *testing.T) {
 	})
 }
 
+// metricsRecorder records the calls to a [ppmetrics.Recorder] without their durations.
+type metricsRecorder struct {
+	calls []string
+}
+
+func (m *metricsRecorder) CacheHit(template string)  { m.calls = append(m.calls, "hit "+template) }
+func (m *metricsRecorder) CacheMiss(template string) { m.calls = append(m.calls, "miss "+template) }
+func (m *metricsRecorder) Load(template string, _ time.Duration) {
+	m.calls = append(m.calls, "load "+template)
+}
+func (m *metricsRecorder) Parse(template string, _ time.Duration) {
+	m.calls = append(m.calls, "parse "+template)
+}
+func (m *metricsRecorder) Render(template string, _ time.Duration, _ error) {
+	m.calls = append(m.calls, "render "+template)
+}
+
+func TestLoader_Metrics(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`{{ block "content" . }}{{ end }}`)},
+		"index.tmpl":           {Data: []byte(`Hello`)},
+		"broken.tmpl":          {Data: []byte(`{{ if }}`)},
+	}
+
+	t.Run("records loading and parsing for Standalone and InLayout", func(t *testing.T) {
+		metrics := new(metricsRecorder)
+		loader := ppdefaults.NewLoaderBuilder().WithDefaults(fsys).Metrics(metrics).Build()
+
+		_, err := loader.Standalone("index.tmpl")
+		require.NoError(t, err)
+		_, err = loader.InLayout("index.tmpl", "layouts/default.tmpl")
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"load index.tmpl", "parse index.tmpl", "load index.tmpl", "parse index.tmpl"}, metrics.calls)
+	})
+
+	t.Run("records parsing that fails", func(t *testing.T) {
+		metrics := new(metricsRecorder)
+		loader := ppdefaults.NewLoaderBuilder().WithDefaults(fsys).Metrics(metrics).Build()
+
+		_, err := loader.Standalone("broken.tmpl")
+
+		require.Error(t, err)
+		require.Equal(t, []string{"load broken.tmpl", "parse broken.tmpl"}, metrics.calls)
+	})
+}
+
+func TestLoader_Logger(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/default.tmpl":      {Data: []byte(`{{ block "content" . }}{{ end }}`)},
+		"index.tmpl":                {Data: []byte(`{{ template "index/_item.tmpl" }}`)},
+		"index/_item.tmpl":          {Data: []byte(`item`)},
+		"layouts/default/_nav.tmpl": {Data: []byte(`nav`)},
+	}
+	newLogger := func(level slog.Level) (*slog.Logger, *bytes.Buffer) {
+		buf := new(bytes.Buffer)
+		return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+			Level: level,
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		})), buf
+	}
+
+	t.Run("logs the resolved files in the order they're parsed", func(t *testing.T) {
+		logger, buf := newLogger(slog.LevelDebug)
+		loader := ppdefaults.NewLoaderBuilder().WithDefaults(fsys).Logger(logger).Build()
+
+		_, err := loader.InLayout("index.tmpl", "layouts/default.tmpl")
+
+		require.NoError(t, err)
+		require.Equal(t, strings.Join([]string{
+			`level=DEBUG msg="resolved template file" template=index.tmpl layout=layouts/default.tmpl file=layouts/default/_nav.tmpl kind=partial order=0`,
+			`level=DEBUG msg="resolved template file" template=index.tmpl layout=layouts/default.tmpl file=index/_item.tmpl kind=partial order=1`,
+			`level=DEBUG msg="resolved template file" template=index.tmpl layout=layouts/default.tmpl file=layouts/default.tmpl kind=layout order=2`,
+			`level=DEBUG msg="resolved template file" template=index.tmpl layout=layouts/default.tmpl file=index.tmpl kind=page order=3`,
+			``,
+		}, "\n"), buf.String())
+	})
+
+	t.Run("is silent above debug level", func(t *testing.T) {
+		logger, buf := newLogger(slog.LevelInfo)
+		loader := ppdefaults.NewLoaderBuilder().WithDefaults(fsys).Logger(logger).Build()
+
+		_, err := loader.Standalone("index.tmpl")
+
+		require.NoError(t, err)
+		require.Empty(t, buf.String())
+	})
+}
+
+func TestLoaderBuilder_WithSharedLayoutPartials(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/default.tmpl":                {Data: []byte(`{{ template "layouts/shared/_nav.tmpl" }}|{{ template "footer" }}|{{ block "content" . }}{{ end }}`)},
+		"layouts/default/_footer.tmpl":        {Data: []byte(`{{ define "footer" }}default footer{{ end }}`)},
+		"layouts/shared/_nav.tmpl":            {Data: []byte(`nav {{ template "layouts/shared/flash/_messages.tmpl" }}`)},
+		"layouts/shared/_footer.tmpl":         {Data: []byte(`{{ define "footer" }}shared footer{{ end }}`)},
+		"layouts/shared/flash/_messages.tmpl": {Data: []byte(`messages`)},
+		"index.tmpl":                          {Data: []byte(`index`)},
+	}
+	loader := ppdefaults.NewLoaderBuilder().WithDefaults(fsys).WithSharedLayoutPartials(fsys, "layouts/shared").Build()
+
+	t.Run("adds the shared partials and their subfolders to every InLayout before the layout's own", func(t *testing.T) {
+		tmpl, err := loader.InLayout("index.tmpl", "layouts/default.tmpl")
+		require.NoError(t, err)
+		buf := new(bytes.Buffer)
+		require.NoError(t, tmpl.ExecuteTemplate(buf, "layouts/default.tmpl", nil))
+
+		require.Equal(t, "nav messages|default footer|index", buf.String(), "expected the layout's own partials to override the shared ones")
+	})
+
+	t.Run("doesn't add them to Standalone", func(t *testing.T) {
+		files, err := loader.StandaloneFiles("index.tmpl")
+
+		require.NoError(t, err)
+		require.Equal(t, []ppdefaults.FileWithContent{{Name: "index.tmpl", Content: "index"}}, files)
+	})
+
+	t.Run("searches the shared folder for the layout", func(t *testing.T) {
+		require.Equal(t, []string{"layouts/shared", "layouts/default", "index"}, loader.SearchedDirs("index.tmpl", "layouts/default.tmpl"))
+	})
+}
+
+func TestLoader_SearchedDirs(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for _, tc := range []struct {
+		name     string
+		loader   *ppdefaults.Loader
+		layout   string
+		expected []string
+	}{
+		{
+			name:     "the page's folder with the defaults",
+			loader:   ppdefaults.NewLoaderBuilder().WithDefaults(fsys).Build(),
+			expected: []string{"reviews/show"},
+		},
+		{
+			name:     "the layout's folder before the page's",
+			loader:   ppdefaults.NewLoaderBuilder().WithDefaults(fsys).Build(),
+			layout:   "layouts/default.tmpl",
+			expected: []string{"layouts/default", "reviews/show"},
+		},
+		{
+			name:     "the common folder once when set with WithPartials",
+			loader:   ppdefaults.NewLoaderBuilder().WithDefaults(fsys).WithPartials(&ppdefaults.PartialsWithCommon{FS: fsys, CommonDir: "partials"}).Build(),
+			layout:   "layouts/default.tmpl",
+			expected: []string{"partials", "layouts/default", "reviews/show"},
+		},
+		{
+			name:     "nothing when PartialDirs isn't set",
+			loader:   &ppdefaults.Loader{},
+			expected: nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.loader.SearchedDirs("reviews/show.tmpl", tc.layout))
+		})
+	}
+}
+
 func TestTemplateByNameLoader_Standalone(t *testing.T) {
 	t.Run("when the file doesn't exist it returns an error", func(t *testing.T) {
 		l := ppdefaults.TemplateByNameLoader{FS: fstest.MapFS{}}
@@ -331,8 +552,29 @@ func TestTemplateByNameLoader_Standalone(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, []ppdefaults.FileWithContent{{Name: "test.tmpl", Content: "Hello"}}, actual)
 	})
+
+	t.Run("rejects names that could reach outside of the templates without reading them", func(t *testing.T) {
+		l := ppdefaults.TemplateByNameLoader{FS: anyFileFS{}}
+
+		for _, name := range []string{"../secrets", "/etc/passwd", ""} {
+			actual, err := l.Standalone(name)
+
+			require.ErrorIs(t, err, ppdefaults.ErrInvalidName, name)
+			require.Nil(t, actual)
+		}
+
+		_, err := l.InLayout("test.tmpl", "layouts/../../secrets")
+		require.EqualError(t, err, `failed to read layout template: invalid template name "layouts/../../secrets": it contains ".."`)
+	})
 }
 
+// anyFileFS has every file, whatever its name.
+type anyFileFS struct{}
+
+func (anyFileFS) Open(name string) (fs.File, error) { return nil, fs.ErrInvalid }
+
+func (anyFileFS) ReadFile(name string) ([]byte, error) { return []byte(name), nil }
+
 func TestTemplateByNameLoader_InLayout(t *testing.T) {
 	t.Run("when the file doesn't exist it returns an error", func(t *testing.T) {
 		l := ppdefaults.TemplateByNameLoader{FS: fstest.MapFS{}}
@@ -366,9 +608,63 @@ func TestTemplateByNameLoader_InLayout(t *testing.T) {
 		require.Equal(t, []ppdefaults.FileWithContent{
 			// IMPORTANT: the layout is first so any "define"s made in the layout doesn't override ones made in subsequent templates.
 			{Name: "layout.tmpl", Content: "Layout content"},
-			{Name: "test.tmpl", Content: `{{ define "content" }}Hello{{ end }}`},
+			{Name: "test.tmpl", Content: `{{ define "content" }}Hello{{ end }}`, Block: "content"},
 		}, actual)
 	})
+
+	t.Run("a template that already defines the content block isn't wrapped again", func(t *testing.T) {
+		l := ppdefaults.TemplateByNameLoader{FS: fstest.MapFS{
+			"test.tmpl":   {Data: []byte(`{{ define "title" }}Hi{{ end }}{{ define "content" }}Hello{{ end }}`)},
+			"layout.tmpl": {Data: []byte(`{{ block "title" . }}{{ end }}: {{ block "content" . }}{{ end }}`)},
+		}}
+
+		actual, err := l.InLayout("test.tmpl", "layout.tmpl")
+		require.NoError(t, err)
+		require.Equal(t, `{{ define "title" }}Hi{{ end }}{{ define "content" }}Hello{{ end }}`, actual[1].Content)
+
+		tmpl, err := ppdefaults.CreateTemplate(nil, actual)
+		require.NoError(t, err)
+		out := new(bytes.Buffer)
+		require.NoError(t, tmpl.ExecuteTemplate(out, "layout.tmpl", nil))
+		require.Equal(t, "Hi: Hello", out.String())
+	})
+
+	t.Run("a template with only defines isn't wrapped so the layout uses the blocks directly", func(t *testing.T) {
+		page := "{{/* the blocks of the layout */}}\n{{ define \"title\" }}About{{ end }}\n{{ define \"main\" }}Us{{ end }}\n"
+		l := ppdefaults.TemplateByNameLoader{FS: fstest.MapFS{
+			"test.tmpl":   {Data: []byte(page)},
+			"layout.tmpl": {Data: []byte(`{{ block "title" . }}{{ end }}|{{ block "main" . }}{{ end }}`)},
+		}}
+
+		actual, err := l.InLayout("test.tmpl", "layout.tmpl")
+		require.NoError(t, err)
+		require.Equal(t, page, actual[1].Content)
+
+		tmpl, err := ppdefaults.CreateTemplate(nil, actual)
+		require.NoError(t, err)
+		out := new(bytes.Buffer)
+		require.NoError(t, tmpl.ExecuteTemplate(out, "layout.tmpl", nil))
+		require.Equal(t, "About|Us", out.String())
+	})
+
+	t.Run("with a ContentBlockName the template is defined as that block of the layout", func(t *testing.T) {
+		l := ppdefaults.TemplateByNameLoader{ContentBlockName: "main", FS: fstest.MapFS{
+			"test.tmpl":   {Data: []byte("Hello")},
+			"layout.tmpl": {Data: []byte(`<main>{{ block "main" . }}{{ end }}</main>`)},
+		}}
+
+		actual, err := l.InLayout("test.tmpl", "layout.tmpl")
+
+		require.NoError(t, err)
+		require.Equal(t, `{{ define "main" }}Hello{{ end }}`, actual[1].Content)
+		require.Equal(t, "Hello", actual[1].Unwrapped(), "expected the custom block to be unwrapped")
+
+		tmpl, err := ppdefaults.CreateTemplate(nil, actual)
+		require.NoError(t, err)
+		out := new(bytes.Buffer)
+		require.NoError(t, tmpl.ExecuteTemplate(out, "layout.tmpl", nil))
+		require.Equal(t, "<main>Hello</main>", out.String())
+	})
 }
 
 func TestCreateTemplate(t *testing.T) {
@@ -382,6 +678,34 @@ func TestCreateTemplate(t *testing.T) {
 		require.Nil(t, actual, "expected no results when an error is returned")
 	})
 
+	t.Run("the parse error has the path, line, and an excerpt of the file", func(t *testing.T) {
+		_, err := ppdefaults.CreateTemplate(nil, []ppdefaults.FileWithContent{
+			{Name: "ok.tmpl", Content: "fine"},
+			{Name: "reviews/show.tmpl", Content: "<h1>\n{{ if }}\n</h1>"},
+		})
+
+		var parseErr *ppdefaults.ParseError
+		require.ErrorAs(t, err, &parseErr)
+		require.Equal(t, "reviews/show.tmpl", parseErr.Path)
+		require.Equal(t, 2, parseErr.Line)
+		require.Equal(t, "  1 | <h1>\n> 2 | {{ if }}\n  3 | </h1>", parseErr.Excerpt)
+		require.Equal(t, "failed to parse template reviews/show.tmpl:2: missing value for if", err.Error())
+	})
+
+	t.Run("the excerpt of a page wrapped for a layout is the page as written", func(t *testing.T) {
+		files, err := (&ppdefaults.TemplateByNameLoader{FS: fstest.MapFS{
+			"layout.tmpl": {Data: []byte(`{{ block "content" . }}{{ end }}`)},
+			"page.tmpl":   {Data: []byte(`{{ if }}`)},
+		}}).InLayout("page.tmpl", "layout.tmpl")
+		require.NoError(t, err)
+
+		_, err = ppdefaults.CreateTemplate(nil, files)
+
+		var parseErr *ppdefaults.ParseError
+		require.ErrorAs(t, err, &parseErr)
+		require.Equal(t, "> 1 | {{ if }}", parseErr.Excerpt)
+	})
+
 	t.Run("it has all the passed in files as templates", func(t *testing.T) {
 		baseTemplate := template.New("base")
 		files := []ppdefaults.FileWithContent{
@@ -415,3 +739,91 @@ func TestCreateTemplate(t *testing.T) {
 		require.Equal(t, "custom", buf.String(), "expected the base template's custom function to be available")
 	})
 }
+
+func TestLoaderBuilder_WithTemplateOption(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		builder *ppdefaults.LoaderBuilder
+	}{
+		{name: "without a TemplateConfig", builder: ppdefaults.NewLoaderBuilder()},
+		{name: "with a TemplateConfig", builder: ppdefaults.NewLoaderBuilder().TemplateConfig(template.New("config"))},
+	} {
+		t.Run(tc.name+" makes missing keys an error after the template is cloned", func(t *testing.T) {
+			loader := tc.builder.
+				WithDefaults(fstest.MapFS{"index.tmpl": {Data: []byte(`{{ .missing }}`)}}).
+				WithTemplateOption("missingkey=error").
+				Build()
+			tmpl, err := loader.Standalone("index.tmpl")
+			require.NoError(t, err)
+
+			err = tmpl.ExecuteTemplate(new(bytes.Buffer), "index.tmpl", map[string]any{})
+
+			require.ErrorContains(t, err, `map has no entry for key "missing"`)
+		})
+	}
+}
+
+func TestLoaderBuilder_WithFuncs(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		builder *ppdefaults.LoaderBuilder
+	}{
+		{name: "without a TemplateConfig", builder: ppdefaults.NewLoaderBuilder()},
+		{name: "with a TemplateConfig", builder: ppdefaults.NewLoaderBuilder().TemplateConfig(template.New("config").Option("missingkey=error"))},
+	} {
+		t.Run(tc.name+" the funcs can be used by the templates", func(t *testing.T) {
+			loader := tc.builder.
+				WithDefaults(fstest.MapFS{"index.tmpl": {Data: []byte(`{{ greet "World" }}`)}}).
+				WithFuncs(template.FuncMap{"greet": func(name string) string { return "Hello, " + name }}).
+				Build()
+			tmpl, err := loader.Standalone("index.tmpl")
+			require.NoError(t, err)
+			out := new(bytes.Buffer)
+
+			require.NoError(t, tmpl.ExecuteTemplate(out, "index.tmpl", nil))
+			require.Equal(t, "Hello, World", out.String())
+		})
+	}
+}
+
+func TestLoaderBuilder_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		builder       *ppdefaults.LoaderBuilder
+		expectedError string
+	}{
+		{
+			name:    "passes with the defaults",
+			builder: ppdefaults.NewLoaderBuilder().WithDefaults(fstest.MapFS{}),
+		},
+		{
+			name: "passes with PartialsForContext instead of PartialsFor",
+			builder: ppdefaults.NewLoaderBuilder().
+				TemplateLoader(&ppdefaults.TemplateByNameLoader{FS: fstest.MapFS{}}).
+				CreateTemplate(ppdefaults.CreateTemplate).
+				PartialsForContext(func(context.Context, string) ([]ppdefaults.FileWithContent, error) { return nil, nil }),
+		},
+		{
+			name:          "names the missing fields and suggests the defaults",
+			builder:       ppdefaults.NewLoaderBuilder(),
+			expectedError: "failed to validate loader: TemplateLoader, PartialsFor, CreateTemplate not set, use LoaderBuilder.WithDefaults to set the defaults",
+		},
+		{
+			name:          "names only the fields missing",
+			builder:       ppdefaults.NewLoaderBuilder().WithDefaults(fstest.MapFS{}).CreateTemplate(nil),
+			expectedError: "failed to validate loader: CreateTemplate not set, use LoaderBuilder.WithDefaults to set the defaults",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.builder.Validate()
+
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				require.PanicsWithError(t, tc.expectedError, func() { tc.builder.MustBuild() })
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, tc.builder.MustBuild())
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package ppdefaults
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+// Manifest maps the name of every file in a template filesystem to a hash of its content.
+// Create one at build time and compare it with the manifest of the previous deploy,
+// see [CachedLoader.PurgeChanged], to know what changed.
+type Manifest map[string]string
+
+// NewManifest hashes every file in fsys.
+func NewManifest(fsys fs.FS) (Manifest, error) {
+	m := make(Manifest)
+	err := fs.WalkDir(fsys, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		content, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(content)
+		m[filePath] = hex.EncodeToString(sum[:])
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create manifest: %w", err)
+	}
+
+	return m, nil
+}
+
+// Changes are the differences between two manifests.
+type Changes struct {
+	Added    []string
+	Removed  []string
+	Modified []string
+}
+
+// Empty reports whether nothing changed.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Modified) == 0
+}
+
+// Changes compares m with a newer manifest, all names are sorted.
+func (m Manifest) Changes(newer Manifest) Changes {
+	var c Changes
+	for name, hash := range newer {
+		old, ok := m[name]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, name)
+		case old != hash:
+			c.Modified = append(c.Modified, name)
+		}
+	}
+
+	for name := range m {
+		if _, ok := newer[name]; !ok {
+			c.Removed = append(c.Removed, name)
+		}
+	}
+
+	sort.Strings(c.Added)
+	sort.Strings(c.Removed)
+	sort.Strings(c.Modified)
+
+	return c
+}
@@ -0,0 +1,45 @@
+package ppdefaults_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+func TestNewManifest(t *testing.T) {
+	t.Run("hashes every file so only changed content gets a new hash", func(t *testing.T) {
+		old, err := ppdefaults.NewManifest(fstest.MapFS{
+			"index.tmpl":       {Data: []byte("index")},
+			"index/_item.tmpl": {Data: []byte("item")},
+		})
+		require.NoError(t, err)
+
+		newer, err := ppdefaults.NewManifest(fstest.MapFS{
+			"index.tmpl":       {Data: []byte("index")},
+			"index/_item.tmpl": {Data: []byte("changed item")},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, old, 2)
+		require.Equal(t, old["index.tmpl"], newer["index.tmpl"])
+		require.NotEqual(t, old["index/_item.tmpl"], newer["index/_item.tmpl"])
+	})
+}
+
+func TestManifest_Changes(t *testing.T) {
+	old := ppdefaults.Manifest{"same.tmpl": "a", "modified.tmpl": "b", "removed.tmpl": "c"}
+	newer := ppdefaults.Manifest{"same.tmpl": "a", "modified.tmpl": "B", "added.tmpl": "d"}
+
+	changes := old.Changes(newer)
+
+	require.Equal(t, ppdefaults.Changes{
+		Added:    []string{"added.tmpl"},
+		Removed:  []string{"removed.tmpl"},
+		Modified: []string{"modified.tmpl"},
+	}, changes)
+	require.False(t, changes.Empty())
+	require.True(t, old.Changes(old).Empty())
+}
@@ -0,0 +1,67 @@
+package ppdefaults
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+// MapLoader implements [TemplateLoader], and through [MapLoader.Load] a [PartialLoader] like [PartialsInFolderOnly],
+// with templates kept in memory by name, for tests and programmatically generated templates.
+type MapLoader map[string]string
+
+// WithMap sets the template and partial loaders to load from templates, and [CreateTemplate] to create them.
+func (b *LoaderBuilder) WithMap(templates map[string]string) *LoaderBuilder {
+	m := MapLoader(templates)
+	b.build.PartialsFor = m.Load
+	b.build.PartialDirs = m.Dirs
+	b.build.TemplateLoader = m
+	b.build.CreateTemplate = CreateTemplate
+
+	return b
+}
+
+func (m MapLoader) Standalone(name string) ([]FileWithContent, error) {
+	content, ok := m[name]
+	if !ok {
+		return nil, fmt.Errorf("failed to read template: %w", &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist})
+	}
+
+	return []FileWithContent{{Name: name, Content: content}}, nil
+}
+
+func (m MapLoader) InLayout(name, layout string) ([]FileWithContent, error) {
+	pages, err := m.Standalone(name)
+	if err != nil {
+		return nil, err
+	}
+
+	layoutContent, ok := m[layout]
+	if !ok {
+		return nil, fmt.Errorf("failed to read layout template: %w", &fs.PathError{Op: "open", Path: layout, Err: fs.ErrNotExist})
+	}
+
+	return wrapInLayout(pages, FileWithContent{Name: layout, Content: layoutContent}, ""), nil
+}
+
+// Load returns the templates in the folder named after name without its extension, sorted by name,
+// the same as [PartialsInFolderOnly.Load].
+// Dirs returns the folder [MapLoader.Load] loads the partials for name from.
+func (m MapLoader) Dirs(name string) []string {
+	return []string{partialDir(name)}
+}
+
+func (m MapLoader) Load(name string) ([]FileWithContent, error) {
+	dir := partialDir(name) + "/"
+
+	var files []FileWithContent
+	for templateName, content := range m {
+		if strings.HasPrefix(templateName, dir) {
+			files = append(files, FileWithContent{Name: templateName, Content: content})
+		}
+	}
+	slices.SortFunc(files, func(a, b FileWithContent) int { return strings.Compare(a.Name, b.Name) })
+
+	return files, nil
+}
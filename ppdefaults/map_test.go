@@ -0,0 +1,70 @@
+package ppdefaults_test
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+func TestMapLoader(t *testing.T) {
+	loader := ppdefaults.MapLoader{
+		"layouts/default.tmpl":    `<main>{{ block "content" . }}{{ end }}</main>`,
+		"index.tmpl":              `{{ template "index/_item.tmpl" . }}`,
+		"index/_item.tmpl":        `item {{ . }}`,
+		"index/nested/_deep.tmpl": `deep`,
+		"index2/_not.tmpl":        `not a partial of index`,
+	}
+
+	t.Run("Standalone returns the template", func(t *testing.T) {
+		actual, err := loader.Standalone("index.tmpl")
+
+		require.NoError(t, err)
+		require.Equal(t, []ppdefaults.FileWithContent{{Name: "index.tmpl", Content: `{{ template "index/_item.tmpl" . }}`}}, actual)
+	})
+
+	t.Run("Standalone returns a not exist error for a missing template", func(t *testing.T) {
+		_, err := loader.Standalone("missing.tmpl")
+
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("InLayout wraps the page for the layout", func(t *testing.T) {
+		actual, err := loader.InLayout("index/_item.tmpl", "layouts/default.tmpl")
+
+		require.NoError(t, err)
+		require.Equal(t, []ppdefaults.FileWithContent{
+			{Name: "layouts/default.tmpl", Content: `<main>{{ block "content" . }}{{ end }}</main>`},
+			{Name: "index/_item.tmpl", Content: `{{ define "content" }}item {{ . }}{{ end }}`, Block: "content"},
+		}, actual)
+	})
+
+	t.Run("InLayout returns a not exist error for a missing layout", func(t *testing.T) {
+		_, err := loader.InLayout("index.tmpl", "layouts/missing.tmpl")
+
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("Load returns the partials in the folder named after the template", func(t *testing.T) {
+		actual, err := loader.Load("index.tmpl")
+
+		require.NoError(t, err)
+		require.Equal(t, []ppdefaults.FileWithContent{
+			{Name: "index/_item.tmpl", Content: `item {{ . }}`},
+			{Name: "index/nested/_deep.tmpl", Content: `deep`},
+		}, actual)
+	})
+
+	t.Run("WithMap renders templates from the map", func(t *testing.T) {
+		built := ppdefaults.NewLoaderBuilder().WithMap(loader).Build()
+		tmpl, err := built.InLayout("index.tmpl", "layouts/default.tmpl")
+		require.NoError(t, err)
+		buf := new(bytes.Buffer)
+
+		require.NoError(t, tmpl.ExecuteTemplate(buf, "layouts/default.tmpl", "one"))
+		require.Equal(t, "<main>item one</main>", buf.String())
+	})
+}
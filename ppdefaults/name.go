@@ -0,0 +1,47 @@
+package ppdefaults
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrInvalidName is returned by the loaders for template names that could reach outside of their templates, see
+// [ValidateName]. Find it with [errors.Is].
+var ErrInvalidName = errors.New("invalid template name")
+
+// NormalizeName returns name as the loaders name templates: with "/" as the separator, without a leading "./" or "/",
+// and cleaned with [path.Clean], so ".\reviews\index.tmpl" and "/reviews//index.tmpl" are both "reviews/index.tmpl".
+// An empty name is returned as it is.
+func NormalizeName(name string) string {
+	if name == "" {
+		return name
+	}
+
+	return strings.TrimPrefix(path.Clean(strings.ReplaceAll(name, `\`, "/")), "/")
+}
+
+// ValidateName returns an error wrapping [ErrInvalidName] unless name is a template name the loaders can load: not
+// empty, not absolute, without ".." or other elements that aren't cleaned, and with "/" as the only separator.
+// The loaders check every name before reading it, so names influenced by users can't reach outside of the templates
+// whatever the filesystem. Clean up names with [NormalizeName] first to accept them as written by people.
+func ValidateName(name string) error {
+	var reason string
+	switch {
+	case name == "":
+		reason = "it's empty"
+	case strings.HasPrefix(name, "/") || len(name) >= 2 && name[1] == ':':
+		reason = "it's an absolute path"
+	case strings.Contains(name, `\`):
+		reason = `it uses "\" as a separator`
+	case name == ".." || strings.HasPrefix(name, "../") || strings.HasSuffix(name, "/..") || strings.Contains(name, "/../"):
+		reason = `it contains ".."`
+	case name != path.Clean(name) || name == ".":
+		reason = "it isn't clean"
+	default:
+		return nil
+	}
+
+	return fmt.Errorf("%w %q: %s", ErrInvalidName, name, reason)
+}
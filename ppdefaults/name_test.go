@@ -0,0 +1,60 @@
+package ppdefaults_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+func TestNormalizeName(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		expected string
+	}{
+		{name: "reviews/index.tmpl", expected: "reviews/index.tmpl"},
+		{name: `reviews\index.tmpl`, expected: "reviews/index.tmpl"},
+		{name: `.\reviews\index.tmpl`, expected: "reviews/index.tmpl"},
+		{name: "./reviews/index.tmpl", expected: "reviews/index.tmpl"},
+		{name: "/reviews//index.tmpl", expected: "reviews/index.tmpl"},
+		{name: "reviews/../index.tmpl", expected: "index.tmpl"},
+		{name: "", expected: ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, ppdefaults.NormalizeName(tc.name))
+		})
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		expected string
+	}{
+		{name: "reviews/index.tmpl"},
+		{name: "reviews/index/_item..tmpl"},
+		{name: "", expected: `invalid template name "": it's empty`},
+		{name: "/etc/passwd", expected: `invalid template name "/etc/passwd": it's an absolute path`},
+		{name: `C:\secrets`, expected: `invalid template name "C:\\secrets": it's an absolute path`},
+		{name: `reviews\index.tmpl`, expected: `invalid template name "reviews\\index.tmpl": it uses "\" as a separator`},
+		{name: "../secrets", expected: `invalid template name "../secrets": it contains ".."`},
+		{name: "reviews/../../secrets", expected: `invalid template name "reviews/../../secrets": it contains ".."`},
+		{name: "..", expected: `invalid template name "..": it contains ".."`},
+		{name: "./reviews/index.tmpl", expected: `invalid template name "./reviews/index.tmpl": it isn't clean`},
+		{name: "reviews//index.tmpl", expected: `invalid template name "reviews//index.tmpl": it isn't clean`},
+		{name: ".", expected: `invalid template name ".": it isn't clean`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ppdefaults.ValidateName(tc.name)
+
+			if tc.expected == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.expected)
+			require.True(t, errors.Is(err, ppdefaults.ErrInvalidName))
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package ppdefaults
+
+import (
+	"fmt"
+
+	"github.com/gaqzi/passepartout/internal/ppparse"
+)
+
+// ParseError is returned by [CreateTemplate] when a file fails to parse, with where in the file it failed.
+type ParseError struct {
+	// Path is the name of the file in the loader's filesystem.
+	Path string
+	// Line is 0 when the parser didn't say which line.
+	Line    int
+	Message string
+	// Excerpt is the source around Line, numbered and with Line marked with ">", empty when Line is 0.
+	Excerpt string
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("failed to parse template %s: %s", e.Path, e.Message)
+	}
+
+	return fmt.Sprintf("failed to parse template %s:%d: %s", e.Path, e.Line, e.Message)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func newParseError(file FileWithContent, err error) *ParseError {
+	parsed := ppparse.NewError(file.Name, file.Unwrapped(), err)
+
+	return &ParseError{Path: file.Name, Line: parsed.Line, Message: parsed.Message, Excerpt: parsed.Excerpt, Err: err}
+}
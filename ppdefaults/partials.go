@@ -4,7 +4,10 @@ import (
 	"errors"
 	"io/fs"
 	"path"
+	"slices"
 	"strings"
+
+	"github.com/gaqzi/passepartout/internal/ppparse"
 )
 
 // PartialsInFolderOnly implements the [PartialLoader] interface.
@@ -14,78 +17,251 @@ type PartialsInFolderOnly struct {
 
 // Load gets files from a folder named after the passed in template and treats them as partials.
 // Ex: a template named "something/hello.tmpl" will load any files in the folder "something/hello/".
+// The subfolders are loaded too, so a partial like "something/hello/_table.tmpl" can have its own partials in
+// "something/hello/_table/", and is loaded with only those when rendered on its own.
 func (p *PartialsInFolderOnly) Load(name string) ([]FileWithContent, error) {
-	ext := path.Ext(name)
-	dirName := strings.TrimSuffix(name, ext)
+	return filesIn(p.FS, partialDir(name))
+}
+
+// Dirs returns the folder [PartialsInFolderOnly.Load] loads the partials for name from.
+func (p *PartialsInFolderOnly) Dirs(name string) []string {
+	return []string{partialDir(name)}
+}
 
+// Priority decides whose partials win when the page's folder and the CommonDir of [PartialsWithCommon] define the
+// same template. Partials are parsed in the order they're loaded, and a define overrides any earlier define of the
+// same name, so whatever is loaded last wins.
+type Priority int
+
+const (
+	// PriorityPage loads the CommonDir first so the page's own partials override the common ones, the default.
+	PriorityPage Priority = iota
+	// PriorityCommon loads the page's folder first so the common partials override the page's.
+	PriorityCommon
+)
+
+// PartialsWithCommon implements the [PartialLoader] interface.
+type PartialsWithCommon struct {
+	FS        fs.ReadDirFS
+	CommonDir string
+	// Priority decides the order the folders are loaded in, by default the page's partials are loaded last.
+	Priority Priority
+}
+
+// Load partials in the same way as [PartialsInFolderOnly.Load] and from a CommonDir, for example "partials".
+// The folders are loaded in the order of [PartialsWithCommon.Dirs], and within a folder the files are loaded in the
+// lexical order of [fs.WalkDir], so the order is the same on every load.
+func (p *PartialsWithCommon) Load(name string) ([]FileWithContent, error) {
 	var files []FileWithContent
-	err := fs.WalkDir(p.FS, dirName, func(filePath string, entry fs.DirEntry, err error) error {
+
+	for _, dir := range p.Dirs(name) {
+		result, err := filesIn(p.FS, dir)
 		if err != nil {
-			if errors.Is(err, fs.ErrNotExist) {
-				return nil
-			}
-			return err
+			return nil, err
 		}
+		files = append(files, result...)
+	}
 
-		if entry.IsDir() {
-			return nil
+	return files, nil
+}
+
+// Dirs returns the folders [PartialsWithCommon.Load] loads the partials for name from, in the order they're loaded.
+func (p *PartialsWithCommon) Dirs(name string) []string {
+	if p.Priority == PriorityCommon {
+		return []string{partialDir(name), p.CommonDir}
+	}
+
+	return []string{p.CommonDir, partialDir(name)}
+}
+
+// PartialsInDir implements the [PartialLoader] interface by loading the partials in Dir, and its subfolders, for every
+// template.
+type PartialsInDir struct {
+	FS  fs.ReadDirFS
+	Dir string
+}
+
+// Load gets the files in Dir, whatever the template.
+func (p *PartialsInDir) Load(string) ([]FileWithContent, error) {
+	return filesIn(p.FS, p.Dir)
+}
+
+// Dirs returns Dir.
+func (p *PartialsInDir) Dirs(string) []string {
+	return []string{p.Dir}
+}
+
+// PartialsReferenced implements the [PartialLoader] interface like [PartialsInFolderOnly], but only loads the partials
+// the template uses, and the partials they use in turn, instead of every file in the folder, for folders with many
+// partials where most aren't used by each template.
+//
+// Partials are found by the name of their file, like {{ template "reviews/index/_item.tmpl" }}, so the defines in a
+// partial are only available when its file is used by name too. When a template doesn't parse every partial is
+// loaded, so the error is returned as usual when the template is created.
+type PartialsReferenced struct {
+	FS fs.ReadDirFS
+}
+
+// Load reads the template name and the partials in its folder that it references, following their references.
+// The partials are returned sorted by name.
+func (p *PartialsReferenced) Load(name string) ([]FileWithContent, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	content, err := fs.ReadFile(p.FS, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil // the template loader returns the error for a missing template
 		}
+		return nil, err
+	}
+
+	dir := partialDir(name)
+	var files []FileWithContent
+	seen := make(map[string]struct{})
+	queue := []FileWithContent{{Name: name, Content: string(content)}}
+	for len(queue) > 0 {
+		file := queue[0]
+		queue = queue[1:]
 
-		content, err := fs.ReadFile(p.FS, filePath)
+		parsed, err := ppparse.Parse(file.Name, file.Content)
 		if err != nil {
-			return err
+			return filesIn(p.FS, dir)
 		}
 
-		files = append(files, FileWithContent{Name: filePath, Content: string(content)})
+		for _, ref := range parsed.References() {
+			if _, ok := seen[ref]; ok || !strings.HasPrefix(ref, dir+"/") || ValidateName(ref) != nil {
+				continue
+			}
+			seen[ref] = struct{}{}
 
-		return nil
-	})
-	if err != nil {
-		return nil, err
+			content, err := fs.ReadFile(p.FS, ref)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue // defined somewhere else, like in the layout or in another partial
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			partial := FileWithContent{Name: ref, Content: string(content)}
+			files = append(files, partial)
+			queue = append(queue, partial)
+		}
 	}
+	slices.SortFunc(files, func(a, b FileWithContent) int { return strings.Compare(a.Name, b.Name) })
 
 	return files, nil
 }
 
-// PartialsWithCommon implements the [PartialLoader] interface.
-type PartialsWithCommon struct {
-	FS        fs.ReadDirFS
-	CommonDir string
+// Dirs returns the folder [PartialsReferenced.Load] loads the partials for name from.
+func (p *PartialsReferenced) Dirs(name string) []string {
+	return []string{partialDir(name)}
 }
 
-// Load partials in the same way as [PartialsInFolderOnly.Load] and from a CommonDir, for example "partials".
-func (p *PartialsWithCommon) Load(name string) ([]FileWithContent, error) {
-	var files []FileWithContent
+// partialDir is the folder named after the template name without its extension.
+func partialDir(name string) string {
+	return strings.TrimSuffix(name, path.Ext(name))
+}
 
-	ext := path.Ext(name)
-	dirName := strings.TrimSuffix(name, ext)
+// filesIn reads all files in dir and its subfolders, a dir that doesn't exist has no files.
+// When fsys implements [fs.GlobFS] the files are found with Glob instead of walking the directories.
+func filesIn(fsys fs.ReadDirFS, dir string) ([]FileWithContent, error) {
+	if !fs.ValidPath(dir) {
+		return nil, &fs.PathError{Op: "open", Path: dir, Err: fs.ErrInvalid}
+	}
 
-	for _, dir := range []string{dirName, p.CommonDir} {
-		err := fs.WalkDir(p.FS, dir, func(filePath string, entry fs.DirEntry, err error) error {
-			if err != nil {
-				if errors.Is(err, fs.ErrNotExist) {
-					return nil
-				}
-				return err
-			}
+	var names []string
+	var err error
+	if globFS, ok := fsys.(fs.GlobFS); ok {
+		names, err = globFiles(globFS, dir)
+	} else {
+		names, err = walkFiles(fsys, dir)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-			if entry.IsDir() {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	files := make([]FileWithContent, 0, len(names))
+	for _, name := range names {
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, FileWithContent{Name: name, Content: string(content)})
+	}
+
+	return files, nil
+}
+
+func walkFiles(fsys fs.FS, dir string) ([]string, error) {
+	var names []string
+	err := fs.WalkDir(fsys, dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
 				return nil
 			}
+			return err
+		}
 
-			content, err := fs.ReadFile(p.FS, filePath)
-			if err != nil {
-				return err
-			}
+		if entry.IsDir() {
+			return nil
+		}
 
-			files = append(files, FileWithContent{Name: filePath, Content: string(content)})
+		names = append(names, filePath)
 
-			return nil
-		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// globFiles finds the same files as walkFiles, in the same order, by globbing one level of folders at a time.
+func globFiles(fsys fs.GlobFS, dir string) ([]string, error) {
+	matches, err := fsys.Glob(path.Join(escapeGlob(dir), "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, match := range matches {
+		info, err := fs.Stat(fsys, match)
 		if err != nil {
 			return nil, err
 		}
+
+		if !info.IsDir() {
+			names = append(names, match)
+			continue
+		}
+
+		nested, err := globFiles(fsys, match)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, nested...)
 	}
 
-	return files, nil
+	return names, nil
+}
+
+// escapeGlob escapes the characters that have a special meaning in [path.Match] so name only matches itself.
+func escapeGlob(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
 }
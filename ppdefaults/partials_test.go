@@ -1,6 +1,9 @@
 package ppdefaults_test
 
 import (
+	"bytes"
+	"errors"
+	"io/fs"
 	"testing"
 	"testing/fstest"
 
@@ -36,6 +39,27 @@ func TestPartialsInFolderOnly(t *testing.T) {
 				)
 			},
 		},
+		{
+			name:     "returns the partials in the partial folders of the partials, so big partials can be split up too",
+			pageName: "test.tmpl",
+			fs: fstest.MapFS{
+				"test/_table.tmpl":          {Data: []byte("table partial")},
+				"test/_table/_row.tmpl":     {Data: []byte("row partial")},
+				"test/_table/_row/_td.tmpl": {Data: []byte("cell partial")},
+			},
+			expect: func(t *testing.T, actual []ppdefaults.FileWithContent, err error) {
+				require.NoError(t, err)
+				require.Equal(
+					t,
+					[]ppdefaults.FileWithContent{
+						{Name: "test/_table/_row/_td.tmpl", Content: "cell partial"},
+						{Name: "test/_table/_row.tmpl", Content: "row partial"},
+						{Name: "test/_table.tmpl", Content: "table partial"},
+					},
+					actual,
+				)
+			},
+		},
 		{
 			// I'm doing this just to indicate that this is expected behavior,
 			// I don't care about removing _all extensions_, so this is the decision and it's recorded.
@@ -191,3 +215,183 @@ func TestPartialsWithCommon(t *testing.T) {
 		})
 	}
 }
+
+func TestPartialsWithCommon_Priority(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test.tmpl":              {Data: []byte(`{{ template "title" }}`)},
+		"test/_title.tmpl":       {Data: []byte(`{{ define "title" }}page{{ end }}`)},
+		"partials/_title.tmpl":   {Data: []byte(`{{ define "title" }}common{{ end }}`)},
+		"partials/_another.tmpl": {Data: []byte(`another`)},
+		"layouts/default.tmpl":   {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+	}
+
+	for _, tc := range []struct {
+		name          string
+		priority      ppdefaults.Priority
+		expectedOrder []string
+		expected      string
+	}{
+		{
+			name:          "by default the common partials are loaded first so the page's partials override them",
+			priority:      ppdefaults.PriorityPage,
+			expectedOrder: []string{"partials/_another.tmpl", "partials/_title.tmpl", "test/_title.tmpl"},
+			expected:      "page",
+		},
+		{
+			name:          "with PriorityCommon the page's partials are loaded first so the common partials override them",
+			priority:      ppdefaults.PriorityCommon,
+			expectedOrder: []string{"test/_title.tmpl", "partials/_another.tmpl", "partials/_title.tmpl"},
+			expected:      "common",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			partials := &ppdefaults.PartialsWithCommon{FS: fsys, CommonDir: "partials", Priority: tc.priority}
+			loader := ppdefaults.NewLoaderBuilder().WithDefaults(fsys).WithPartials(partials).Build()
+
+			files, err := partials.Load("test.tmpl")
+			require.NoError(t, err)
+			var order []string
+			for _, f := range files {
+				order = append(order, f.Name)
+			}
+			require.Equal(t, tc.expectedOrder, order)
+
+			tmpl, err := loader.Standalone("test.tmpl")
+			require.NoError(t, err)
+			buf := new(bytes.Buffer)
+			require.NoError(t, tmpl.ExecuteTemplate(buf, "test.tmpl", nil))
+			require.Equal(t, tc.expected, buf.String())
+
+			tmpl, err = loader.InLayout("test.tmpl", "layouts/default.tmpl")
+			require.NoError(t, err)
+			buf.Reset()
+			require.NoError(t, tmpl.ExecuteTemplate(buf, "layouts/default.tmpl", nil))
+			require.Equal(t, "<main>"+tc.expected+"</main>", buf.String(), "expected the same partials to win in a layout")
+		})
+	}
+}
+
+// globOnlyFS fails any attempt at walking directories so the only way to find files is through Glob.
+type globOnlyFS struct {
+	fstest.MapFS
+	globs int
+}
+
+func (g *globOnlyFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return nil, errors.New("expected to not have read directories when Glob is available")
+}
+
+func (g *globOnlyFS) Glob(pattern string) ([]string, error) {
+	g.globs++
+	return g.MapFS.Glob(pattern)
+}
+
+func TestPartials_WithGlobFS(t *testing.T) {
+	newFS := func() *globOnlyFS {
+		return &globOnlyFS{MapFS: fstest.MapFS{
+			"test/_item.tmpl":          {Data: []byte("item partial")},
+			"test/nested/_deep.tmpl":   {Data: []byte("deep partial")},
+			"test/_last.tmpl":          {Data: []byte("last partial")},
+			"test[1]/_escaped.tmpl":    {Data: []byte("escaped partial")},
+			"partials/_common.tmpl":    {Data: []byte("common partial")},
+			"something-else/_not.tmpl": {Data: []byte("not loaded")},
+		}}
+	}
+
+	t.Run("PartialsInFolderOnly finds the same files in the same order as walking would", func(t *testing.T) {
+		fsys := newFS()
+		loader := ppdefaults.PartialsInFolderOnly{FS: fsys}
+
+		actual, err := loader.Load("test.tmpl")
+
+		require.NoError(t, err)
+		require.Equal(t, []ppdefaults.FileWithContent{
+			{Name: "test/_item.tmpl", Content: "item partial"},
+			{Name: "test/_last.tmpl", Content: "last partial"},
+			{Name: "test/nested/_deep.tmpl", Content: "deep partial"},
+		}, actual)
+		require.Positive(t, fsys.globs, "expected Glob to have been used")
+
+		walkOnly := struct{ fs.ReadDirFS }{newFS().MapFS}
+		walked, err := (&ppdefaults.PartialsInFolderOnly{FS: walkOnly}).Load("test.tmpl")
+		require.NoError(t, err)
+		require.Equal(t, walked, actual)
+	})
+
+	t.Run("PartialsInFolderOnly treats glob characters in names literally", func(t *testing.T) {
+		loader := ppdefaults.PartialsInFolderOnly{FS: newFS()}
+
+		actual, err := loader.Load("test[1].tmpl")
+
+		require.NoError(t, err)
+		require.Equal(t, []ppdefaults.FileWithContent{{Name: "test[1]/_escaped.tmpl", Content: "escaped partial"}}, actual)
+	})
+
+	t.Run("PartialsWithCommon finds partials in both folders", func(t *testing.T) {
+		loader := ppdefaults.PartialsWithCommon{FS: newFS(), CommonDir: "partials"}
+
+		actual, err := loader.Load("test.tmpl")
+
+		require.NoError(t, err)
+		require.Len(t, actual, 4)
+		require.Equal(t, ppdefaults.FileWithContent{Name: "partials/_common.tmpl", Content: "common partial"}, actual[0], "expected the common partials to be loaded first")
+	})
+}
+
+func TestPartialsReferenced(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test.tmpl":              {Data: []byte(`{{ template "test/_table.tmpl" . }}{{ template "layouts/x.tmpl" }}{{ template "test/_defined.tmpl" }}`)},
+		"test/_table.tmpl":       {Data: []byte(`{{ define "row" }}{{ template "test/_table/_row.tmpl" . }}{{ end }}{{ template "row" . }}`)},
+		"test/_table/_row.tmpl":  {Data: []byte(`{{ define "back" }}{{ template "test/_table.tmpl" }}{{ end }}row`)},
+		"test/_unused.tmpl":      {Data: []byte(`{{ .Broken`)},
+		"broken.tmpl":            {Data: []byte(`{{ .Broken`)},
+		"broken/_partial.tmpl":   {Data: []byte(`partial`)},
+		"broken/nested/_x.tmpl":  {Data: []byte(`nested`)},
+		"other/_not-loaded.tmpl": {Data: []byte(`not loaded`)},
+	}
+	loader := ppdefaults.PartialsReferenced{FS: fsys}
+
+	for _, tc := range []struct {
+		name     string
+		template string
+		expected []ppdefaults.FileWithContent
+	}{
+		{
+			name:     "loads only the partials referenced, following the references of the partials and their defines",
+			template: "test.tmpl",
+			expected: []ppdefaults.FileWithContent{
+				{Name: "test/_table.tmpl", Content: `{{ define "row" }}{{ template "test/_table/_row.tmpl" . }}{{ end }}{{ template "row" . }}`},
+				{Name: "test/_table/_row.tmpl", Content: `{{ define "back" }}{{ template "test/_table.tmpl" }}{{ end }}row`},
+			},
+		},
+		{
+			name:     "loads every partial in the folder when the template doesn't parse",
+			template: "broken.tmpl",
+			expected: []ppdefaults.FileWithContent{
+				{Name: "broken/_partial.tmpl", Content: "partial"},
+				{Name: "broken/nested/_x.tmpl", Content: "nested"},
+			},
+		},
+		{
+			name:     "loads nothing for a template that doesn't exist",
+			template: "missing.tmpl",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := loader.Load(tc.template)
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+
+	t.Run("creates a template from only the referenced partials", func(t *testing.T) {
+		l := ppdefaults.NewLoaderBuilder().WithDefaults(fsys).WithPartials(&loader).Build()
+
+		tmpl, err := l.Standalone("test/_table.tmpl")
+		require.NoError(t, err, "expected the unused partial that doesn't parse to not have been loaded")
+		buf := new(bytes.Buffer)
+		require.NoError(t, tmpl.ExecuteTemplate(buf, "test/_table/_row.tmpl", nil))
+		require.Equal(t, "row", buf.String())
+	})
+}
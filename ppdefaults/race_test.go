@@ -0,0 +1,7 @@
+//go:build race
+
+package ppdefaults_test
+
+func init() {
+	raceEnabled = true
+}
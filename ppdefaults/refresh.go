@@ -0,0 +1,33 @@
+package ppdefaults
+
+import (
+	"sync"
+)
+
+// refresher keeps track of the refreshes of cache entries done in the background, so a refresh that finishes after
+// a newer refresh of the same entry doesn't replace it with older files. The zero value is ready to use.
+type refresher struct {
+	mu         sync.Mutex
+	generation map[cacheKey]uint64
+}
+
+// refresh runs load in the background and calls done with its error if no newer refresh of key has started.
+func (r *refresher) refresh(key cacheKey, load func() error, done func(err error)) {
+	r.mu.Lock()
+	if r.generation == nil {
+		r.generation = make(map[cacheKey]uint64)
+	}
+	r.generation[key]++
+	generation := r.generation[key]
+	r.mu.Unlock()
+
+	go func() {
+		err := load()
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.generation[key] == generation {
+			done(err)
+		}
+	}()
+}
@@ -0,0 +1,205 @@
+package ppdefaults
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// HTTPLoader implements [TemplateLoader] and [TemplateLoaderContext] by fetching templates from an HTTP endpoint,
+// like a headless CMS exposing template bodies, where "reviews/show.tmpl" is fetched from "<baseURL>/reviews/show.tmpl".
+//
+// It remembers the ETag and Last-Modified of every template it has fetched so [HTTPLoader.Manifest] can revalidate
+// them with conditional requests, and [CachedLoader.PurgeChanged] evict only what changed.
+type HTTPLoader struct {
+	baseURL  string
+	client   *http.Client
+	mu       sync.Mutex
+	versions map[string]remoteVersion
+}
+
+type remoteVersion struct {
+	etag         string
+	lastModified string
+	hash         string
+}
+
+// version is what identifies the content in a [Manifest], preferring what the server uses to identify it.
+func (v remoteVersion) version() string {
+	switch {
+	case v.etag != "":
+		return v.etag
+	case v.lastModified != "":
+		return v.lastModified
+	default:
+		return v.hash
+	}
+}
+
+// NewHTTPLoader fetches templates relative to baseURL using client, or [http.DefaultClient] when nil.
+func NewHTTPLoader(baseURL string, client *http.Client) *HTTPLoader {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &HTTPLoader{baseURL: strings.TrimSuffix(baseURL, "/"), client: client, versions: make(map[string]remoteVersion)}
+}
+
+func (h *HTTPLoader) Standalone(name string) ([]FileWithContent, error) {
+	return h.StandaloneContext(context.Background(), name)
+}
+
+func (h *HTTPLoader) InLayout(name, layout string) ([]FileWithContent, error) {
+	return h.InLayoutContext(context.Background(), name, layout)
+}
+
+func (h *HTTPLoader) StandaloneContext(ctx context.Context, name string) ([]FileWithContent, error) {
+	content, err := h.fetch(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	return []FileWithContent{{Name: name, Content: content}}, nil
+}
+
+func (h *HTTPLoader) InLayoutContext(ctx context.Context, name, layout string) ([]FileWithContent, error) {
+	pages, err := h.StandaloneContext(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	layoutContent, err := h.fetch(ctx, layout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read layout template: %w", err)
+	}
+
+	return wrapInLayout(pages, FileWithContent{Name: layout, Content: layoutContent}, ""), nil
+}
+
+func (h *HTTPLoader) fetch(ctx context.Context, name string) (string, error) {
+	resp, err := h.get(ctx, name, remoteVersion{})
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError(name, resp)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %q: %w", name, err)
+	}
+	h.remember(name, resp, content)
+
+	return string(content), nil
+}
+
+// get requests name, conditionally when known has validators.
+func (h *HTTPLoader) get(ctx context.Context, name string, known remoteVersion) (*http.Response, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.baseURL+"/"+(&url.URL{Path: name}).EscapedPath(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %q: %w", name, err)
+	}
+	if known.etag != "" {
+		req.Header.Set("If-None-Match", known.etag)
+	}
+	if known.lastModified != "" {
+		req.Header.Set("If-Modified-Since", known.lastModified)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %q: %w", name, err)
+	}
+
+	return resp, nil
+}
+
+func (h *HTTPLoader) remember(name string, resp *http.Response, content []byte) {
+	sum := sha256.Sum256(content)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.versions[name] = remoteVersion{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		hash:         hex.EncodeToString(sum[:]),
+	}
+}
+
+func statusError(name string, resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return fmt.Errorf("failed to fetch %q: unexpected status %s", name, resp.Status)
+}
+
+// Manifest revalidates every template fetched so far with a conditional request and returns their current versions.
+// Templates that no longer exist are left out, so comparing it with the previous manifest using
+// [CachedLoader.PurgeChanged] evicts what was modified or removed on the server.
+func (h *HTTPLoader) Manifest(ctx context.Context) (Manifest, error) {
+	h.mu.Lock()
+	known := make(map[string]remoteVersion, len(h.versions))
+	for name, v := range h.versions {
+		known[name] = v
+	}
+	h.mu.Unlock()
+
+	m := make(Manifest, len(known))
+	for name, v := range known {
+		version, ok, err := h.revalidate(ctx, name, v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create manifest: %w", err)
+		}
+		if ok {
+			m[name] = version
+		}
+	}
+
+	return m, nil
+}
+
+// revalidate returns the current version of name, and false if it no longer exists.
+func (h *HTTPLoader) revalidate(ctx context.Context, name string, known remoteVersion) (string, bool, error) {
+	resp, err := h.get(ctx, name, known)
+	if err != nil {
+		return "", false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return known.version(), true, nil
+	case http.StatusNotFound:
+		h.mu.Lock()
+		delete(h.versions, name)
+		h.mu.Unlock()
+		return "", false, nil
+	case http.StatusOK:
+		content, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read %q: %w", name, err)
+		}
+		h.remember(name, resp, content)
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return h.versions[name].version(), true, nil
+	default:
+		return "", false, statusError(name, resp)
+	}
+}
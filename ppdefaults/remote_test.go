@@ -0,0 +1,135 @@
+package ppdefaults_test
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// templateServer serves templates with an ETag that changes with every update, and counts unmodified responses.
+type templateServer struct {
+	mu          sync.Mutex
+	templates   map[string]string
+	versions    map[string]int
+	notModified int
+}
+
+func newTemplateServer(t *testing.T, templates map[string]string) (*templateServer, string) {
+	s := &templateServer{templates: templates, versions: make(map[string]int)}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+
+	return s, server.URL + "/templates"
+}
+
+func (s *templateServer) update(name, content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.templates[name] = content
+	s.versions[name]++
+}
+
+func (s *templateServer) remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.templates, name)
+}
+
+func (s *templateServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := strings.TrimPrefix(r.URL.Path, "/templates/")
+	content, ok := s.templates[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	etag := fmt.Sprintf(`"%s-%d"`, name, s.versions[name])
+	if r.Header.Get("If-None-Match") == etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	_, _ = w.Write([]byte(content))
+}
+
+func TestHTTPLoader(t *testing.T) {
+	t.Run("fetches templates and wraps pages for a layout", func(t *testing.T) {
+		_, baseURL := newTemplateServer(t, map[string]string{
+			"reviews/show.tmpl":    "show",
+			"layouts/default.tmpl": `{{ block "content" . }}{{ end }}`,
+		})
+		loader := ppdefaults.NewHTTPLoader(baseURL, nil)
+
+		standalone, err := loader.Standalone("reviews/show.tmpl")
+		require.NoError(t, err)
+		require.Equal(t, []ppdefaults.FileWithContent{{Name: "reviews/show.tmpl", Content: "show"}}, standalone)
+
+		inLayout, err := loader.InLayout("reviews/show.tmpl", "layouts/default.tmpl")
+		require.NoError(t, err)
+		require.Equal(t, []ppdefaults.FileWithContent{
+			{Name: "layouts/default.tmpl", Content: `{{ block "content" . }}{{ end }}`},
+			{Name: "reviews/show.tmpl", Content: `{{ define "content" }}show{{ end }}`, Block: "content"},
+		}, inLayout)
+	})
+
+	t.Run("returns a not exist error for a missing template", func(t *testing.T) {
+		_, baseURL := newTemplateServer(t, map[string]string{})
+		loader := ppdefaults.NewHTTPLoader(baseURL, nil)
+
+		_, err := loader.Standalone("missing.tmpl")
+
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("respects the context", func(t *testing.T) {
+		_, baseURL := newTemplateServer(t, map[string]string{"index.tmpl": "index"})
+		loader := ppdefaults.NewHTTPLoader(baseURL, nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := loader.StandaloneContext(ctx, "index.tmpl")
+
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("revalidates fetched templates so the cache only evicts what changed", func(t *testing.T) {
+		server, baseURL := newTemplateServer(t, map[string]string{
+			"index.tmpl":   "index",
+			"about.tmpl":   "about",
+			"contact.tmpl": "contact",
+		})
+		loader := ppdefaults.NewHTTPLoader(baseURL, nil)
+		cache := ppdefaults.NewCachedLoader(loader)
+		for _, name := range []string{"index.tmpl", "about.tmpl", "contact.tmpl"} {
+			_, err := cache.Standalone(name)
+			require.NoError(t, err)
+		}
+		before, err := loader.Manifest(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 3, server.notModified, "expected every template to be revalidated with a conditional request")
+
+		server.update("about.tmpl", "about us")
+		server.remove("contact.tmpl")
+		after, err := loader.Manifest(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"about.tmpl", "contact.tmpl"}, cache.PurgeChanged(before, after))
+		files, err := cache.Standalone("about.tmpl")
+		require.NoError(t, err)
+		require.Equal(t, "about us", files[0].Content)
+	})
+}
@@ -0,0 +1,51 @@
+package ppdefaults
+
+import (
+	"html/template"
+)
+
+// CacheStats is what a cache holds, to keep an eye on the memory used by services with large template sets.
+type CacheStats struct {
+	// Entries are the cached templates, a page in a layout is an entry of its own.
+	Entries int
+	// Files are the files the entries were loaded from, a file used by many entries is counted for each of them.
+	Files int
+	// SourceBytes is the size of the content of the files.
+	SourceBytes int
+	// Trees are the parsed templates, including every define and partial, zero when only the files are cached.
+	Trees int
+}
+
+func (s *CacheStats) add(entry cacheEntry, tmplt *template.Template) {
+	s.Entries++
+	s.Files += len(entry.files)
+	for _, f := range entry.files {
+		s.SourceBytes += len(f.Content)
+	}
+	if tmplt != nil {
+		s.Trees += len(tmplt.Templates())
+	}
+}
+
+// Stats returns what the cache holds.
+func (c *CachedLoader) Stats() CacheStats {
+	var stats CacheStats
+	c.data.Range(func(_, value any) bool {
+		stats.add(value.(cacheEntry), nil)
+		return true
+	})
+
+	return stats
+}
+
+// Stats returns what the cache holds.
+func (c *TemplateCache) Stats() CacheStats {
+	var stats CacheStats
+	c.data.Range(func(_, value any) bool {
+		entry := value.(templateEntry)
+		stats.add(entry.cacheEntry, entry.template)
+		return true
+	})
+
+	return stats
+}
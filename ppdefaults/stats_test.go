@@ -0,0 +1,52 @@
+package ppdefaults_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+func TestStats(t *testing.T) {
+	layout := `<main>{{ block "content" . }}{{ end }}</main>`
+	page := `{{ template "page/_a.tmpl" }}`
+	inLayout := `{{ define "content" }}` + page + `{{ end }}`
+	fsys := fstest.MapFS{
+		"layout.tmpl":     {Data: []byte(layout)},
+		"page.tmpl":       {Data: []byte(page)},
+		"page/_a.tmpl":    {Data: []byte(`a`)},
+		"missing/_b.tmpl": {Data: []byte(`b`)},
+	}
+
+	t.Run("CachedLoader counts the cached entries, their files, and the size of the files", func(t *testing.T) {
+		cache := ppdefaults.NewCachedLoader(&ppdefaults.TemplateByNameLoader{FS: fsys})
+		require.Equal(t, ppdefaults.CacheStats{}, cache.Stats())
+
+		_, err := cache.Standalone("page.tmpl")
+		require.NoError(t, err)
+		_, err = cache.InLayout("page.tmpl", "layout.tmpl")
+		require.NoError(t, err)
+		_, err = cache.Standalone("missing.tmpl")
+		require.Error(t, err)
+
+		require.Equal(t, ppdefaults.CacheStats{Entries: 2, Files: 3, SourceBytes: len(page) + len(layout) + len(inLayout)}, cache.Stats())
+	})
+
+	t.Run("TemplateCache counts the parsed templates too", func(t *testing.T) {
+		cache := ppdefaults.NewTemplateCache(ppdefaults.NewLoaderBuilder().WithDefaults(fsys).Build())
+
+		standalone, err := cache.Standalone("page.tmpl")
+		require.NoError(t, err)
+		wrapped, err := cache.InLayout("page.tmpl", "layout.tmpl")
+		require.NoError(t, err)
+
+		require.Equal(t, ppdefaults.CacheStats{
+			Entries:     2,
+			Files:       5,
+			SourceBytes: len("a") + len(page) + len("a") + len(layout) + len(inLayout),
+			Trees:       len(standalone.Templates()) + len(wrapped.Templates()),
+		}, cache.Stats())
+	})
+}
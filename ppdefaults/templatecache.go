@@ -0,0 +1,143 @@
+package ppdefaults
+
+import (
+	"context"
+	"html/template"
+	"sort"
+	"sync"
+)
+
+// TemplateCache caches the templates created by a [Loader] so rendering a cached template never loads or parses,
+// the same template is executed for every render, which html/template allows to be done concurrently.
+// Failed loads aren't cached.
+//
+// Unlike [CachedLoader], which caches the files a template is created from, it also skips parsing. A template is only
+// created once, so use [TemplateCache.PurgeChanged] or [TemplateCache.RefreshChanged] when the files change.
+type TemplateCache struct {
+	loader  *Loader
+	data    *sync.Map
+	refresh refresher
+}
+
+type templateEntry struct {
+	cacheEntry
+	template *template.Template
+}
+
+// NewTemplateCache caches the templates created by l.
+func NewTemplateCache(l *Loader) *TemplateCache {
+	return &TemplateCache{loader: l, data: new(sync.Map)}
+}
+
+func (c *TemplateCache) loadOrStore(key cacheKey, load func() (*template.Template, []FileWithContent, error)) (*template.Template, error) {
+	if v, ok := c.data.Load(key); ok {
+		return v.(templateEntry).template, nil
+	}
+
+	tmplt, files, err := load()
+	if err != nil {
+		return nil, err
+	}
+	// Another render might have created it at the same time, use whichever was stored first so it's only escaped once.
+	v, _ := c.data.LoadOrStore(key, templateEntry{cacheEntry: cacheEntry{templates: key.templates(), files: files}, template: tmplt})
+
+	return v.(templateEntry).template, nil
+}
+
+func (c *TemplateCache) Standalone(name string) (*template.Template, error) {
+	return c.StandaloneContext(context.Background(), name)
+}
+
+// StandaloneContext is [Loader.StandaloneContext] creating the template only when it isn't cached.
+func (c *TemplateCache) StandaloneContext(ctx context.Context, name string) (*template.Template, error) {
+	return c.loadOrStore(cacheKey{name: name}, func() (*template.Template, []FileWithContent, error) {
+		return c.loader.standalone(ctx, name)
+	})
+}
+
+func (c *TemplateCache) InLayout(page string, layout string) (*template.Template, error) {
+	return c.InLayoutContext(context.Background(), page, layout)
+}
+
+// InLayoutContext is [Loader.InLayoutContext] creating the template only when it isn't cached.
+func (c *TemplateCache) InLayoutContext(ctx context.Context, page string, layout string) (*template.Template, error) {
+	return c.loadOrStore(cacheKey{name: page, layout: layout}, func() (*template.Template, []FileWithContent, error) {
+		return c.loader.inLayout(ctx, page, layout)
+	})
+}
+
+// StandaloneFiles is [Loader.StandaloneFiles], the files aren't cached.
+func (c *TemplateCache) StandaloneFiles(name string) ([]FileWithContent, error) {
+	return c.loader.StandaloneFiles(name)
+}
+
+// InLayoutFiles is [Loader.InLayoutFiles], the files aren't cached.
+func (c *TemplateCache) InLayoutFiles(page string, layout string) ([]FileWithContent, error) {
+	return c.loader.InLayoutFiles(page, layout)
+}
+
+// SearchedDirs is [Loader.SearchedDirs].
+func (c *TemplateCache) SearchedDirs(page, layout string) []string {
+	return c.loader.SearchedDirs(page, layout)
+}
+
+// PurgeChanged evicts the cached templates affected by the changes between the old and new manifest, in the same way
+// as [CachedLoader.PurgeChanged], and returns their cache keys, sorted.
+func (c *TemplateCache) PurgeChanged(old, newer Manifest) []string {
+	changed, addedDirs, ok := manifestChanges(old, newer)
+	if !ok {
+		return nil
+	}
+
+	var purged []string
+	c.data.Range(func(key, value any) bool {
+		if value.(templateEntry).affectedBy(changed, addedDirs) {
+			c.data.Delete(key)
+			purged = append(purged, key.(cacheKey).String())
+		}
+		return true
+	})
+	sort.Strings(purged)
+
+	return purged
+}
+
+// RefreshChanged creates the cached templates affected by the changes between the old and new manifest again in the
+// background, like [CachedLoader.RefreshChanged], rendering the stale templates until they're created.
+// It returns the refreshed cache keys, sorted.
+func (c *TemplateCache) RefreshChanged(old, newer Manifest) []string {
+	changed, addedDirs, ok := manifestChanges(old, newer)
+	if !ok {
+		return nil
+	}
+
+	var refreshed []string
+	c.data.Range(func(key, value any) bool {
+		if !value.(templateEntry).affectedBy(changed, addedDirs) {
+			return true
+		}
+
+		k := key.(cacheKey)
+		var tmplt *template.Template
+		var files []FileWithContent
+		c.refresh.refresh(k, func() (err error) {
+			if k.layout == "" {
+				tmplt, files, err = c.loader.standalone(context.Background(), k.name)
+			} else {
+				tmplt, files, err = c.loader.inLayout(context.Background(), k.name, k.layout)
+			}
+			return err
+		}, func(err error) {
+			if err != nil {
+				c.data.Delete(k)
+				return
+			}
+			c.data.Store(k, templateEntry{cacheEntry: cacheEntry{templates: k.templates(), files: files}, template: tmplt})
+		})
+		refreshed = append(refreshed, k.String())
+		return true
+	})
+	sort.Strings(refreshed)
+
+	return refreshed
+}
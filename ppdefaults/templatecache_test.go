@@ -0,0 +1,116 @@
+package ppdefaults_test
+
+import (
+	"bytes"
+	"html/template"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// countingCache returns a cache whose loader counts the templates it creates.
+func countingCache(fsys fstest.MapFS) (*ppdefaults.TemplateCache, *atomic.Int32) {
+	created := new(atomic.Int32)
+	loader := ppdefaults.NewLoaderBuilder().WithDefaults(fsys).
+		CreateTemplate(func(base *template.Template, files []ppdefaults.FileWithContent) (*template.Template, error) {
+			created.Add(1)
+			return ppdefaults.CreateTemplate(base, files)
+		}).
+		Build()
+
+	return ppdefaults.NewTemplateCache(loader), created
+}
+
+func TestTemplateCache(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":           {Data: []byte(`Hello, {{ . }}!`)},
+	}
+
+	t.Run("creates each template once and renders the same template after that", func(t *testing.T) {
+		cache, created := countingCache(fsys)
+
+		for _, data := range []string{"first", "second"} {
+			standalone, err := cache.Standalone("index.tmpl")
+			require.NoError(t, err)
+			inLayout, err := cache.InLayout("index.tmpl", "layouts/default.tmpl")
+			require.NoError(t, err)
+
+			buf := new(bytes.Buffer)
+			require.NoError(t, standalone.ExecuteTemplate(buf, "index.tmpl", data))
+			require.NoError(t, inLayout.ExecuteTemplate(buf, "layouts/default.tmpl", data))
+			require.Equal(t, "Hello, "+data+"!<main>Hello, "+data+"!</main>", buf.String())
+		}
+		require.Equal(t, int32(2), created.Load(), "expected one template standalone and one in the layout")
+	})
+
+	t.Run("doesn't cache failures", func(t *testing.T) {
+		cache, created := countingCache(fstest.MapFS{})
+
+		for range 2 {
+			_, err := cache.Standalone("missing.tmpl")
+			require.Error(t, err)
+		}
+		require.Equal(t, int32(0), created.Load())
+	})
+
+	t.Run("renders the cached template concurrently", func(t *testing.T) {
+		cache, _ := countingCache(fsys)
+		var wg sync.WaitGroup
+
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				tmpl, err := cache.InLayout("index.tmpl", "layouts/default.tmpl")
+				require.NoError(t, err)
+				require.NoError(t, tmpl.ExecuteTemplate(new(bytes.Buffer), "layouts/default.tmpl", "concurrent"))
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("creates the templates affected by a change again after PurgeChanged", func(t *testing.T) {
+		cache, created := countingCache(fsys)
+		_, err := cache.Standalone("index.tmpl")
+		require.NoError(t, err)
+		_, err = cache.InLayout("index.tmpl", "layouts/default.tmpl")
+		require.NoError(t, err)
+
+		purged := cache.PurgeChanged(
+			ppdefaults.Manifest{"index.tmpl": "1", "layouts/default.tmpl": "1"},
+			ppdefaults.Manifest{"index.tmpl": "1", "layouts/default.tmpl": "2"},
+		)
+
+		require.Equal(t, []string{"index.tmpl|layouts/default.tmpl"}, purged)
+		_, err = cache.InLayout("index.tmpl", "layouts/default.tmpl")
+		require.NoError(t, err)
+		require.Equal(t, int32(3), created.Load())
+	})
+
+	t.Run("renders the stale templates until the changed templates are created again by RefreshChanged", func(t *testing.T) {
+		fsys := fstest.MapFS{"index.tmpl": {Data: []byte(`Hello, {{ . }}!`)}}
+		cache, created := countingCache(fsys)
+		_, err := cache.Standalone("index.tmpl")
+		require.NoError(t, err)
+		fsys["index.tmpl"] = &fstest.MapFile{Data: []byte(`Goodbye, {{ . }}!`)}
+
+		refreshed := cache.RefreshChanged(ppdefaults.Manifest{"index.tmpl": "1"}, ppdefaults.Manifest{"index.tmpl": "2"})
+
+		require.Equal(t, []string{"index.tmpl"}, refreshed)
+		require.Eventually(t, func() bool { return created.Load() == 2 }, time.Second, time.Millisecond)
+		require.Eventually(t, func() bool {
+			tmplt, err := cache.Standalone("index.tmpl")
+			require.NoError(t, err)
+			buf := new(bytes.Buffer)
+			require.NoError(t, tmplt.ExecuteTemplate(buf, "index.tmpl", "world"))
+			return buf.String() == "Goodbye, world!"
+		}, time.Second, time.Millisecond)
+	})
+}
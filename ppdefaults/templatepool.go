@@ -0,0 +1,109 @@
+package ppdefaults
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"sort"
+	"sync"
+)
+
+// TemplatePool hands out clones of the templates created by a [Loader] so request-scoped funcs can be bound with
+// [template.Template.Funcs] without sharing them between concurrent renders.
+// Each template is created once and kept unexecuted so it can be cloned, and the clones are reused after they have
+// been put back, so only the first renders of a template pay for cloning and escaping it.
+//
+// Funcs can only be replaced, not added, so every func bound must already be in the loader's TemplateConfig, for
+// example as a placeholder that returns an error. Bind the same funcs on every render, a reused clone keeps the funcs
+// of the render before it. Failed loads aren't cached.
+type TemplatePool struct {
+	loader *Loader
+	data   *sync.Map
+}
+
+type poolEntry struct {
+	cacheEntry
+	// master is never executed, html/template can't clone a template after it has been executed.
+	master *template.Template
+	clones *sync.Pool
+}
+
+// NewTemplatePool pools clones of the templates created by l.
+func NewTemplatePool(l *Loader) *TemplatePool {
+	return &TemplatePool{loader: l, data: new(sync.Map)}
+}
+
+// Standalone returns a clone of the template for name with funcs bound, call put when done executing it so the clone
+// can be reused. The clone must not be used after put.
+func (p *TemplatePool) Standalone(ctx context.Context, name string, funcs template.FuncMap) (tmpl *template.Template, put func(), err error) {
+	return p.get(cacheKey{name: name}, funcs, func() (*template.Template, []FileWithContent, error) {
+		return p.loader.standalone(ctx, name)
+	})
+}
+
+// InLayout returns a clone of the template for page within layout with funcs bound, in the same way as
+// [TemplatePool.Standalone].
+func (p *TemplatePool) InLayout(ctx context.Context, page, layout string, funcs template.FuncMap) (tmpl *template.Template, put func(), err error) {
+	return p.get(cacheKey{name: page, layout: layout}, funcs, func() (*template.Template, []FileWithContent, error) {
+		return p.loader.inLayout(ctx, page, layout)
+	})
+}
+
+func (p *TemplatePool) get(key cacheKey, funcs template.FuncMap, load func() (*template.Template, []FileWithContent, error)) (*template.Template, func(), error) {
+	entry, err := p.entry(key, load)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tmpl, ok := entry.clones.Get().(*template.Template)
+	if !ok {
+		tmpl, err = entry.master.Clone()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to clone template for %q: %w", key, err)
+		}
+	}
+	if funcs != nil {
+		tmpl.Funcs(funcs)
+	}
+
+	return tmpl, func() { entry.clones.Put(tmpl) }, nil
+}
+
+func (p *TemplatePool) entry(key cacheKey, load func() (*template.Template, []FileWithContent, error)) (*poolEntry, error) {
+	if v, ok := p.data.Load(key); ok {
+		return v.(*poolEntry), nil
+	}
+
+	master, files, err := load()
+	if err != nil {
+		return nil, err
+	}
+	v, _ := p.data.LoadOrStore(key, &poolEntry{
+		cacheEntry: cacheEntry{templates: key.templates(), files: files},
+		master:     master,
+		clones:     new(sync.Pool),
+	})
+
+	return v.(*poolEntry), nil
+}
+
+// PurgeChanged evicts the pooled templates affected by the changes between the old and new manifest, in the same way
+// as [CachedLoader.PurgeChanged], and returns their cache keys, sorted. Clones in use when purged are dropped when put.
+func (p *TemplatePool) PurgeChanged(old, newer Manifest) []string {
+	changed, addedDirs, ok := manifestChanges(old, newer)
+	if !ok {
+		return nil
+	}
+
+	var purged []string
+	p.data.Range(func(key, value any) bool {
+		if value.(*poolEntry).affectedBy(changed, addedDirs) {
+			p.data.Delete(key)
+			purged = append(purged, key.(cacheKey).String())
+		}
+		return true
+	})
+	sort.Strings(purged)
+
+	return purged
+}
@@ -0,0 +1,97 @@
+package ppdefaults_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+func TestTemplatePool(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":           {Data: []byte(`Hello, {{ user }}!`)},
+	}
+	newPool := func() *ppdefaults.TemplatePool {
+		return ppdefaults.NewTemplatePool(ppdefaults.NewLoaderBuilder().WithDefaults(fsys).
+			TemplateConfig(template.New("").Funcs(template.FuncMap{
+				"user": func() (string, error) { return "", errors.New("user isn't bound") },
+			})).
+			Build())
+	}
+	forUser := func(name string) template.FuncMap {
+		return template.FuncMap{"user": func() string { return name }}
+	}
+
+	t.Run("binds the funcs for each render on a reused clone", func(t *testing.T) {
+		pool := newPool()
+
+		for _, user := range []string{"alice", "bob"} {
+			tmpl, put, err := pool.InLayout(context.Background(), "index.tmpl", "layouts/default.tmpl", forUser(user))
+			require.NoError(t, err)
+			out := new(bytes.Buffer)
+			require.NoError(t, tmpl.ExecuteTemplate(out, "layouts/default.tmpl", nil))
+			put()
+
+			require.Equal(t, "<main>Hello, "+user+"!</main>", out.String())
+		}
+	})
+
+	t.Run("uses the TemplateConfig funcs when none are bound", func(t *testing.T) {
+		tmpl, put, err := newPool().Standalone(context.Background(), "index.tmpl", nil)
+		require.NoError(t, err)
+		defer put()
+
+		require.ErrorContains(t, tmpl.ExecuteTemplate(new(bytes.Buffer), "index.tmpl", nil), "user isn't bound")
+	})
+
+	t.Run("renders with different funcs concurrently", func(t *testing.T) {
+		pool := newPool()
+		var wg sync.WaitGroup
+
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				user := fmt.Sprintf("user%d", i)
+				tmpl, put, err := pool.Standalone(context.Background(), "index.tmpl", forUser(user))
+				require.NoError(t, err)
+				defer put()
+
+				out := new(bytes.Buffer)
+				require.NoError(t, tmpl.ExecuteTemplate(out, "index.tmpl", nil))
+				require.Equal(t, "Hello, "+user+"!", out.String())
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("returns the error from the loader", func(t *testing.T) {
+		_, _, err := newPool().Standalone(context.Background(), "missing.tmpl", nil)
+
+		require.ErrorContains(t, err, "missing.tmpl")
+	})
+
+	t.Run("PurgeChanged evicts the templates affected by a change", func(t *testing.T) {
+		pool := newPool()
+		_, _, err := pool.Standalone(context.Background(), "index.tmpl", nil)
+		require.NoError(t, err)
+		_, _, err = pool.InLayout(context.Background(), "index.tmpl", "layouts/default.tmpl", nil)
+		require.NoError(t, err)
+
+		purged := pool.PurgeChanged(
+			ppdefaults.Manifest{"index.tmpl": "1", "layouts/default.tmpl": "1"},
+			ppdefaults.Manifest{"index.tmpl": "2", "layouts/default.tmpl": "1"},
+		)
+
+		require.Equal(t, []string{"index.tmpl", "index.tmpl|layouts/default.tmpl"}, purged)
+	})
+}
@@ -0,0 +1,43 @@
+package ppdefaults
+
+import (
+	"fmt"
+	"path"
+)
+
+// Transformer returns the content of file changed before it's created into a template, for example Markdown
+// rendered to HTML, an SVG sanitized to inline it, or minified CSS. Template actions in the content are kept.
+// Pages in a layout are transformed without the define [Loader.InLayout] wraps them in.
+type Transformer func(file FileWithContent) (string, error)
+
+// transform runs the Transformers on files, files itself is never changed since it can be cached.
+func (l *Loader) transform(files []FileWithContent) ([]FileWithContent, error) {
+	if len(l.Transformers) == 0 {
+		return files, nil
+	}
+
+	var transformed []FileWithContent
+	for i, file := range files {
+		transformers := l.Transformers[path.Ext(file.Name)]
+		if len(transformers) == 0 {
+			continue
+		}
+		if transformed == nil {
+			transformed = append(make([]FileWithContent, 0, len(files)), files...)
+		}
+
+		define, content, end := file.split()
+		for _, transform := range transformers {
+			var err error
+			if content, err = transform(FileWithContent{Name: file.Name, Content: content}); err != nil {
+				return nil, fmt.Errorf("failed to transform %q: %w", file.Name, err)
+			}
+		}
+		transformed[i].Content = define + content + end
+	}
+	if transformed == nil {
+		return files, nil
+	}
+
+	return transformed, nil
+}
@@ -0,0 +1,135 @@
+package ppdefaults_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// heading renders lines starting with "# " as headings, a stand-in for a Markdown renderer.
+func heading(file ppdefaults.FileWithContent) (string, error) {
+	lines := strings.Split(file.Content, "\n")
+	for i, line := range lines {
+		if title, ok := strings.CutPrefix(line, "# "); ok {
+			lines[i] = "<h1>" + title + "</h1>"
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func TestLoaderBuilder_WithTransformer(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"about.md":             {Data: []byte("# About {{ . }}\n{{ template \"about/_team.md\" }}")},
+		"about/_team.md":       {Data: []byte(`# Team`)},
+		"index.tmpl":           {Data: []byte(`# Not markdown`)},
+	}
+	loader := ppdefaults.NewLoaderBuilder().
+		WithDefaults(fsys).
+		WithTransformer(".md", heading).
+		WithTransformer(".md", func(file ppdefaults.FileWithContent) (string, error) {
+			return strings.ReplaceAll(file.Content, "h1>", "h2>"), nil
+		}).
+		Build()
+
+	for _, tc := range []struct {
+		name     string
+		render   func(out *bytes.Buffer) error
+		expected string
+	}{
+		{
+			name: "transforms the page and its partials with the transformers for the extension in order",
+			render: func(out *bytes.Buffer) error {
+				tmpl, err := loader.Standalone("about.md")
+				if err != nil {
+					return err
+				}
+				return tmpl.ExecuteTemplate(out, "about.md", "us")
+			},
+			expected: "<h2>About us</h2>\n<h2>Team</h2>",
+		},
+		{
+			name: "transforms the page in a layout without the content define",
+			render: func(out *bytes.Buffer) error {
+				tmpl, err := loader.InLayout("about.md", "layouts/default.tmpl")
+				if err != nil {
+					return err
+				}
+				return tmpl.ExecuteTemplate(out, "layouts/default.tmpl", "us")
+			},
+			expected: "<main><h2>About us</h2>\n<h2>Team</h2></main>",
+		},
+		{
+			name: "doesn't transform other extensions",
+			render: func(out *bytes.Buffer) error {
+				tmpl, err := loader.Standalone("index.tmpl")
+				if err != nil {
+					return err
+				}
+				return tmpl.ExecuteTemplate(out, "index.tmpl", nil)
+			},
+			expected: "# Not markdown",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+
+			require.NoError(t, tc.render(out))
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+
+	t.Run("transforms files with defines of their own as they're written", func(t *testing.T) {
+		var transformed []string
+		files, err := ppdefaults.NewLoaderBuilder().
+			WithDefaults(fstest.MapFS{
+				"layouts/default.md": {Data: []byte(`{{ block "a" . }}{{ end }}{{ block "b" . }}{{ end }}`)},
+				"index.md":           {Data: []byte(`{{ define "a" }}A{{ end }}{{ define "b" }}B{{ end }}`)},
+			}).
+			WithTransformer(".md", func(file ppdefaults.FileWithContent) (string, error) {
+				transformed = append(transformed, file.Content)
+				return file.Content, nil
+			}).
+			Build().
+			InLayoutFiles("index.md", "layouts/default.md")
+
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			`{{ block "a" . }}{{ end }}{{ block "b" . }}{{ end }}`,
+			`{{ define "a" }}A{{ end }}{{ define "b" }}B{{ end }}`,
+		}, transformed)
+		require.Equal(t, `{{ define "a" }}A{{ end }}{{ define "b" }}B{{ end }}`, files[1].Content)
+	})
+
+	t.Run("doesn't change cached files", func(t *testing.T) {
+		cached := ppdefaults.NewLoaderBuilder().
+			WithDefaults(fsys).
+			TemplateLoader(ppdefaults.NewCachedLoader(&ppdefaults.TemplateByNameLoader{FS: fsys})).
+			WithTransformer(".md", func(file ppdefaults.FileWithContent) (string, error) { return "!" + file.Content, nil }).
+			Build()
+
+		for range 2 {
+			files, err := cached.StandaloneFiles("about.md")
+			require.NoError(t, err)
+			require.Equal(t, "!# About {{ . }}\n{{ template \"about/_team.md\" }}", files[len(files)-1].Content)
+		}
+	})
+
+	t.Run("returns the error from a transformer", func(t *testing.T) {
+		failing := ppdefaults.NewLoaderBuilder().
+			WithDefaults(fsys).
+			WithTransformer(".md", func(ppdefaults.FileWithContent) (string, error) { return "", errors.New("invalid markdown") }).
+			Build()
+
+		_, err := failing.Standalone("about.md")
+
+		require.ErrorContains(t, err, `failed to transform "about/_team.md": invalid markdown`)
+	})
+}
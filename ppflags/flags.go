@@ -0,0 +1,98 @@
+// Package ppflags exposes boolean feature flags to all templates through the "flag" func,
+// so template level rollouts don't require passing flags through every handler's data.
+//
+// The flags are meant to be registered on the base template, see [ppdefaults.Loader.TemplateConfig]:
+//
+//	flags := ppflags.New(nil)
+//	loader := ppdefaults.NewLoaderBuilder().
+//		WithDefaults(fsys).
+//		TemplateConfig(template.New("").Funcs(flags.FuncMap())).
+//		Build()
+//	go flags.Poll(ctx, time.Minute, fetchFlagsFromRemoteConfig, nil)
+//
+// And then used in templates as:
+//
+//	{{ if flag "new-nav" }}...{{ end }}
+package ppflags
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+)
+
+// Provider returns the current state of all flags, for example from a remote configuration service.
+type Provider func(ctx context.Context) (map[string]bool, error)
+
+// Flags is a concurrency safe set of named boolean flags, any flag that hasn't been set is disabled.
+type Flags struct {
+	mu      sync.RWMutex
+	enabled map[string]bool
+}
+
+// New creates flags with an initial state, which can be nil.
+func New(initial map[string]bool) *Flags {
+	f := &Flags{}
+	f.Replace(initial)
+
+	return f
+}
+
+// Enabled reports whether the flag name is turned on.
+func (f *Flags) Enabled(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.enabled[name]
+}
+
+// Set turns a single flag on or off, for providers that push changes as they happen.
+func (f *Flags) Set(name string, enabled bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.enabled[name] = enabled
+}
+
+// Replace swaps out the state of all flags, any flag not in flags is turned off.
+func (f *Flags) Replace(flags map[string]bool) {
+	enabled := maps.Clone(flags)
+	if enabled == nil {
+		enabled = make(map[string]bool)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.enabled = enabled
+}
+
+// FuncMap returns the "flag" func for use with [template.Template.Funcs].
+func (f *Flags) FuncMap() map[string]any {
+	return map[string]any{"flag": f.Enabled}
+}
+
+// Poll replaces the flags with the result from provider right away and then every interval until ctx is done.
+// When provider returns an error the previous flags are kept and onError, if not nil, is called with the error.
+func (f *Flags) Poll(ctx context.Context, interval time.Duration, provider Provider, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		flags, err := provider(ctx)
+		if err != nil {
+			if onError != nil {
+				onError(err)
+			}
+		} else {
+			f.Replace(flags)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package ppflags_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/ppdefaults"
+	"github.com/gaqzi/passepartout/ppflags"
+)
+
+func TestFlags(t *testing.T) {
+	t.Run("flags that haven't been set are disabled", func(t *testing.T) {
+		flags := ppflags.New(nil)
+
+		require.False(t, flags.Enabled("new-nav"))
+	})
+
+	t.Run("Set changes a single flag", func(t *testing.T) {
+		flags := ppflags.New(map[string]bool{"other": true})
+
+		flags.Set("new-nav", true)
+
+		require.True(t, flags.Enabled("new-nav"))
+		require.True(t, flags.Enabled("other"), "expected to not have touched other flags")
+	})
+
+	t.Run("Replace turns off flags that aren't passed in", func(t *testing.T) {
+		flags := ppflags.New(map[string]bool{"other": true})
+
+		flags.Replace(map[string]bool{"new-nav": true})
+
+		require.True(t, flags.Enabled("new-nav"))
+		require.False(t, flags.Enabled("other"))
+	})
+
+	t.Run("the flag func is available in templates", func(t *testing.T) {
+		flags := ppflags.New(map[string]bool{"new-nav": true})
+		fsys := fstest.MapFS{"index.tmpl": {Data: []byte(`{{ if flag "new-nav" }}new{{ else }}old{{ end }}`)}}
+		pp := passepartout.New(ppdefaults.NewLoaderBuilder().
+			WithDefaults(fsys).
+			TemplateConfig(template.New("").Funcs(flags.FuncMap())).
+			Build())
+
+		buf := new(bytes.Buffer)
+		require.NoError(t, pp.Render(buf, "index.tmpl", nil))
+		require.Equal(t, "new", buf.String())
+
+		flags.Set("new-nav", false)
+		buf.Reset()
+		require.NoError(t, pp.Render(buf, "index.tmpl", nil))
+		require.Equal(t, "old", buf.String(), "expected the flag to be read on every render")
+	})
+}
+
+func TestFlags_Poll(t *testing.T) {
+	t.Run("replaces the flags with what the provider returns until the context is done", func(t *testing.T) {
+		flags := ppflags.New(nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		var calls atomic.Int32
+		done := make(chan struct{})
+
+		go func() {
+			flags.Poll(ctx, time.Millisecond, func(ctx context.Context) (map[string]bool, error) {
+				if calls.Add(1) == 3 {
+					cancel()
+				}
+				return map[string]bool{"new-nav": true}, nil
+			}, nil)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("expected Poll to have returned after the context was cancelled")
+		}
+		require.True(t, flags.Enabled("new-nav"))
+		require.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("keeps the previous flags and reports errors from the provider", func(t *testing.T) {
+		flags := ppflags.New(map[string]bool{"new-nav": true})
+		ctx, cancel := context.WithCancel(context.Background())
+		var reported error
+
+		flags.Poll(ctx, time.Millisecond, func(ctx context.Context) (map[string]bool, error) {
+			cancel()
+			return nil, errors.New("uh-oh")
+		}, func(err error) { reported = err })
+
+		require.EqualError(t, reported, "uh-oh")
+		require.True(t, flags.Enabled("new-nav"), "expected the flags to not have been replaced")
+	})
+}
@@ -0,0 +1,162 @@
+// Package ppform renders form fields with the values submitted and the validation errors, so a form that failed
+// validation is rendered again without boilerplate in every template.
+//
+// Register the funcs on the base template, see [ppdefaults.Loader.TemplateConfig]:
+//
+//	loader := ppdefaults.NewLoaderBuilder().
+//		WithDefaults(fsys).
+//		WithFuncs(ppform.FuncMap()).
+//		Build()
+//
+// And then render a [*Form] in templates as:
+//
+//	<label for="email">Email</label>
+//	{{ inputText .Form "email" "placeholder" "you@example.com" }}
+//	{{ range errorsFor .Form "email" }}<p class="error">{{ . }}</p>{{ end }}
+package ppform
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Form is the state of a submitted form: the values it was submitted with and the errors of its fields.
+// A nil Form is an empty form, for rendering it before it has been submitted.
+type Form struct {
+	Values url.Values
+	// Errors are the error messages of each field by its name.
+	Errors map[string][]string
+}
+
+// New returns a form with values and no errors, like the [net/http.Request.PostForm] of a submitted form.
+func New(values url.Values) *Form {
+	return &Form{Values: values, Errors: make(map[string][]string)}
+}
+
+// AddError adds the error message to the field.
+func (f *Form) AddError(field, message string) {
+	if f.Errors == nil {
+		f.Errors = make(map[string][]string)
+	}
+	f.Errors[field] = append(f.Errors[field], message)
+}
+
+// Valid reports whether none of the fields have errors.
+func (f *Form) Valid() bool {
+	return f == nil || len(f.Errors) == 0
+}
+
+// FuncMap returns the funcs for rendering a [*Form]:
+//   - inputText renders a text input, see [InputText].
+//   - select renders a select, see [Select].
+//   - errorsFor returns the errors of a field, see [ErrorsFor].
+//   - oldValue returns the submitted value of a field, see [OldValue].
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"inputText": InputText,
+		"select":    Select,
+		"errorsFor": ErrorsFor,
+		"oldValue":  OldValue,
+	}
+}
+
+// OldValue returns the value the field was submitted with, empty when it wasn't.
+func OldValue(f *Form, name string) string {
+	if f == nil {
+		return ""
+	}
+
+	return f.Values.Get(name)
+}
+
+// ErrorsFor returns the errors of the field.
+func ErrorsFor(f *Form, name string) []string {
+	if f == nil {
+		return nil
+	}
+
+	return f.Errors[name]
+}
+
+// InputText renders <input type="text"> for the field, with its id and name set to name and the value it was
+// submitted with. attrs are pairs of attribute names and values added to the input, like "placeholder" "Name", and
+// an attribute without a value when it's empty, like "required" "". A field with errors is marked aria-invalid.
+func InputText(f *Form, name string, attrs ...string) (template.HTML, error) {
+	extra, err := attributes(attrs)
+	if err != nil {
+		return "", fmt.Errorf("failed to render input %q: %w", name, err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<input type="text" id="%s" name="%s" value="%s"`, escape(name), escape(name), escape(OldValue(f, name)))
+	if len(ErrorsFor(f, name)) > 0 {
+		b.WriteString(` aria-invalid="true"`)
+	}
+	b.WriteString(extra)
+	b.WriteString(">")
+
+	return template.HTML(b.String()), nil
+}
+
+// Select renders <select> for the field, with its id and name set to name, and the option it was submitted with
+// selected. options are pairs of values and labels, like "se" "Sweden" "no" "Norway". A field with errors is marked
+// aria-invalid.
+func Select(f *Form, name string, options ...string) (template.HTML, error) {
+	if len(options)%2 != 0 {
+		return "", fmt.Errorf("failed to render select %q: options must be pairs of values and labels, got %d", name, len(options))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<select id="%s" name="%s"`, escape(name), escape(name))
+	if len(ErrorsFor(f, name)) > 0 {
+		b.WriteString(` aria-invalid="true"`)
+	}
+	b.WriteString(">")
+
+	selected := OldValue(f, name)
+	for i := 0; i < len(options); i += 2 {
+		fmt.Fprintf(&b, `<option value="%s"`, escape(options[i]))
+		if options[i] == selected {
+			b.WriteString(" selected")
+		}
+		fmt.Fprintf(&b, ">%s</option>", escape(options[i+1]))
+	}
+	b.WriteString("</select>")
+
+	return template.HTML(b.String()), nil
+}
+
+// attributeName matches the attribute names that are safe to write as they are.
+var attributeName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]*$`)
+
+func attributes(attrs []string) (string, error) {
+	if len(attrs)%2 != 0 {
+		return "", fmt.Errorf("attributes must be pairs of names and values, got %d", len(attrs))
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(attrs); i += 2 {
+		name, value := attrs[i], attrs[i+1]
+		if !attributeName.MatchString(name) {
+			return "", fmt.Errorf("invalid attribute name %q", name)
+		}
+		// Event handlers run the value as JavaScript, which isn't escaped.
+		if strings.HasPrefix(strings.ToLower(name), "on") {
+			return "", fmt.Errorf("event handler attribute %q isn't allowed", name)
+		}
+
+		b.WriteString(" " + name)
+		if value != "" {
+			fmt.Fprintf(&b, `="%s"`, escape(value))
+		}
+	}
+
+	return b.String(), nil
+}
+
+func escape(s string) string {
+	return template.HTMLEscapeString(s)
+}
@@ -0,0 +1,106 @@
+package ppform_test
+
+import (
+	"bytes"
+	"html/template"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppform"
+)
+
+func render(t *testing.T, tmpl string, form *ppform.Form) (string, error) {
+	t.Helper()
+
+	tmplt := template.Must(template.New("form").Funcs(ppform.FuncMap()).Parse(tmpl))
+	buf := new(bytes.Buffer)
+	err := tmplt.Execute(buf, map[string]any{"Form": form})
+
+	return buf.String(), err
+}
+
+func TestFuncMap(t *testing.T) {
+	submitted := ppform.New(url.Values{"email": {`"ada"@example`}, "country": {"no"}})
+	submitted.AddError("email", "must be a valid email")
+	submitted.AddError("email", "is already taken")
+
+	for _, tc := range []struct {
+		name        string
+		template    string
+		form        *ppform.Form
+		expected    string
+		expectedErr string
+	}{
+		{
+			name:     "renders a text input with the submitted value, escaped, and marked invalid",
+			template: `{{ inputText .Form "email" "placeholder" "you@example.com" "required" "" }}`,
+			form:     submitted,
+			expected: `<input type="text" id="email" name="email" value="&#34;ada&#34;@example" aria-invalid="true" placeholder="you@example.com" required>`,
+		},
+		{
+			name:     "renders an empty text input for a form that hasn't been submitted",
+			template: `{{ inputText .Form "name" }}`,
+			form:     nil,
+			expected: `<input type="text" id="name" name="name" value="">`,
+		},
+		{
+			name:     "renders a select with the submitted option selected",
+			template: `{{ select .Form "country" "se" "Sweden" "no" "Norway" }}`,
+			form:     submitted,
+			expected: `<select id="country" name="country"><option value="se">Sweden</option><option value="no" selected>Norway</option></select>`,
+		},
+		{
+			name:     "ranges over the errors of a field",
+			template: `{{ range errorsFor .Form "email" }}<p>{{ . }}</p>{{ end }}`,
+			form:     submitted,
+			expected: `<p>must be a valid email</p><p>is already taken</p>`,
+		},
+		{
+			name:     "returns the submitted value",
+			template: `{{ oldValue .Form "country" }}|{{ oldValue .Form "missing" }}`,
+			form:     submitted,
+			expected: `no|`,
+		},
+		{
+			name:        "fails on attributes that aren't pairs",
+			template:    `{{ inputText .Form "name" "placeholder" }}`,
+			expectedErr: `failed to render input "name": attributes must be pairs of names and values, got 1`,
+		},
+		{
+			name:        "fails on event handler attributes",
+			template:    `{{ inputText .Form "name" "onfocus" "alert(1)" }}`,
+			expectedErr: `failed to render input "name": event handler attribute "onfocus" isn't allowed`,
+		},
+		{
+			name:        "fails on invalid attribute names",
+			template:    `{{ inputText .Form "name" "a b" "c" }}`,
+			expectedErr: `failed to render input "name": invalid attribute name "a b"`,
+		},
+		{
+			name:        "fails on options that aren't pairs",
+			template:    `{{ select .Form "country" "se" }}`,
+			expectedErr: `failed to render select "country": options must be pairs of values and labels, got 1`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := render(t, tc.template, tc.form)
+
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestForm_Valid(t *testing.T) {
+	form := ppform.New(url.Values{})
+	require.True(t, form.Valid())
+
+	form.AddError("email", "is required")
+	require.False(t, form.Valid())
+}
@@ -0,0 +1,90 @@
+// Package pphtmx renders pages for htmx, https://htmx.org: the full page within its layout for regular requests, and
+// only the part that's swapped in for requests made by htmx.
+//
+// Define the parts of a page htmx targets as templates named after the id of the element they're swapped into:
+//
+//	<div id="reviews">{{ block "reviews" . }}{{ range .Reviews }}...{{ end }}{{ end }}</div>
+//
+// And render it with [Render], which renders only "reviews" for a request with "HX-Target: reviews":
+//
+//	err := pphtmx.Render(w, r, p, "layouts/base.tmpl", "reviews/index.tmpl", data)
+package pphtmx
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gaqzi/passepartout"
+)
+
+// IsRequest reports whether r was made by htmx, from its HX-Request header.
+func IsRequest(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true"
+}
+
+// IsBoosted reports whether r was made by an element with hx-boost, which swaps in the whole page.
+func IsBoosted(r *http.Request) bool {
+	return r.Header.Get("HX-Boosted") == "true"
+}
+
+// Target returns the id of the element the response of r is swapped into, from its HX-Target header.
+func Target(r *http.Request) string {
+	return r.Header.Get("HX-Target")
+}
+
+// Render renders name within layout for regular and boosted requests. For other requests made by htmx it renders the
+// template of name that's named after the [Target], or name standalone when there's no target or name has no template
+// with its name. The output is written as HTML, with "Vary: HX-Request" so caches keep the full page and the fragments
+// apart, and a failed render sends a plain text error.
+// A template rendered for the target is executed directly, without the error template, post-renders, and hooks of p.
+func Render(w http.ResponseWriter, r *http.Request, p *passepartout.Passepartout, layout, name string, data any) error {
+	w.Header().Add("Vary", "HX-Request")
+
+	buf := new(bytes.Buffer)
+	var err error
+	switch {
+	case !IsRequest(r) || IsBoosted(r):
+		err = p.RenderInLayoutContext(r.Context(), buf, layout, name, data)
+	case Target(r) != "":
+		err = renderTarget(r, p, buf, name, data)
+	default:
+		err = p.RenderContext(r.Context(), buf, name, data)
+	}
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err = buf.WriteTo(w)
+
+	return err
+}
+
+func renderTarget(r *http.Request, p *passepartout.Passepartout, buf *bytes.Buffer, name string, data any) error {
+	tmpl, err := p.Template(name)
+	if err != nil {
+		return err
+	}
+	if tmpl.Lookup(Target(r)) == nil {
+		return p.RenderContext(r.Context(), buf, name, data)
+	}
+
+	if err := tmpl.ExecuteTemplate(buf, Target(r), data); err != nil {
+		return fmt.Errorf("failed to render %q of %q: %w", Target(r), name, err)
+	}
+
+	return nil
+}
+
+// Trigger sets the HX-Trigger response header, so htmx triggers the events on the client, like "reviewAdded".
+func Trigger(w http.ResponseWriter, events ...string) {
+	w.Header().Set("HX-Trigger", strings.Join(events, ", "))
+}
+
+// PushURL sets the HX-Push-Url response header, so htmx pushes url into the browser's history.
+func PushURL(w http.ResponseWriter, url string) {
+	w.Header().Set("HX-Push-Url", url)
+}
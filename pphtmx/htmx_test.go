@@ -0,0 +1,82 @@
+package pphtmx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/pphtmx"
+)
+
+func TestRender(t *testing.T) {
+	pp, err := passepartout.LoadFrom(fstest.MapFS{
+		"layouts/base.tmpl":  {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"reviews/index.tmpl": {Data: []byte(`<h1>Reviews</h1><div id="reviews">{{ block "reviews" . }}{{ range . }}<p>{{ . }}</p>{{ end }}{{ end }}</div>`)},
+	})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		headers  map[string]string
+		expected string
+	}{
+		{
+			name:     "renders the page in the layout for regular requests",
+			expected: `<main><h1>Reviews</h1><div id="reviews"><p>Great</p></div></main>`,
+		},
+		{
+			name:     "renders the page in the layout for boosted requests",
+			headers:  map[string]string{"HX-Request": "true", "HX-Boosted": "true"},
+			expected: `<main><h1>Reviews</h1><div id="reviews"><p>Great</p></div></main>`,
+		},
+		{
+			name:     "renders only the template named after the target",
+			headers:  map[string]string{"HX-Request": "true", "HX-Target": "reviews"},
+			expected: `<p>Great</p>`,
+		},
+		{
+			name:     "renders the page standalone for a target without a template",
+			headers:  map[string]string{"HX-Request": "true", "HX-Target": "main"},
+			expected: `<h1>Reviews</h1><div id="reviews"><p>Great</p></div>`,
+		},
+		{
+			name:     "renders the page standalone without a target",
+			headers:  map[string]string{"HX-Request": "true"},
+			expected: `<h1>Reviews</h1><div id="reviews"><p>Great</p></div>`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/reviews", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+
+			require.NoError(t, pphtmx.Render(rec, req, pp, "layouts/base.tmpl", "reviews/index.tmpl", []string{"Great"}))
+			require.Equal(t, tc.expected, rec.Body.String())
+			require.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+			require.Equal(t, "HX-Request", rec.Header().Get("Vary"))
+		})
+	}
+
+	t.Run("sends an error when rendering fails", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		require.Error(t, pphtmx.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), pp, "layouts/base.tmpl", "missing.tmpl", nil))
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
+
+func TestResponseHeaders(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	pphtmx.Trigger(rec, "reviewAdded", "flash")
+	pphtmx.PushURL(rec, "/reviews?page=2")
+
+	require.Equal(t, "reviewAdded, flash", rec.Header().Get("HX-Trigger"))
+	require.Equal(t, "/reviews?page=2", rec.Header().Get("HX-Push-Url"))
+}
@@ -0,0 +1,114 @@
+package pphttp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// ETagMiddleware buffers the successful responses to GET and HEAD requests and sends them with a strong ETag of their
+// content, or a 304 Not Modified when the request's If-None-Match has the ETag, so a page that hasn't changed isn't
+// sent again. HEAD requests get the same ETag as GET as long as the handler writes the same body, which net/http
+// doesn't send.
+// cacheControl is the Cache-Control of the responses that don't set their own, like "no-cache" to always revalidate,
+// and none is set when it's empty. A response with its own ETag is compared with that ETag instead, and a response
+// that's flushed, like server-sent events, is streamed without an ETag.
+func ETagMiddleware(cacheControl string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ew := &etagWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(ew, r)
+			if ew.streaming {
+				return
+			}
+
+			header := w.Header()
+			if ew.status != http.StatusOK {
+				w.WriteHeader(ew.status)
+				_, _ = ew.buf.WriteTo(w)
+				return
+			}
+
+			if cacheControl != "" && header.Get("Cache-Control") == "" {
+				header.Set("Cache-Control", cacheControl)
+			}
+			etag := header.Get("ETag")
+			if etag == "" {
+				sum := sha256.Sum256(ew.buf.Bytes())
+				etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+				header.Set("ETag", etag)
+			}
+
+			if matchesETag(r.Header.Get("If-None-Match"), etag) {
+				header.Del("Content-Type")
+				header.Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			_, _ = ew.buf.WriteTo(w)
+		})
+	}
+}
+
+// matchesETag reports whether etag is in the If-None-Match header, which compares ETags weakly.
+func matchesETag(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// etagWriter buffers the response until it's flushed, after which everything is written to the ResponseWriter.
+type etagWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	streaming   bool
+	buf         bytes.Buffer
+}
+
+func (w *etagWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+
+	return w.buf.Write(b)
+}
+
+func (w *etagWriter) Flush() {
+	flusher, ok := w.ResponseWriter.(http.Flusher)
+	if !ok {
+		return
+	}
+
+	if !w.streaming {
+		w.streaming = true
+		w.ResponseWriter.WriteHeader(w.status)
+		_, _ = w.buf.WriteTo(w.ResponseWriter)
+	}
+	flusher.Flush()
+}
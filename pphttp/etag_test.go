@@ -0,0 +1,114 @@
+package pphttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/pphttp"
+)
+
+func TestETagMiddleware(t *testing.T) {
+	page := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte("<p>Hello</p>"))
+	}
+	serve := func(t *testing.T, handler http.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		pphttp.ETagMiddleware("no-cache")(handler).ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	t.Run("sends the response with an ETag of its content and the Cache-Control", func(t *testing.T) {
+		first := serve(t, page, httptest.NewRequest(http.MethodGet, "/", nil))
+		second := serve(t, page, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		require.Equal(t, http.StatusOK, first.Code)
+		require.Equal(t, "<p>Hello</p>", first.Body.String())
+		require.Regexp(t, `^"[0-9a-f]{32}"$`, first.Header().Get("ETag"))
+		require.Equal(t, first.Header().Get("ETag"), second.Header().Get("ETag"), "expected the same content to have the same ETag")
+		require.Equal(t, "no-cache", first.Header().Get("Cache-Control"))
+	})
+
+	t.Run("sends 304 Not Modified when the If-None-Match has the ETag", func(t *testing.T) {
+		etag := serve(t, page, httptest.NewRequest(http.MethodGet, "/", nil)).Header().Get("ETag")
+
+		for _, ifNoneMatch := range []string{etag, `"other", W/` + etag, "*"} {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("If-None-Match", ifNoneMatch)
+
+			rec := serve(t, page, req)
+
+			require.Equal(t, http.StatusNotModified, rec.Code, ifNoneMatch)
+			require.Empty(t, rec.Body.String())
+			require.Equal(t, etag, rec.Header().Get("ETag"))
+		}
+	})
+
+	t.Run("sends the same ETag and 304 Not Modified to HEAD requests", func(t *testing.T) {
+		etag := serve(t, page, httptest.NewRequest(http.MethodGet, "/", nil)).Header().Get("ETag")
+
+		head := serve(t, page, httptest.NewRequest(http.MethodHead, "/", nil))
+		req := httptest.NewRequest(http.MethodHead, "/", nil)
+		req.Header.Set("If-None-Match", etag)
+		notModified := serve(t, page, req)
+
+		require.Equal(t, http.StatusOK, head.Code)
+		require.Equal(t, etag, head.Header().Get("ETag"))
+		require.Equal(t, "no-cache", head.Header().Get("Cache-Control"))
+		require.Equal(t, http.StatusNotModified, notModified.Code)
+		require.Equal(t, etag, notModified.Header().Get("ETag"))
+	})
+
+	t.Run("sends the response when the If-None-Match has another ETag", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-None-Match", `"other"`)
+
+		rec := serve(t, page, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "<p>Hello</p>", rec.Body.String())
+	})
+
+	t.Run("keeps the ETag and Cache-Control set by the handler", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-None-Match", `"v1"`)
+
+		rec := serve(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Cache-Control", "max-age=60")
+			page(w, r)
+		}, req)
+
+		require.Equal(t, http.StatusNotModified, rec.Code)
+		require.Equal(t, "max-age=60", rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("sends other statuses and methods without an ETag", func(t *testing.T) {
+		notFound := serve(t, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "not found", http.StatusNotFound)
+		}, httptest.NewRequest(http.MethodGet, "/", nil))
+		post := serve(t, page, httptest.NewRequest(http.MethodPost, "/", nil))
+
+		require.Equal(t, http.StatusNotFound, notFound.Code)
+		require.Equal(t, "not found\n", notFound.Body.String())
+		require.Empty(t, notFound.Header().Get("ETag"))
+		require.Equal(t, http.StatusOK, post.Code)
+		require.Empty(t, post.Header().Get("ETag"))
+	})
+
+	t.Run("streams a flushed response without an ETag", func(t *testing.T) {
+		rec := serve(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("data: 1\n\n"))
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte("data: 2\n\n"))
+		}, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		require.True(t, rec.Flushed)
+		require.Equal(t, "data: 1\n\ndata: 2\n\n", rec.Body.String())
+		require.Empty(t, rec.Header().Get("ETag"))
+	})
+}
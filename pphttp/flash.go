@@ -0,0 +1,82 @@
+package pphttp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// FlashCookieName is the name of the cookie the flash messages are kept in between requests.
+const FlashCookieName = "flash"
+
+// maxFlashCookie is the size browsers are guaranteed to store for a cookie.
+const maxFlashCookie = 4096
+
+// Flash is a message shown once on the next page rendered, like "Your review was saved" after a redirect.
+type Flash struct {
+	// Kind is what kind of message it is, like "success" or "error", for styling it.
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+type flashesKey struct{}
+
+// WithFlashes returns a copy of ctx with the flash messages to render for the request, for flashes kept somewhere
+// else than the cookie of [SetFlashes], like a session.
+func WithFlashes(ctx context.Context, flashes []Flash) context.Context {
+	return context.WithValue(ctx, flashesKey{}, flashes)
+}
+
+// Flashes returns the flash messages set with [WithFlashes], or none.
+func Flashes(ctx context.Context) []Flash {
+	flashes, _ := ctx.Value(flashesKey{}).([]Flash)
+	return flashes
+}
+
+// SetFlashes keeps flashes in a cookie until the next request, where [FlashMiddleware] reads them.
+// The cookie isn't signed, so a user could change the messages shown to them, which are escaped when rendered.
+func SetFlashes(w http.ResponseWriter, r *http.Request, flashes ...Flash) error {
+	content, err := json.Marshal(flashes)
+	if err != nil {
+		return fmt.Errorf("failed to set flashes: %w", err)
+	}
+
+	cookie := &http.Cookie{
+		Name:     FlashCookieName,
+		Value:    base64.RawURLEncoding.EncodeToString(content),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if len(cookie.String()) > maxFlashCookie {
+		return fmt.Errorf("failed to set flashes: the cookie is larger than %d bytes", maxFlashCookie)
+	}
+	http.SetCookie(w, cookie)
+
+	return nil
+}
+
+// FlashMiddleware reads the flash messages set with [SetFlashes] into the request's context with [WithFlashes], and
+// removes the cookie so they're only shown once. A cookie that can't be read is removed and ignored.
+func FlashMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(FlashCookieName)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: FlashCookieName, Path: "/", MaxAge: -1, HttpOnly: true, Secure: r.TLS != nil, SameSite: http.SameSiteLaxMode})
+
+		var flashes []Flash
+		if content, err := base64.RawURLEncoding.DecodeString(cookie.Value); err == nil {
+			if err := json.Unmarshal(content, &flashes); err != nil {
+				flashes = nil
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithFlashes(r.Context(), flashes)))
+	})
+}
@@ -0,0 +1,75 @@
+package pphttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+	"github.com/gaqzi/passepartout/pphttp"
+)
+
+func TestFlashes(t *testing.T) {
+	pool := ppdefaults.NewTemplatePool(ppdefaults.NewLoaderBuilder().
+		WithDefaults(fstest.MapFS{
+			"page.tmpl": {Data: []byte(`{{ range flashes }}<p class="{{ .Kind }}">{{ .Message }}</p>{{ end }}`)},
+		}).
+		WithFuncs(pphttp.Placeholders()).
+		Build())
+	handler := pphttp.FlashMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tmpl, put, err := pool.Standalone(r.Context(), "page.tmpl", pphttp.Funcs(r.Context()))
+		require.NoError(t, err)
+		defer put()
+
+		require.NoError(t, tmpl.ExecuteTemplate(w, "page.tmpl", nil))
+	}))
+	flashCookie := func(t *testing.T, flashes ...pphttp.Flash) *http.Cookie {
+		rec := httptest.NewRecorder()
+		require.NoError(t, pphttp.SetFlashes(rec, httptest.NewRequest(http.MethodPost, "/reviews", nil), flashes...))
+
+		return rec.Result().Cookies()[0]
+	}
+
+	t.Run("renders the flashes set on the previous response once", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/reviews", nil)
+		req.AddCookie(flashCookie(t, pphttp.Flash{Kind: "success", Message: "Saved <b>review</b>"}))
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		require.Equal(t, `<p class="success">Saved &lt;b&gt;review&lt;/b&gt;</p>`, rec.Body.String())
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		require.Equal(t, pphttp.FlashCookieName, cookies[0].Name)
+		require.Equal(t, -1, cookies[0].MaxAge, "removes the cookie")
+	})
+
+	t.Run("renders nothing without flashes", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reviews", nil))
+
+		require.Empty(t, rec.Body.String())
+		require.Empty(t, rec.Result().Cookies())
+	})
+
+	t.Run("ignores a cookie that can't be read", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/reviews", nil)
+		req.AddCookie(&http.Cookie{Name: pphttp.FlashCookieName, Value: "not-json"})
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		require.Empty(t, rec.Body.String())
+	})
+
+	t.Run("fails to set flashes too large for a cookie", func(t *testing.T) {
+		err := pphttp.SetFlashes(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil), pphttp.Flash{Message: strings.Repeat("a", 4096)})
+
+		require.EqualError(t, err, "failed to set flashes: the cookie is larger than 4096 bytes")
+	})
+}
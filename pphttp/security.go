@@ -0,0 +1,103 @@
+// Package pphttp has the request-scoped helpers templates rendering HTML for a request need, like the CSRF form field
+// and the CSP nonce for inline scripts. The values are read from the request's context, where middleware put them,
+// and bound per request with [github.com/gaqzi/passepartout/ppdefaults.TemplatePool].
+package pphttp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+)
+
+// CSRFFieldName is the name of the form field rendered by csrfField.
+const CSRFFieldName = "csrf_token"
+
+type csrfTokenKey struct{}
+
+type nonceKey struct{}
+
+// WithCSRFToken returns a copy of ctx with the CSRF token of the request, for example from the CSRF middleware in use.
+func WithCSRFToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, csrfTokenKey{}, token)
+}
+
+// CSRFToken returns the token set with [WithCSRFToken], or an empty string.
+func CSRFToken(ctx context.Context) string {
+	token, _ := ctx.Value(csrfTokenKey{}).(string)
+	return token
+}
+
+// WithNonce returns a copy of ctx with the CSP nonce of the request.
+func WithNonce(ctx context.Context, nonce string) context.Context {
+	return context.WithValue(ctx, nonceKey{}, nonce)
+}
+
+// Nonce returns the nonce set with [WithNonce], or an empty string.
+func Nonce(ctx context.Context) string {
+	nonce, _ := ctx.Value(nonceKey{}).(string)
+	return nonce
+}
+
+// NonceMiddleware creates a random nonce for every request and sets it with [WithNonce] before calling next, which
+// is responsible for sending it in the Content-Security-Policy header with [Nonce].
+func NonceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithNonce(r.Context(), base64.StdEncoding.EncodeToString(nonce))))
+	})
+}
+
+// Funcs returns the funcs for a request, bound to the values in ctx:
+//   - csrfField renders a hidden input named [CSRFFieldName] with the CSRF token
+//   - csrfToken returns the CSRF token
+//   - nonce returns the CSP nonce, for <script nonce="{{ nonce }}">
+//   - flashes returns the flash messages, for {{ range flashes }}, see [FlashMiddleware]
+//
+// Except for flashes they return an error when the value isn't in ctx, so a missing middleware doesn't render a form
+// that always fails.
+func Funcs(ctx context.Context) template.FuncMap {
+	csrfToken := func() (string, error) {
+		if token := CSRFToken(ctx); token != "" {
+			return token, nil
+		}
+		return "", errors.New("no CSRF token in the request context, set it with pphttp.WithCSRFToken")
+	}
+
+	return template.FuncMap{
+		"csrfField": func() (template.HTML, error) {
+			token, err := csrfToken()
+			if err != nil {
+				return "", err
+			}
+
+			return template.HTML(fmt.Sprintf(
+				`<input type="hidden" name="%s" value="%s">`, CSRFFieldName, template.HTMLEscapeString(token),
+			)), nil
+		},
+		"csrfToken": csrfToken,
+		"nonce": func() (string, error) {
+			if nonce := Nonce(ctx); nonce != "" {
+				return nonce, nil
+			}
+			return "", errors.New("no CSP nonce in the request context, set it with pphttp.WithNonce")
+		},
+		"flashes": func() []Flash {
+			return Flashes(ctx)
+		},
+	}
+}
+
+// Placeholders are the funcs of [Funcs] without a request, to add to the TemplateConfig so templates using them can be
+// parsed before they're bound for a request. Rendering them returns an error.
+func Placeholders() template.FuncMap {
+	return Funcs(context.Background())
+}
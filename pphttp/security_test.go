@@ -0,0 +1,71 @@
+package pphttp_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+	"github.com/gaqzi/passepartout/pphttp"
+)
+
+func TestFuncs(t *testing.T) {
+	pool := ppdefaults.NewTemplatePool(ppdefaults.NewLoaderBuilder().
+		WithDefaults(fstest.MapFS{
+			"form.tmpl": {Data: []byte(`<form>{{ csrfField }}</form><script nonce="{{ nonce }}"></script>`)},
+		}).
+		WithFuncs(pphttp.Placeholders()).
+		Build())
+	render := func(ctx context.Context) (string, error) {
+		tmpl, put, err := pool.Standalone(ctx, "form.tmpl", pphttp.Funcs(ctx))
+		if err != nil {
+			return "", err
+		}
+		defer put()
+
+		out := new(bytes.Buffer)
+		err = tmpl.ExecuteTemplate(out, "form.tmpl", nil)
+		return out.String(), err
+	}
+
+	t.Run("renders the CSRF field and nonce from the context", func(t *testing.T) {
+		ctx := pphttp.WithNonce(pphttp.WithCSRFToken(context.Background(), `tok"en`), "abc123")
+
+		out, err := render(ctx)
+
+		require.NoError(t, err)
+		require.Equal(t, `<form><input type="hidden" name="csrf_token" value="tok&#34;en"></form><script nonce="abc123"></script>`, out)
+	})
+
+	t.Run("returns an error when the CSRF token isn't in the context", func(t *testing.T) {
+		_, err := render(pphttp.WithNonce(context.Background(), "abc123"))
+
+		require.ErrorContains(t, err, "no CSRF token in the request context")
+	})
+
+	t.Run("returns an error when the nonce isn't in the context", func(t *testing.T) {
+		_, err := render(pphttp.WithCSRFToken(context.Background(), "token"))
+
+		require.ErrorContains(t, err, "no CSP nonce in the request context")
+	})
+}
+
+func TestNonceMiddleware(t *testing.T) {
+	var nonces []string
+	handler := pphttp.NonceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonces = append(nonces, pphttp.Nonce(r.Context()))
+	}))
+
+	for range 2 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	require.Len(t, nonces, 2)
+	require.Len(t, nonces[0], 24, "expected 16 random bytes in base64")
+	require.NotEqual(t, nonces[0], nonces[1])
+}
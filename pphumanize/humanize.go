@@ -0,0 +1,243 @@
+// Package pphumanize formats numbers, sizes, and times for people, in the format of their locale, so templates don't
+// need ad-hoc formatting logic.
+//
+// Register the funcs on the base template, see [ppdefaults.Loader.TemplateConfig]:
+//
+//	loader := ppdefaults.NewLoaderBuilder().
+//		WithDefaults(fsys).
+//		WithFuncs(pphumanize.New("en").FuncMap()).
+//		Build()
+//
+// And then use them in templates, with the locale of the request as the last argument, for example from the global
+// data of [github.com/gaqzi/passepartout.WithGlobalData], or leave it out for the default locale:
+//
+//	{{ formatNumber .Count .Locale }} reviews, {{ humanizeBytes .Size .Locale }}, {{ timeAgo .CreatedAt .Locale }}
+package pphumanize
+
+import (
+	"fmt"
+	"html/template"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Unit is a unit of time used by [Humanizer.TimeAgo].
+type Unit int
+
+const (
+	Second Unit = iota
+	Minute
+	Hour
+	Day
+	Week
+	Month
+	Year
+)
+
+// Locale is how numbers and times are written in a language.
+type Locale struct {
+	// Decimal separates the decimals, and Group the groups of thousands.
+	Decimal, Group string
+	// JustNow is a time less than a second away.
+	JustNow string
+	// Ago and In format a time in the past and in the future, like "%s ago" and "in %s".
+	Ago, In string
+	// Units are the singular and plural of each [Unit], like "minute" and "minutes".
+	Units [Year + 1][2]string
+}
+
+// Locales are the locales known by default, by their language.
+var Locales = map[string]Locale{
+	"en": {
+		Decimal: ".", Group: ",", JustNow: "just now", Ago: "%s ago", In: "in %s",
+		Units: [...][2]string{{"second", "seconds"}, {"minute", "minutes"}, {"hour", "hours"}, {"day", "days"}, {"week", "weeks"}, {"month", "months"}, {"year", "years"}},
+	},
+	"sv": {
+		Decimal: ",", Group: "\u00a0", JustNow: "nyss", Ago: "för %s sedan", In: "om %s",
+		Units: [...][2]string{{"sekund", "sekunder"}, {"minut", "minuter"}, {"timme", "timmar"}, {"dag", "dagar"}, {"vecka", "veckor"}, {"månad", "månader"}, {"år", "år"}},
+	},
+	"de": {
+		Decimal: ",", Group: ".", JustNow: "gerade eben", Ago: "vor %s", In: "in %s",
+		Units: [...][2]string{{"Sekunde", "Sekunden"}, {"Minute", "Minuten"}, {"Stunde", "Stunden"}, {"Tag", "Tagen"}, {"Woche", "Wochen"}, {"Monat", "Monaten"}, {"Jahr", "Jahren"}},
+	},
+	"fr": {
+		Decimal: ",", Group: "\u202f", JustNow: "à l'instant", Ago: "il y a %s", In: "dans %s",
+		Units: [...][2]string{{"seconde", "secondes"}, {"minute", "minutes"}, {"heure", "heures"}, {"jour", "jours"}, {"semaine", "semaines"}, {"mois", "mois"}, {"an", "ans"}},
+	},
+	"es": {
+		Decimal: ",", Group: ".", JustNow: "justo ahora", Ago: "hace %s", In: "dentro de %s",
+		Units: [...][2]string{{"segundo", "segundos"}, {"minuto", "minutos"}, {"hora", "horas"}, {"día", "días"}, {"semana", "semanas"}, {"mes", "meses"}, {"año", "años"}},
+	},
+}
+
+// Humanizer formats for the locales in Locales.
+type Humanizer struct {
+	// Locale is used when a func isn't given one, or is given one that isn't known.
+	Locale string
+	// Locales are the locales by their language, or by their full tag like "en-GB" to override the language's.
+	Locales map[string]Locale
+	// Now is the time [Humanizer.TimeAgo] is relative to, [time.Now] when nil.
+	Now func() time.Time
+}
+
+// New returns a Humanizer for the default [Locales], using locale when a func isn't given one.
+func New(locale string) *Humanizer {
+	return &Humanizer{Locale: locale, Locales: maps.Clone(Locales)}
+}
+
+// locale finds the locale of tag, like "sv-SE", by the full tag and then by its language, and falls back on the default
+// locale and then on English.
+func (h *Humanizer) locale(tag []string) Locale {
+	for _, name := range slices.Concat(tag, []string{h.Locale}) {
+		name = strings.ReplaceAll(name, "_", "-")
+		if l, ok := h.Locales[name]; ok {
+			return l
+		}
+		language, _, _ := strings.Cut(name, "-")
+		if l, ok := h.Locales[strings.ToLower(language)]; ok {
+			return l
+		}
+	}
+
+	return Locales["en"]
+}
+
+// FormatNumber writes n with the separators of the locale, like "1,234,567.89", with at most two decimals.
+func (h *Humanizer) FormatNumber(n any, locale ...string) (string, error) {
+	l := h.locale(locale)
+	v := reflect.ValueOf(n)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return formatInt(v.Int(), l), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return group(strconv.FormatUint(v.Uint(), 10), l.Group), nil
+	case reflect.Float32, reflect.Float64:
+		return formatFloat(v.Float(), 2, l), nil
+	default:
+		return "", fmt.Errorf("failed to format number: expected a number, got %T", n)
+	}
+}
+
+// HumanizeBytes writes the size n in bytes with the largest unit it's at least one of, like "1.5 MB", in powers of
+// 1000 with one decimal.
+func (h *Humanizer) HumanizeBytes(n int64, locale ...string) string {
+	l := h.locale(locale)
+	if n < 1000 && n > -1000 {
+		return formatInt(n, l) + " B"
+	}
+
+	units := []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+	size, unit := float64(n), 0
+	// Rounded like it's formatted, so 999,950 bytes is "1 MB" rather than "1000 kB".
+	for math.Abs(math.Round(size*10)/10) >= 1000 && unit < len(units)-1 {
+		size /= 1000
+		unit++
+	}
+
+	return formatFloat(size, 1, l) + " " + units[unit]
+}
+
+// TimeAgo writes how long ago t was, like "5 minutes ago", or how long until it is, like "in 3 days", in the largest
+// whole unit.
+func (h *Humanizer) TimeAgo(t time.Time, locale ...string) string {
+	l := h.locale(locale)
+	now := time.Now
+	if h.Now != nil {
+		now = h.Now
+	}
+
+	d := now().Sub(t)
+	format := l.Ago
+	if d < 0 {
+		d, format = -d, l.In
+	}
+	if d < time.Second {
+		return l.JustNow
+	}
+
+	unit, count := Second, int64(d/time.Second)
+	for _, u := range []struct {
+		unit Unit
+		size time.Duration
+	}{
+		{Year, 365 * 24 * time.Hour},
+		{Month, 30 * 24 * time.Hour},
+		{Week, 7 * 24 * time.Hour},
+		{Day, 24 * time.Hour},
+		{Hour, time.Hour},
+		{Minute, time.Minute},
+	} {
+		if d >= u.size {
+			unit, count = u.unit, int64(d/u.size)
+			break
+		}
+	}
+
+	name := l.Units[unit][1]
+	if count == 1 {
+		name = l.Units[unit][0]
+	}
+
+	return fmt.Sprintf(format, strconv.FormatInt(count, 10)+" "+name)
+}
+
+// FuncMap returns the "formatNumber", "humanizeBytes", and "timeAgo" funcs for use with [template.Template.Funcs].
+func (h *Humanizer) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"formatNumber":  h.FormatNumber,
+		"humanizeBytes": h.HumanizeBytes,
+		"timeAgo":       h.TimeAgo,
+	}
+}
+
+func formatInt(n int64, l Locale) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	return sign + group(digits, l.Group)
+}
+
+// formatFloat writes n with at most decimals decimals, leaving out trailing zeros.
+func formatFloat(n float64, decimals int, l Locale) string {
+	formatted := strconv.FormatFloat(n, 'f', decimals, 64)
+	sign := ""
+	if strings.HasPrefix(formatted, "-") {
+		sign, formatted = "-", formatted[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(formatted, ".")
+	fraction = strings.TrimRight(fraction, "0")
+	if fraction == "" {
+		return sign + group(whole, l.Group)
+	}
+
+	return sign + group(whole, l.Group) + l.Decimal + fraction
+}
+
+// group separates the digits into groups of three with sep.
+func group(digits, sep string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	var b strings.Builder
+	first := len(digits) % 3
+	if first == 0 {
+		first = 3
+	}
+	b.WriteString(digits[:first])
+	for i := first; i < len(digits); i += 3 {
+		b.WriteString(sep)
+		b.WriteString(digits[i : i+3])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package pphumanize_test
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/pphumanize"
+)
+
+func TestHumanizer_FormatNumber(t *testing.T) {
+	h := pphumanize.New("en")
+
+	for _, tc := range []struct {
+		name     string
+		n        any
+		locale   []string
+		expected string
+	}{
+		{name: "groups the thousands", n: 1234567, expected: "1,234,567"},
+		{name: "keeps small numbers as they are", n: 123, expected: "123"},
+		{name: "groups negative numbers", n: int64(-1234), expected: "-1,234"},
+		{name: "rounds to two decimals", n: 1234.5678, expected: "1,234.57"},
+		{name: "leaves out trailing zeros", n: 12.5, expected: "12.5"},
+		{name: "formats every kind of integer", n: int8(-12), expected: "-12"},
+		{name: "formats small unsigned integers", n: uint16(65535), expected: "65,535"},
+		{name: "formats named number types", n: time.Duration(1500), expected: "1,500"},
+		{name: "groups unsigned numbers", n: uint64(18446744073709551615), expected: "18,446,744,073,709,551,615"},
+		{name: "uses the separators of the locale", n: 1234567.5, locale: []string{"de"}, expected: "1.234.567,5"},
+		{name: "finds the locale by its language", n: 1234.5, locale: []string{"sv-SE"}, expected: "1\u00a0234,5"},
+		{name: "falls back on the default locale", n: 1234.5, locale: []string{"xx"}, expected: "1,234.5"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := h.FormatNumber(tc.n, tc.locale...)
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+
+	t.Run("fails for values that aren't numbers", func(t *testing.T) {
+		_, err := h.FormatNumber("12")
+
+		require.EqualError(t, err, "failed to format number: expected a number, got string")
+	})
+}
+
+func TestHumanizer_HumanizeBytes(t *testing.T) {
+	h := pphumanize.New("en")
+
+	for _, tc := range []struct {
+		n        int64
+		locale   string
+		expected string
+	}{
+		{n: 999, expected: "999 B"},
+		{n: 1000, expected: "1 kB"},
+		{n: 999_949, expected: "999.9 kB"},
+		{n: 999_950, expected: "1 MB"},
+		{n: 1_500_000, expected: "1.5 MB"},
+		{n: 1_500_000, locale: "fr", expected: "1,5 MB"},
+		{n: 3_210_000_000_000, expected: "3.2 TB"},
+	} {
+		t.Run(tc.expected, func(t *testing.T) {
+			require.Equal(t, tc.expected, h.HumanizeBytes(tc.n, tc.locale))
+		})
+	}
+}
+
+func TestHumanizer_TimeAgo(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	h := pphumanize.New("en")
+	h.Now = func() time.Time { return now }
+
+	for _, tc := range []struct {
+		t        time.Time
+		locale   string
+		expected string
+	}{
+		{t: now, expected: "just now"},
+		{t: now.Add(-time.Second), expected: "1 second ago"},
+		{t: now.Add(-5 * time.Minute), expected: "5 minutes ago"},
+		{t: now.Add(-25 * time.Hour), expected: "1 day ago"},
+		{t: now.Add(-15 * 24 * time.Hour), expected: "2 weeks ago"},
+		{t: now.AddDate(-3, 0, 0), expected: "3 years ago"},
+		{t: now.Add(3 * time.Hour), expected: "in 3 hours"},
+		{t: now.Add(-2 * time.Hour), locale: "sv", expected: "för 2 timmar sedan"},
+		{t: now.Add(48 * time.Hour), locale: "es", expected: "dentro de 2 días"},
+	} {
+		t.Run(tc.expected, func(t *testing.T) {
+			require.Equal(t, tc.expected, h.TimeAgo(tc.t, tc.locale))
+		})
+	}
+
+	t.Run("uses the locales added to the Humanizer", func(t *testing.T) {
+		h := pphumanize.New("en-GB")
+		h.Now = func() time.Time { return now }
+		gb := h.Locales["en"]
+		gb.JustNow = "right now"
+		h.Locales["en-GB"] = gb
+
+		require.Equal(t, "right now", h.TimeAgo(now))
+		require.Equal(t, "just now", pphumanize.New("en").TimeAgo(time.Now()), "doesn't change the default locales")
+	})
+}
+
+func TestHumanizer_FuncMap(t *testing.T) {
+	h := pphumanize.New("en")
+	tmpl := template.Must(template.New("").Funcs(h.FuncMap()).
+		Parse(`{{ formatNumber .Count .Locale }} reviews, {{ humanizeBytes .Size .Locale }}, {{ formatNumber .Count }}`))
+	out := new(bytes.Buffer)
+
+	require.NoError(t, tmpl.Execute(out, map[string]any{"Count": 12345, "Size": int64(2_500_000), "Locale": "de"}))
+	require.Equal(t, `12.345 reviews, 2,5 MB, 12,345`, out.String())
+}
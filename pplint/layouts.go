@@ -0,0 +1,111 @@
+// Package pplint finds problems in templates without rendering them.
+package pplint
+
+import (
+	"fmt"
+
+	"github.com/gaqzi/passepartout/internal/ppparse"
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// Finding is a single problem found when linting.
+type Finding struct {
+	// Rule is the rule that found the problem.
+	Rule Rule
+	Page string
+	// Line is where in Page the problem is, 0 when it's about the whole template.
+	Line    int
+	Layout  string
+	Message string
+}
+
+func (f Finding) String() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", f.Page, f.Line, f.Message)
+	}
+	if f.Layout == "" {
+		return fmt.Sprintf("%s: %s", f.Page, f.Message)
+	}
+
+	return fmt.Sprintf("%s in %s: %s", f.Page, f.Layout, f.Message)
+}
+
+// Combination is a page rendered within a layout.
+type Combination struct {
+	Page   string
+	Layout string
+}
+
+// Matrix returns every page in every layout.
+func Matrix(pages, layouts []string) []Combination {
+	combinations := make([]Combination, 0, len(pages)*len(layouts))
+	for _, layout := range layouts {
+		for _, page := range pages {
+			combinations = append(combinations, Combination{Page: page, Layout: layout})
+		}
+	}
+
+	return combinations
+}
+
+// InLayoutFileLoader collects the files for a page in a layout, implemented by [ppdefaults.Loader].
+type InLayoutFileLoader interface {
+	InLayoutFiles(page string, layout string) ([]ppdefaults.FileWithContent, error)
+}
+
+// LayoutCompatibility loads each combination and reports the ones where the page's and layout's blocks don't line up:
+//   - the page defines a block that the layout never renders, for example after the layout's block was renamed
+//   - the layout, or a page or partial rendered by it, uses a template that isn't defined anywhere
+//
+// Combinations that fail to load or parse are reported as findings too.
+func LayoutCompatibility(loader InLayoutFileLoader, combinations []Combination) []Finding {
+	var findings []Finding
+	for _, c := range combinations {
+		report := func(rule Rule, format string, args ...any) {
+			findings = append(findings, Finding{Rule: rule, Page: c.Page, Layout: c.Layout, Message: fmt.Sprintf(format, args...)})
+		}
+
+		files, err := loader.InLayoutFiles(c.Page, c.Layout)
+		if err != nil {
+			report(RuleLoad, "%s", err)
+			continue
+		}
+
+		set, err := parseAll(files)
+		if err != nil {
+			report(RuleLoad, "%s", err)
+			continue
+		}
+
+		rendered := make(map[string]struct{})
+		for _, name := range set.Reachable(c.Layout) {
+			rendered[name] = struct{}{}
+			if !set.Defined(name) {
+				report(RuleUndefined, "template %q is used but never defined", name)
+			}
+		}
+
+		if page := set.File(c.Page); page != nil {
+			for _, name := range page.Defines() {
+				if _, ok := rendered[name]; !ok {
+					report(RuleUnrenderedBlock, "page defines %q but the layout never renders it", name)
+				}
+			}
+		}
+	}
+
+	return findings
+}
+
+func parseAll(files []ppdefaults.FileWithContent) (*ppparse.Set, error) {
+	parsed := make([]*ppparse.File, 0, len(files))
+	for _, f := range files {
+		p, err := ppparse.Parse(f.Name, f.Content)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, p)
+	}
+
+	return ppparse.NewSet(parsed...), nil
+}
@@ -0,0 +1,85 @@
+package pplint_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+	"github.com/gaqzi/passepartout/pplint"
+)
+
+func TestMatrix(t *testing.T) {
+	actual := pplint.Matrix([]string{"index.tmpl", "show.tmpl"}, []string{"layouts/a.tmpl", "layouts/b.tmpl"})
+
+	require.Equal(t, []pplint.Combination{
+		{Page: "index.tmpl", Layout: "layouts/a.tmpl"},
+		{Page: "show.tmpl", Layout: "layouts/a.tmpl"},
+		{Page: "index.tmpl", Layout: "layouts/b.tmpl"},
+		{Page: "show.tmpl", Layout: "layouts/b.tmpl"},
+	}, actual)
+}
+
+func TestLayoutCompatibility(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		fs       fstest.MapFS
+		expected []pplint.Finding
+	}{
+		{
+			name: "a page whose blocks are all rendered by the layout has no findings",
+			fs: fstest.MapFS{
+				"layouts/default.tmpl": {Data: []byte(`{{ block "title" . }}{{ end }} {{ block "content" . }}{{ end }}`)},
+				"index.tmpl":           {Data: []byte(`body`)},
+			},
+			expected: nil,
+		},
+		{
+			name: "a page defining a block the layout doesn't render is reported",
+			fs: fstest.MapFS{
+				"layouts/default.tmpl": {Data: []byte(`{{ block "main" . }}{{ end }}`)},
+				"index.tmpl":           {Data: []byte(`body`)},
+			},
+			expected: []pplint.Finding{
+				{Rule: pplint.RuleUnrenderedBlock, Page: "index.tmpl", Layout: "layouts/default.tmpl", Message: `page defines "content" but the layout never renders it`},
+			},
+		},
+		{
+			name: "a layout using a template that isn't defined is reported",
+			fs: fstest.MapFS{
+				"layouts/default.tmpl": {Data: []byte(`{{ template "header" . }}{{ block "content" . }}{{ end }}`)},
+				"index.tmpl":           {Data: []byte(`body`)},
+			},
+			expected: []pplint.Finding{
+				{Rule: pplint.RuleUndefined, Page: "index.tmpl", Layout: "layouts/default.tmpl", Message: `template "header" is used but never defined`},
+			},
+		},
+		{
+			name: "blocks rendered through the layout's partials count as rendered",
+			fs: fstest.MapFS{
+				"layouts/default.tmpl":       {Data: []byte(`{{ template "layouts/default/_main.tmpl" . }}`)},
+				"layouts/default/_main.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+				"index.tmpl":                 {Data: []byte(`body`)},
+			},
+			expected: nil,
+		},
+		{
+			name: "a combination that fails to load is reported",
+			fs: fstest.MapFS{
+				"index.tmpl": {Data: []byte(`body`)},
+			},
+			expected: []pplint.Finding{
+				{Rule: pplint.RuleLoad, Page: "index.tmpl", Layout: "layouts/default.tmpl", Message: `failed to collect all for "index.tmpl" in layout "layouts/default.tmpl": failed to read layout template: open layouts/default.tmpl: file does not exist`},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			loader := ppdefaults.NewLoaderBuilder().WithDefaults(tc.fs).Build()
+
+			actual := pplint.LayoutCompatibility(loader, pplint.Matrix([]string{"index.tmpl"}, []string{"layouts/default.tmpl"}))
+
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package pplint
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+	"unicode"
+
+	"github.com/gaqzi/passepartout/internal/ppparse"
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// Rule names a check that [Lint] can run, and the check that found a [Finding].
+type Rule string
+
+const (
+	// RuleLoad is a template that failed to load or parse, always reported.
+	RuleLoad Rule = "load"
+	// RuleUndefined is a template that's used but never defined.
+	RuleUndefined Rule = "undefined"
+	// RuleUnusedPartial is a partial that no page or layout uses.
+	RuleUnusedPartial Rule = "unused-partial"
+	// RuleUnusedDefine is a template defined in a partial or layout that is never used. The defines of pages aren't
+	// reported, they're blocks for the layouts, see [LayoutCompatibility].
+	RuleUnusedDefine Rule = "unused-define"
+	// RuleMissingContent is a layout that never renders the block the pages are rendered as, "content" unless the loader
+	// says otherwise, like [ppdefaults.Loader] with the ContentBlockName of [ppdefaults.TemplateByNameLoader].
+	RuleMissingContent Rule = "missing-content"
+	// RuleRawHTML is a call to a func that by convention marks its input as safe, so html/template won't escape it.
+	RuleRawHTML Rule = "raw-html"
+	// RuleNaming is a template named with a different style, like snake_case, than most templates.
+	RuleNaming Rule = "naming"
+	// RuleUnrenderedBlock is a block defined by a page that the layout never renders, see [LayoutCompatibility].
+	RuleUnrenderedBlock Rule = "unrendered-block"
+)
+
+// Rules are the rules run by [Lint] when none are given.
+var Rules = []Rule{RuleUndefined, RuleUnusedPartial, RuleUnusedDefine, RuleMissingContent, RuleRawHTML, RuleNaming}
+
+// rawFuncs are the names commonly given to funcs that return their input as template.HTML and friends.
+var rawFuncs = map[string]struct{}{
+	"safeHTML":     {},
+	"safeHTMLAttr": {},
+	"safeCSS":      {},
+	"safeJS":       {},
+	"safeURL":      {},
+	"raw":          {},
+	"unescaped":    {},
+}
+
+// ParseRules parses a comma separated list of rules, like "unused-define,naming".
+func ParseRules(s string) ([]Rule, error) {
+	var rules []Rule
+	for _, name := range strings.Split(s, ",") {
+		rule := Rule(strings.TrimSpace(name))
+		if rule == "" {
+			continue
+		}
+		if !rule.known() {
+			return nil, fmt.Errorf("failed to parse rules: unknown rule %q", rule)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func (r Rule) known() bool {
+	for _, rule := range Rules {
+		if r == rule {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Lint loads every page and layout in templates, telling them apart with [ppdefaults.KindOf], and reports what the
+// rules find, running all [Rules] when none are given. The findings of [Strict] come first, followed by the findings
+// of each rule in the order of [Rules].
+func Lint(loader StandaloneFileLoader, templates []string, rules ...Rule) []Finding {
+	if len(rules) == 0 {
+		rules = Rules
+	}
+	enabled := make(map[Rule]bool, len(rules))
+	for _, rule := range rules {
+		enabled[rule] = true
+	}
+
+	var findings []Finding
+	for _, f := range Strict(loader, templates) {
+		if f.Rule == RuleLoad || enabled[f.Rule] {
+			findings = append(findings, f)
+		}
+	}
+
+	// Templates that fail to load were reported by Strict.
+	sets := make(map[string]*ppparse.Set)
+	for _, name := range templates {
+		if ppdefaults.KindOf(name) == ppdefaults.KindPartial {
+			continue
+		}
+
+		files, err := templateFiles(loader, name)
+		if err != nil {
+			continue
+		}
+		if set, err := parseAll(files); err == nil {
+			sets[name] = set
+		}
+	}
+
+	if enabled[RuleUnusedDefine] {
+		findings = append(findings, unusedDefines(templates, sets)...)
+	}
+	if enabled[RuleMissingContent] {
+		findings = append(findings, missingContent(templates, sets, contentBlock(loader))...)
+	}
+	if enabled[RuleRawHTML] {
+		findings = append(findings, rawHTML(templates, sets)...)
+	}
+	if enabled[RuleNaming] {
+		findings = append(findings, naming(templates)...)
+	}
+
+	return findings
+}
+
+func unusedDefines(templates []string, sets map[string]*ppparse.Set) []Finding {
+	files := make(map[string]*ppparse.File)
+	usedFiles := make(map[string]struct{})
+	used := make(map[[2]string]struct{})
+	for _, name := range templates {
+		set, ok := sets[name]
+		if !ok {
+			continue
+		}
+
+		definedIn := make(map[string]string)
+		for _, f := range set.Files {
+			files[f.Name] = f
+			for tmpl := range f.Trees {
+				definedIn[tmpl] = f.Name
+			}
+		}
+		for _, tmpl := range append(set.Reachable(name), name) {
+			if file, ok := definedIn[tmpl]; ok {
+				usedFiles[file] = struct{}{}
+				used[[2]string{file, tmpl}] = struct{}{}
+			}
+		}
+	}
+
+	var findings []Finding
+	for _, name := range sortedNames(files) {
+		// Pages define blocks for layouts, and partials that aren't used at all are reported as unused partials.
+		if _, ok := usedFiles[name]; !ok || ppdefaults.KindOf(name) == ppdefaults.KindPage {
+			continue
+		}
+
+		for _, define := range files[name].Defines() {
+			if _, ok := used[[2]string{name, define}]; !ok {
+				findings = append(findings, Finding{Rule: RuleUnusedDefine, Page: name, Message: fmt.Sprintf("template %q is defined but never used", define)})
+			}
+		}
+	}
+
+	return findings
+}
+
+// contentBlockLoader is implemented by loaders that know the block of the layouts the pages are defined as, like
+// [ppdefaults.Loader].
+type contentBlockLoader interface {
+	ContentBlock() string
+}
+
+func contentBlock(loader StandaloneFileLoader) string {
+	if l, ok := loader.(contentBlockLoader); ok {
+		return l.ContentBlock()
+	}
+
+	return "content"
+}
+
+func missingContent(templates []string, sets map[string]*ppparse.Set, block string) []Finding {
+	var findings []Finding
+	for _, name := range templates {
+		set, ok := sets[name]
+		if !ok || ppdefaults.KindOf(name) != ppdefaults.KindLayout {
+			continue
+		}
+
+		if !slices.Contains(set.Reachable(name), block) {
+			findings = append(findings, Finding{Rule: RuleMissingContent, Page: name, Message: fmt.Sprintf("layout never renders the %q block the pages are rendered as", block)})
+		}
+	}
+
+	return findings
+}
+
+func rawHTML(templates []string, sets map[string]*ppparse.Set) []Finding {
+	files := make(map[string]*ppparse.File)
+	for _, name := range templates {
+		if set, ok := sets[name]; ok {
+			for _, f := range set.Files {
+				files[f.Name] = f
+			}
+		}
+	}
+
+	var findings []Finding
+	for _, name := range sortedNames(files) {
+		var found []Finding
+		for _, tree := range files[name].Trees {
+			ppparse.Walk(tree.Root, func(node parse.Node) {
+				ident, ok := node.(*parse.IdentifierNode)
+				if !ok {
+					return
+				}
+				if _, raw := rawFuncs[ident.Ident]; raw {
+					found = append(found, Finding{Rule: RuleRawHTML, Page: name, Line: line(tree, node), Message: fmt.Sprintf("%s marks its input as safe so it isn't escaped, make sure the input can be trusted", ident.Ident)})
+				}
+			})
+		}
+		sort.SliceStable(found, func(i, j int) bool { return found[i].Line < found[j].Line })
+		findings = append(findings, found...)
+	}
+
+	return findings
+}
+
+func line(tree *parse.Tree, node parse.Node) int {
+	location, _ := tree.ErrorContext(node)
+	// The location is "name:line:column".
+	parts := strings.Split(location, ":")
+	if len(parts) < 3 {
+		return 0
+	}
+	n, _ := strconv.Atoi(parts[len(parts)-2])
+
+	return n
+}
+
+// naming reports the templates whose name is in a different style than most of the templates, where a style is a
+// separator between words, "-" or "_", or camelCase, and the names mixing styles. Names of a single lowercase word fit
+// every style.
+func naming(templates []string) []Finding {
+	styles := make(map[string][]string, len(templates))
+	counts := make(map[string]int)
+	for _, name := range templates {
+		if style := nameStyles(name); len(style) > 0 {
+			styles[name] = style
+			if len(style) == 1 {
+				counts[style[0]]++
+			}
+		}
+	}
+
+	common := ""
+	for _, style := range []string{"kebab-case", "snake_case", "camelCase"} {
+		if counts[style] > counts[common] {
+			common = style
+		}
+	}
+
+	var findings []Finding
+	for _, name := range templates {
+		style, ok := styles[name]
+		switch {
+		case !ok:
+		case len(style) > 1:
+			findings = append(findings, Finding{Rule: RuleNaming, Page: name, Message: "name mixes " + strings.Join(style, " and ")})
+		case style[0] != common:
+			findings = append(findings, Finding{Rule: RuleNaming, Page: name, Message: fmt.Sprintf("name is %s while most templates are %s", style[0], common)})
+		}
+	}
+
+	return findings
+}
+
+func nameStyles(name string) []string {
+	base := strings.TrimPrefix(path.Base(name), "_")
+	if i := strings.Index(base, "."); i >= 0 {
+		base = base[:i]
+	}
+
+	var styles []string
+	if strings.Contains(base, "-") {
+		styles = append(styles, "kebab-case")
+	}
+	if strings.Contains(base, "_") {
+		styles = append(styles, "snake_case")
+	}
+	if strings.IndexFunc(base, unicode.IsUpper) >= 0 {
+		styles = append(styles, "camelCase")
+	}
+
+	return styles
+}
+
+func sortedNames(files map[string]*ppparse.File) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
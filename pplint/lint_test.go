@@ -0,0 +1,143 @@
+package pplint_test
+
+import (
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+	"github.com/gaqzi/passepartout/pplint"
+)
+
+func TestLint(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		fs       fstest.MapFS
+		loader   func(fsys fstest.MapFS) *ppdefaults.LoaderBuilder
+		rules    []pplint.Rule
+		expected []pplint.Finding
+	}{
+		{
+			name: "reports nothing for templates without problems",
+			fs: fstest.MapFS{
+				"index.tmpl":                {Data: []byte(`{{ define "title" }}Home{{ end }}{{ template "index/_item.tmpl" . }}`)},
+				"index/_item.tmpl":          {Data: []byte(`{{ define "item" }}item{{ end }}{{ template "item" }}`)},
+				"layouts/default.tmpl":      {Data: []byte(`{{ template "layouts/default/_nav.tmpl" }}{{ block "content" . }}{{ end }}`)},
+				"layouts/default/_nav.tmpl": {Data: []byte(`nav`)},
+			},
+		},
+		{
+			name: "reports defines in partials and layouts that are never used",
+			fs: fstest.MapFS{
+				"index.tmpl":           {Data: []byte(`{{ template "index/_item.tmpl" . }}`)},
+				"index/_item.tmpl":     {Data: []byte(`{{ define "item" }}item{{ end }}{{ define "old" }}old{{ end }}{{ template "item" }}`)},
+				"layouts/default.tmpl": {Data: []byte(`{{ define "footer" }}{{ end }}{{ block "content" . }}{{ end }}`)},
+			},
+			expected: []pplint.Finding{
+				{Rule: pplint.RuleUnusedDefine, Page: "index/_item.tmpl", Message: `template "old" is defined but never used`},
+				{Rule: pplint.RuleUnusedDefine, Page: "layouts/default.tmpl", Message: `template "footer" is defined but never used`},
+			},
+		},
+		{
+			name: "reports layouts without a content block",
+			fs: fstest.MapFS{
+				"layouts/default.tmpl": {Data: []byte(`{{ block "main" . }}{{ end }}`)},
+			},
+			expected: []pplint.Finding{
+				{Rule: pplint.RuleMissingContent, Page: "layouts/default.tmpl", Message: `layout never renders the "content" block the pages are rendered as`},
+			},
+		},
+		{
+			name: "reports layouts without the ContentBlockName of the template loader",
+			fs: fstest.MapFS{
+				"layouts/default.tmpl": {Data: []byte(`{{ block "main" . }}{{ end }}`)},
+				"layouts/old.tmpl":     {Data: []byte(`{{ block "content" . }}{{ end }}`)},
+			},
+			loader: func(fsys fstest.MapFS) *ppdefaults.LoaderBuilder {
+				return ppdefaults.NewLoaderBuilder().WithDefaults(fsys).
+					TemplateLoader(&ppdefaults.TemplateByNameLoader{FS: fsys, ContentBlockName: "main"})
+			},
+			expected: []pplint.Finding{
+				{Rule: pplint.RuleMissingContent, Page: "layouts/old.tmpl", Message: `layout never renders the "main" block the pages are rendered as`},
+			},
+		},
+		{
+			name: "uses the shared layout partials with the layouts",
+			fs: fstest.MapFS{
+				"layouts/default.tmpl":     {Data: []byte(`{{ template "layouts/shared/_nav.tmpl" }}{{ block "content" . }}{{ end }}`)},
+				"layouts/shared/_nav.tmpl": {Data: []byte(`{{ define "nav" }}nav{{ end }}{{ template "nav" }}`)},
+			},
+			loader: func(fsys fstest.MapFS) *ppdefaults.LoaderBuilder {
+				return ppdefaults.NewLoaderBuilder().WithDefaults(fsys).WithSharedLayoutPartials(fsys, "layouts/shared")
+			},
+		},
+		{
+			name: "reports funcs skipping HTML escaping with their line",
+			fs: fstest.MapFS{
+				"index.tmpl": {Data: []byte("{{ .Title }}\n{{ .Body | safeHTML }}\n{{ define \"x\" }}{{ raw .X }}{{ end }}{{ template \"x\" }}")},
+			},
+			expected: []pplint.Finding{
+				{Rule: pplint.RuleRawHTML, Page: "index.tmpl", Line: 2, Message: "safeHTML marks its input as safe so it isn't escaped, make sure the input can be trusted"},
+				{Rule: pplint.RuleRawHTML, Page: "index.tmpl", Line: 3, Message: "raw marks its input as safe so it isn't escaped, make sure the input can be trusted"},
+			},
+		},
+		{
+			name: "reports names in a different style than most templates",
+			fs: fstest.MapFS{
+				"user-profile.tmpl":                {Data: []byte(`{{ template "user-profile/_avatar-image.tmpl" }}{{ template "user-profile/_recent_posts.tmpl" }}{{ template "user-profile/_mixed-up_Name.tmpl" }}`)},
+				"user-profile/_avatar-image.tmpl":  {Data: []byte(`avatar`)},
+				"user-profile/_recent_posts.tmpl":  {Data: []byte(`posts`)},
+				"user-profile/_mixed-up_Name.tmpl": {Data: []byte(`mixed`)},
+				"index.tmpl":                       {Data: []byte(`index`)},
+			},
+			expected: []pplint.Finding{
+				{Rule: pplint.RuleNaming, Page: "user-profile/_mixed-up_Name.tmpl", Message: "name mixes kebab-case and snake_case and camelCase"},
+				{Rule: pplint.RuleNaming, Page: "user-profile/_recent_posts.tmpl", Message: "name is snake_case while most templates are kebab-case"},
+			},
+		},
+		{
+			name: "only runs the rules given, along with load failures",
+			fs: fstest.MapFS{
+				"index.tmpl":           {Data: []byte(`{{ template "missing" }}{{ safeHTML . }}`)},
+				"broken.tmpl":          {Data: []byte(`{{ if }}`)},
+				"layouts/default.tmpl": {Data: []byte(`layout`)},
+			},
+			rules: []pplint.Rule{pplint.RuleMissingContent},
+			expected: []pplint.Finding{
+				{Rule: pplint.RuleLoad, Page: "broken.tmpl", Message: "broken.tmpl:1: missing value for if"},
+				{Rule: pplint.RuleMissingContent, Page: "layouts/default.tmpl", Message: `layout never renders the "content" block the pages are rendered as`},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			builder := ppdefaults.NewLoaderBuilder().WithDefaults(tc.fs)
+			if tc.loader != nil {
+				builder = tc.loader(tc.fs)
+			}
+			var templates []string
+			for name := range tc.fs {
+				templates = append(templates, name)
+			}
+			sort.Strings(templates)
+
+			actual := pplint.Lint(builder.Build(), templates, tc.rules...)
+
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := pplint.ParseRules("naming, raw-html")
+	require.NoError(t, err)
+	require.Equal(t, []pplint.Rule{pplint.RuleNaming, pplint.RuleRawHTML}, rules)
+
+	_, err = pplint.ParseRules("naming,nope")
+	require.EqualError(t, err, `failed to parse rules: unknown rule "nope"`)
+}
+
+func TestFinding_String(t *testing.T) {
+	require.Equal(t, "index.tmpl:2: uses raw", pplint.Finding{Page: "index.tmpl", Line: 2, Message: "uses raw"}.String())
+}
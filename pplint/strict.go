@@ -0,0 +1,95 @@
+package pplint
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// StandaloneFileLoader collects the files for a template, implemented by [ppdefaults.Loader].
+type StandaloneFileLoader interface {
+	StandaloneFiles(name string) ([]ppdefaults.FileWithContent, error)
+}
+
+// sharedLayoutLoader is implemented by loaders adding partials to the pages of every layout, like [ppdefaults.Loader]
+// with LayoutPartials.
+type sharedLayoutLoader interface {
+	SharedLayoutPartials(layout string) ([]ppdefaults.FileWithContent, error)
+}
+
+// templateFiles collects the files for name from loader, with the shared layout partials first for a layout like when
+// a page is rendered in it.
+func templateFiles(loader StandaloneFileLoader, name string) ([]ppdefaults.FileWithContent, error) {
+	files, err := loader.StandaloneFiles(name)
+	if err != nil {
+		return nil, err
+	}
+	shared, ok := loader.(sharedLayoutLoader)
+	if !ok || ppdefaults.KindOf(name) != ppdefaults.KindLayout {
+		return files, nil
+	}
+
+	partials, err := shared.SharedLayoutPartials(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.Concat(partials, files), nil
+}
+
+// Strict loads every page and layout in templates, telling them apart with [ppdefaults.KindOf], and reports:
+//   - pages and layouts that use a template that isn't defined anywhere
+//   - partials in templates that no page or layout ends up using, so the templates folder doesn't rot
+//
+// Layouts are loaded with the shared layout partials of loaders that have them, like [ppdefaults.Loader] with
+// LayoutPartials. Templates that fail to load or parse are reported as findings too.
+func Strict(loader StandaloneFileLoader, templates []string) []Finding {
+	var findings []Finding
+	used := make(map[string]struct{})
+	for _, name := range templates {
+		if ppdefaults.KindOf(name) == ppdefaults.KindPartial {
+			continue
+		}
+		report := func(rule Rule, format string, args ...any) {
+			findings = append(findings, Finding{Rule: rule, Page: name, Message: fmt.Sprintf(format, args...)})
+		}
+
+		files, err := templateFiles(loader, name)
+		if err != nil {
+			report(RuleLoad, "%s", err)
+			continue
+		}
+
+		set, err := parseAll(files)
+		if err != nil {
+			report(RuleLoad, "%s", err)
+			continue
+		}
+
+		// Later files override the defines of earlier ones, the same as when the template is created.
+		definedIn := make(map[string]string)
+		for _, f := range set.Files {
+			for tmpl := range f.Trees {
+				definedIn[tmpl] = f.Name
+			}
+		}
+
+		for _, tmpl := range set.Reachable(name) {
+			file, ok := definedIn[tmpl]
+			if !ok {
+				report(RuleUndefined, "template %q is used but never defined", tmpl)
+				continue
+			}
+			used[file] = struct{}{}
+		}
+	}
+
+	for _, name := range templates {
+		if _, ok := used[name]; !ok && ppdefaults.KindOf(name) == ppdefaults.KindPartial {
+			findings = append(findings, Finding{Rule: RuleUnusedPartial, Page: name, Message: "partial is never used by a page or layout"})
+		}
+	}
+
+	return findings
+}
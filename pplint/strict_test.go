@@ -0,0 +1,77 @@
+package pplint_test
+
+import (
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+	"github.com/gaqzi/passepartout/pplint"
+)
+
+func TestStrict(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		fs       fstest.MapFS
+		expected []pplint.Finding
+	}{
+		{
+			name: "reports nothing when every partial is used and every template is defined",
+			fs: fstest.MapFS{
+				"index.tmpl":                {Data: []byte(`{{ template "index/_list.tmpl" . }}`)},
+				"index/_list.tmpl":          {Data: []byte(`{{ template "item" . }}`)},
+				"index/_item.tmpl":          {Data: []byte(`{{ define "item" }}item{{ end }}`)},
+				"layouts/default.tmpl":      {Data: []byte(`{{ template "layouts/default/_nav.tmpl" }}{{ block "content" . }}{{ end }}`)},
+				"layouts/default/_nav.tmpl": {Data: []byte(`nav`)},
+			},
+		},
+		{
+			name: "reports partials that nothing uses",
+			fs: fstest.MapFS{
+				"index.tmpl":         {Data: []byte(`{{ template "index/_item.tmpl" . }}`)},
+				"index/_item.tmpl":   {Data: []byte(`item`)},
+				"index/_unused.tmpl": {Data: []byte(`unused`)},
+				"other/_orphan.tmpl": {Data: []byte(`orphan`)},
+			},
+			expected: []pplint.Finding{
+				{Rule: pplint.RuleUnusedPartial, Page: "index/_unused.tmpl", Message: "partial is never used by a page or layout"},
+				{Rule: pplint.RuleUnusedPartial, Page: "other/_orphan.tmpl", Message: "partial is never used by a page or layout"},
+			},
+		},
+		{
+			name: "reports pages and layouts using undefined templates",
+			fs: fstest.MapFS{
+				"index.tmpl":           {Data: []byte(`{{ template "index/_missing.tmpl" . }}`)},
+				"layouts/default.tmpl": {Data: []byte(`{{ template "header" }}{{ block "content" . }}{{ end }}`)},
+			},
+			expected: []pplint.Finding{
+				{Rule: pplint.RuleUndefined, Page: "index.tmpl", Message: `template "index/_missing.tmpl" is used but never defined`},
+				{Rule: pplint.RuleUndefined, Page: "layouts/default.tmpl", Message: `template "header" is used but never defined`},
+			},
+		},
+		{
+			name: "reports templates that fail to parse",
+			fs: fstest.MapFS{
+				"index.tmpl": {Data: []byte(`{{ if }}`)},
+			},
+			expected: []pplint.Finding{
+				{Rule: pplint.RuleLoad, Page: "index.tmpl", Message: "index.tmpl:1: missing value for if"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			loader := ppdefaults.NewLoaderBuilder().WithDefaults(tc.fs).Build()
+			var templates []string
+			for name := range tc.fs {
+				templates = append(templates, name)
+			}
+			sort.Strings(templates)
+
+			actual := pplint.Strict(loader, templates)
+
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+// Package ppmail renders emails from a folder per email, like "emails/welcome/" with "subject.tmpl", "html.tmpl",
+// and "text.tmpl", so the subject, HTML, and plain text versions of an email are kept together and share partials.
+package ppmail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// Email is a rendered email.
+type Email struct {
+	// Subject is rendered from "subject.tmpl" with text/template, with the surrounding whitespace removed.
+	Subject string
+	// HTML is rendered from "html.tmpl" with html/template.
+	HTML string
+	// Text is rendered from "text.tmpl" with text/template, empty when the email has no text template.
+	Text string
+}
+
+// Renderer renders the emails in FS.
+type Renderer struct {
+	FS fs.ReadDirFS
+	// Ext is the extension of the templates, defaults to ".tmpl".
+	Ext string
+	// Partials loads the partials of an email by the name of its folder, defaults to the other files in the folder,
+	// and its subfolders. Use [ppdefaults.PartialsWithCommon] to share partials, like a footer, between all emails.
+	Partials ppdefaults.Partials
+	// HTMLConfig and TextConfig are used as the base of the templates when set, to configure funcs and options.
+	HTMLConfig *htmltemplate.Template
+	TextConfig *texttemplate.Template
+	// PostRender changes the rendered HTML when set, like passepartout's WithPostRender, to inline the CSS.
+	PostRender func(name string, html []byte) ([]byte, error)
+}
+
+// RenderEmail renders the email in the folder dir, like "emails/welcome", with data.
+// The subject and HTML templates are required.
+func (r *Renderer) RenderEmail(dir string, data any) (Email, error) {
+	names := r.names(dir)
+	partials, err := r.partials(dir, names)
+	if err != nil {
+		return Email{}, fmt.Errorf("failed to load partials for email %q: %w", dir, err)
+	}
+
+	var email Email
+	if email.Subject, err = r.renderText(names.subject, partials, data, true); err != nil {
+		return Email{}, err
+	}
+	email.Subject = strings.TrimSpace(email.Subject)
+	if email.HTML, err = r.renderHTML(names.html, partials, data); err != nil {
+		return Email{}, err
+	}
+	if email.Text, err = r.renderText(names.text, partials, data, false); err != nil {
+		return Email{}, err
+	}
+
+	return email, nil
+}
+
+type emailNames struct {
+	subject, html, text string
+}
+
+func (r *Renderer) names(dir string) emailNames {
+	ext := r.Ext
+	if ext == "" {
+		ext = ".tmpl"
+	}
+
+	return emailNames{
+		subject: path.Join(dir, "subject"+ext),
+		html:    path.Join(dir, "html"+ext),
+		text:    path.Join(dir, "text"+ext),
+	}
+}
+
+// partials are the files loaded by Partials, without the email's own templates.
+func (r *Renderer) partials(dir string, names emailNames) ([]ppdefaults.FileWithContent, error) {
+	partials := r.Partials
+	if partials == nil {
+		partials = &ppdefaults.PartialsInFolderOnly{FS: r.FS}
+	}
+
+	files, err := partials.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := files[:0:0]
+	for _, file := range files {
+		if file.Name != names.subject && file.Name != names.html && file.Name != names.text {
+			kept = append(kept, file)
+		}
+	}
+
+	return kept, nil
+}
+
+func (r *Renderer) renderHTML(name string, partials []ppdefaults.FileWithContent, data any) (string, error) {
+	content, err := fs.ReadFile(r.FS, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read email template: %w", err)
+	}
+
+	tmpl, err := ppdefaults.CreateTemplate(r.HTMLConfig, slices.Concat(partials, []ppdefaults.FileWithContent{{Name: name, Content: string(content)}}))
+	if err != nil {
+		return "", fmt.Errorf("failed to create email template %q: %w", name, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&out, name, data); err != nil {
+		return "", fmt.Errorf("failed to render email template %q: %w", name, err)
+	}
+	if r.PostRender == nil {
+		return out.String(), nil
+	}
+
+	html, err := r.PostRender(name, out.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("failed to post-render email template %q: %w", name, err)
+	}
+
+	return string(html), nil
+}
+
+// renderText renders name with text/template, a template that doesn't exist is rendered as empty unless required.
+func (r *Renderer) renderText(name string, partials []ppdefaults.FileWithContent, data any, required bool) (string, error) {
+	content, err := fs.ReadFile(r.FS, name)
+	if err != nil {
+		if !required && errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read email template: %w", err)
+	}
+
+	tmpl := texttemplate.New("")
+	if r.TextConfig != nil {
+		if tmpl, err = r.TextConfig.Clone(); err != nil {
+			return "", fmt.Errorf("failed to copy base template: %w", err)
+		}
+	}
+	for _, file := range slices.Concat(partials, []ppdefaults.FileWithContent{{Name: name, Content: string(content)}}) {
+		if _, err := tmpl.New(file.Name).Parse(file.Content); err != nil {
+			return "", fmt.Errorf("failed to create email template %q: %w", name, err)
+		}
+	}
+
+	var out strings.Builder
+	if err := tmpl.ExecuteTemplate(&out, name, data); err != nil {
+		return "", fmt.Errorf("failed to render email template %q: %w", name, err)
+	}
+
+	return out.String(), nil
+}
@@ -0,0 +1,89 @@
+package ppmail_test
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"testing"
+	"testing/fstest"
+	texttemplate "text/template"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+	"github.com/gaqzi/passepartout/ppmail"
+)
+
+func TestRenderer_RenderEmail(t *testing.T) {
+	fsys := fstest.MapFS{
+		"emails/welcome/subject.tmpl":  {Data: []byte("\n  Welcome, {{ .Name }}!\n")},
+		"emails/welcome/html.tmpl":     {Data: []byte(`<p>Hi {{ .Name }}</p>{{ template "emails/welcome/_sign.tmpl" . }}`)},
+		"emails/welcome/text.tmpl":     {Data: []byte(`Hi {{ .Name }} {{ template "emails/welcome/_sign.tmpl" . }}`)},
+		"emails/welcome/_sign.tmpl":    {Data: []byte(`-- {{ .Team }}`)},
+		"emails/reset/subject.tmpl":    {Data: []byte(`Reset your password`)},
+		"emails/reset/html.tmpl":       {Data: []byte(`<a href="{{ .URL }}">Reset</a>{{ template "partials/_footer.tmpl" }}`)},
+		"partials/_footer.tmpl":        {Data: []byte(`<footer>{{ year }}</footer>`)},
+		"emails/broken/subject.tmpl":   {Data: []byte(`Broken`)},
+		"emails/broken/html.tmpl":      {Data: []byte(`{{ .Name `)},
+		"emails/no-html/subject.tmpl":  {Data: []byte(`No HTML`)},
+		"emails/no-html/text.tmpl":     {Data: []byte(`text`)},
+		"emails/welcome/extra/_x.tmpl": {Data: []byte(`unused`)},
+	}
+	data := map[string]string{"Name": "<Ada>", "Team": "The Team", "URL": "https://example.com/reset?a=1&b=2"}
+
+	t.Run("renders the subject, HTML, and text sharing the partials", func(t *testing.T) {
+		email, err := (&ppmail.Renderer{FS: fsys}).RenderEmail("emails/welcome", data)
+
+		require.NoError(t, err)
+		require.Equal(t, ppmail.Email{
+			Subject: "Welcome, <Ada>!",
+			HTML:    "<p>Hi &lt;Ada&gt;</p>-- The Team",
+			Text:    "Hi <Ada> -- The Team",
+		}, email)
+	})
+
+	t.Run("renders without text when there's no text template, with common partials and funcs", func(t *testing.T) {
+		funcs := map[string]any{"year": func() int { return 2026 }}
+		renderer := &ppmail.Renderer{
+			FS:         fsys,
+			Partials:   &ppdefaults.PartialsWithCommon{FS: fsys, CommonDir: "partials"},
+			HTMLConfig: htmltemplate.New("").Funcs(funcs),
+			TextConfig: texttemplate.New("").Funcs(funcs),
+		}
+
+		email, err := renderer.RenderEmail("emails/reset", data)
+
+		require.NoError(t, err)
+		require.Equal(t, ppmail.Email{
+			Subject: "Reset your password",
+			HTML:    `<a href="https://example.com/reset?a=1&amp;b=2">Reset</a><footer>2026</footer>`,
+		}, email)
+	})
+
+	t.Run("post-renders the HTML", func(t *testing.T) {
+		renderer := &ppmail.Renderer{FS: fsys, PostRender: func(name string, html []byte) ([]byte, error) {
+			return bytes.ReplaceAll(html, []byte("<p>"), []byte(`<p style="color: red">`)), nil
+		}}
+
+		email, err := renderer.RenderEmail("emails/welcome", data)
+
+		require.NoError(t, err)
+		require.Equal(t, `<p style="color: red">Hi &lt;Ada&gt;</p>-- The Team`, email.HTML)
+		require.Equal(t, "Hi <Ada> -- The Team", email.Text)
+	})
+
+	for _, tc := range []struct {
+		name     string
+		dir      string
+		expected string
+	}{
+		{name: "returns an error when the HTML template is missing", dir: "emails/no-html", expected: "failed to read email template: open emails/no-html/html.tmpl"},
+		{name: "returns an error when a template fails to parse", dir: "emails/broken", expected: `failed to create email template "emails/broken/html.tmpl"`},
+		{name: "returns an error when the email doesn't exist", dir: "emails/missing", expected: "failed to read email template: open emails/missing/subject.tmpl"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := (&ppmail.Renderer{FS: fsys}).RenderEmail(tc.dir, data)
+
+			require.ErrorContains(t, err, tc.expected)
+		})
+	}
+}
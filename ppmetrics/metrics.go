@@ -0,0 +1,84 @@
+// Package ppmetrics defines the metrics recorded about loading and rendering templates, see
+// [passepartout.WithMetrics], and an [Expvar] implementation of them.
+//
+// To export the metrics to Prometheus implement [Recorder] with counters and histograms registered in a registry,
+// for example with the template as a label on histograms observing the durations in seconds.
+package ppmetrics
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// Recorder records metrics, and is called synchronously so it should be quick.
+type Recorder interface {
+	// CacheHit is called when [ppdefaults.CachedLoader] returns the files for template from its cache.
+	CacheHit(template string)
+	// CacheMiss is called when [ppdefaults.CachedLoader] has to load the files for template.
+	CacheMiss(template string)
+	// Load is called with how long it took to load all the files for template, including its partials.
+	Load(template string, d time.Duration)
+	// Parse is called with how long it took to parse all the loaded files for template.
+	Parse(template string, d time.Duration)
+	// Render is called with how long it took to render template, and the error if it failed.
+	Render(template string, d time.Duration, err error)
+}
+
+// Expvar is a [Recorder] that is also an [expvar.Var], publish it with [expvar.Publish] to serve it on /debug/vars:
+//
+//	{"cache_hits": 1, "cache_misses": 1, "load": {"index.tmpl": {"count": 1, "seconds": 0.0001}}, "parse": {...},
+//	 "render": {...}, "render_errors": {"index.tmpl": 1}}
+type Expvar struct {
+	expvar.Map
+
+	mu           sync.Mutex
+	cacheHits    expvar.Int
+	cacheMisses  expvar.Int
+	load         expvar.Map
+	parse        expvar.Map
+	render       expvar.Map
+	renderErrors expvar.Map
+}
+
+// NewExpvar returns an unpublished [Expvar].
+func NewExpvar() *Expvar {
+	e := new(Expvar)
+	e.Set("cache_hits", &e.cacheHits)
+	e.Set("cache_misses", &e.cacheMisses)
+	e.Set("load", &e.load)
+	e.Set("parse", &e.parse)
+	e.Set("render", &e.render)
+	e.Set("render_errors", &e.renderErrors)
+
+	return e
+}
+
+func (e *Expvar) CacheHit(string) { e.cacheHits.Add(1) }
+
+func (e *Expvar) CacheMiss(string) { e.cacheMisses.Add(1) }
+
+func (e *Expvar) Load(template string, d time.Duration) { e.observe(&e.load, template, d) }
+
+func (e *Expvar) Parse(template string, d time.Duration) { e.observe(&e.parse, template, d) }
+
+func (e *Expvar) Render(template string, d time.Duration, err error) {
+	e.observe(&e.render, template, d)
+	if err != nil {
+		e.renderErrors.Add(template, 1)
+	}
+}
+
+// observe adds d to the count and total seconds of template in m.
+func (e *Expvar) observe(m *expvar.Map, template string, d time.Duration) {
+	e.mu.Lock()
+	timing, ok := m.Get(template).(*expvar.Map)
+	if !ok {
+		timing = new(expvar.Map)
+		m.Set(template, timing)
+	}
+	e.mu.Unlock()
+
+	timing.Add("count", 1)
+	timing.AddFloat("seconds", d.Seconds())
+}
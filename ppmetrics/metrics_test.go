@@ -0,0 +1,60 @@
+package ppmetrics_test
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppmetrics"
+)
+
+func TestExpvar(t *testing.T) {
+	t.Run("starts out with every metric empty", func(t *testing.T) {
+		var metrics map[string]any
+		require.NoError(t, json.Unmarshal([]byte(ppmetrics.NewExpvar().String()), &metrics))
+
+		require.Equal(t, map[string]any{
+			"cache_hits":    0.0,
+			"cache_misses":  0.0,
+			"load":          map[string]any{},
+			"parse":         map[string]any{},
+			"render":        map[string]any{},
+			"render_errors": map[string]any{},
+		}, metrics)
+	})
+
+	t.Run("counts and sums the durations by template", func(t *testing.T) {
+		e := ppmetrics.NewExpvar()
+
+		e.CacheHit("index.tmpl")
+		e.CacheMiss("index.tmpl")
+		e.CacheMiss("about.tmpl")
+		e.Load("index.tmpl", time.Second)
+		e.Parse("index.tmpl", 2*time.Second)
+		e.Render("index.tmpl", time.Second, nil)
+		e.Render("index.tmpl", 500*time.Millisecond, errors.New("failed"))
+
+		var metrics map[string]any
+		require.NoError(t, json.Unmarshal([]byte(e.String()), &metrics))
+		require.Equal(t, map[string]any{
+			"cache_hits":    1.0,
+			"cache_misses":  2.0,
+			"load":          map[string]any{"index.tmpl": map[string]any{"count": 1.0, "seconds": 1.0}},
+			"parse":         map[string]any{"index.tmpl": map[string]any{"count": 1.0, "seconds": 2.0}},
+			"render":        map[string]any{"index.tmpl": map[string]any{"count": 2.0, "seconds": 1.5}},
+			"render_errors": map[string]any{"index.tmpl": 1.0},
+		}, metrics)
+	})
+
+	t.Run("can be published", func(t *testing.T) {
+		e := ppmetrics.NewExpvar()
+
+		expvar.Publish("ppmetrics_test", e)
+
+		require.Same(t, e, expvar.Get("ppmetrics_test"))
+	})
+}
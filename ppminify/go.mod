@@ -0,0 +1,20 @@
+module github.com/gaqzi/passepartout/ppminify
+
+go 1.24.1
+
+// Develop against the passepartout in the parent folder, a release requires the tagged passepartout it was built for
+// since the replace is ignored by the modules depending on this one.
+replace github.com/gaqzi/passepartout => ../
+
+require (
+	github.com/gaqzi/passepartout v0.1.0
+	github.com/stretchr/testify v1.10.0
+	github.com/tdewolff/minify/v2 v2.24.10
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/tdewolff/parse/v2 v2.8.16 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
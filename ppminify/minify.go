@@ -0,0 +1,48 @@
+// Package ppminify is a [passepartout.Minifier] with github.com/tdewolff/minify, in a module of its own so
+// passepartout itself doesn't depend on it:
+//
+//	p, err := passepartout.LoadFrom(templates, passepartout.WithMinify(ppminify.New()))
+package ppminify
+
+import (
+	"errors"
+	"io"
+	"regexp"
+
+	"github.com/tdewolff/minify/v2"
+	"github.com/tdewolff/minify/v2/css"
+	"github.com/tdewolff/minify/v2/html"
+	"github.com/tdewolff/minify/v2/js"
+	"github.com/tdewolff/minify/v2/json"
+	"github.com/tdewolff/minify/v2/svg"
+	"github.com/tdewolff/minify/v2/xml"
+)
+
+// Minifier minifies HTML, CSS, JS, SVG, JSON, and XML, and copies other media types, like text/plain, as they are.
+type Minifier struct {
+	// M can be configured further, for example with the options of the HTML minifier.
+	M *minify.M
+}
+
+// New creates a Minifier with the default options of each minifier.
+func New() *Minifier {
+	m := minify.New()
+	m.AddFunc("text/html", html.Minify)
+	m.AddFunc("text/css", css.Minify)
+	m.AddFunc("image/svg+xml", svg.Minify)
+	m.AddFuncRegexp(regexp.MustCompile(`^(application|text)/(x-)?(java|ecma)script$`), js.Minify)
+	m.AddFuncRegexp(regexp.MustCompile(`[/+]json$`), json.Minify)
+	m.AddFuncRegexp(regexp.MustCompile(`[/+]xml$`), xml.Minify)
+
+	return &Minifier{M: m}
+}
+
+// Minify minifies r into w by mediaType, without a minifier for mediaType it's copied as it is.
+func (m *Minifier) Minify(mediaType string, w io.Writer, r io.Reader) error {
+	err := m.M.Minify(mediaType, w, r)
+	if errors.Is(err, minify.ErrNotExist) {
+		_, err = io.Copy(w, r)
+	}
+
+	return err
+}
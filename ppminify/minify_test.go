@@ -0,0 +1,45 @@
+package ppminify_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/ppminify"
+)
+
+func TestMinifier(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		mediaType string
+		input     string
+		expected  string
+	}{
+		{name: "minifies HTML", mediaType: "text/html", input: "<p>\n  Hello   <b>World</b>\n</p>", expected: "<p>Hello <b>World</b>"},
+		{name: "minifies CSS", mediaType: "text/css", input: "body {\n  color: #ff0000;\n}\n", expected: "body{color:red}"},
+		{name: "minifies JSON", mediaType: "application/json", input: `{ "a": [1, 2] }`, expected: `{"a":[1,2]}`},
+		{name: "copies other media types", mediaType: "text/plain", input: "User-agent: *\n\nDisallow: /", expected: "User-agent: *\n\nDisallow: /"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+
+			require.NoError(t, ppminify.New().Minify(tc.mediaType, out, strings.NewReader(tc.input)))
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func TestMinifier_WithMinify(t *testing.T) {
+	pp, err := passepartout.LoadFrom(fstest.MapFS{
+		"index.tmpl": {Data: []byte("<ul>\n  {{ range . }}<li> {{ . }} </li>\n  {{ end }}</ul>")},
+	}, passepartout.WithMinify(ppminify.New()))
+	require.NoError(t, err)
+	out := new(bytes.Buffer)
+
+	require.NoError(t, pp.Render(out, "index.tmpl", []string{"a", "b"}))
+	require.Equal(t, "<ul><li>a<li>b</ul>", out.String())
+}
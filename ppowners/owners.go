@@ -0,0 +1,150 @@
+// Package ppowners maps templates to the teams owning them, so render errors and lint findings can be routed to the
+// right team. Owners are declared by the template itself with a directive, or in a CODEOWNERS-style file:
+//
+//	{{/* owner: @reviews-team @alice */}}
+package ppowners
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/gaqzi/passepartout/internal/ppparse"
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// Rule assigns Owners to the templates matching Pattern, a rule without owners makes the templates unowned.
+type Rule struct {
+	Pattern string
+	Owners  []string
+}
+
+// Rules are matched in order and the last matching rule wins, like CODEOWNERS.
+type Rules []Rule
+
+// ParseRules parses a CODEOWNERS-style file where each line is a pattern followed by its owners:
+//
+//	# comments and blank lines are ignored
+//	*.tmpl          @web
+//	reviews/        @reviews-team
+//	layouts/*.tmpl  @design @web
+//
+// A pattern without a "/" matches a file or folder name at any depth, a pattern ending with "/" matches everything in the
+// folder, and any other pattern is matched against the full name with [path.Match] and matches folders by prefix.
+func ParseRules(content string) (Rules, error) {
+	var rules Rules
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		pattern := strings.TrimPrefix(fields[0], "/")
+		if _, err := path.Match(strings.TrimSuffix(pattern, "/"), ""); err != nil {
+			return nil, fmt.Errorf("failed to parse owners line %d: pattern %q: %w", line, fields[0], err)
+		}
+
+		rules = append(rules, Rule{Pattern: pattern, Owners: fields[1:]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse owners: %w", err)
+	}
+
+	return rules, nil
+}
+
+// Match returns the owners of the last rule matching name.
+func (r Rules) Match(name string) []string {
+	var owners []string
+	for _, rule := range r {
+		if matches(rule.Pattern, name) {
+			owners = rule.Owners
+		}
+	}
+
+	return owners
+}
+
+func matches(pattern, name string) bool {
+	if dir, ok := strings.CutSuffix(pattern, "/"); ok {
+		return strings.HasPrefix(name, dir+"/")
+	}
+
+	if !strings.Contains(pattern, "/") {
+		for _, segment := range strings.Split(name, "/") {
+			if ok, _ := path.Match(pattern, segment); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	for candidate := name; candidate != "."; candidate = path.Dir(candidate) {
+		if ok, _ := path.Match(pattern, candidate); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Source is where the owners of a template were declared.
+type Source string
+
+const (
+	SourceDirective Source = "directive"
+	SourceRules     Source = "rules"
+)
+
+// Owners returns the owners of the template name with content, the owner directive takes precedence over rules.
+func Owners(name, content string, rules Rules) ([]string, Source) {
+	if value, ok := ppparse.Directive(content, "owner"); ok {
+		return strings.Fields(value), SourceDirective
+	}
+
+	if owners := rules.Match(name); len(owners) > 0 {
+		return owners, SourceRules
+	}
+
+	return nil, ""
+}
+
+// Entry is the owners of a template.
+type Entry struct {
+	Template string
+	Kind     ppdefaults.Kind
+	// Owners is empty when the template is unowned.
+	Owners []string
+	Source Source
+}
+
+// Report returns the owners of every file in fsys ending with ext, in the order [fs.WalkDir] visits them.
+func Report(fsys fs.FS, ext string, rules Rules) ([]Entry, error) {
+	var entries []Entry
+	err := fs.WalkDir(fsys, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || !strings.HasSuffix(filePath, ext) {
+			return nil
+		}
+
+		content, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return err
+		}
+
+		owners, source := Owners(filePath, string(content), rules)
+		entries = append(entries, Entry{Template: filePath, Kind: ppdefaults.KindOf(filePath), Owners: owners, Source: source})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to report owners: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,78 @@
+package ppowners_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+	"github.com/gaqzi/passepartout/ppowners"
+)
+
+func TestParseRules(t *testing.T) {
+	t.Run("parses patterns with owners and skips comments and blank lines", func(t *testing.T) {
+		rules, err := ppowners.ParseRules("# comment\n\n*.tmpl @web\n/reviews/ @reviews-team @alice\nreviews/drafts/\n")
+
+		require.NoError(t, err)
+		require.Equal(t, ppowners.Rules{
+			{Pattern: "*.tmpl", Owners: []string{"@web"}},
+			{Pattern: "reviews/", Owners: []string{"@reviews-team", "@alice"}},
+			{Pattern: "reviews/drafts/", Owners: []string{}},
+		}, rules)
+	})
+
+	t.Run("returns an error with the line of an invalid pattern", func(t *testing.T) {
+		_, err := ppowners.ParseRules("*.tmpl @web\n[ @broken\n")
+
+		require.ErrorContains(t, err, "failed to parse owners line 2")
+	})
+}
+
+func TestRules_Match(t *testing.T) {
+	rules, err := ppowners.ParseRules(`
+*.tmpl          @web
+reviews/        @reviews-team
+reviews/drafts/
+layouts/*.tmpl  @design
+admin           @admin
+`)
+	require.NoError(t, err)
+
+	for name, expected := range map[string][]string{
+		"index.tmpl":                {"@web"},
+		"home/index.tmpl":           {"@web"},
+		"reviews/show.tmpl":         {"@reviews-team"},
+		"reviews/show/_item.tmpl":   {"@reviews-team"},
+		"reviews/drafts/edit.tmpl":  {},
+		"layouts/default.tmpl":      {"@design"},
+		"layouts/default/_nav.tmpl": {"@web"},
+		"admin/users.tmpl":          {"@admin"},
+		"style.css":                 nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, expected, rules.Match(name))
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.tmpl":         {Data: []byte(`{{/* owner: @home @bob */}}home`)},
+		"reviews/show.tmpl":  {Data: []byte(`show`)},
+		"reviews/_item.tmpl": {Data: []byte(`item`)},
+		"about.tmpl":         {Data: []byte(`about`)},
+		"reviews/styles.css": {Data: []byte(`body {}`)},
+	}
+	rules := ppowners.Rules{{Pattern: "reviews/", Owners: []string{"@reviews-team"}}}
+
+	actual, err := ppowners.Report(fsys, ".tmpl", rules)
+
+	require.NoError(t, err)
+	require.Equal(t, []ppowners.Entry{
+		{Template: "about.tmpl", Kind: ppdefaults.KindPage},
+		{Template: "index.tmpl", Kind: ppdefaults.KindPage, Owners: []string{"@home", "@bob"}, Source: ppowners.SourceDirective},
+		{Template: "reviews/_item.tmpl", Kind: ppdefaults.KindPartial, Owners: []string{"@reviews-team"}, Source: ppowners.SourceRules},
+		{Template: "reviews/show.tmpl", Kind: ppdefaults.KindPage, Owners: []string{"@reviews-team"}, Source: ppowners.SourceRules},
+	}, actual)
+}
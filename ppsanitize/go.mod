@@ -0,0 +1,22 @@
+module github.com/gaqzi/passepartout/ppsanitize
+
+go 1.24.1
+
+// Develop against the passepartout in the parent folder, a release requires the tagged passepartout it was built for
+// since the replace is ignored by the modules depending on this one.
+replace github.com/gaqzi/passepartout => ../
+
+require (
+	github.com/gaqzi/passepartout v0.1.0
+	github.com/microcosm-cc/bluemonday v1.0.27
+	github.com/stretchr/testify v1.10.0
+)
+
+require (
+	github.com/aymerick/douceur v0.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/gorilla/css v1.0.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.47.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,29 @@
+// Package ppsanitize configures [passepartout.Sanitizer] policies with github.com/microcosm-cc/bluemonday, in a module
+// of its own so passepartout itself doesn't depend on it:
+//
+//	p, err := passepartout.LoadFrom(templates,
+//		passepartout.WithSanitizer(ppsanitize.UGC()),
+//		passepartout.WithSanitizePolicy("strict", ppsanitize.Strict()),
+//	)
+//
+// Any [bluemonday.Policy] can be used as a policy, and the ones returned here can be configured further.
+package ppsanitize
+
+import (
+	"github.com/microcosm-cc/bluemonday"
+
+	"github.com/gaqzi/passepartout"
+)
+
+var _ passepartout.Sanitizer = (*bluemonday.Policy)(nil)
+
+// UGC allows the formatting, links, images, lists, and tables of user-generated content like comments and posts,
+// without scripts, styles, or event handlers. Links get rel="nofollow".
+func UGC() *bluemonday.Policy {
+	return bluemonday.UGCPolicy()
+}
+
+// Strict removes every tag and keeps only the text, for fields like a name or a bio that shouldn't have markup.
+func Strict() *bluemonday.Policy {
+	return bluemonday.StrictPolicy()
+}
@@ -0,0 +1,60 @@
+package ppsanitize_test
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/ppsanitize"
+)
+
+func TestPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		policy   passepartout.Sanitizer
+		input    string
+		expected string
+	}{
+		{
+			name:     "UGC keeps formatting and links without scripts or event handlers",
+			policy:   ppsanitize.UGC(),
+			input:    `<p onclick="steal()"><b>Hi</b> <a href="https://example.com">there</a></p><script>alert(1)</script>`,
+			expected: `<p><b>Hi</b> <a href="https://example.com" rel="nofollow">there</a></p>`,
+		},
+		{
+			name:     "UGC removes javascript links",
+			policy:   ppsanitize.UGC(),
+			input:    `<a href="javascript:alert(1)">click</a>`,
+			expected: `click`,
+		},
+		{
+			name:     "Strict keeps only the text",
+			policy:   ppsanitize.Strict(),
+			input:    `<b>Hi</b> <i>there</i>`,
+			expected: `Hi there`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.policy.Sanitize(tc.input))
+		})
+	}
+}
+
+func TestPolicies_WithSanitizer(t *testing.T) {
+	pp, err := passepartout.LoadFrom(fstest.MapFS{
+		"comment.tmpl": {Data: []byte(`<div>{{ sanitize .Body }}</div><span>{{ sanitize .Name "strict" }}</span>`)},
+	},
+		passepartout.WithSanitizer(ppsanitize.UGC()),
+		passepartout.WithSanitizePolicy("strict", ppsanitize.Strict()),
+	)
+	require.NoError(t, err)
+	out := new(bytes.Buffer)
+
+	err = pp.Render(out, "comment.tmpl", map[string]string{"Body": `<b>Hi</b><img src=x onerror="steal()">`, "Name": `<b>Ada</b>`})
+
+	require.NoError(t, err)
+	require.Equal(t, `<div><b>Hi</b><img src="x"></div><span>Ada</span>`, out.String())
+}
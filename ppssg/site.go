@@ -0,0 +1,343 @@
+// Package ppssg renders every page in a passepartout template tree to files on disk, producing a static site.
+package ppssg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Renderer is the part of [passepartout.Passepartout] that is used to render pages.
+type Renderer interface {
+	Render(out io.Writer, name string, data any) error
+	RenderInLayout(out io.Writer, layout string, name string, data any) error
+}
+
+// Site walks FS and renders every page it finds through Renderer into an output directory.
+//
+// A page is any file ending in TemplateExt that isn't a partial (the filename, or a folder it's in, starts with "_")
+// and isn't in LayoutsDir.
+// The output file is named after the page with TemplateExt removed, and if nothing is left of the extension
+// ".html" is added, so "index.tmpl" becomes "index.html" while "feed.xml.tmpl" becomes "feed.xml".
+type Site struct {
+	Renderer Renderer
+	// FS is the same filesystem the Renderer loads its templates from.
+	FS fs.FS
+	// Layout is used to render all pages within, when empty pages are rendered standalone.
+	Layout string
+	// Data returns the data to render a page with, when nil all pages are rendered with nil data.
+	Data func(page string) (any, error)
+	// TemplateExt is the extension used to tell templates apart from other files, defaults to ".tmpl".
+	TemplateExt string
+	// LayoutsDir is never rendered as pages, defaults to "layouts".
+	LayoutsDir string
+	// PagesGlob selects the pages to render with [fs.Glob], e.g. "reviews/*.tmpl", instead of walking all of FS.
+	// It uses the Glob of FS when it implements [fs.GlobFS]. Partials and layouts matched are still skipped.
+	PagesGlob string
+
+	// CopyAssets copies every file that isn't a template (images, CSS, JS, etc.) into the output unchanged, except for
+	// the fixtures of the pages, like "index.tmpl.data/", and OWNERS files.
+	CopyAssets bool
+	// FingerprintAssets renames copied assets to include a hash of their content, e.g. "app.css" becomes
+	// "app.1a2b3c4d.css", so they can be cached forever.
+	FingerprintAssets bool
+	// AssetManifest is where in the output directory to write a manifest of the copied assets, when empty no manifest
+	// is written. The manifest uses the format of Vite's manifest.json: {"app.css": {"file": "app.1a2b3c4d.css"}}.
+	AssetManifest string
+
+	// SitemapBaseURL is the URL the site is served from, like "https://example.com", and when set a sitemap.xml of
+	// the HTML pages rendered is written to the output directory. Pages named "index" are listed as their folder.
+	SitemapBaseURL string
+	// SitemapEntry returns when a page was last modified, how often it changes, and its priority for the sitemap,
+	// when nil only the location of the pages is listed.
+	SitemapEntry func(page string) (SitemapEntry, error)
+}
+
+// SitemapEntry is what the sitemap says about a page, the zero values are left out.
+type SitemapEntry struct {
+	LastMod time.Time
+	// ChangeFreq is how often the page changes, like "daily" or "monthly".
+	ChangeFreq string
+	// Priority is how important the page is compared to the other pages of the site, from 0.0 to 1.0.
+	Priority float64
+	// Skip leaves the page out of the sitemap.
+	Skip bool
+}
+
+// Result describes what was written by [Site.Generate].
+type Result struct {
+	// Pages are the names of the pages rendered.
+	Pages []string
+	// Assets maps the name of each copied asset to the name it was written as.
+	Assets map[string]string
+}
+
+// ManifestEntry is a single asset in the manifest written to [Site.AssetManifest].
+type ManifestEntry struct {
+	File string `json:"file"`
+}
+
+// Generate renders all pages, and copies assets if configured, into outDir.
+func (s *Site) Generate(outDir string) (*Result, error) {
+	result := &Result{Assets: make(map[string]string)}
+
+	pages, assets, err := s.find()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pages: %w", err)
+	}
+
+	if s.CopyAssets {
+		for _, asset := range assets {
+			written, err := s.copyAsset(outDir, asset)
+			if err != nil {
+				return nil, fmt.Errorf("failed to copy asset %q: %w", asset, err)
+			}
+			result.Assets[asset] = written
+		}
+
+		if s.AssetManifest != "" {
+			if err := writeManifest(filepath.Join(outDir, filepath.FromSlash(s.AssetManifest)), result.Assets); err != nil {
+				return nil, fmt.Errorf("failed to write asset manifest: %w", err)
+			}
+		}
+	}
+
+	for _, page := range pages {
+		if err := s.renderPage(outDir, page); err != nil {
+			return nil, fmt.Errorf("failed to render page %q: %w", page, err)
+		}
+		result.Pages = append(result.Pages, page)
+	}
+
+	if s.SitemapBaseURL != "" {
+		if err := s.writeSitemap(filepath.Join(outDir, "sitemap.xml"), result.Pages); err != nil {
+			return nil, fmt.Errorf("failed to write sitemap: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// find returns the pages to render and the assets that can be copied.
+func (s *Site) find() (pages []string, assets []string, err error) {
+	if s.PagesGlob != "" {
+		matches, err := fs.Glob(s.FS, s.PagesGlob)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, match := range matches {
+			if strings.HasSuffix(match, s.templateExt()) && s.isPage(match) {
+				pages = append(pages, match)
+			}
+		}
+
+		if !s.CopyAssets {
+			return pages, nil, nil
+		}
+	}
+
+	err = fs.WalkDir(s.FS, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			// The fixtures of the pages, like "index.tmpl.data/", are for tests and previews and not part of the site.
+			if path.Ext(filePath) == ".data" && strings.HasSuffix(strings.TrimSuffix(filePath, ".data"), s.templateExt()) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if path.Base(filePath) == "OWNERS" {
+			return nil
+		}
+
+		if !strings.HasSuffix(filePath, s.templateExt()) {
+			assets = append(assets, filePath)
+		} else if s.PagesGlob == "" && s.isPage(filePath) {
+			pages = append(pages, filePath)
+		}
+
+		return nil
+	})
+
+	return pages, assets, err
+}
+
+func (s *Site) templateExt() string {
+	if s.TemplateExt == "" {
+		return ".tmpl"
+	}
+
+	return s.TemplateExt
+}
+
+func (s *Site) layoutsDir() string {
+	if s.LayoutsDir == "" {
+		return "layouts"
+	}
+
+	return s.LayoutsDir
+}
+
+func (s *Site) isPage(name string) bool {
+	// Partials, and the partial folders of partials, start with "_".
+	if strings.HasPrefix(name, "_") || strings.Contains(name, "/_") {
+		return false
+	}
+
+	return !strings.HasPrefix(name, s.layoutsDir()+"/")
+}
+
+// outputName is the name of the rendered file for a page, see [Site] for the rules.
+func (s *Site) outputName(page string) string {
+	name := strings.TrimSuffix(page, s.templateExt())
+	if path.Ext(name) == "" {
+		name += ".html"
+	}
+
+	return name
+}
+
+func (s *Site) renderPage(outDir, page string) error {
+	var data any
+	if s.Data != nil {
+		var err error
+		data, err = s.Data(page)
+		if err != nil {
+			return fmt.Errorf("failed to get data: %w", err)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	var err error
+	if s.Layout != "" {
+		err = s.Renderer.RenderInLayout(buf, s.Layout, page, data)
+	} else {
+		err = s.Renderer.Render(buf, page, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filepath.Join(outDir, filepath.FromSlash(s.outputName(page))), buf.Bytes())
+}
+
+func (s *Site) copyAsset(outDir, asset string) (string, error) {
+	content, err := fs.ReadFile(s.FS, asset)
+	if err != nil {
+		return "", err
+	}
+
+	name := asset
+	if s.FingerprintAssets {
+		name = fingerprint(asset, content)
+	}
+
+	if err := writeFile(filepath.Join(outDir, filepath.FromSlash(name)), content); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// fingerprint inserts a short hash of the content before the extension of name.
+func fingerprint(name string, content []byte) string {
+	sum := sha256.Sum256(content)
+	ext := path.Ext(name)
+
+	return strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:4]) + ext
+}
+
+func writeManifest(filePath string, assets map[string]string) error {
+	manifest := make(map[string]ManifestEntry, len(assets))
+	for name, file := range assets {
+		manifest[name] = ManifestEntry{File: file}
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filePath, content)
+}
+
+type sitemapURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+func (s *Site) writeSitemap(filePath string, pages []string) error {
+	urlSet := sitemapURLSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for _, page := range pages {
+		name := s.outputName(page)
+		if path.Ext(name) != ".html" {
+			continue
+		}
+
+		var entry SitemapEntry
+		if s.SitemapEntry != nil {
+			var err error
+			if entry, err = s.SitemapEntry(page); err != nil {
+				return fmt.Errorf("failed to get the sitemap entry of %q: %w", page, err)
+			}
+		}
+		if entry.Skip {
+			continue
+		}
+
+		loc := name
+		if path.Base(name) == "index.html" {
+			loc = strings.TrimSuffix(name, "index.html")
+		}
+		segments := strings.Split(loc, "/")
+		for i, segment := range segments {
+			segments[i] = url.PathEscape(segment)
+		}
+		u := sitemapURL{Loc: strings.TrimSuffix(s.SitemapBaseURL, "/") + "/" + strings.Join(segments, "/"), ChangeFreq: entry.ChangeFreq}
+		if !entry.LastMod.IsZero() {
+			u.LastMod = entry.LastMod.Format(time.RFC3339)
+		}
+		if entry.Priority != 0 {
+			u.Priority = strconv.FormatFloat(entry.Priority, 'f', -1, 64)
+		}
+		urlSet.URLs = append(urlSet.URLs, u)
+	}
+
+	content, err := xml.MarshalIndent(urlSet, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filePath, append([]byte(xml.Header), content...))
+}
+
+func writeFile(filePath string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, content, 0o644)
+}
@@ -0,0 +1,251 @@
+package ppssg_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/ppssg"
+)
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(name)
+	require.NoError(t, err, "expected to have written %q", name)
+
+	return string(content)
+}
+
+func siteFS() fstest.MapFS {
+	return fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":           {Data: []byte(`home {{ template "index/_item.tmpl" . }}`)},
+		"index/_item.tmpl":     {Data: []byte(`item`)},
+		"index/_item/row.tmpl": {Data: []byte(`in the partial folder of a partial`)},
+		"feed.xml.tmpl":        {Data: []byte(`<feed/>`)},
+		"css/app.css":          {Data: []byte(`body { color: red; }`)},
+		"img/logo.png":         {Data: []byte{0x89, 0x50, 0x4e, 0x47}},
+	}
+}
+
+func TestSite_Generate(t *testing.T) {
+	t.Run("renders every page and skips partials, layouts, and assets by default", func(t *testing.T) {
+		fsys := siteFS()
+		pp, err := passepartout.LoadFrom(fsys)
+		require.NoError(t, err)
+		out := t.TempDir()
+		site := ppssg.Site{Renderer: pp, FS: fsys}
+
+		result, err := site.Generate(out)
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"feed.xml.tmpl", "index.tmpl"}, result.Pages)
+		require.Empty(t, result.Assets, "expected no assets to be copied unless asked to")
+		require.Equal(t, "home item", readFile(t, filepath.Join(out, "index.html")))
+		require.Equal(t, "<feed/>", readFile(t, filepath.Join(out, "feed.xml")), "expected the inner extension to be kept")
+		require.NoFileExists(t, filepath.Join(out, "css", "app.css"))
+		require.NoFileExists(t, filepath.Join(out, "layouts", "default.html"))
+	})
+
+	t.Run("renders pages within the layout when configured", func(t *testing.T) {
+		fsys := siteFS()
+		pp, err := passepartout.LoadFrom(fsys)
+		require.NoError(t, err)
+		out := t.TempDir()
+		site := ppssg.Site{Renderer: pp, FS: fsys, Layout: "layouts/default.tmpl"}
+
+		_, err = site.Generate(out)
+
+		require.NoError(t, err)
+		require.Equal(t, "<main>home item</main>", readFile(t, filepath.Join(out, "index.html")))
+	})
+
+	t.Run("copies assets unchanged when CopyAssets is set", func(t *testing.T) {
+		fsys := siteFS()
+		pp, err := passepartout.LoadFrom(fsys)
+		require.NoError(t, err)
+		out := t.TempDir()
+		site := ppssg.Site{Renderer: pp, FS: fsys, CopyAssets: true}
+
+		result, err := site.Generate(out)
+
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"css/app.css": "css/app.css", "img/logo.png": "img/logo.png"}, result.Assets)
+		require.Equal(t, "body { color: red; }", readFile(t, filepath.Join(out, "css", "app.css")))
+		require.Equal(t, string([]byte{0x89, 0x50, 0x4e, 0x47}), readFile(t, filepath.Join(out, "img", "logo.png")))
+	})
+
+	t.Run("doesn't copy the fixtures of the pages or OWNERS files", func(t *testing.T) {
+		fsys := siteFS()
+		fsys["index.tmpl.data/empty.json"] = &fstest.MapFile{Data: []byte(`{}`)}
+		fsys["OWNERS"] = &fstest.MapFile{Data: []byte("css/ @design\n")}
+		fsys["css/OWNERS"] = &fstest.MapFile{Data: []byte("* @design\n")}
+		pp, err := passepartout.LoadFrom(fsys)
+		require.NoError(t, err)
+		out := t.TempDir()
+		site := ppssg.Site{Renderer: pp, FS: fsys, CopyAssets: true}
+
+		result, err := site.Generate(out)
+
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"css/app.css": "css/app.css", "img/logo.png": "img/logo.png"}, result.Assets)
+		require.NoDirExists(t, filepath.Join(out, "index.tmpl.data"))
+		require.NoFileExists(t, filepath.Join(out, "OWNERS"))
+	})
+
+	t.Run("fingerprints copied assets and writes a manifest of them", func(t *testing.T) {
+		fsys := siteFS()
+		pp, err := passepartout.LoadFrom(fsys)
+		require.NoError(t, err)
+		out := t.TempDir()
+		site := ppssg.Site{Renderer: pp, FS: fsys, CopyAssets: true, FingerprintAssets: true, AssetManifest: "manifest.json"}
+
+		result, err := site.Generate(out)
+
+		require.NoError(t, err)
+		require.Regexp(t, `^css/app\.[0-9a-f]{8}\.css$`, result.Assets["css/app.css"])
+		require.Equal(t, "body { color: red; }", readFile(t, filepath.Join(out, filepath.FromSlash(result.Assets["css/app.css"]))))
+		require.NoFileExists(t, filepath.Join(out, "css", "app.css"), "expected only the fingerprinted name to be written")
+
+		var manifest map[string]ppssg.ManifestEntry
+		require.NoError(t, json.Unmarshal([]byte(readFile(t, filepath.Join(out, "manifest.json"))), &manifest))
+		require.Equal(t, result.Assets["css/app.css"], manifest["css/app.css"].File)
+		require.Equal(t, result.Assets["img/logo.png"], manifest["img/logo.png"].File)
+	})
+
+	t.Run("returns an error naming the page when a page fails to render", func(t *testing.T) {
+		fsys := fstest.MapFS{"index.tmpl": {Data: []byte(`{{ template "missing" }}`)}}
+		pp, err := passepartout.LoadFrom(fsys)
+		require.NoError(t, err)
+		site := ppssg.Site{Renderer: pp, FS: fsys}
+
+		_, err = site.Generate(t.TempDir())
+
+		require.ErrorContains(t, err, `failed to render page "index.tmpl"`)
+	})
+}
+
+func TestSite_Generate_PagesGlob(t *testing.T) {
+	fsys := siteFS()
+	fsys["reviews/index.tmpl"] = &fstest.MapFile{Data: []byte(`reviews`)}
+	fsys["reviews/index/_item.tmpl"] = &fstest.MapFile{Data: []byte(`item`)}
+	fsys["reviews/show.tmpl"] = &fstest.MapFile{Data: []byte(`review`)}
+	pp, err := passepartout.LoadFrom(fsys)
+	require.NoError(t, err)
+	out := t.TempDir()
+	site := ppssg.Site{Renderer: pp, FS: fsys, PagesGlob: "reviews/*.tmpl"}
+
+	result, err := site.Generate(out)
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"reviews/index.tmpl", "reviews/show.tmpl"}, result.Pages)
+	require.Equal(t, "review", readFile(t, filepath.Join(out, "reviews", "show.html")))
+	require.NoFileExists(t, filepath.Join(out, "index.html"), "expected only the pages matching the glob to be rendered")
+}
+
+func TestSite_Generate_Sitemap(t *testing.T) {
+	fsys := siteFS()
+	fsys["reviews/show.tmpl"] = &fstest.MapFile{Data: []byte(`review`)}
+	fsys["drafts.tmpl"] = &fstest.MapFile{Data: []byte(`drafts`)}
+	pp, err := passepartout.LoadFrom(fsys)
+	require.NoError(t, err)
+
+	t.Run("lists the HTML pages rendered with what the entry says about them", func(t *testing.T) {
+		out := t.TempDir()
+		site := ppssg.Site{
+			Renderer:       pp,
+			FS:             fsys,
+			SitemapBaseURL: "https://example.com/",
+			SitemapEntry: func(page string) (ppssg.SitemapEntry, error) {
+				switch page {
+				case "reviews/show.tmpl":
+					return ppssg.SitemapEntry{LastMod: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), ChangeFreq: "weekly", Priority: 0.8}, nil
+				case "drafts.tmpl":
+					return ppssg.SitemapEntry{Skip: true}, nil
+				}
+				return ppssg.SitemapEntry{}, nil
+			},
+		}
+
+		_, err := site.Generate(out)
+
+		require.NoError(t, err)
+		require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://example.com/</loc>
+  </url>
+  <url>
+    <loc>https://example.com/reviews/show.html</loc>
+    <lastmod>2024-05-01T12:00:00Z</lastmod>
+    <changefreq>weekly</changefreq>
+    <priority>0.8</priority>
+  </url>
+</urlset>`, readFile(t, filepath.Join(out, "sitemap.xml")))
+	})
+
+	t.Run("escapes the paths of the pages and keeps every digit of the priority", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"café/index.tmpl": {Data: []byte(`café`)},
+			"our team.tmpl":   {Data: []byte(`team`)},
+		}
+		pp, err := passepartout.LoadFrom(fsys)
+		require.NoError(t, err)
+		out := t.TempDir()
+		site := ppssg.Site{
+			Renderer:       pp,
+			FS:             fsys,
+			SitemapBaseURL: "https://example.com",
+			SitemapEntry: func(page string) (ppssg.SitemapEntry, error) {
+				return ppssg.SitemapEntry{Priority: 0.85}, nil
+			},
+		}
+
+		_, err = site.Generate(out)
+
+		require.NoError(t, err)
+		require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://example.com/caf%C3%A9/</loc>
+    <priority>0.85</priority>
+  </url>
+  <url>
+    <loc>https://example.com/our%20team.html</loc>
+    <priority>0.85</priority>
+  </url>
+</urlset>`, readFile(t, filepath.Join(out, "sitemap.xml")))
+	})
+
+	t.Run("is only written with a base URL", func(t *testing.T) {
+		out := t.TempDir()
+		site := ppssg.Site{Renderer: pp, FS: fsys}
+
+		_, err := site.Generate(out)
+
+		require.NoError(t, err)
+		require.NoFileExists(t, filepath.Join(out, "sitemap.xml"))
+	})
+
+	t.Run("returns an error naming the page when its entry fails", func(t *testing.T) {
+		site := ppssg.Site{
+			Renderer:       pp,
+			FS:             fsys,
+			SitemapBaseURL: "https://example.com",
+			SitemapEntry: func(page string) (ppssg.SitemapEntry, error) {
+				return ppssg.SitemapEntry{}, errors.New("no such page")
+			},
+		}
+
+		_, err := site.Generate(t.TempDir())
+
+		require.ErrorContains(t, err, `failed to write sitemap: failed to get the sitemap entry of "drafts.tmpl": no such page`)
+	})
+}
@@ -0,0 +1,89 @@
+package pptest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"testing"
+)
+
+// FixtureExt is the extension of fixture files.
+const FixtureExt = ".json"
+
+// Fixture is a named set of data to render a page with, like "empty" or "long-names".
+type Fixture struct {
+	Name string
+	Data any
+}
+
+// FixturesDir is the folder the fixtures for page are kept in, "index.tmpl" has its fixtures in "index.tmpl.data/".
+func FixturesDir(page string) string {
+	return page + ".data"
+}
+
+// Fixtures loads all the fixtures for page in fsys, sorted by name. Each fixture is a JSON file in the page's
+// [FixturesDir] named after the fixture, so "index.tmpl.data/empty.json" is the fixture "empty" for "index.tmpl".
+// A page without a fixtures folder has no fixtures.
+func Fixtures(fsys fs.FS, page string) ([]Fixture, error) {
+	entries, err := fs.ReadDir(fsys, FixturesDir(page))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list fixtures for %q: %w", page, err)
+	}
+
+	var fixtures []Fixture
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != FixtureExt {
+			continue
+		}
+
+		name := strings.TrimSuffix(entry.Name(), FixtureExt)
+		data, err := LoadFixture(fsys, page, name)
+		if err != nil {
+			return nil, err
+		}
+		fixtures = append(fixtures, Fixture{Name: name, Data: data})
+	}
+
+	return fixtures, nil
+}
+
+// LoadFixture loads the fixture name for page.
+func LoadFixture(fsys fs.FS, page string, name string) (any, error) {
+	content, err := fs.ReadFile(fsys, path.Join(FixturesDir(page), name+FixtureExt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture %q for %q: %w", name, page, err)
+	}
+
+	var data any
+	if err := json.Unmarshal(content, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %q for %q: %w", name, page, err)
+	}
+
+	return data, nil
+}
+
+// EachFixture runs fn as a subtest named after each fixture of page,
+// and fails the test if the page has no fixtures so a misplaced fixtures folder doesn't go unnoticed.
+func EachFixture(t *testing.T, fsys fs.FS, page string, fn func(t *testing.T, f Fixture)) {
+	t.Helper()
+
+	fixtures, err := Fixtures(fsys, page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatalf("expected fixtures for %q in %q", page, FixturesDir(page))
+	}
+
+	for _, f := range fixtures {
+		t.Run(f.Name, func(t *testing.T) {
+			fn(t, f)
+		})
+	}
+}
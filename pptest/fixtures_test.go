@@ -0,0 +1,62 @@
+package pptest_test
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/pptest"
+)
+
+func fixturesFS() fstest.MapFS {
+	return fstest.MapFS{
+		"index.tmpl":                 {Data: []byte(`{{ range .Items }}{{ . }},{{ else }}nothing{{ end }}`)},
+		"index.tmpl.data/full.json":  {Data: []byte(`{"Items": ["a", "b"]}`)},
+		"index.tmpl.data/empty.json": {Data: []byte(`{"Items": []}`)},
+		"index.tmpl.data/notes.txt":  {Data: []byte(`not a fixture`)},
+		"broken.tmpl.data/bad.json":  {Data: []byte(`{`)},
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	t.Run("loads every fixture for the page sorted by name", func(t *testing.T) {
+		actual, err := pptest.Fixtures(fixturesFS(), "index.tmpl")
+
+		require.NoError(t, err)
+		require.Equal(t, []pptest.Fixture{
+			{Name: "empty", Data: map[string]any{"Items": []any{}}},
+			{Name: "full", Data: map[string]any{"Items": []any{"a", "b"}}},
+		}, actual)
+	})
+
+	t.Run("returns no fixtures for a page without a fixtures folder", func(t *testing.T) {
+		actual, err := pptest.Fixtures(fixturesFS(), "about.tmpl")
+
+		require.NoError(t, err)
+		require.Empty(t, actual)
+	})
+
+	t.Run("returns an error naming the fixture that fails to parse", func(t *testing.T) {
+		_, err := pptest.Fixtures(fixturesFS(), "broken.tmpl")
+
+		require.ErrorContains(t, err, `failed to parse fixture "bad" for "broken.tmpl"`)
+	})
+}
+
+func TestEachFixture(t *testing.T) {
+	fsys := fixturesFS()
+	pp, err := passepartout.LoadFrom(fsys)
+	require.NoError(t, err)
+	rendered := make(map[string]string)
+
+	pptest.EachFixture(t, fsys, "index.tmpl", func(t *testing.T, f pptest.Fixture) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, pp.Render(buf, "index.tmpl", f.Data))
+		rendered[f.Name] = buf.String()
+	})
+
+	require.Equal(t, map[string]string{"empty": "nothing", "full": "a,b,"}, rendered)
+}
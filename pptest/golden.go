@@ -0,0 +1,101 @@
+package pptest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// Case is a page rendered with one set of data and compared with the golden file Name.
+type Case struct {
+	Page string
+	// Name is the golden file name, the page itself or "<page>/<fixture>" when rendered with a fixture.
+	Name string
+	Data any
+}
+
+// Cases returns a case for every page ending with ext in templates, following [ppdefaults.KindOf].
+// A page with fixtures, see [Fixtures], gets one case per fixture, otherwise it's rendered with the data in
+// "<page>.json" from data, or nil data when there's no such file or data is nil.
+func Cases(templates fs.FS, ext string, data fs.FS) ([]Case, error) {
+	var cases []Case
+	err := fs.WalkDir(templates, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || !strings.HasSuffix(filePath, ext) || ppdefaults.KindOf(filePath) != ppdefaults.KindPage {
+			return nil
+		}
+
+		fixtures, err := Fixtures(templates, filePath)
+		if err != nil {
+			return err
+		}
+		for _, f := range fixtures {
+			cases = append(cases, Case{Page: filePath, Name: filePath + "/" + f.Name, Data: f.Data})
+		}
+		if len(fixtures) > 0 {
+			return nil
+		}
+
+		pageData, err := loadData(data, filePath)
+		if err != nil {
+			return err
+		}
+		cases = append(cases, Case{Page: filePath, Name: filePath, Data: pageData})
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find golden cases: %w", err)
+	}
+
+	return cases, nil
+}
+
+func loadData(data fs.FS, page string) (any, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	name := page + FixtureExt
+	content, err := fs.ReadFile(data, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read data for %q: %w", page, err)
+	}
+
+	var v any
+	if err := json.Unmarshal(content, &v); err != nil {
+		return nil, fmt.Errorf("failed to parse data %q: %w", name, err)
+	}
+
+	return v, nil
+}
+
+// GoldenPages renders every case, within layout unless it's empty, and compares it with its golden file as a subtest,
+// a safety net when refactoring shared partials and layouts. See [Golden] for how to update the golden files.
+func GoldenPages(t *testing.T, r Renderer, layout string, cases []Case) {
+	t.Helper()
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			var out string
+			if layout != "" {
+				out = RenderInLayout(t, r, layout, c.Page, c.Data)
+			} else {
+				out = Render(t, r, c.Page, c.Data)
+			}
+
+			Golden(t, c.Name, out)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package pptest_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/pptest"
+)
+
+func goldenFS() fstest.MapFS {
+	return fstest.MapFS{
+		"layouts/default.tmpl":       {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":                 {Data: []byte(`{{ range .Items }}{{ . }},{{ else }}nothing{{ end }}`)},
+		"index.tmpl.data/empty.json": {Data: []byte(`{"Items": []}`)},
+		"index.tmpl.data/full.json":  {Data: []byte(`{"Items": ["a", "b"]}`)},
+		"index/_item.tmpl":           {Data: []byte(`item`)},
+		"about.tmpl":                 {Data: []byte(`about {{ .Name }}`)},
+		"contact.tmpl":               {Data: []byte(`contact`)},
+		"style.css":                  {Data: []byte(`body {}`)},
+	}
+}
+
+func TestCases(t *testing.T) {
+	t.Run("returns a case per fixture or with the page's data", func(t *testing.T) {
+		data := fstest.MapFS{"about.tmpl.json": {Data: []byte(`{"Name": "us"}`)}}
+
+		actual, err := pptest.Cases(goldenFS(), ".tmpl", data)
+
+		require.NoError(t, err)
+		require.Equal(t, []pptest.Case{
+			{Page: "about.tmpl", Name: "about.tmpl", Data: map[string]any{"Name": "us"}},
+			{Page: "contact.tmpl", Name: "contact.tmpl"},
+			{Page: "index.tmpl", Name: "index.tmpl/empty", Data: map[string]any{"Items": []any{}}},
+			{Page: "index.tmpl", Name: "index.tmpl/full", Data: map[string]any{"Items": []any{"a", "b"}}},
+		}, actual)
+	})
+
+	t.Run("returns an error for data that fails to parse", func(t *testing.T) {
+		_, err := pptest.Cases(goldenFS(), ".tmpl", fstest.MapFS{"about.tmpl.json": {Data: []byte(`{`)}})
+
+		require.ErrorContains(t, err, `failed to parse data "about.tmpl.json"`)
+	})
+}
+
+func TestGoldenPages(t *testing.T) {
+	t.Chdir(t.TempDir())
+	fsys := goldenFS()
+	pp, err := passepartout.LoadFrom(fsys)
+	require.NoError(t, err)
+	cases, err := pptest.Cases(fsys, ".tmpl", nil)
+	require.NoError(t, err)
+
+	setUpdate(t, "true")
+	pptest.GoldenPages(t, pp, "layouts/default.tmpl", cases)
+	setUpdate(t, "false")
+
+	pptest.GoldenPages(t, pp, "layouts/default.tmpl", cases)
+	require.Equal(t, "<main>a,b,</main>", readGolden(t, "index.tmpl/full"))
+	require.Equal(t, "<main>about </main>", readGolden(t, "about.tmpl"))
+}
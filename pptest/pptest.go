@@ -0,0 +1,85 @@
+// Package pptest helps with testing templates rendered by passepartout: rendering without buffers, comparing with
+// golden files, normalizing HTML, and rendering pages with each of their fixtures.
+package pptest
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files used by pptest.Golden")
+
+// Renderer is what renders templates, like [passepartout.Passepartout].
+type Renderer interface {
+	Render(out io.Writer, name string, data any) error
+	RenderInLayout(out io.Writer, layout string, name string, data any) error
+}
+
+// Render renders name with data and fails the test if it fails.
+func Render(t testing.TB, r Renderer, name string, data any) string {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	require.NoError(t, r.Render(buf, name, data), "expected %q to render", name)
+
+	return buf.String()
+}
+
+// RenderInLayout renders name within layout with data and fails the test if it fails.
+func RenderInLayout(t testing.TB, r Renderer, layout string, name string, data any) string {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	require.NoError(t, r.RenderInLayout(buf, layout, name, data), "expected %q to render in %q", name, layout)
+
+	return buf.String()
+}
+
+// GoldenFile is where the golden file for name is kept, "testdata/<name>.golden" relative to the test's package.
+func GoldenFile(name string) string {
+	return filepath.Join("testdata", filepath.FromSlash(name)+".golden")
+}
+
+// Golden compares actual with the golden file for name, see [GoldenFile], and fails the test if they differ.
+// Run the tests with -update to write actual as the new golden file instead.
+func Golden(t testing.TB, name string, actual string) {
+	t.Helper()
+	file := GoldenFile(name)
+
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		require.NoError(t, os.WriteFile(file, []byte(actual), 0o644))
+		return
+	}
+
+	expected, err := os.ReadFile(file)
+	require.NoError(t, err, "expected golden file %q to exist, run the tests with -update to create it", file)
+	require.Equal(t, string(expected), actual, "expected the output to match %q, run the tests with -update if the change is intended", file)
+}
+
+var (
+	betweenTags = regexp.MustCompile(`>\s+<`)
+	whitespace  = regexp.MustCompile(`\s+`)
+)
+
+// NormalizeHTML makes HTML comparable regardless of indentation by removing the whitespace between tags and
+// collapsing all other whitespace to a single space. It doesn't parse the HTML, so whitespace inside of <pre>
+// and attributes is collapsed as well.
+func NormalizeHTML(html string) string {
+	html = betweenTags.ReplaceAllString(html, "><")
+	html = whitespace.ReplaceAllString(html, " ")
+
+	return strings.TrimSpace(html)
+}
+
+// EqualHTML fails the test unless expected and actual are equal after [NormalizeHTML].
+func EqualHTML(t testing.TB, expected string, actual string) {
+	t.Helper()
+	require.Equal(t, NormalizeHTML(expected), NormalizeHTML(actual))
+}
@@ -0,0 +1,110 @@
+package pptest_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/pptest"
+)
+
+// recordingT records failures instead of stopping the test, to test the helpers failing.
+type recordingT struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recordingT) Helper()                           {}
+func (r *recordingT) Errorf(format string, args ...any) { r.failed = true }
+func (r *recordingT) FailNow()                          { r.failed = true }
+
+func TestRender(t *testing.T) {
+	pp, err := passepartout.LoadFrom(fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":           {Data: []byte(`Hello, {{ . }}!`)},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "Hello, World!", pptest.Render(t, pp, "index.tmpl", "World"))
+	require.Equal(t, "<main>Hello, World!</main>", pptest.RenderInLayout(t, pp, "layouts/default.tmpl", "index.tmpl", "World"))
+
+	rt := &recordingT{TB: t}
+	pptest.Render(rt, pp, "missing.tmpl", nil)
+	require.True(t, rt.failed, "expected a failed render to fail the test")
+}
+
+func setUpdate(t *testing.T, value string) {
+	t.Helper()
+	require.NoError(t, flag.Set("update", value))
+	t.Cleanup(func() { _ = flag.Set("update", "false") })
+}
+
+func readGolden(t *testing.T, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(pptest.GoldenFile(name))
+	require.NoError(t, err)
+
+	return string(content)
+}
+
+func TestGolden(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	t.Run("writes the golden file with -update", func(t *testing.T) {
+		setUpdate(t, "true")
+
+		pptest.Golden(t, "reviews/index", "<h1>Reviews</h1>")
+
+		require.FileExists(t, filepath.Join("testdata", "reviews", "index.golden"))
+		require.Equal(t, "<h1>Reviews</h1>", readGolden(t, "reviews/index"))
+	})
+
+	t.Run("passes when the output matches", func(t *testing.T) {
+		pptest.Golden(t, "reviews/index", "<h1>Reviews</h1>")
+	})
+
+	t.Run("fails when the output differs", func(t *testing.T) {
+		rt := &recordingT{TB: t}
+
+		pptest.Golden(rt, "reviews/index", "<h1>Changed</h1>")
+
+		require.True(t, rt.failed)
+	})
+
+	t.Run("fails when the golden file is missing", func(t *testing.T) {
+		rt := &recordingT{TB: t}
+
+		pptest.Golden(rt, "missing", "")
+
+		require.True(t, rt.failed)
+	})
+}
+
+func TestNormalizeHTML(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{name: "removes indentation between tags", html: "<ul>\n  <li>One</li>\n  <li>Two</li>\n</ul>\n", expected: "<ul><li>One</li><li>Two</li></ul>"},
+		{name: "collapses whitespace in text", html: "<p>Hello,\n\t  World!</p>", expected: "<p>Hello, World!</p>"},
+		{name: "keeps single spaces between words and tags", html: "<p>Hello <b>World</b></p>", expected: "<p>Hello <b>World</b></p>"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, pptest.NormalizeHTML(tc.html))
+		})
+	}
+
+	t.Run("EqualHTML ignores whitespace differences", func(t *testing.T) {
+		pptest.EqualHTML(t, "<ul><li>One</li></ul>", "<ul>\n  <li>One</li>\n</ul>")
+
+		rt := &recordingT{TB: t}
+		pptest.EqualHTML(rt, "<ul><li>One</li></ul>", "<ul><li>Two</li></ul>")
+		require.True(t, rt.failed)
+	})
+}
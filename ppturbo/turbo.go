@@ -0,0 +1,94 @@
+// Package ppturbo renders Turbo Stream responses, https://turbo.hotwired.dev/handbook/streams, where each stream wraps
+// a rendered template in a <turbo-stream> telling Turbo what to do with it:
+//
+//	err := ppturbo.RenderHTTP(w, p,
+//		ppturbo.Stream{Action: ppturbo.Prepend, Target: "reviews", Template: "reviews/_review.tmpl", Data: review},
+//		ppturbo.Stream{Action: ppturbo.Update, Target: "review-count", Template: "reviews/_count.tmpl", Data: count},
+//	)
+package ppturbo
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gaqzi/passepartout"
+)
+
+// ContentType is the media type of Turbo Stream responses.
+const ContentType = "text/vnd.turbo-stream.html"
+
+// Action is what Turbo does with the content of a stream.
+type Action string
+
+// The actions of Turbo Streams.
+const (
+	Append  Action = "append"
+	Prepend Action = "prepend"
+	Replace Action = "replace"
+	Update  Action = "update"
+	// Remove removes the target and is the only action without a template.
+	Remove Action = "remove"
+	Before Action = "before"
+	After  Action = "after"
+)
+
+// Stream is one <turbo-stream> of a response.
+type Stream struct {
+	Action Action
+	// Target is the id of the element the action applies to.
+	Target string
+	// Template is rendered standalone with Data as the content of the stream.
+	Template string
+	Data     any
+}
+
+// IsStreamRequest reports whether r accepts a Turbo Stream response, like the form submissions made by Turbo.
+func IsStreamRequest(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(accept); err == nil && mediaType == ContentType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Render writes streams to out in order, with each template rendered by p. Nothing is written when a template fails to
+// render.
+func Render(out io.Writer, p *passepartout.Passepartout, streams ...Stream) error {
+	buf := new(bytes.Buffer)
+	for _, s := range streams {
+		fmt.Fprintf(buf, `<turbo-stream action="%s" target="%s">`, template.HTMLEscapeString(string(s.Action)), template.HTMLEscapeString(s.Target))
+		if s.Action != Remove {
+			buf.WriteString("<template>")
+			if err := p.Render(buf, s.Template, s.Data); err != nil {
+				return fmt.Errorf("failed to render stream %s %q: %w", s.Action, s.Target, err)
+			}
+			buf.WriteString("</template>")
+		}
+		buf.WriteString("</turbo-stream>")
+	}
+
+	_, err := buf.WriteTo(out)
+	return err
+}
+
+// RenderHTTP writes streams to w like [Render] with the [ContentType], and sends a plain text error when a template
+// fails to render.
+func RenderHTTP(w http.ResponseWriter, p *passepartout.Passepartout, streams ...Stream) error {
+	buf := new(bytes.Buffer)
+	if err := Render(buf, p, streams...); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return err
+	}
+
+	w.Header().Set("Content-Type", ContentType+"; charset=utf-8")
+	_, err := buf.WriteTo(w)
+
+	return err
+}
@@ -0,0 +1,59 @@
+package ppturbo_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/ppturbo"
+)
+
+func TestRenderHTTP(t *testing.T) {
+	pp, err := passepartout.LoadFrom(fstest.MapFS{
+		"reviews/_review.tmpl": {Data: []byte(`<p>{{ . }}</p>`)},
+		"reviews/_count.tmpl":  {Data: []byte(`{{ . }} reviews`)},
+	})
+	require.NoError(t, err)
+
+	t.Run("renders the streams in order in one response", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		require.NoError(t, ppturbo.RenderHTTP(rec, pp,
+			ppturbo.Stream{Action: ppturbo.Prepend, Target: "reviews", Template: "reviews/_review.tmpl", Data: "<b>Great</b>"},
+			ppturbo.Stream{Action: ppturbo.Update, Target: "review-count", Template: "reviews/_count.tmpl", Data: 3},
+			ppturbo.Stream{Action: ppturbo.Remove, Target: `empty"state`},
+		))
+
+		require.Equal(t, "text/vnd.turbo-stream.html; charset=utf-8", rec.Header().Get("Content-Type"))
+		require.Equal(t, ``+
+			`<turbo-stream action="prepend" target="reviews"><template><p>&lt;b&gt;Great&lt;/b&gt;</p></template></turbo-stream>`+
+			`<turbo-stream action="update" target="review-count"><template>3 reviews</template></turbo-stream>`+
+			`<turbo-stream action="remove" target="empty&#34;state"></turbo-stream>`,
+			rec.Body.String())
+	})
+
+	t.Run("sends an error and none of the streams when a template fails", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		err := ppturbo.RenderHTTP(rec, pp,
+			ppturbo.Stream{Action: ppturbo.Append, Target: "reviews", Template: "reviews/_review.tmpl", Data: "Great"},
+			ppturbo.Stream{Action: ppturbo.Replace, Target: "missing", Template: "missing.tmpl"},
+		)
+
+		require.ErrorContains(t, err, `failed to render stream replace "missing"`)
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+		require.NotContains(t, rec.Body.String(), "turbo-stream")
+	})
+}
+
+func TestIsStreamRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/reviews", nil)
+	require.False(t, ppturbo.IsStreamRequest(req))
+
+	req.Header.Set("Accept", "text/vnd.turbo-stream.html, text/html, application/xhtml+xml")
+	require.True(t, ppturbo.IsStreamRequest(req))
+}
@@ -0,0 +1,147 @@
+// Package ppurl builds URLs in templates, with the values escaped for where they're used, so links with query strings,
+// email addresses, and phone numbers don't have to be escaped by hand.
+//
+// Register the funcs on the base template, see [ppdefaults.Loader.TemplateConfig]:
+//
+//	loader := ppdefaults.NewLoaderBuilder().
+//		WithDefaults(fsys).
+//		WithFuncs(ppurl.FuncMap()).
+//		Build()
+//
+// And then use them in templates as:
+//
+//	<a href="{{ withQuery "/search" "q" .Query "page" .Page }}">Next</a>
+//	<a href="{{ mailto .Email "subject" "Hello there" }}">Email</a>
+//	<a href="{{ telLink .Phone }}">Call</a>
+//	<a href="{{ safeURL .User.Website }}">Website</a>
+package ppurl
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+)
+
+// unsafeURL is what html/template renders in place of a URL it doesn't trust.
+const unsafeURL = template.URL("#ZgotmplZ")
+
+// SafeURL returns rawURL as a trusted URL when it's relative or uses the http, https, mailto, or tel scheme, escaped
+// where needed, and otherwise "#ZgotmplZ" like html/template, for URLs from users such as the website on a profile.
+func SafeURL(rawURL string) template.URL {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return unsafeURL
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto", "tel":
+		return template.URL(u.String())
+	default:
+		return unsafeURL
+	}
+}
+
+// Mailto returns a mailto link to address, with the params as pairs of header names and values, like "subject" and
+// "body".
+func Mailto(address string, params ...string) (template.URL, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("failed to build mailto link: expected pairs of names and values, got %d params", len(params))
+	}
+
+	link := "mailto:" + url.PathEscape(address)
+	if len(params) > 0 {
+		values := make(url.Values, len(params)/2)
+		for i := 0; i < len(params); i += 2 {
+			values.Add(params[i], params[i+1])
+		}
+		// Mail clients read "+" as a plus, so spaces are escaped as "%20" instead.
+		link += "?" + strings.ReplaceAll(values.Encode(), "+", "%20")
+	}
+
+	return template.URL(link), nil
+}
+
+// TelLink returns a tel link to number, without the spaces, dashes, dots, and parentheses used to format it.
+func TelLink(number string) template.URL {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(number) {
+		if r >= '0' && r <= '9' || r == '+' && i == 0 {
+			b.WriteRune(r)
+		}
+	}
+
+	return template.URL("tel:" + b.String())
+}
+
+// Query returns the query string of pairs of names and values, like `q=go+templates&page=2`. Values are formatted
+// with [fmt.Sprint], a slice adds the name once for each of its values, and a nil value leaves the name out.
+func Query(pairs ...any) (template.URL, error) {
+	values, err := queryValues(url.Values{}, pairs)
+	if err != nil {
+		return "", fmt.Errorf("failed to build query: %w", err)
+	}
+
+	return template.URL(values.Encode()), nil
+}
+
+// WithQuery returns base, which has to be trusted like with [SafeURL], with the pairs of names and values of [Query]
+// set in its query string, replacing the values already there, like "/search?q=go&page=2" for a pagination link.
+func WithQuery(base string, pairs ...any) (template.URL, error) {
+	if SafeURL(base) == unsafeURL {
+		return "", fmt.Errorf("failed to build URL: %q isn't a trusted URL", base)
+	}
+	u, err := url.Parse(strings.TrimSpace(base))
+	if err != nil {
+		return "", fmt.Errorf("failed to build URL: %w", err)
+	}
+
+	values, err := queryValues(u.Query(), pairs)
+	if err != nil {
+		return "", fmt.Errorf("failed to build URL: %w", err)
+	}
+	u.RawQuery = values.Encode()
+
+	return template.URL(u.String()), nil
+}
+
+// queryValues sets the pairs of names and values in values.
+func queryValues(values url.Values, pairs []any) (url.Values, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("expected pairs of names and values, got %d arguments", len(pairs))
+	}
+
+	for i := 0; i < len(pairs); i += 2 {
+		name, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("expected argument %d to be a name, got %T", i, pairs[i])
+		}
+
+		values.Del(name)
+		switch value := pairs[i+1].(type) {
+		case nil:
+		case []string:
+			values[name] = append(values[name], value...)
+		case []any:
+			for _, v := range value {
+				values.Add(name, fmt.Sprint(v))
+			}
+		default:
+			values.Set(name, fmt.Sprint(value))
+		}
+	}
+
+	return values, nil
+}
+
+// FuncMap returns the "safeURL", "mailto", "telLink", "query", and "withQuery" funcs for use with
+// [template.Template.Funcs].
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"safeURL":   SafeURL,
+		"mailto":    Mailto,
+		"telLink":   TelLink,
+		"query":     Query,
+		"withQuery": WithQuery,
+	}
+}
@@ -0,0 +1,101 @@
+package ppurl_test
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout/ppurl"
+)
+
+func TestSafeURL(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		url      string
+		expected template.URL
+	}{
+		{name: "keeps https URLs", url: "https://example.com/a b?q=1", expected: "https://example.com/a%20b?q=1"},
+		{name: "keeps relative URLs", url: "/reviews/1", expected: "/reviews/1"},
+		{name: "keeps mailto links", url: "mailto:ada@example.com", expected: "mailto:ada@example.com"},
+		{name: "rejects javascript URLs", url: " JavaScript:alert(1)", expected: "#ZgotmplZ"},
+		{name: "rejects data URLs", url: "data:text/html,<script>alert(1)</script>", expected: "#ZgotmplZ"},
+		{name: "rejects URLs that don't parse", url: "java\tscript:alert(1)", expected: "#ZgotmplZ"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, ppurl.SafeURL(tc.url))
+		})
+	}
+}
+
+func TestMailto(t *testing.T) {
+	t.Run("escapes the address and the params", func(t *testing.T) {
+		link, err := ppurl.Mailto("ada lovelace@example.com", "subject", "Hello & welcome", "body", "1+1")
+
+		require.NoError(t, err)
+		require.Equal(t, template.URL("mailto:ada%20lovelace@example.com?body=1%2B1&subject=Hello%20%26%20welcome"), link)
+	})
+
+	t.Run("fails without a value for every param", func(t *testing.T) {
+		_, err := ppurl.Mailto("ada@example.com", "subject")
+
+		require.EqualError(t, err, "failed to build mailto link: expected pairs of names and values, got 1 params")
+	})
+}
+
+func TestTelLink(t *testing.T) {
+	require.Equal(t, template.URL("tel:+46701234567"), ppurl.TelLink(" +46 (70) 123-45.67"))
+	require.Equal(t, template.URL("tel:0701234567"), ppurl.TelLink("070 123+45 67"), "only keeps a leading plus")
+}
+
+func TestQuery(t *testing.T) {
+	t.Run("escapes the names and values", func(t *testing.T) {
+		query, err := ppurl.Query("q", "go & templates", "page", 2, "tag", []string{"a", "b"}, "empty", nil)
+
+		require.NoError(t, err)
+		require.Equal(t, template.URL("page=2&q=go+%26+templates&tag=a&tag=b"), query)
+	})
+
+	t.Run("fails for a name that isn't a string", func(t *testing.T) {
+		_, err := ppurl.Query(1, "a")
+
+		require.EqualError(t, err, "failed to build query: expected argument 0 to be a name, got int")
+	})
+}
+
+func TestWithQuery(t *testing.T) {
+	t.Run("replaces the values already in the query", func(t *testing.T) {
+		u, err := ppurl.WithQuery("/search?q=go&page=1", "page", 2)
+
+		require.NoError(t, err)
+		require.Equal(t, template.URL("/search?page=2&q=go"), u)
+	})
+
+	t.Run("fails for a base that isn't trusted", func(t *testing.T) {
+		_, err := ppurl.WithQuery("javascript:alert(1)", "page", 2)
+
+		require.EqualError(t, err, `failed to build URL: "javascript:alert(1)" isn't a trusted URL`)
+	})
+}
+
+func TestFuncMap(t *testing.T) {
+	tmpl := template.Must(template.New("").Funcs(ppurl.FuncMap()).Parse(
+		`<a href="{{ withQuery "/search" "q" .Query }}">` +
+			`<a href="/search?{{ query "q" .Query }}">` +
+			`<a href="{{ mailto .Email "subject" "Hi there" }}">` +
+			`<a href="{{ telLink .Phone }}">` +
+			`<a href="{{ safeURL .Website }}">`))
+	out := new(bytes.Buffer)
+
+	err := tmpl.Execute(out, map[string]string{
+		"Query": `"><script>`, "Email": "ada@example.com", "Phone": "+46 70", "Website": "javascript:alert(1)",
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, `<a href="/search?q=%22%3E%3Cscript%3E">`+
+		`<a href="/search?q=%22%3E%3Cscript%3E">`+
+		`<a href="mailto:ada@example.com?subject=Hi%20there">`+
+		`<a href="tel:&#43;4670">`+
+		`<a href="#ZgotmplZ">`, out.String())
+}
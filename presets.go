@@ -0,0 +1,104 @@
+package passepartout
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"slices"
+	"strings"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// Dev loads the templates in fsys like [LoadFrom] for development: the templates are read on every render so changes
+// show up without a restart, the browser reloads when they change with [WithLiveReload], the HTML says which files
+// it was rendered from with [WithDebugComments], and fields missing from the data are errors with
+// [WithDataValidation] and "missingkey=error". opts are applied after the preset's options.
+func Dev(fsys fs.FS, opts ...Option) (*Passepartout, error) {
+	return LoadFrom(fsys, append([]Option{
+		WithLiveReload(0),
+		WithDebugComments(),
+		WithDataValidation(),
+		WithTemplateOption("missingkey=error"),
+	}, opts...)...)
+}
+
+// Prod loads the templates in fsys like [LoadFrom] for production: every template is created once with
+// [WithTemplateCache], all the pages and layouts are loaded up front with [Passepartout.Preload] so broken templates
+// fail at startup, and the output is written at once with [WithBuffering]. opts are applied after the preset's
+// options.
+func Prod(fsys fs.FS, opts ...Option) (*Passepartout, error) {
+	p, err := LoadFrom(fsys, append([]Option{WithTemplateCache(), WithBuffering()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.Preload(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Preload loads every page and layout, following [ppdefaults.KindOf], and returns the errors of the ones that fail.
+// Only the files with the extensions of [WithExtensions] are loaded when it's used, otherwise every file is.
+// With [WithTemplateCache] the templates are kept so the first renders don't have to load them.
+// Only instances created with [LoadFrom] know their filesystem, others return an error.
+func (p *Passepartout) Preload(ctx context.Context) error {
+	return p.preload(ctx, p.loader.Load())
+}
+
+// preload is Preload loading the templates from l.
+func (p *Passepartout) preload(ctx context.Context, l loader) error {
+	templates, err := p.Templates()
+	if err != nil {
+		return fmt.Errorf("failed to preload: %w", err)
+	}
+
+	var errs []error
+	for _, t := range templates {
+		if t.Kind != ppdefaults.KindPage && t.Kind != ppdefaults.KindLayout {
+			continue
+		}
+		if len(p.extensions) > 0 && !slices.ContainsFunc(p.extensions, func(ext string) bool { return strings.HasSuffix(t.Name, ext) }) {
+			continue
+		}
+
+		if _, err := p.standaloneFrom(ctx, l, t.Name); err != nil {
+			errs = append(errs, fmt.Errorf("failed to preload %q: %w", t.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// debugComment adds a comment naming the template, layout, and files to HTML output.
+func (p *Passepartout) debugComment(buf *bytes.Buffer, layout, name string) {
+	if mediaType, _, _ := mime.ParseMediaType(contentType(name)); mediaType != "text/html" {
+		return
+	}
+
+	comment := fmt.Sprintf("passepartout: rendered %q", name)
+	if layout != "" {
+		comment += fmt.Sprintf(" in layout %q", layout)
+	}
+	if l, ok := p.loader.Load().(fileLoader); ok {
+		files, err := l.StandaloneFiles(name)
+		if layout != "" {
+			files, err = l.InLayoutFiles(name, layout)
+		}
+		if err == nil {
+			names := make([]string, 0, len(files))
+			for _, f := range files {
+				names = append(names, f.Name)
+			}
+			comment += " from " + strings.Join(names, ", ")
+		}
+	}
+
+	// "--" can't be in a comment, and file names could have it.
+	_, _ = fmt.Fprintf(buf, "\n<!-- %s -->\n", strings.ReplaceAll(comment, "--", "- -"))
+}
@@ -0,0 +1,107 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+func TestDev(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<body>{{ block "content" . }}{{ end }}</body>`)},
+		"index.tmpl":           {Data: []byte(`{{ template "index/_item.tmpl" . }}`)},
+		"index/_item.tmpl":     {Data: []byte(`{{ .name }}`)},
+	}
+	pp, err := passepartout.Dev(fs)
+	require.NoError(t, err)
+
+	t.Run("adds the live reload script and a debug comment", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, pp.RenderInLayout(buf, "layouts/default.tmpl", "index.tmpl", map[string]any{"name": "dev"}))
+
+		require.Equal(t, `<body>dev`+passepartout.LiveReloadScript+`</body>`+
+			"\n<!-- passepartout: rendered \"index.tmpl\" in layout \"layouts/default.tmpl\" from index/_item.tmpl, layouts/default.tmpl, index.tmpl -->\n", buf.String())
+	})
+
+	t.Run("fails on fields missing from the data", func(t *testing.T) {
+		err := pp.Render(new(bytes.Buffer), "index.tmpl", map[string]any{})
+
+		require.ErrorContains(t, err, `reads fields the data doesn't have: .name`)
+	})
+}
+
+func TestProd(t *testing.T) {
+	t.Run("loads every page and layout up front", func(t *testing.T) {
+		fs := fstest.MapFS{
+			"index.tmpl":       {Data: []byte(`{{ template "index/_item.tmpl" . }}`)},
+			"index/_item.tmpl": {Data: []byte(`{{ . }}`)},
+		}
+		pp, err := passepartout.Prod(fs)
+		require.NoError(t, err)
+		delete(fs, "index.tmpl")
+		delete(fs, "index/_item.tmpl")
+
+		buf := new(bytes.Buffer)
+		require.NoError(t, pp.Render(buf, "index.tmpl", "prod"))
+		require.Equal(t, "prod", buf.String(), "expected the preloaded template to be rendered")
+	})
+
+	t.Run("fails when a template is broken", func(t *testing.T) {
+		fs := fstest.MapFS{
+			"index.tmpl":       {Data: []byte(`ok`)},
+			"broken.tmpl":      {Data: []byte(`{{ if }}`)},
+			"index/_item.tmpl": {Data: []byte(`{{ if }}`)},
+		}
+
+		_, err := passepartout.Prod(fs)
+
+		require.ErrorContains(t, err, `failed to preload "broken.tmpl"`)
+		require.ErrorContains(t, err, `failed to preload "index.tmpl"`)
+	})
+}
+
+func TestPassepartout_Preload(t *testing.T) {
+	t.Run("only loads the templates with the extensions when set", func(t *testing.T) {
+		fs := fstest.MapFS{
+			"index.tmpl":                  {Data: []byte(`ok`)},
+			"index.tmpl.data/broken.json": {Data: []byte(`{{ if }}`)},
+		}
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithExtensions(".tmpl"))
+		require.NoError(t, err)
+
+		require.NoError(t, pp.Preload(context.Background()))
+	})
+
+	t.Run("fails without a filesystem", func(t *testing.T) {
+		err := passepartout.New(nil).Preload(context.Background())
+
+		require.ErrorContains(t, err, "failed to preload")
+	})
+}
+
+func TestWithBuffering(t *testing.T) {
+	fs := fstest.MapFS{"index.tmpl": {Data: []byte(`{{ range . }}{{ . }}{{ end }}`)}}
+	pp, err := passepartout.LoadFrom(fs, passepartout.WithBuffering())
+	require.NoError(t, err)
+	out := &countingWriter{}
+
+	require.NoError(t, pp.Render(out, "index.tmpl", []string{"a", "b", "c"}))
+
+	require.Equal(t, "abc", out.String())
+	require.Equal(t, 1, out.writes)
+}
+
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
@@ -0,0 +1,47 @@
+package passepartout
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Provider returns the data a template is rendered with by [Passepartout.RenderAuto], for example by querying the
+// database for the reviews "reviews/index.tmpl" lists.
+type Provider func(ctx context.Context) (any, error)
+
+// Provide registers provider as the data for the template name, replacing the earlier provider for it.
+// Safe to call while rendering.
+func (p *Passepartout) Provide(name string, provider Provider) {
+	p.providers.Store(p.resolve(name), provider)
+}
+
+// RenderAuto renders name with the data from its [Provider], or with nil data when none is registered for it.
+func (p *Passepartout) RenderAuto(ctx context.Context, out io.Writer, name string) error {
+	data, err := p.provide(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return p.RenderContext(ctx, out, name, data)
+}
+
+// ProvidedData returns the data from the [Provider] of page, or nil when none is registered, so it can be used as the
+// Data of ppssg.Site.
+func (p *Passepartout) ProvidedData(page string) (any, error) {
+	return p.provide(context.Background(), page)
+}
+
+func (p *Passepartout) provide(ctx context.Context, name string) (any, error) {
+	provider, ok := p.providers.Load(p.resolve(name))
+	if !ok {
+		return nil, nil
+	}
+
+	data, err := provider.(Provider)(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to provide data for %q: %w", name, err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,75 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+type contextKey struct{}
+
+func TestPassepartout_RenderAuto(t *testing.T) {
+	newPP := func(t *testing.T) *passepartout.Passepartout {
+		pp, err := passepartout.LoadFrom(fstest.MapFS{
+			"reviews/index.tmpl": {Data: []byte(`{{ range . }}<li>{{ . }}</li>{{ end }}`)},
+			"about.tmpl":         {Data: []byte(`About {{ . }}`)},
+		}, passepartout.WithExtensions(".tmpl"))
+		require.NoError(t, err)
+		return pp
+	}
+
+	t.Run("renders the template with the data from its provider, which gets the context", func(t *testing.T) {
+		pp := newPP(t)
+		pp.Provide("reviews/index.tmpl", func(ctx context.Context) (any, error) {
+			return []string{"Good", ctx.Value(contextKey{}).(string)}, nil
+		})
+		out := new(bytes.Buffer)
+
+		require.NoError(t, pp.RenderAuto(context.WithValue(context.Background(), contextKey{}, "Bad"), out, "reviews/index.tmpl"))
+		require.Equal(t, "<li>Good</li><li>Bad</li>", out.String())
+	})
+
+	t.Run("renders with nil data when there's no provider", func(t *testing.T) {
+		out := new(bytes.Buffer)
+
+		require.NoError(t, newPP(t).RenderAuto(context.Background(), out, "about.tmpl"))
+		require.Equal(t, "About ", out.String())
+	})
+
+	t.Run("finds the provider by the resolved name", func(t *testing.T) {
+		pp := newPP(t)
+		pp.Provide("about", func(context.Context) (any, error) { return "us", nil })
+		out := new(bytes.Buffer)
+
+		require.NoError(t, pp.RenderAuto(context.Background(), out, "about.tmpl"))
+		require.Equal(t, "About us", out.String())
+	})
+
+	t.Run("replaces the earlier provider", func(t *testing.T) {
+		pp := newPP(t)
+		pp.Provide("about.tmpl", func(context.Context) (any, error) { return "them", nil })
+		pp.Provide("about.tmpl", func(context.Context) (any, error) { return "us", nil })
+
+		data, err := pp.ProvidedData("about.tmpl")
+
+		require.NoError(t, err)
+		require.Equal(t, "us", data)
+	})
+
+	t.Run("returns the error from the provider without rendering", func(t *testing.T) {
+		pp := newPP(t)
+		pp.Provide("about.tmpl", func(context.Context) (any, error) { return nil, errors.New("database down") })
+		out := new(bytes.Buffer)
+
+		err := pp.RenderAuto(context.Background(), out, "about.tmpl")
+
+		require.EqualError(t, err, `failed to provide data for "about.tmpl": database down`)
+		require.Empty(t, out.String())
+	})
+}
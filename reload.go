@@ -0,0 +1,160 @@
+package passepartout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// TemplateSet is a snapshot of the templates of an instance, built with [Passepartout.NewTemplateSet] and rendered
+// once it's swapped in with [Passepartout.SwapTemplateSet]. Renders in progress keep using the set they started with.
+// A set holds the templates it has created with [WithTemplateCache], any other template is created from the files as
+// they are when it's first rendered.
+type TemplateSet struct {
+	// Version counts the sets built for an instance, the templates it was created with are version 1.
+	Version int
+	// Created is when the set was built.
+	Created time.Time
+
+	owner  *currentLoader
+	loader loader
+	// overlays are the loaders of [Passepartout.RenderVariant] and [Passepartout.ForTenant], by variant or tenant,
+	// built from the same filesystem.
+	overlays sync.Map
+}
+
+// currentLoader holds the template set of an instance so it can be replaced while renders are using it, and the set
+// it replaced so it can be rolled back to.
+type currentLoader struct {
+	set atomic.Pointer[TemplateSet]
+
+	mu       sync.Mutex
+	previous *TemplateSet
+	versions int
+}
+
+func (c *currentLoader) Load() loader {
+	if set := c.set.Load(); set != nil {
+		return set.loader
+	}
+
+	return nil
+}
+
+// Store sets the loader an instance is created with, as version 1.
+func (c *currentLoader) Store(l loader) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.versions = 1
+	c.previous = nil
+	c.set.Store(&TemplateSet{Version: 1, Created: time.Now(), owner: c, loader: l})
+}
+
+func (c *currentLoader) newSet(l loader) *TemplateSet {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.versions++
+	return &TemplateSet{Version: c.versions, Created: time.Now(), owner: c, loader: l}
+}
+
+// TemplateSet returns the set of templates being rendered.
+func (p *Passepartout) TemplateSet() *TemplateSet {
+	return p.loader.set.Load()
+}
+
+// NewTemplateSet builds the loader again from the filesystem, so the templates cached by [WithTemplateCache] are
+// created from the files as they are now, and loads every page and layout like [Passepartout.Preload] does.
+// It returns the errors of the templates that fail to load, otherwise the set is ready to be swapped in with
+// [Passepartout.SwapTemplateSet]. Only instances created with [LoadFrom] know their filesystem, others return an error.
+func (p *Passepartout) NewTemplateSet(ctx context.Context) (*TemplateSet, error) {
+	set, err := p.newTemplateSet(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build template set: %w", err)
+	}
+
+	return set, nil
+}
+
+func (p *Passepartout) newTemplateSet(ctx context.Context) (*TemplateSet, error) {
+	if p.fs == nil {
+		return nil, errors.New("no filesystem, create passepartout with LoadFrom")
+	}
+
+	next, err := p.buildLoader(ppdefaults.NewLoaderBuilder().WithDefaults(p.fs))
+	if err != nil {
+		return nil, err
+	}
+	if err := p.preload(ctx, next); err != nil {
+		return nil, err
+	}
+
+	return p.loader.newSet(next), nil
+}
+
+// SwapTemplateSet renders set from now on, keeping the set it replaces for [Passepartout.Rollback].
+// The outputs cached in a [MemoryOutputStore] are removed, other stores keep them until they expire.
+func (p *Passepartout) SwapTemplateSet(set *TemplateSet) error {
+	if set == nil || set.owner != &p.loader {
+		return errors.New("failed to swap template set: the set wasn't built by this instance")
+	}
+
+	p.loader.mu.Lock()
+	p.loader.previous = p.loader.set.Swap(set)
+	p.loader.mu.Unlock()
+	p.clearOutputs()
+
+	return nil
+}
+
+// Rollback renders the set that was replaced by the last [Passepartout.SwapTemplateSet] again, like when the new
+// templates fail to render. It returns an error when there's no set to roll back to, which there isn't after a
+// rollback until the next swap.
+func (p *Passepartout) Rollback() error {
+	p.loader.mu.Lock()
+	previous := p.loader.previous
+	if previous == nil {
+		p.loader.mu.Unlock()
+		return errors.New("failed to roll back: no previous template set")
+	}
+	p.loader.set.Store(previous)
+	p.loader.previous = nil
+	p.loader.mu.Unlock()
+	p.clearOutputs()
+
+	return nil
+}
+
+// Reload builds a new template set with [Passepartout.NewTemplateSet] and swaps it in, for templates mounted from a
+// volume that's updated in place. When any template fails to load the old templates are kept and the errors are
+// returned. Renders in progress finish with the templates they started with.
+func (p *Passepartout) Reload(ctx context.Context) error {
+	set, err := p.newTemplateSet(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reload: %w", err)
+	}
+
+	return p.SwapTemplateSet(set)
+}
+
+func (p *Passepartout) clearOutputs() {
+	if p.outputCache != nil {
+		p.outputCache.clearPolicies()
+		clearOutputs(p.outputCache.store)
+	}
+	if p.fragmentCache != nil {
+		clearOutputs(p.fragmentCache)
+	}
+}
+
+func clearOutputs(store OutputStore) {
+	if m, ok := store.(*MemoryOutputStore); ok {
+		m.clear()
+	}
+}
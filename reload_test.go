@@ -0,0 +1,200 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+func TestPassepartout_Reload(t *testing.T) {
+	render := func(t *testing.T, pp *passepartout.Passepartout) string {
+		t.Helper()
+		out := new(bytes.Buffer)
+		require.NoError(t, pp.RenderInLayout(out, "layouts/app.tmpl", "index.tmpl", nil))
+		return out.String()
+	}
+
+	t.Run("renders the templates as they are in the filesystem after reloading", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"layouts/app.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+			"index.tmpl":       {Data: []byte(`v1`)},
+		}
+		pp, err := passepartout.LoadFrom(fsys, passepartout.WithTemplateCache())
+		require.NoError(t, err)
+		require.Equal(t, "<main>v1</main>", render(t, pp))
+
+		fsys["index.tmpl"] = &fstest.MapFile{Data: []byte(`v2`)}
+		require.Equal(t, "<main>v1</main>", render(t, pp), "expected the cached template before reloading")
+
+		require.NoError(t, pp.Reload(context.Background()))
+		require.Equal(t, "<main>v2</main>", render(t, pp))
+	})
+
+	t.Run("keeps the old templates when a template fails to load", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"layouts/app.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+			"index.tmpl":       {Data: []byte(`v1`)},
+		}
+		pp, err := passepartout.LoadFrom(fsys, passepartout.WithTemplateCache())
+		require.NoError(t, err)
+		require.Equal(t, "<main>v1</main>", render(t, pp))
+
+		fsys["index.tmpl"] = &fstest.MapFile{Data: []byte(`v2`)}
+		fsys["broken.tmpl"] = &fstest.MapFile{Data: []byte(`{{ if }}`)}
+
+		require.ErrorContains(t, pp.Reload(context.Background()), `failed to reload: failed to preload "broken.tmpl"`)
+		require.Equal(t, "<main>v1</main>", render(t, pp))
+	})
+
+	t.Run("removes the outputs cached in memory", func(t *testing.T) {
+		fsys := fstest.MapFS{"index.tmpl": {Data: []byte(`{{ cache "title" "1h" }}v1{{ endcache }}`)}}
+		pp, err := passepartout.LoadFrom(fsys, passepartout.WithFragmentCache(passepartout.NewMemoryOutputStore()))
+		require.NoError(t, err)
+		require.NoError(t, pp.Render(new(bytes.Buffer), "index.tmpl", nil))
+		require.Equal(t, 1, pp.Stats().FragmentCache.Entries)
+
+		require.NoError(t, pp.Reload(context.Background()))
+
+		require.Zero(t, pp.Stats().FragmentCache.Entries)
+	})
+
+	t.Run("uses the cache directives as they are after reloading", func(t *testing.T) {
+		fsys := fstest.MapFS{"index.tmpl": {Data: []byte(`{{/* cache: ttl=1h vary=locale */}}{{ .locale }}`)}}
+		store := &recordingStore{}
+		pp, err := passepartout.LoadFrom(fsys, passepartout.WithOutputCacheStore(store, nil, 0))
+		require.NoError(t, err)
+		require.NoError(t, pp.Render(new(bytes.Buffer), "index.tmpl", map[string]any{"locale": "sv", "user": "ada"}))
+
+		fsys["index.tmpl"] = &fstest.MapFile{Data: []byte(`{{/* cache: ttl=5m vary=locale,user */}}{{ .locale }}`)}
+		require.NoError(t, pp.Reload(context.Background()))
+		require.NoError(t, pp.Render(new(bytes.Buffer), "index.tmpl", map[string]any{"locale": "sv", "user": "ada"}))
+
+		require.Equal(t, []string{"|index.tmpl|locale=sv", "|index.tmpl|locale=sv|user=ada"}, store.keys)
+		require.Equal(t, []time.Duration{time.Hour, 5 * time.Minute}, store.ttls)
+	})
+
+	t.Run("renders while reloading", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"layouts/app.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+			"index.tmpl":       {Data: []byte(`v1`)},
+		}
+		pp, err := passepartout.LoadFrom(fsys, passepartout.WithTemplateCache())
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 50 {
+					out := new(bytes.Buffer)
+					if err := pp.RenderInLayout(out, "layouts/app.tmpl", "index.tmpl", nil); err != nil || out.String() != "<main>v1</main>" {
+						t.Errorf("expected to render while reloading, got %q: %v", out.String(), err)
+						return
+					}
+				}
+			}()
+		}
+		for range 10 {
+			require.NoError(t, pp.Reload(context.Background()))
+		}
+		wg.Wait()
+	})
+
+	t.Run("fails without a filesystem", func(t *testing.T) {
+		err := passepartout.New(nil).Reload(context.Background())
+
+		require.EqualError(t, err, "failed to reload: no filesystem, create passepartout with LoadFrom")
+	})
+}
+
+func TestPassepartout_TemplateSet(t *testing.T) {
+	newPP := func(t *testing.T) (*passepartout.Passepartout, fstest.MapFS) {
+		t.Helper()
+		fsys := fstest.MapFS{"index.tmpl": {Data: []byte(`v1`)}}
+		pp, err := passepartout.LoadFrom(fsys, passepartout.WithTemplateCache())
+		require.NoError(t, err)
+		return pp, fsys
+	}
+	render := func(t *testing.T, pp *passepartout.Passepartout) string {
+		t.Helper()
+		out := new(bytes.Buffer)
+		require.NoError(t, pp.Render(out, "index.tmpl", nil))
+		return out.String()
+	}
+
+	t.Run("builds a set that's only rendered once it's swapped in", func(t *testing.T) {
+		pp, fsys := newPP(t)
+		require.Equal(t, 1, pp.TemplateSet().Version)
+		require.Equal(t, "v1", render(t, pp))
+
+		fsys["index.tmpl"] = &fstest.MapFile{Data: []byte(`v2`)}
+		set, err := pp.NewTemplateSet(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 2, set.Version)
+		require.Equal(t, "v1", render(t, pp), "expected the old set until the new one is swapped in")
+
+		require.NoError(t, pp.SwapTemplateSet(set))
+		require.Equal(t, "v2", render(t, pp))
+		require.Same(t, set, pp.TemplateSet())
+	})
+
+	t.Run("rolls back to the set that was replaced", func(t *testing.T) {
+		pp, fsys := newPP(t)
+		first := pp.TemplateSet()
+		require.EqualError(t, pp.Rollback(), "failed to roll back: no previous template set")
+		require.Equal(t, "v1", render(t, pp))
+
+		fsys["index.tmpl"] = &fstest.MapFile{Data: []byte(`v2`)}
+		require.NoError(t, pp.Reload(context.Background()))
+		require.Equal(t, "v2", render(t, pp))
+
+		require.NoError(t, pp.Rollback())
+		require.Equal(t, "v1", render(t, pp))
+		require.Same(t, first, pp.TemplateSet())
+		require.EqualError(t, pp.Rollback(), "failed to roll back: no previous template set", "expected to only roll back once")
+	})
+
+	t.Run("returns the errors of the templates that fail to load", func(t *testing.T) {
+		pp, fsys := newPP(t)
+		fsys["broken.tmpl"] = &fstest.MapFile{Data: []byte(`{{ if }}`)}
+
+		set, err := pp.NewTemplateSet(context.Background())
+
+		require.ErrorContains(t, err, `failed to build template set: failed to preload "broken.tmpl"`)
+		require.Nil(t, set)
+	})
+
+	t.Run("only swaps in sets built by the same instance", func(t *testing.T) {
+		pp, _ := newPP(t)
+		other, _ := newPP(t)
+
+		err := pp.SwapTemplateSet(other.TemplateSet())
+
+		require.EqualError(t, err, "failed to swap template set: the set wasn't built by this instance")
+		require.Equal(t, "v1", render(t, pp))
+	})
+}
+
+// recordingStore records the keys and ttls outputs are set with, and never has an output.
+type recordingStore struct {
+	keys []string
+	ttls []time.Duration
+}
+
+func (s *recordingStore) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, nil
+}
+
+func (s *recordingStore) Set(_ context.Context, key string, _ []byte, ttl time.Duration) error {
+	s.keys = append(s.keys, key)
+	s.ttls = append(s.ttls, ttl)
+	return nil
+}
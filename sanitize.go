@@ -0,0 +1,50 @@
+package passepartout
+
+import (
+	"fmt"
+	"html/template"
+)
+
+// Sanitizer removes everything from html that isn't allowed by its policy, like scripts and event handlers, so
+// user-generated HTML can be rendered as it is. The Policy of github.com/microcosm-cc/bluemonday implements it, and
+// the ppsanitize module configures policies for user-generated content and for text only.
+type Sanitizer interface {
+	Sanitize(html string) string
+}
+
+// sanitizeFunc is the sanitize func of [WithSanitizer], which sanitizes html with the sanitizer of policy, or the
+// default policy when none is given.
+func sanitizeFunc(policies map[string]Sanitizer) func(html any, policy ...string) (template.HTML, error) {
+	return func(html any, policy ...string) (template.HTML, error) {
+		if len(policy) > 1 {
+			return "", fmt.Errorf("failed to sanitize: expected at most one policy, got %d", len(policy))
+		}
+
+		name := ""
+		if len(policy) == 1 {
+			name = policy[0]
+		}
+		s, ok := policies[name]
+		if !ok {
+			if name == "" {
+				return "", fmt.Errorf("failed to sanitize: no default policy, configure one with WithSanitizer")
+			}
+			return "", fmt.Errorf("failed to sanitize: unknown policy %q", name)
+		}
+
+		if html == nil {
+			return "", nil
+		}
+
+		return template.HTML(s.Sanitize(fmt.Sprint(html))), nil
+	}
+}
+
+// sanitizePolicy adds s as the policy name of the sanitize func, adding the func the first time.
+func (p *Passepartout) sanitizePolicy(name string, s Sanitizer) {
+	if p.sanitizers == nil {
+		p.sanitizers = make(map[string]Sanitizer)
+		p.funcs = append(p.funcs, template.FuncMap{"sanitize": sanitizeFunc(p.sanitizers)})
+	}
+	p.sanitizers[name] = s
+}
@@ -0,0 +1,90 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"html/template"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+// tagSanitizer removes the tags matching its pattern.
+type tagSanitizer struct {
+	tags *regexp.Regexp
+}
+
+func (s tagSanitizer) Sanitize(html string) string {
+	return s.tags.ReplaceAllString(html, "")
+}
+
+func TestWithSanitizer(t *testing.T) {
+	pp, err := passepartout.LoadFrom(fstest.MapFS{
+		"comment.tmpl": {Data: []byte(`<div>{{ sanitize .Body }}</div>`)},
+		"bio.tmpl":     {Data: []byte(`<p>{{ sanitize .Body "strict" }}</p>`)},
+		"unknown.tmpl": {Data: []byte(`{{ sanitize .Body "unknown" }}`)},
+	},
+		passepartout.WithSanitizer(tagSanitizer{tags: regexp.MustCompile(`</?script[^>]*>`)}),
+		passepartout.WithSanitizePolicy("strict", tagSanitizer{tags: regexp.MustCompile(`<[^>]*>`)}),
+	)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		template string
+		data     any
+		expected string
+	}{
+		{
+			name:     "sanitizes with the default policy",
+			template: "comment.tmpl",
+			data:     map[string]any{"Body": `<b>hi</b><script>alert(1)</script>`},
+			expected: `<div><b>hi</b>alert(1)</div>`,
+		},
+		{
+			name:     "sanitizes with a named policy",
+			template: "bio.tmpl",
+			data:     map[string]any{"Body": `<b>hi</b>`},
+			expected: `<p>hi</p>`,
+		},
+		{
+			name:     "renders nothing for nil",
+			template: "comment.tmpl",
+			data:     map[string]any{"Body": nil},
+			expected: `<div></div>`,
+		},
+		{
+			name:     "sanitizes HTML that's already trusted",
+			template: "comment.tmpl",
+			data:     map[string]any{"Body": template.HTML(`<script>x</script>`)},
+			expected: `<div>x</div>`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+
+			require.NoError(t, pp.Render(out, tc.template, tc.data))
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+
+	t.Run("fails with an unknown policy", func(t *testing.T) {
+		err := pp.Render(new(bytes.Buffer), "unknown.tmpl", map[string]any{"Body": "hi"})
+
+		require.ErrorContains(t, err, `failed to sanitize: unknown policy "unknown"`)
+	})
+
+	t.Run("fails without a default policy", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fstest.MapFS{
+			"comment.tmpl": {Data: []byte(`{{ sanitize .Body }}`)},
+		}, passepartout.WithSanitizePolicy("strict", tagSanitizer{tags: regexp.MustCompile(`<[^>]*>`)}))
+		require.NoError(t, err)
+
+		err = pp.Render(new(bytes.Buffer), "comment.tmpl", map[string]any{"Body": "hi"})
+
+		require.ErrorContains(t, err, "failed to sanitize: no default policy, configure one with WithSanitizer")
+	})
+}
@@ -0,0 +1,81 @@
+package passepartout
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Event is a server-sent event with its data rendered from a template, see [Passepartout.RenderEvents].
+type Event struct {
+	// Name is the type of the event for addEventListener on an EventSource, "message" when empty.
+	Name string
+	// ID is sent back by the browser as Last-Event-ID when it reconnects, when set.
+	ID string
+	// Template is rendered standalone with Data as the data of the event.
+	Template string
+	Data     any
+}
+
+// RenderEvents streams the events sent on events to w as server-sent events, for live updating HTML, until events is
+// closed or the request is done. Each event is flushed as soon as it's written, and the stream stops at the first
+// event that fails to render.
+func (p *Passepartout) RenderEvents(w http.ResponseWriter, r *http.Request, events <-chan Event) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return errors.New("failed to stream events: the response can't be streamed")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := p.WriteEvent(r.Context(), w, event); err != nil {
+				return err
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// WriteEvent renders event into out as a server-sent event, with a "data:" line for every line of the output.
+// Nothing is written when the template fails to render.
+func (p *Passepartout) WriteEvent(ctx context.Context, out io.Writer, event Event) error {
+	if strings.ContainsAny(event.Name+event.ID, "\r\n") {
+		return fmt.Errorf("failed to write event %q: the name and ID can't have line breaks", event.Name)
+	}
+
+	rendered := new(bytes.Buffer)
+	if err := p.RenderContext(ctx, rendered, event.Template, event.Data); err != nil {
+		return fmt.Errorf("failed to write event %q: %w", event.Name, err)
+	}
+
+	buf := new(bytes.Buffer)
+	if event.Name != "" {
+		fmt.Fprintf(buf, "event: %s\n", event.Name)
+	}
+	if event.ID != "" {
+		fmt.Fprintf(buf, "id: %s\n", event.ID)
+	}
+	data := strings.ReplaceAll(strings.ReplaceAll(rendered.String(), "\r\n", "\n"), "\r", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(buf, "data: %s\n", line)
+	}
+	buf.WriteString("\n")
+
+	_, err := buf.WriteTo(out)
+	return err
+}
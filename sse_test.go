@@ -0,0 +1,63 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+func TestPassepartout_RenderEvents(t *testing.T) {
+	pp, err := passepartout.LoadFrom(fstest.MapFS{
+		"scores/_score.tmpl": {Data: []byte("<ul>\n<li>{{ . }}</li>\n</ul>")},
+	})
+	require.NoError(t, err)
+
+	t.Run("streams an event for every event sent until closed", func(t *testing.T) {
+		events := make(chan passepartout.Event, 2)
+		events <- passepartout.Event{Name: "score", ID: "1", Template: "scores/_score.tmpl", Data: "1-0"}
+		events <- passepartout.Event{Template: "scores/_score.tmpl", Data: "2-0"}
+		close(events)
+		rec := httptest.NewRecorder()
+
+		require.NoError(t, pp.RenderEvents(rec, httptest.NewRequest(http.MethodGet, "/scores", nil), events))
+
+		require.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+		require.Equal(t, ""+
+			"event: score\nid: 1\ndata: <ul>\ndata: <li>1-0</li>\ndata: </ul>\n\n"+
+			"data: <ul>\ndata: <li>2-0</li>\ndata: </ul>\n\n",
+			rec.Body.String())
+		require.True(t, rec.Flushed)
+	})
+
+	t.Run("stops when the request is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		require.NoError(t, pp.RenderEvents(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/scores", nil).WithContext(ctx), make(chan passepartout.Event)))
+	})
+
+	t.Run("stops at an event that fails to render", func(t *testing.T) {
+		events := make(chan passepartout.Event, 1)
+		events <- passepartout.Event{Name: "score", Template: "missing.tmpl"}
+
+		err := pp.RenderEvents(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/scores", nil), events)
+
+		require.ErrorContains(t, err, `failed to write event "score"`)
+	})
+
+	t.Run("fails on a name with line breaks", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+
+		err := pp.WriteEvent(context.Background(), buf, passepartout.Event{Name: "score\ndata: injected", Template: "scores/_score.tmpl"})
+
+		require.ErrorContains(t, err, "the name and ID can't have line breaks")
+		require.Empty(t, buf.String())
+	})
+}
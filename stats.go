@@ -0,0 +1,54 @@
+package passepartout
+
+import (
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// Stats is what passepartout keeps in memory, see [Passepartout.Stats].
+type Stats struct {
+	// Templates are the templates cached by [WithTemplateCache], or a [ppdefaults.TemplateCache] passed to [New].
+	Templates ppdefaults.CacheStats
+	// Files are the template files cached by a [ppdefaults.CachedLoader] used as the TemplateLoader of a
+	// [ppdefaults.Loader] passed to [New].
+	Files ppdefaults.CacheStats
+	// OutputCache is what the store of [WithOutputCache] holds, when it's a [MemoryOutputStore].
+	OutputCache OutputStoreStats
+	// FragmentCache is what the store of [WithFragmentCache] holds, when it's a [MemoryOutputStore].
+	FragmentCache OutputStoreStats
+}
+
+// OutputStoreStats is what an [OutputStore] holds.
+type OutputStoreStats struct {
+	Entries int
+	Bytes   int
+}
+
+// Stats returns the number and size of the templates, files, and outputs cached, so memory growth can be monitored
+// in long-running services. Caches that passepartout can't look into are left as zero.
+func (p *Passepartout) Stats() Stats {
+	var stats Stats
+	switch l := p.loader.Load().(type) {
+	case *ppdefaults.TemplateCache:
+		stats.Templates = l.Stats()
+	case *ppdefaults.Loader:
+		if c, ok := l.TemplateLoader.(*ppdefaults.CachedLoader); ok {
+			stats.Files = c.Stats()
+		}
+	}
+	if p.outputCache != nil {
+		stats.OutputCache = outputStoreStats(p.outputCache.store)
+	}
+	if p.fragmentCache != nil {
+		stats.FragmentCache = outputStoreStats(p.fragmentCache)
+	}
+
+	return stats
+}
+
+func outputStoreStats(store OutputStore) OutputStoreStats {
+	if m, ok := store.(*MemoryOutputStore); ok {
+		return m.Stats()
+	}
+
+	return OutputStoreStats{}
+}
@@ -0,0 +1,54 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+func TestPassepartout_Stats(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.tmpl":   {Data: []byte(`{{ cache "hero" "1h" }}hero{{ endcache }}index`)},
+		"about.tmpl":   {Data: []byte(`about`)},
+		"other.tmpl":   {Data: []byte(`other`)},
+		"index/_.tmpl": {Data: []byte(`partial`)},
+	}
+
+	t.Run("reports the templates and outputs cached", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fsys,
+			passepartout.WithTemplateCache(),
+			passepartout.WithOutputCache(),
+			passepartout.WithFragmentCache(passepartout.NewMemoryOutputStore()),
+		)
+		require.NoError(t, err)
+		require.Equal(t, passepartout.Stats{}, pp.Stats(), "expected nothing to be cached before rendering")
+
+		require.NoError(t, pp.Render(new(bytes.Buffer), "index.tmpl", nil))
+		require.NoError(t, pp.Render(new(bytes.Buffer), "about.tmpl", nil))
+
+		stats := pp.Stats()
+		require.Equal(t, 2, stats.Templates.Entries)
+		require.Equal(t, 3, stats.Templates.Files)
+		require.Positive(t, stats.Templates.SourceBytes)
+		require.Positive(t, stats.Templates.Trees)
+		require.Equal(t, passepartout.OutputStoreStats{}, stats.OutputCache, "expected no outputs without a cache policy or key")
+		require.Equal(t, passepartout.OutputStoreStats{Entries: 1, Bytes: len("hero")}, stats.FragmentCache)
+		require.Zero(t, stats.Files)
+	})
+
+	t.Run("reports the files cached by a CachedLoader", func(t *testing.T) {
+		pp := passepartout.New(ppdefaults.NewLoaderBuilder().
+			WithDefaults(fsys).
+			TemplateLoader(ppdefaults.NewCachedLoader(&ppdefaults.TemplateByNameLoader{FS: fsys})).
+			Build())
+
+		require.NoError(t, pp.Render(new(bytes.Buffer), "other.tmpl", nil))
+
+		require.Equal(t, passepartout.Stats{Files: ppdefaults.CacheStats{Entries: 1, Files: 1, SourceBytes: len("other")}}, pp.Stats())
+	})
+}
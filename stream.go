@@ -0,0 +1,44 @@
+package passepartout
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// StreamInLayout renders name within layout straight into w and flushes after every write when w is an
+// [http.Flusher]. Templates write their markup as execution reaches it, so the layout's head is sent to the browser
+// while a slow data-dependent body, like a method on data that queries a database, is still rendering.
+//
+// Since the output is sent as it's rendered, the error template and capture configured with options are not used,
+// and if rendering fails the client has already received part of the page.
+func (p *Passepartout) StreamInLayout(w io.Writer, layout string, name string, data any) error {
+	layout, name, data = p.resolve(layout), p.resolve(name), p.withGlobalData(context.Background(), data)
+	if f, ok := w.(http.Flusher); ok {
+		w = &flushWriter{w: w, f: f}
+	}
+	if err := p.guard(name, layout); err != nil {
+		return err
+	}
+
+	return p.observeRender(w, layout, name, func(w io.Writer) error {
+		t, err := p.inLayout(context.Background(), name, layout)
+		if err != nil {
+			return err
+		}
+
+		return p.explainMissingPartial(p.execute(t, p.limitRender(context.Background(), w, layout, name), layout, layout, name, data), layout, name)
+	})
+}
+
+type flushWriter struct {
+	w io.Writer
+	f http.Flusher
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	fw.f.Flush()
+
+	return n, err
+}
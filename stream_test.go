@@ -0,0 +1,59 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+// slowBody records what had been flushed to the client by the time the body was rendered.
+type slowBody struct {
+	rec         *httptest.ResponseRecorder
+	flushedHead bool
+}
+
+func (s *slowBody) Body() string {
+	s.flushedHead = s.rec.Flushed && bytes.Contains(s.rec.Body.Bytes(), []byte("<head>"))
+	return "slow"
+}
+
+func TestPassepartout_StreamInLayout(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<head><title>Hi</title></head><body>{{ block "content" . }}{{ end }}</body>`)},
+		"index.tmpl":           {Data: []byte(`<p>{{ .Body }}</p>`)},
+		"broken.tmpl":          {Data: []byte(`{{ index .Missing 1 }}`)},
+	}
+	pp, err := passepartout.LoadFrom(fs, passepartout.WithErrorTemplate("index.tmpl"))
+	require.NoError(t, err)
+
+	t.Run("flushes the head before the body is rendered", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		data := &slowBody{rec: rec}
+
+		require.NoError(t, pp.StreamInLayout(rec, "layouts/default.tmpl", "index.tmpl", data))
+
+		require.True(t, data.flushedHead, "expected the head to have been flushed before the body was rendered")
+		require.Equal(t, `<head><title>Hi</title></head><body><p>slow</p></body>`, rec.Body.String())
+	})
+
+	t.Run("writes to any writer", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+
+		require.NoError(t, pp.StreamInLayout(buf, "layouts/default.tmpl", "index.tmpl", map[string]string{"Body": "fast"}))
+		require.Equal(t, `<head><title>Hi</title></head><body><p>fast</p></body>`, buf.String())
+	})
+
+	t.Run("returns the error and keeps what was sent without rendering the error template", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+
+		err := pp.StreamInLayout(buf, "layouts/default.tmpl", "broken.tmpl", map[string]any{})
+
+		require.Error(t, err)
+		require.Equal(t, `<head><title>Hi</title></head><body>`, buf.String())
+	})
+}
@@ -0,0 +1,111 @@
+package passepartout
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// TemplateInfo describes a template found by [Passepartout.Templates].
+type TemplateInfo struct {
+	// Name is what the template is rendered as.
+	Name string
+	Kind ppdefaults.Kind
+	// Path is where the template is in the filesystem passed to [LoadFrom], the same as Name by default.
+	Path string
+	// Size of the template in bytes.
+	Size int64
+}
+
+// Templates returns every template in the filesystem, in the order [fs.WalkDir] visits them, with its kind following [ppdefaults.KindOf].
+// Useful for admin dashboards or for building a route table from the pages.
+// Only instances created with [LoadFrom] know their filesystem, others return an error.
+func (p *Passepartout) Templates() ([]TemplateInfo, error) {
+	if p.fs == nil {
+		return nil, errors.New("failed to list templates: no filesystem, create passepartout with LoadFrom")
+	}
+
+	var templates []TemplateInfo
+	err := fs.WalkDir(p.fs, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		templates = append(templates, TemplateInfo{
+			Name: filePath,
+			Kind: ppdefaults.KindOf(filePath),
+			Path: filePath,
+			Size: info.Size(),
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+// Has reports whether the template name exists, without loading or parsing it,
+// so routing code can fall through to a 404 before rendering.
+// Instances not created with [LoadFrom] ask the loader for the template's files instead.
+func (p *Passepartout) Has(name string) bool {
+	if p.normalizeNames {
+		name = ppdefaults.NormalizeName(name)
+	}
+	if p.fs != nil {
+		name = p.foldCase(name)
+		info, err := fs.Stat(p.fs, name)
+		return err == nil && !info.IsDir()
+	}
+
+	if l, ok := p.loader.Load().(fileLoader); ok {
+		_, err := l.StandaloneFiles(name)
+		return err == nil
+	}
+
+	_, err := p.loader.Load().Standalone(name)
+	return err == nil
+}
+
+// resolve finds the template name refers to with the extensions configured by [WithExtensions], after normalizing it
+// when configured with [WithNormalizedNames], and ignoring case when configured with [WithCaseInsensitiveLookup].
+func (p *Passepartout) resolve(name string) string {
+	if p.normalizeNames {
+		name = ppdefaults.NormalizeName(name)
+	}
+	if len(p.extensions) == 0 {
+		return p.foldCase(name)
+	}
+
+	for _, ext := range p.extensions {
+		if strings.HasSuffix(name, ext) {
+			return p.foldCase(name)
+		}
+	}
+	for _, ext := range p.extensions {
+		if p.Has(name + ext) {
+			return p.foldCase(name + ext)
+		}
+	}
+
+	return name
+}
+
+// HasLayout reports whether layout exists and is a layout following [ppdefaults.KindOf].
+func (p *Passepartout) HasLayout(layout string) bool {
+	return ppdefaults.KindOf(layout) == ppdefaults.KindLayout && p.Has(layout)
+}
@@ -0,0 +1,72 @@
+package passepartout_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+func TestPassepartout_Templates(t *testing.T) {
+	t.Run("lists every template with its kind and size", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fstest.MapFS{
+			"layouts/default.tmpl":      {Data: []byte(`layout`)},
+			"layouts/default/_nav.tmpl": {Data: []byte(`nav`)},
+			"reviews/show.tmpl":         {Data: []byte(`show`)},
+			"reviews/show/_item.tmpl":   {Data: []byte(`item!`)},
+		})
+		require.NoError(t, err)
+
+		actual, err := pp.Templates()
+
+		require.NoError(t, err)
+		require.Equal(t, []passepartout.TemplateInfo{
+			{Name: "layouts/default/_nav.tmpl", Kind: ppdefaults.KindPartial, Path: "layouts/default/_nav.tmpl", Size: 3},
+			{Name: "layouts/default.tmpl", Kind: ppdefaults.KindLayout, Path: "layouts/default.tmpl", Size: 6},
+			{Name: "reviews/show/_item.tmpl", Kind: ppdefaults.KindPartial, Path: "reviews/show/_item.tmpl", Size: 5},
+			{Name: "reviews/show.tmpl", Kind: ppdefaults.KindPage, Path: "reviews/show.tmpl", Size: 4},
+		}, actual)
+	})
+
+	t.Run("returns an error without a filesystem", func(t *testing.T) {
+		pp := passepartout.New(ppdefaults.NewLoaderBuilder().WithDefaults(fstest.MapFS{}).Build())
+
+		_, err := pp.Templates()
+
+		require.ErrorContains(t, err, "no filesystem")
+	})
+}
+
+func TestPassepartout_Has(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`layout`)},
+		"index.tmpl":           {Data: []byte(`{{ broken`)},
+		"index/_item.tmpl":     {Data: []byte(`item`)},
+	}
+	fromFS, err := passepartout.LoadFrom(fs)
+	require.NoError(t, err)
+	fromLoader := passepartout.New(ppdefaults.NewLoaderBuilder().WithDefaults(fs).Build())
+
+	for _, tc := range []struct {
+		name         string
+		template     string
+		expectHas    bool
+		expectLayout bool
+	}{
+		{name: "finds a page without parsing it", template: "index.tmpl", expectHas: true},
+		{name: "finds a layout", template: "layouts/default.tmpl", expectHas: true, expectLayout: true},
+		{name: "doesn't find a missing template", template: "missing.tmpl"},
+		{name: "doesn't find a missing layout", template: "layouts/missing.tmpl"},
+		{name: "doesn't treat a folder as a template", template: "index"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, pp := range map[string]*passepartout.Passepartout{"LoadFrom": fromFS, "New": fromLoader} {
+				require.Equal(t, tc.expectHas, pp.Has(tc.template), "Has with %s", name)
+				require.Equal(t, tc.expectLayout, pp.HasLayout(tc.template), "HasLayout with %s", name)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package passepartout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// Tenant renders with the templates of one tenant, see [Passepartout.ForTenant].
+type Tenant struct {
+	p    *Passepartout
+	name string
+}
+
+// ForTenant returns a view of p that renders with the files in "tenants/<name>/" in place of the shared files of the
+// same name, like "tenants/acme/index.tmpl" for "index.tmpl". Pages, partials, and layouts can all be overridden, a
+// tenant can add partials of its own, and the files it doesn't override are shared.
+//
+// Each tenant has its own templates, created and cached separately from the other tenants' and replaced on
+// [Passepartout.Reload], while everything else is configured once on p. Only tenants with a folder are rendered, others
+// return an error wrapping [fs.ErrNotExist], so names taken from requests, like the host, can't grow the caches
+// without bound. Tenants aren't cached by [WithOutputCache].
+// Only instances created with [LoadFrom] know their filesystem, others return an error when rendering.
+func (p *Passepartout) ForTenant(name string) *Tenant {
+	return &Tenant{p: p, name: name}
+}
+
+// Name is the name of the tenant.
+func (t *Tenant) Name() string {
+	return t.name
+}
+
+// Render renders name like [Passepartout.Render] with the tenant's templates.
+func (t *Tenant) Render(out io.Writer, name string, data any) error {
+	return t.RenderContext(context.Background(), out, name, data)
+}
+
+// RenderContext renders name like [Passepartout.RenderContext] with the tenant's templates.
+func (t *Tenant) RenderContext(ctx context.Context, out io.Writer, name string, data any) error {
+	return t.render(ctx, out, "", name, data)
+}
+
+// RenderInLayout renders name within layout like [Passepartout.RenderInLayout] with the tenant's templates.
+func (t *Tenant) RenderInLayout(out io.Writer, layout string, name string, data any) error {
+	return t.RenderInLayoutContext(context.Background(), out, layout, name, data)
+}
+
+// RenderInLayoutContext renders name within layout like [Passepartout.RenderInLayoutContext] with the tenant's
+// templates.
+func (t *Tenant) RenderInLayoutContext(ctx context.Context, out io.Writer, layout string, name string, data any) error {
+	return t.render(ctx, out, layout, name, data)
+}
+
+func (t *Tenant) render(ctx context.Context, out io.Writer, layout, name string, data any) error {
+	if t.name == "" || t.name == "." || t.name == ".." || strings.Contains(t.name, "/") {
+		return fmt.Errorf("failed to render %q for tenant %q: a tenant needs a name without slashes", name, t.name)
+	}
+
+	l, err := t.p.overlayLoader("tenant/"+t.name, func(fsys ppdefaults.FS) (ppdefaults.FS, error) {
+		dir := path.Join("tenants", t.name)
+		if info, err := fs.Stat(fsys, dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("no folder %q: %w", dir, fs.ErrNotExist)
+		}
+
+		return &tenantFS{fsys: fsys, dir: dir}, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render %q for tenant %q: %w", name, t.name, err)
+	}
+
+	return t.p.renderFrom(ctx, out, l, layout, name, data)
+}
+
+// tenantFS opens the tenant's file in place of the shared file when there is one, so the file keeps its name, and
+// lists the files of both in folders.
+type tenantFS struct {
+	fsys ppdefaults.FS
+	dir  string
+}
+
+func (t *tenantFS) name(name string) string {
+	override := path.Join(t.dir, name)
+	if _, err := fs.Stat(t.fsys, override); err == nil {
+		return override
+	}
+
+	return name
+}
+
+func (t *tenantFS) Open(name string) (fs.File, error) {
+	return t.fsys.Open(t.name(name))
+}
+
+func (t *tenantFS) ReadFile(name string) ([]byte, error) {
+	return t.fsys.ReadFile(t.name(name))
+}
+
+func (t *tenantFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	shared, err := t.fsys.ReadDir(name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	own, ownErr := t.fsys.ReadDir(path.Join(t.dir, name))
+	if ownErr != nil {
+		if errors.Is(ownErr, fs.ErrNotExist) {
+			return shared, err
+		}
+		return nil, ownErr
+	}
+
+	entries := slices.Clone(shared)
+	for _, entry := range own {
+		if !slices.ContainsFunc(shared, func(e fs.DirEntry) bool { return e.Name() == entry.Name() }) {
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+
+	return entries, nil
+}
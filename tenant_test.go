@@ -0,0 +1,114 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+func TestPassepartout_ForTenant(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/app.tmpl":                {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":                      {Data: []byte(`<h1>Hi</h1>{{ template "index/_item.tmpl" . }}`)},
+		"index/_item.tmpl":                {Data: []byte(`<p>{{ . }}</p>`)},
+		"tenants/acme/layouts/app.tmpl":   {Data: []byte(`<main class="acme">{{ block "content" . }}{{ end }}</main>`)},
+		"tenants/acme/index/_item.tmpl":   {Data: []byte(`<b>{{ . }}</b>{{ template "index/_logo.tmpl" }}`)},
+		"tenants/acme/index/_logo.tmpl":   {Data: []byte(`<img src="acme.png">`)},
+		"tenants/globex/index.tmpl":       {Data: []byte(`<h1>Globex</h1>{{ template "index/_item.tmpl" . }}`)},
+		"tenants/initech/other/page.tmpl": {Data: []byte(`only initech`)},
+	}
+	pp, err := passepartout.LoadFrom(fsys, passepartout.WithTemplateCache())
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		render   func(out *bytes.Buffer) error
+		expected string
+	}{
+		{
+			name: "renders the tenant's partials, including those only the tenant has",
+			render: func(out *bytes.Buffer) error {
+				return pp.ForTenant("acme").Render(out, "index.tmpl", "item")
+			},
+			expected: `<h1>Hi</h1><b>item</b><img src="acme.png">`,
+		},
+		{
+			name: "renders the tenant's layout",
+			render: func(out *bytes.Buffer) error {
+				return pp.ForTenant("acme").RenderInLayout(out, "layouts/app.tmpl", "index.tmpl", "item")
+			},
+			expected: `<main class="acme"><h1>Hi</h1><b>item</b><img src="acme.png"></main>`,
+		},
+		{
+			name: "renders the tenant's page with the shared partials",
+			render: func(out *bytes.Buffer) error {
+				return pp.ForTenant("globex").RenderContext(context.Background(), out, "index.tmpl", "item")
+			},
+			expected: `<h1>Globex</h1><p>item</p>`,
+		},
+		{
+			name: "renders pages only the tenant has",
+			render: func(out *bytes.Buffer) error {
+				return pp.ForTenant("initech").Render(out, "other/page.tmpl", nil)
+			},
+			expected: `only initech`,
+		},
+		{
+			name:     "keeps the shared templates as they are",
+			render:   func(out *bytes.Buffer) error { return pp.Render(out, "index.tmpl", "item") },
+			expected: `<h1>Hi</h1><p>item</p>`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+
+			require.NoError(t, tc.render(out))
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+
+	t.Run("returns an error for tenants without a folder", func(t *testing.T) {
+		out := new(bytes.Buffer)
+
+		err := pp.ForTenant("unknown").Render(out, "index.tmpl", "item")
+
+		require.EqualError(t, err, `failed to render "index.tmpl" for tenant "unknown": no folder "tenants/unknown": file does not exist`)
+		require.ErrorIs(t, err, fs.ErrNotExist)
+		require.Empty(t, out.String())
+	})
+
+	t.Run("returns an error for tenant names that aren't a single folder", func(t *testing.T) {
+		for _, name := range []string{"", ".", "..", "acme/../globex"} {
+			out := new(bytes.Buffer)
+
+			err := pp.ForTenant(name).Render(out, "index.tmpl", nil)
+
+			require.ErrorContains(t, err, "a tenant needs a name without slashes", name)
+			require.Empty(t, out.String())
+		}
+	})
+
+	t.Run("creates the tenant's templates again after a reload", func(t *testing.T) {
+		tenant := pp.ForTenant("globex")
+		require.NoError(t, tenant.Render(new(bytes.Buffer), "index.tmpl", "item"))
+
+		fsys["tenants/globex/index.tmpl"] = &fstest.MapFile{Data: []byte(`<h1>Globex 2</h1>`)}
+		require.NoError(t, pp.Reload(context.Background()))
+		out := new(bytes.Buffer)
+
+		require.NoError(t, tenant.Render(out, "index.tmpl", "item"))
+		require.Equal(t, `<h1>Globex 2</h1>`, out.String())
+	})
+
+	t.Run("fails without a filesystem", func(t *testing.T) {
+		err := passepartout.New(nil).ForTenant("acme").Render(new(bytes.Buffer), "index.tmpl", nil)
+
+		require.EqualError(t, err, `failed to render "index.tmpl" for tenant "acme": no filesystem, create passepartout with LoadFrom`)
+	})
+}
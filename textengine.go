@@ -0,0 +1,57 @@
+package passepartout
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// XMLEngine renders XML templates, like RSS and Atom feeds or sitemaps, with text/template, since html/template
+// escapes them as HTML. Use it for the XML templates with [WithEngineFor]:
+//
+//	passepartout.LoadFrom(fsys, passepartout.WithEngineFor(".xml.tmpl", passepartout.XMLEngine))
+//
+// The templates can use the funcs:
+//   - xmlEscape escapes a value for text or an attribute, like <title>{{ xmlEscape .Title }}</title>
+//   - rfc3339 formats a [time.Time] for Atom and sitemaps, like 2006-01-02T15:04:05Z
+//   - rfc1123 formats a [time.Time] for RSS, like Mon, 02 Jan 2006 15:04:05 +0000
+//
+// Templates named like "feed.rss.tmpl" and "feed.atom.tmpl" are served as RSS and Atom by [Passepartout.RenderHTTP].
+// A page is rendered in a layout as the block the template loader defined it as, like with html/template.
+var XMLEngine Engine = EngineFunc(func(page, layout string, files []ppdefaults.FileWithContent) (Executable, error) {
+	return compileText(template.FuncMap{
+		"xmlEscape": xmlEscape,
+		"rfc3339":   func(t time.Time) string { return t.Format(time.RFC3339) },
+		"rfc1123":   func(t time.Time) string { return t.Format(time.RFC1123Z) },
+	}, page, layout, files)
+})
+
+func xmlEscape(v any) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := xml.EscapeText(buf, []byte(fmt.Sprint(v))); err != nil {
+		return "", fmt.Errorf("failed to escape XML: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// compileText parses files with text/template and funcs, defining the page as the block of the layout the template
+// loader wrapped it in.
+func compileText(funcs template.FuncMap, page, layout string, files []ppdefaults.FileWithContent) (*template.Template, error) {
+	tmpl := template.New("").Funcs(funcs)
+	for _, file := range files {
+		content := file.Content
+		if layout != "" && file.Name == page && file.Block != "" {
+			content = fmt.Sprintf("{{ define %q }}", file.Block) + content + `{{ end }}`
+		}
+		if _, err := tmpl.New(file.Name).Parse(content); err != nil {
+			return nil, err
+		}
+	}
+
+	return tmpl, nil
+}
@@ -0,0 +1,109 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+func TestXMLEngine(t *testing.T) {
+	pp, err := passepartout.LoadFrom(fstest.MapFS{
+		"feed.rss.tmpl":       {Data: []byte(`<rss><channel>{{ range . }}{{ template "feed.rss/_item.tmpl" . }}{{ end }}</channel></rss>`)},
+		"feed.rss/_item.tmpl": {Data: []byte(`<item><title>{{ xmlEscape .Title }}</title><pubDate>{{ rfc1123 .Published }}</pubDate></item>`)},
+		"sitemap.xml.tmpl":    {Data: []byte(`<urlset>{{ range . }}<url><lastmod>{{ rfc3339 .Published }}</lastmod></url>{{ end }}</urlset>`)},
+	}, passepartout.WithEngineFor(".rss.tmpl", passepartout.XMLEngine), passepartout.WithEngineFor(".xml.tmpl", passepartout.XMLEngine))
+	require.NoError(t, err)
+	items := []map[string]any{{"Title": `Fish & "chips"`, "Published": time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}}
+
+	for _, tc := range []struct {
+		name                string
+		template            string
+		expected            string
+		expectedContentType string
+	}{
+		{
+			name:                "renders an RSS feed with its partials, escaped for XML",
+			template:            "feed.rss.tmpl",
+			expected:            `<rss><channel><item><title>Fish &amp; &#34;chips&#34;</title><pubDate>Fri, 01 Mar 2024 12:00:00 +0000</pubDate></item></channel></rss>`,
+			expectedContentType: "application/rss+xml; charset=utf-8",
+		},
+		{
+			name:                "renders a sitemap",
+			template:            "sitemap.xml.tmpl",
+			expected:            `<urlset><url><lastmod>2024-03-01T12:00:00Z</lastmod></url></urlset>`,
+			expectedContentType: mime.TypeByExtension(".xml"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			require.NoError(t, pp.RenderHTTP(rec, http.StatusOK, tc.template, items))
+			require.Equal(t, tc.expected, rec.Body.String())
+			require.Equal(t, tc.expectedContentType, rec.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestXMLEngine_InLayout(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/feed.xml.tmpl":  {Data: []byte(`<rss>{{ block "content" . }}{{ end }}</rss>`)},
+		"layouts/main.xml.tmpl":  {Data: []byte(`<rss>{{ block "main" . }}{{ end }}</rss>`)},
+		"layouts/title.xml.tmpl": {Data: []byte(`<rss><title>{{ block "title" . }}{{ end }}</title>{{ block "content" . }}{{ end }}</rss>`)},
+		"feed.xml.tmpl":          {Data: []byte(`{{ template "item" }}`)},
+		"feed.xml/_item.tmpl":    {Data: []byte(`{{ define "item" }}<item/>{{ end }}`)},
+		"defines.xml.tmpl":       {Data: []byte(`{{ define "title" }}News{{ end }}{{ define "content" }}<item/>{{ end }}`)},
+	}
+	withEngine := passepartout.WithEngineFor(".xml.tmpl", passepartout.XMLEngine)
+	pp, err := passepartout.LoadFrom(fsys, withEngine)
+	require.NoError(t, err)
+	mainBlock := passepartout.New(ppdefaults.NewLoaderBuilder().
+		WithDefaults(fsys).
+		TemplateLoader(&ppdefaults.TemplateByNameLoader{FS: fsys, ContentBlockName: "main"}).
+		Build(), withEngine)
+
+	for _, tc := range []struct {
+		name     string
+		pp       *passepartout.Passepartout
+		layout   string
+		page     string
+		expected string
+	}{
+		{
+			name:     "keeps the defines of the partials",
+			pp:       pp,
+			layout:   "layouts/feed.xml.tmpl",
+			page:     "feed.xml.tmpl",
+			expected: "<rss><item/></rss>",
+		},
+		{
+			name:     "defines the page as the ContentBlockName of the template loader",
+			pp:       mainBlock,
+			layout:   "layouts/main.xml.tmpl",
+			page:     "feed.xml.tmpl",
+			expected: "<rss><item/></rss>",
+		},
+		{
+			name:     "doesn't wrap a page made of defines",
+			pp:       pp,
+			layout:   "layouts/title.xml.tmpl",
+			page:     "defines.xml.tmpl",
+			expected: "<rss><title>News</title><item/></rss>",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+
+			require.NoError(t, tc.pp.RenderInLayout(out, tc.layout, tc.page, nil))
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+}
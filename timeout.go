@@ -0,0 +1,66 @@
+package passepartout
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// TimeoutError is returned when a render takes longer than [WithRenderTimeout].
+type TimeoutError struct {
+	Template string
+	// Layout is empty when the template was rendered standalone.
+	Layout  string
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Layout == "" {
+		return fmt.Sprintf("rendering %q timed out after %s", e.Template, e.Timeout)
+	}
+
+	return fmt.Sprintf("rendering %q in layout %q timed out after %s", e.Template, e.Layout, e.Timeout)
+}
+
+// Unwrap makes the error [context.DeadlineExceeded], like a render stopped by its context's deadline.
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// deadlineWriter fails the writes once the render has timed out or its context is done, which is how an executing
+// template is stopped: the execution ends at the first write that fails.
+type deadlineWriter struct {
+	w        io.Writer
+	ctx      context.Context
+	name     string
+	deadline time.Time
+	// timeout is nil when only ctx limits the render.
+	timeout *TimeoutError
+}
+
+// limitRender returns out as it is unless the render can time out or ctx can be done.
+func (p *Passepartout) limitRender(ctx context.Context, out io.Writer, layout, name string) io.Writer {
+	if p.renderTimeout <= 0 && ctx.Done() == nil {
+		return out
+	}
+
+	w := &deadlineWriter{w: out, ctx: ctx, name: name}
+	if p.renderTimeout > 0 {
+		w.deadline = time.Now().Add(p.renderTimeout)
+		w.timeout = &TimeoutError{Template: name, Layout: layout, Timeout: p.renderTimeout}
+	}
+
+	return w
+}
+
+func (w *deadlineWriter) Write(b []byte) (int, error) {
+	if w.timeout != nil && time.Now().After(w.deadline) {
+		return 0, w.timeout
+	}
+	if err := w.ctx.Err(); err != nil {
+		return 0, fmt.Errorf("failed to render %q: %w", w.name, err)
+	}
+
+	return w.w.Write(b)
+}
@@ -0,0 +1,78 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+// slowItems calls onItem and sleeps for every item it's ranged over.
+type slowItems struct {
+	delay  time.Duration
+	onItem func()
+}
+
+func (s slowItems) Items() []int {
+	return make([]int, 1000)
+}
+
+func (s slowItems) Slow() string {
+	if s.onItem != nil {
+		s.onItem()
+	}
+	time.Sleep(s.delay)
+	return "item"
+}
+
+func TestWithRenderTimeout(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":           {Data: []byte(`{{ range .Items }}{{ $.Slow }}{{ end }}`)},
+	}
+
+	t.Run("stops a render taking longer than the timeout", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithRenderTimeout(20*time.Millisecond))
+		require.NoError(t, err)
+		start := time.Now()
+
+		err = pp.RenderInLayout(new(bytes.Buffer), "layouts/default.tmpl", "index.tmpl", slowItems{delay: time.Millisecond})
+
+		require.Less(t, time.Since(start), time.Second, "expected the render to be stopped long before it's done")
+		var timeout *passepartout.TimeoutError
+		require.ErrorAs(t, err, &timeout)
+		require.Equal(t, &passepartout.TimeoutError{Template: "index.tmpl", Layout: "layouts/default.tmpl", Timeout: 20 * time.Millisecond}, timeout)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.EqualError(t, err, `rendering "index.tmpl" in layout "layouts/default.tmpl" timed out after 20ms`)
+	})
+
+	t.Run("renders templates finishing within the timeout", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fstest.MapFS{"index.tmpl": {Data: []byte(`Hello`)}}, passepartout.WithRenderTimeout(time.Second))
+		require.NoError(t, err)
+		out := new(bytes.Buffer)
+
+		require.NoError(t, pp.Render(out, "index.tmpl", nil))
+		require.Equal(t, "Hello", out.String())
+	})
+
+	t.Run("stops a render when its context is done", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fs)
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		items := 0
+
+		err = pp.RenderContext(ctx, new(bytes.Buffer), "index.tmpl", slowItems{onItem: func() {
+			items++
+			cancel()
+		}})
+
+		require.ErrorIs(t, err, context.Canceled)
+		require.EqualError(t, err, `failed to render "index.tmpl": context canceled`)
+		require.Equal(t, 1, items, "expected the render to stop at the first write after being canceled")
+	})
+}
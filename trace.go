@@ -0,0 +1,101 @@
+package passepartout
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gaqzi/passepartout/internal/ppparse"
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// RenderTrace describes a render by [Passepartout.RenderTraced], for debugging or for building an index of the pages
+// that use a partial.
+type RenderTrace struct {
+	// FilesLoaded are the files the template was created from, in the order they're loaded. They're set on cache hits
+	// as well, as the files the cached output was rendered from, and are empty when the loader doesn't expose its files.
+	FilesLoaded []string
+	// BlocksExecuted are the entry template followed by the templates and blocks it can execute, sorted.
+	// They're found by walking the parsed templates, so a template called in a branch that wasn't taken is included.
+	BlocksExecuted []string
+	// CacheHit is true when the output was served from the cache of [WithOutputCache].
+	CacheHit bool
+	Duration time.Duration
+}
+
+type traceKey struct{}
+
+// RenderTraced is [Passepartout.RenderContext] that also returns a trace of the render.
+// The trace is returned when the render fails as well.
+func (p *Passepartout) RenderTraced(ctx context.Context, out io.Writer, name string, data any) (RenderTrace, error) {
+	return p.traced(ctx, "", name, func(ctx context.Context) error {
+		return p.RenderContext(ctx, out, name, data)
+	})
+}
+
+// RenderInLayoutTraced is [Passepartout.RenderInLayoutContext] that also returns a trace of the render like
+// [Passepartout.RenderTraced].
+func (p *Passepartout) RenderInLayoutTraced(ctx context.Context, out io.Writer, layout string, name string, data any) (RenderTrace, error) {
+	return p.traced(ctx, layout, name, func(ctx context.Context) error {
+		return p.RenderInLayoutContext(ctx, out, layout, name, data)
+	})
+}
+
+func (p *Passepartout) traced(ctx context.Context, layout, name string, render func(ctx context.Context) error) (RenderTrace, error) {
+	trace := new(RenderTrace)
+	start := time.Now()
+	renderErr := render(context.WithValue(ctx, traceKey{}, trace))
+	trace.Duration = time.Since(start)
+
+	err := p.traceFiles(trace, p.resolve(layout), p.resolve(name))
+	if renderErr != nil {
+		// The render's error explains a failure to load the files better.
+		return *trace, renderErr
+	}
+	if err != nil {
+		return *trace, fmt.Errorf("failed to trace %q: %w", name, err)
+	}
+
+	return *trace, nil
+}
+
+func (p *Passepartout) traceFiles(trace *RenderTrace, layout, name string) error {
+	l, ok := p.loader.Load().(fileLoader)
+	if !ok {
+		return nil
+	}
+
+	var files []ppdefaults.FileWithContent
+	var err error
+	entry := name
+	if layout == "" {
+		files, err = l.StandaloneFiles(name)
+	} else {
+		files, err = l.InLayoutFiles(name, layout)
+		entry = layout
+	}
+	if err != nil {
+		return err
+	}
+
+	parsed := make([]*ppparse.File, 0, len(files))
+	for _, f := range files {
+		file, err := ppparse.Parse(f.Name, f.Content)
+		if err != nil {
+			return err
+		}
+		parsed = append(parsed, file)
+		trace.FilesLoaded = append(trace.FilesLoaded, f.Name)
+	}
+
+	set := ppparse.NewSet(parsed...)
+	trace.BlocksExecuted = []string{entry}
+	for _, tmpl := range set.Reachable(entry) {
+		if tmpl != entry && set.Defined(tmpl) {
+			trace.BlocksExecuted = append(trace.BlocksExecuted, tmpl)
+		}
+	}
+
+	return nil
+}
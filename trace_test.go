@@ -0,0 +1,93 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+// helloLoader creates templates saying hello, without any files.
+type helloLoader struct{}
+
+func (helloLoader) Standalone(name string) (*template.Template, error) {
+	return template.New(name).Parse(`hello`)
+}
+
+func (helloLoader) InLayout(page string, layout string) (*template.Template, error) {
+	return template.New(layout).Parse(`hello`)
+}
+
+func TestPassepartout_RenderTraced(t *testing.T) {
+	t.Run("reports the files loaded and the templates the render can execute", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(dependenciesFS())
+		require.NoError(t, err)
+
+		buf := new(bytes.Buffer)
+		trace, err := pp.RenderTraced(context.Background(), buf, "index.tmpl", []string{"a", "b"})
+		require.NoError(t, err)
+
+		require.Equal(t, "ab", buf.String())
+		require.Equal(t, []string{"index/_item.tmpl", "index/_list.tmpl", "index/_unused.tmpl", "index.tmpl"}, trace.FilesLoaded)
+		require.Equal(t, []string{"index.tmpl", "index/_list.tmpl", "item"}, trace.BlocksExecuted)
+		require.False(t, trace.CacheHit)
+		require.Positive(t, trace.Duration)
+	})
+
+	t.Run("reports a cache hit when the output is served from the output cache", func(t *testing.T) {
+		fs := fstest.MapFS{"index.tmpl": {Data: []byte(`{{/* cache: ttl=1h */}}{{ . }}`)}}
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithOutputCache())
+		require.NoError(t, err)
+
+		trace, err := pp.RenderTraced(context.Background(), new(bytes.Buffer), "index.tmpl", 1)
+		require.NoError(t, err)
+		require.False(t, trace.CacheHit)
+
+		buf := new(bytes.Buffer)
+		trace, err = pp.RenderTraced(context.Background(), buf, "index.tmpl", 2)
+		require.NoError(t, err)
+		require.True(t, trace.CacheHit)
+		require.Equal(t, "1", buf.String())
+		require.Equal(t, []string{"index.tmpl"}, trace.FilesLoaded)
+	})
+
+	t.Run("returns the trace along with the error of a failed render", func(t *testing.T) {
+		fs := fstest.MapFS{"index.tmpl": {Data: []byte(`{{ template "missing" }}`)}}
+		pp, err := passepartout.LoadFrom(fs)
+		require.NoError(t, err)
+
+		trace, err := pp.RenderTraced(context.Background(), new(bytes.Buffer), "index.tmpl", nil)
+		require.Error(t, err)
+		require.Equal(t, []string{"index.tmpl"}, trace.FilesLoaded)
+	})
+
+	t.Run("leaves the files empty when the loader doesn't expose them", func(t *testing.T) {
+		pp := passepartout.New(helloLoader{})
+
+		buf := new(bytes.Buffer)
+		trace, err := pp.RenderTraced(context.Background(), buf, "index.tmpl", nil)
+		require.NoError(t, err)
+		require.Equal(t, "hello", buf.String())
+		require.Empty(t, trace.FilesLoaded)
+		require.Empty(t, trace.BlocksExecuted)
+	})
+}
+
+func TestPassepartout_RenderInLayoutTraced(t *testing.T) {
+	pp, err := passepartout.LoadFrom(dependenciesFS())
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	trace, err := pp.RenderInLayoutTraced(context.Background(), buf, "layouts/default.tmpl", "about.tmpl", nil)
+	require.NoError(t, err)
+
+	require.Equal(t, "navabout", buf.String())
+	require.Equal(t, []string{"layouts/default.tmpl", "content", "layouts/default/_nav.tmpl"}, trace.BlocksExecuted)
+	require.Contains(t, trace.FilesLoaded, "about.tmpl")
+	require.Contains(t, trace.FilesLoaded, "layouts/default/_nav.tmpl")
+}
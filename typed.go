@@ -0,0 +1,68 @@
+package passepartout
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+)
+
+// Typed renders a group of templates that are all rendered with data of type T, so passing the wrong data to them
+// fails to compile instead of rendering "<no value>". Create one per data type with [NewTyped].
+type Typed[T any] struct {
+	p         *Passepartout
+	templates []string
+}
+
+// NewTyped renders with p, and when templates are given only those can be rendered, so a template can't be rendered
+// with data of the wrong type by mistake either. Layouts aren't checked.
+func NewTyped[T any](p *Passepartout, templates ...string) *Typed[T] {
+	return &Typed[T]{p: p, templates: templates}
+}
+
+// Render is [Passepartout.Render] with data of type T.
+func (t *Typed[T]) Render(out io.Writer, name string, data T) error {
+	return t.RenderContext(context.Background(), out, name, data)
+}
+
+// RenderContext is [Passepartout.RenderContext] with data of type T.
+func (t *Typed[T]) RenderContext(ctx context.Context, out io.Writer, name string, data T) error {
+	if err := t.check(name); err != nil {
+		return err
+	}
+
+	return t.p.RenderContext(ctx, out, name, data)
+}
+
+// RenderInLayout is [Passepartout.RenderInLayout] with data of type T.
+func (t *Typed[T]) RenderInLayout(out io.Writer, layout string, name string, data T) error {
+	return t.RenderInLayoutContext(context.Background(), out, layout, name, data)
+}
+
+// RenderInLayoutContext is [Passepartout.RenderInLayoutContext] with data of type T.
+func (t *Typed[T]) RenderInLayoutContext(ctx context.Context, out io.Writer, layout string, name string, data T) error {
+	if err := t.check(name); err != nil {
+		return err
+	}
+
+	return t.p.RenderInLayoutContext(ctx, out, layout, name, data)
+}
+
+// RenderHTTP is [Passepartout.RenderHTTP] with data of type T.
+func (t *Typed[T]) RenderHTTP(w http.ResponseWriter, status int, name string, data T) error {
+	if err := t.check(name); err != nil {
+		return err
+	}
+
+	return t.p.RenderHTTP(w, status, name, data)
+}
+
+func (t *Typed[T]) check(name string) error {
+	if len(t.templates) == 0 || slices.Contains(t.templates, name) || slices.Contains(t.templates, t.p.resolve(name)) {
+		return nil
+	}
+
+	return fmt.Errorf("failed to render %q: not one of the templates rendered with %s", name, reflect.TypeFor[T]())
+}
@@ -0,0 +1,52 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+type reviewData struct {
+	Title string
+}
+
+func TestTyped(t *testing.T) {
+	fs := fstest.MapFS{
+		"layouts/default.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"reviews/index.tmpl":   {Data: []byte(`{{ .Title }}`)},
+		"users/index.tmpl":     {Data: []byte(`{{ .Name }}`)},
+	}
+	pp, err := passepartout.LoadFrom(fs, passepartout.WithExtensions(".tmpl"))
+	require.NoError(t, err)
+
+	t.Run("renders with the typed data", func(t *testing.T) {
+		reviews := passepartout.NewTyped[reviewData](pp)
+
+		buf := new(bytes.Buffer)
+		require.NoError(t, reviews.Render(buf, "reviews/index.tmpl", reviewData{Title: "Reviews"}))
+		require.Equal(t, "Reviews", buf.String())
+
+		buf.Reset()
+		require.NoError(t, reviews.RenderInLayout(buf, "layouts/default.tmpl", "reviews/index.tmpl", reviewData{Title: "Reviews"}))
+		require.Equal(t, "<main>Reviews</main>", buf.String())
+
+		rec := httptest.NewRecorder()
+		require.NoError(t, reviews.RenderHTTP(rec, http.StatusCreated, "reviews/index.tmpl", reviewData{Title: "Created"}))
+		require.Equal(t, http.StatusCreated, rec.Code)
+		require.Equal(t, "Created", rec.Body.String())
+	})
+
+	t.Run("only renders the templates of its group when given", func(t *testing.T) {
+		reviews := passepartout.NewTyped[reviewData](pp, "reviews/index.tmpl")
+
+		require.NoError(t, reviews.Render(new(bytes.Buffer), "reviews/index", reviewData{}), "expected the resolved name to be in the group")
+		err := reviews.Render(new(bytes.Buffer), "users/index.tmpl", reviewData{})
+		require.EqualError(t, err, `failed to render "users/index.tmpl": not one of the templates rendered with passepartout_test.reviewData`)
+	})
+}
@@ -0,0 +1,284 @@
+package passepartout
+
+import (
+	"fmt"
+	"html/template"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// DataError is returned when rendering with [WithDataValidation] and the template reads fields the data doesn't have.
+type DataError struct {
+	Template string
+	// Layout is empty when the template was rendered standalone.
+	Layout string
+	// Fields are the paths of the fields that are missing from the data, sorted, like ".User.Name" or ".Items[].Title"
+	// for a field read within {{ range .Items }}.
+	Fields []string
+}
+
+func (e *DataError) Error() string {
+	fields := strings.Join(e.Fields, ", ")
+	if e.Layout == "" {
+		return fmt.Sprintf("template %q reads fields the data doesn't have: %s", e.Template, fields)
+	}
+
+	return fmt.Sprintf("template %q in layout %q reads fields the data doesn't have: %s", e.Template, e.Layout, fields)
+}
+
+// validateData returns a [*DataError] when the template execName reads fields that data doesn't have.
+// Only templates created by html/template can be validated.
+func (p *Passepartout) validateData(tmplt Executable, execName, layout, name string, data any) error {
+	t, ok := tmplt.(*template.Template)
+	if !ok {
+		return nil
+	}
+
+	v := &dataValidator{tmpl: t, missing: make(map[string]struct{}), visited: make(map[string]struct{})}
+	root := newDataDot(reflect.ValueOf(data))
+	v.root = root
+	v.template(execName, root, "")
+	if len(v.missing) == 0 {
+		return nil
+	}
+
+	fields := make([]string, 0, len(v.missing))
+	for field := range v.missing {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return &DataError{Template: name, Layout: layout, Fields: fields}
+}
+
+// dataDot is what dot is at a point in a template: the value when it's known, otherwise only its type, and nothing
+// when not even the type is known, like for the result of a func, so there's nothing to check against.
+type dataDot struct {
+	v reflect.Value
+	t reflect.Type
+}
+
+func newDataDot(v reflect.Value) dataDot {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			if v.Kind() == reflect.Pointer {
+				return typeDot(v.Type().Elem())
+			}
+			return dataDot{}
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return dataDot{}
+	}
+
+	return dataDot{v: v, t: v.Type()}
+}
+
+func typeDot(t reflect.Type) dataDot {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface {
+		return dataDot{}
+	}
+
+	return dataDot{t: t}
+}
+
+func (d dataDot) known() bool {
+	return d.t != nil
+}
+
+// field returns the field name of d, and whether d has it.
+func (d dataDot) field(name string) (dataDot, bool) {
+	if !d.known() {
+		return dataDot{}, true
+	}
+
+	// Methods aren't called, since they might have side effects, so only the type of what they return is known.
+	for _, t := range []reflect.Type{d.t, reflect.PointerTo(d.t)} {
+		if m, ok := t.MethodByName(name); ok {
+			if m.Type.NumOut() == 0 {
+				return dataDot{}, true
+			}
+			return typeDot(m.Type.Out(0)), true
+		}
+	}
+
+	switch d.t.Kind() {
+	case reflect.Struct:
+		f, ok := d.t.FieldByName(name)
+		if !ok || !f.IsExported() {
+			return dataDot{}, false
+		}
+		if !d.v.IsValid() {
+			return typeDot(f.Type), true
+		}
+		return newDataDot(d.v.FieldByIndex(f.Index)), true
+	case reflect.Map:
+		if d.t.Key().Kind() != reflect.String {
+			return dataDot{}, true
+		}
+		if !d.v.IsValid() {
+			return typeDot(d.t.Elem()), true
+		}
+		value := d.v.MapIndex(reflect.ValueOf(name).Convert(d.t.Key()))
+		if !value.IsValid() {
+			return dataDot{}, false
+		}
+		return newDataDot(value), true
+	default:
+		return dataDot{}, false
+	}
+}
+
+// elem returns what dot is when ranging over d, the first element when the value is known.
+func (d dataDot) elem() dataDot {
+	if !d.known() {
+		return dataDot{}
+	}
+
+	switch d.t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if d.v.IsValid() && d.v.Len() > 0 {
+			return newDataDot(d.v.Index(0))
+		}
+		return typeDot(d.t.Elem())
+	case reflect.Map:
+		if d.v.IsValid() && d.v.Len() > 0 {
+			return newDataDot(d.v.MapIndex(d.v.MapKeys()[0]))
+		}
+		return typeDot(d.t.Elem())
+	case reflect.Chan:
+		return typeDot(d.t.Elem())
+	default:
+		return dataDot{}
+	}
+}
+
+type dataValidator struct {
+	tmpl    *template.Template
+	root    dataDot
+	missing map[string]struct{}
+	// visited are the templates already checked with a dot, so recursive templates are checked once.
+	visited map[string]struct{}
+}
+
+func (v *dataValidator) template(name string, dot dataDot, path string) {
+	t := v.tmpl.Lookup(name)
+	if t == nil || t.Tree == nil {
+		return
+	}
+	key := fmt.Sprintf("%s|%s|%v", name, path, dot.t)
+	if _, ok := v.visited[key]; ok {
+		return
+	}
+	v.visited[key] = struct{}{}
+
+	v.node(t.Tree.Root, dot, path)
+}
+
+func (v *dataValidator) node(node parse.Node, dot dataDot, path string) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			v.node(child, dot, path)
+		}
+	case *parse.ActionNode:
+		v.pipe(n.Pipe, dot, path)
+	case *parse.IfNode:
+		// {{ if .Optional }} guards its body against the field being missing.
+		if _, _, missing := v.chain(n.Pipe, dot, path); !missing {
+			v.pipe(n.Pipe, dot, path)
+			v.node(n.List, dot, path)
+		}
+		v.node(n.ElseList, dot, path)
+	case *parse.WithNode:
+		if inner, innerPath, missing := v.chain(n.Pipe, dot, path); !missing {
+			v.pipe(n.Pipe, dot, path)
+			v.node(n.List, inner, innerPath)
+		}
+		v.node(n.ElseList, dot, path)
+	case *parse.RangeNode:
+		v.pipe(n.Pipe, dot, path)
+		inner, innerPath, missing := v.chain(n.Pipe, dot, path)
+		if !missing {
+			v.node(n.List, inner.elem(), innerPath+"[]")
+		}
+		v.node(n.ElseList, dot, path)
+	case *parse.TemplateNode:
+		if n.Pipe == nil {
+			v.template(n.Name, dataDot{}, path)
+			return
+		}
+		v.pipe(n.Pipe, dot, path)
+		if inner, innerPath, missing := v.chain(n.Pipe, dot, path); !missing {
+			v.template(n.Name, inner, innerPath)
+		}
+	}
+}
+
+// chain returns what a pipeline of a single field chain, like ".User" or ".", evaluates to, and whether a field in
+// the chain is missing. Other pipelines evaluate to something unknown.
+func (v *dataValidator) chain(pipe *parse.PipeNode, dot dataDot, path string) (dataDot, string, bool) {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return dataDot{}, path, false
+	}
+
+	switch arg := pipe.Cmds[0].Args[0].(type) {
+	case *parse.DotNode:
+		return dot, path, false
+	case *parse.FieldNode:
+		return v.fields(dot, path, arg.Ident, false)
+	case *parse.VariableNode:
+		if arg.Ident[0] == "$" {
+			return v.fields(v.root, "", arg.Ident[1:], false)
+		}
+	}
+
+	return dataDot{}, path, false
+}
+
+// fields follows idents from dot, recording the first missing field when report is set.
+func (v *dataValidator) fields(dot dataDot, path string, idents []string, report bool) (dataDot, string, bool) {
+	for _, ident := range idents {
+		path += "." + ident
+		next, ok := dot.field(ident)
+		if !ok {
+			if report {
+				v.missing[path] = struct{}{}
+			}
+			return dataDot{}, path, true
+		}
+		dot = next
+	}
+
+	return dot, path, false
+}
+
+func (v *dataValidator) pipe(pipe *parse.PipeNode, dot dataDot, path string) {
+	if pipe == nil {
+		return
+	}
+
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			switch a := arg.(type) {
+			case *parse.FieldNode:
+				v.fields(dot, path, a.Ident, true)
+			case *parse.VariableNode:
+				if a.Ident[0] == "$" {
+					v.fields(v.root, "", a.Ident[1:], true)
+				}
+			case *parse.PipeNode:
+				v.pipe(a, dot, path)
+			}
+		}
+	}
+}
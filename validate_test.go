@@ -0,0 +1,96 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+type validateUser struct {
+	Name string
+}
+
+func (validateUser) Initials() string { return "" }
+
+type validateData struct {
+	Title string
+	User  *validateUser
+	Items []validateUser
+}
+
+func TestWithDataValidation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		template string
+		data     any
+		expected []string
+	}{
+		{
+			name:     "passes when the data has every field",
+			template: `{{ .Title }} {{ .User.Name }} {{ .User.Initials }}{{ range .Items }}{{ .Name }}{{ end }}`,
+			data:     validateData{User: &validateUser{}},
+		},
+		{
+			name:     "reports missing map keys in every branch",
+			template: `{{ .title }}{{ if .admin }}{{ .secret }}{{ else }}{{ .nmae }}{{ end }}`,
+			data:     map[string]any{"title": "Hi", "admin": false},
+			expected: []string{".nmae", ".secret"},
+		},
+		{
+			name:     "reports missing struct fields, following with, range, and nil pointers by their type",
+			template: `{{ with .User }}{{ .Nmae }}{{ end }}{{ range .Items }}{{ .Title }}{{ end }}{{ $.Missing }}`,
+			data:     validateData{},
+			expected: []string{".Items[].Title", ".Missing", ".User.Nmae"},
+		},
+		{
+			name:     "allows fields guarded by if and with to be missing",
+			template: `{{ if .notice }}{{ .notice.text }}{{ end }}{{ with .flash }}{{ .message }}{{ end }}`,
+			data:     map[string]any{},
+		},
+		{
+			name:     "follows the data passed to other templates",
+			template: `{{ define "user" }}{{ .Email }}{{ end }}{{ template "user" .User }}`,
+			data:     validateData{User: &validateUser{}},
+			expected: []string{".User.Email"},
+		},
+		{
+			name:     "doesn't check what funcs and untyped values return",
+			template: `{{ with index .items 0 }}{{ .anything }}{{ end }}{{ range .empty }}{{ .whatever }}{{ end }}`,
+			data:     map[string]any{"items": []any{map[string]any{"anything": 1}}, "empty": []any{}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := fstest.MapFS{"index.tmpl": {Data: []byte(tc.template)}}
+			pp, err := passepartout.LoadFrom(fs, passepartout.WithDataValidation())
+			require.NoError(t, err)
+
+			err = pp.Render(new(bytes.Buffer), "index.tmpl", tc.data)
+
+			if tc.expected == nil {
+				require.NoError(t, err)
+				return
+			}
+			var dataErr *passepartout.DataError
+			require.True(t, errors.As(err, &dataErr), "expected a DataError, got: %v", err)
+			require.Equal(t, tc.expected, dataErr.Fields)
+		})
+	}
+
+	t.Run("names the template and layout", func(t *testing.T) {
+		fs := fstest.MapFS{
+			"layouts/default.tmpl": {Data: []byte(`{{ .title }}{{ block "content" . }}{{ end }}`)},
+			"index.tmpl":           {Data: []byte(`{{ .body }}`)},
+		}
+		pp, err := passepartout.LoadFrom(fs, passepartout.WithDataValidation())
+		require.NoError(t, err)
+
+		err = pp.RenderInLayout(new(bytes.Buffer), "layouts/default.tmpl", "index.tmpl", map[string]any{})
+
+		require.EqualError(t, err, `template "index.tmpl" in layout "layouts/default.tmpl" reads fields the data doesn't have: .body, .title`)
+	})
+}
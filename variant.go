@@ -0,0 +1,204 @@
+package passepartout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+// RenderVariant renders name like [Passepartout.Render] with the files of variant, for template-level experiments.
+// The variant of a file is named with the variant before its extension, like "index.b.tmpl" for variant "b" of
+// "index.tmpl", and is used in place of the file wherever there is one. Pages, partials, and layouts can all have
+// variants, and the files without one are used as they are, so an experiment only needs the files it changes.
+// An empty variant renders name as it is, and a variant without any files returns an error wrapping [fs.ErrNotExist], so
+// variants taken from requests can't grow the caches without bound.
+//
+// Variants aren't cached by [WithOutputCache]. Only instances created with [LoadFrom] know their filesystem, others
+// return an error.
+func (p *Passepartout) RenderVariant(out io.Writer, name, variant string, data any) error {
+	if variant == "" {
+		return p.Render(out, name, data)
+	}
+
+	return p.renderVariant(context.Background(), out, "", name, variant, data)
+}
+
+// RenderVariantInLayout renders name within layout with the files of variant, like [Passepartout.RenderVariant].
+func (p *Passepartout) RenderVariantInLayout(out io.Writer, layout, name, variant string, data any) error {
+	if variant == "" {
+		return p.RenderInLayout(out, layout, name, data)
+	}
+
+	return p.renderVariant(context.Background(), out, layout, name, variant, data)
+}
+
+func (p *Passepartout) renderVariant(ctx context.Context, out io.Writer, layout, name, variant string, data any) error {
+	if strings.ContainsAny(variant, "/.") {
+		return fmt.Errorf("failed to render variant %q of %q: a variant can't contain dots or slashes", variant, name)
+	}
+
+	l, err := p.overlayLoader("variant/"+variant, func(fsys ppdefaults.FS) (ppdefaults.FS, error) {
+		ok, err := hasVariant(fsys, variant)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("no files of the variant: %w", fs.ErrNotExist)
+		}
+
+		return &variantFS{fsys: fsys, variant: variant}, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render variant %q of %q: %w", variant, name, err)
+	}
+
+	return p.renderFrom(ctx, out, l, layout, name, data)
+}
+
+// renderFrom renders name, within layout unless it's empty, with the templates of l, without the output cache.
+func (p *Passepartout) renderFrom(ctx context.Context, out io.Writer, l loader, layout, name string, data any) error {
+	name, data = p.resolve(name), p.withGlobalData(ctx, data)
+	execName := name
+	if layout != "" {
+		layout = p.resolve(layout)
+		execName = layout
+	}
+	if err := p.guard(name, layout); err != nil {
+		return err
+	}
+
+	return p.buffered(out, layout, name, func(out io.Writer) error {
+		return p.observeRender(out, layout, name, func(out io.Writer) error {
+			var t Executable
+			var err error
+			if layout == "" {
+				t, err = p.standaloneFrom(ctx, l, name)
+			} else {
+				t, err = p.inLayoutFrom(ctx, l, name, layout)
+			}
+			if err != nil {
+				return err
+			}
+
+			return p.explainMissingPartial(p.execute(t, p.limitRender(ctx, out, layout, name), execName, layout, name, data), layout, name)
+		})
+	})
+}
+
+// overlayLoader returns the loader of the current template set for key, building it from the filesystem returned by
+// overlay the first time. The loaders are kept until the template set is replaced, so overlay returns an error for keys
+// without files of their own, which would otherwise grow the set with every key asked for.
+func (p *Passepartout) overlayLoader(key string, overlay func(fsys ppdefaults.FS) (ppdefaults.FS, error)) (loader, error) {
+	set := p.loader.set.Load()
+	if l, ok := set.overlays.Load(key); ok {
+		return l.(loader), nil
+	}
+	if p.fs == nil {
+		return nil, errors.New("no filesystem, create passepartout with LoadFrom")
+	}
+
+	fsys, err := overlay(ppdefaults.AsFS(p.fs))
+	if err != nil {
+		return nil, err
+	}
+	l, err := p.buildLoader(ppdefaults.NewLoaderBuilder().WithDefaults(fsys))
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := set.overlays.LoadOrStore(key, l)
+
+	return actual.(loader), nil
+}
+
+// variantFS opens the variant of a file in its place when there is one, so the file keeps its name.
+type variantFS struct {
+	fsys    ppdefaults.FS
+	variant string
+}
+
+// variantName is name with variant before its extension.
+func variantName(name, variant string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + variant + ext
+}
+
+func (v *variantFS) name(name string) string {
+	variant := variantName(name, v.variant)
+	if info, err := fs.Stat(v.fsys, variant); err == nil && !info.IsDir() {
+		return variant
+	}
+
+	return name
+}
+
+func (v *variantFS) Open(name string) (fs.File, error) {
+	return v.fsys.Open(v.name(name))
+}
+
+func (v *variantFS) ReadFile(name string) ([]byte, error) {
+	return v.fsys.ReadFile(v.name(name))
+}
+
+// ReadDir leaves out the variants of the other entries, which are opened in their place, so they aren't loaded as
+// partials of their own.
+func (v *variantFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := v.fsys.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		names[entry.Name()] = struct{}{}
+	}
+
+	return slices.DeleteFunc(entries, func(entry fs.DirEntry) bool {
+		base, ok := variantOf(entry.Name())
+		if !ok {
+			return false
+		}
+		_, found := names[base]
+		return found
+	}), nil
+}
+
+// variantOf returns the name of the file name is a variant of, like "index.tmpl" for "index.b.tmpl", and reports
+// whether name is named like a variant.
+func variantOf(name string) (string, bool) {
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	variantExt := path.Ext(stem)
+	if variantExt == "" || variantExt == stem {
+		return "", false
+	}
+
+	return strings.TrimSuffix(stem, variantExt) + ext, true
+}
+
+// hasVariant reports whether fsys has any file of variant.
+func hasVariant(fsys fs.FS, variant string) (bool, error) {
+	found := false
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		if base, ok := variantOf(name); ok && variantName(base, variant) == name {
+			found = true
+			return fs.SkipAll
+		}
+
+		return nil
+	})
+
+	return found, err
+}
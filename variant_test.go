@@ -0,0 +1,97 @@
+package passepartout_test
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+)
+
+func TestPassepartout_RenderVariant(t *testing.T) {
+	pp, err := passepartout.LoadFrom(fstest.MapFS{
+		"layouts/app.tmpl":   {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"layouts/app.c.tmpl": {Data: []byte(`<main class="c">{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":         {Data: []byte(`<h1>Hi</h1>{{ template "index/_item.tmpl" . }}`)},
+		"index.b.tmpl":       {Data: []byte(`<h1>Hello</h1>{{ template "index/_item.tmpl" . }}`)},
+		"index/_item.tmpl":   {Data: []byte(`<p>{{ . }}</p>`)},
+		"index/_item.c.tmpl": {Data: []byte(`<b>{{ . }}</b>`)},
+	}, passepartout.WithTemplateCache())
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		render   func(out *bytes.Buffer) error
+		expected string
+	}{
+		{
+			name:     "renders the variant of the page with the partials of the page",
+			render:   func(out *bytes.Buffer) error { return pp.RenderVariant(out, "index.tmpl", "b", "item") },
+			expected: `<h1>Hello</h1><p>item</p>`,
+		},
+		{
+			name:     "renders the variant of a partial within the page",
+			render:   func(out *bytes.Buffer) error { return pp.RenderVariant(out, "index.tmpl", "c", "item") },
+			expected: `<h1>Hi</h1><b>item</b>`,
+		},
+		{
+			name: "renders the variants of the page and the layout",
+			render: func(out *bytes.Buffer) error {
+				return pp.RenderVariantInLayout(out, "layouts/app.tmpl", "index.tmpl", "c", "item")
+			},
+			expected: `<main class="c"><h1>Hi</h1><b>item</b></main>`,
+		},
+		{
+			name:     "renders the page as it is without a variant",
+			render:   func(out *bytes.Buffer) error { return pp.RenderVariant(out, "index.tmpl", "", "item") },
+			expected: `<h1>Hi</h1><p>item</p>`,
+		},
+		{
+			name:     "doesn't change the renders without a variant",
+			render:   func(out *bytes.Buffer) error { return pp.RenderInLayout(out, "layouts/app.tmpl", "index.tmpl", "item") },
+			expected: `<main><h1>Hi</h1><p>item</p></main>`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+
+			require.NoError(t, tc.render(out))
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+
+	t.Run("returns an error for variants without files", func(t *testing.T) {
+		err := pp.RenderVariant(new(bytes.Buffer), "index.tmpl", "unknown", "item")
+
+		require.EqualError(t, err, `failed to render variant "unknown" of "index.tmpl": no files of the variant: file does not exist`)
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("doesn't load the variant of a partial under its own name", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fstest.MapFS{
+			"index.tmpl":         {Data: []byte(`{{ template "index/_item.c.tmpl" . }}`)},
+			"index/_item.tmpl":   {Data: []byte(`<p>{{ . }}</p>`)},
+			"index/_item.c.tmpl": {Data: []byte(`<b>{{ . }}</b>`)},
+		})
+		require.NoError(t, err)
+
+		err = pp.RenderVariant(new(bytes.Buffer), "index.tmpl", "c", "item")
+
+		require.ErrorContains(t, err, `no such template "index/_item.c.tmpl"`)
+	})
+
+	t.Run("returns an error for variants with dots or slashes", func(t *testing.T) {
+		err := pp.RenderVariant(new(bytes.Buffer), "index.tmpl", "../b", nil)
+
+		require.EqualError(t, err, `failed to render variant "../b" of "index.tmpl": a variant can't contain dots or slashes`)
+	})
+
+	t.Run("fails without a filesystem", func(t *testing.T) {
+		err := passepartout.New(nil).RenderVariant(new(bytes.Buffer), "index.tmpl", "b", nil)
+
+		require.ErrorContains(t, err, "no filesystem, create passepartout with LoadFrom")
+	})
+}
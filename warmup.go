@@ -0,0 +1,56 @@
+package passepartout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// PageLayout is a page to load for [Passepartout.WarmUp], in Layout when it's set.
+type PageLayout struct {
+	Page   string
+	Layout string
+}
+
+// WarmUp loads pages concurrently so the caches of the loader, like [WithTemplateCache] and
+// [ppdefaults.CachedLoader], already hold them when traffic arrives after a deploy. Unlike [Passepartout.Preload] it
+// loads the pages in the layouts they're rendered in, and works for instances not created with [LoadFrom].
+// The errors of the pages that failed to load are returned joined, in the order of pages.
+func (p *Passepartout) WarmUp(ctx context.Context, pages []PageLayout) error {
+	errs := make([]error, len(pages))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(pages)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = p.warmUp(ctx, pages[i])
+			}
+		}()
+	}
+	for i := range pages {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (p *Passepartout) warmUp(ctx context.Context, page PageLayout) error {
+	name := p.resolve(page.Page)
+	if page.Layout == "" {
+		if _, err := p.standalone(ctx, name); err != nil {
+			return fmt.Errorf("failed to warm up %q: %w", page.Page, err)
+		}
+		return nil
+	}
+
+	if _, err := p.inLayout(ctx, name, p.resolve(page.Layout)); err != nil {
+		return fmt.Errorf("failed to warm up %q in layout %q: %w", page.Page, page.Layout, err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package passepartout_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gaqzi/passepartout"
+	"github.com/gaqzi/passepartout/ppdefaults"
+)
+
+func TestPassepartout_WarmUp(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/app.tmpl": {Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.tmpl":       {Data: []byte(`index`)},
+		"about.tmpl":       {Data: []byte(`about`)},
+		"broken.tmpl":      {Data: []byte(`{{ if }}`)},
+	}
+
+	t.Run("loads the pages, in their layouts, into the caches", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fsys, passepartout.WithTemplateCache())
+		require.NoError(t, err)
+
+		err = pp.WarmUp(context.Background(), []passepartout.PageLayout{
+			{Page: "index.tmpl", Layout: "layouts/app.tmpl"},
+			{Page: "about.tmpl", Layout: "layouts/app.tmpl"},
+			{Page: "about.tmpl"},
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, 3, pp.Stats().Templates.Entries)
+	})
+
+	t.Run("fills the file cache of a CachedLoader", func(t *testing.T) {
+		pp := passepartout.New(ppdefaults.NewLoaderBuilder().
+			WithDefaults(fsys).
+			TemplateLoader(ppdefaults.NewCachedLoader(&ppdefaults.TemplateByNameLoader{FS: fsys})).
+			Build())
+
+		require.NoError(t, pp.WarmUp(context.Background(), []passepartout.PageLayout{{Page: "index.tmpl", Layout: "layouts/app.tmpl"}}))
+
+		require.Equal(t, 1, pp.Stats().Files.Entries)
+	})
+
+	t.Run("returns the errors of the pages that fail to load, in order", func(t *testing.T) {
+		pp, err := passepartout.LoadFrom(fsys, passepartout.WithTemplateCache())
+		require.NoError(t, err)
+
+		err = pp.WarmUp(context.Background(), []passepartout.PageLayout{
+			{Page: "broken.tmpl", Layout: "layouts/app.tmpl"},
+			{Page: "index.tmpl"},
+			{Page: "missing.tmpl"},
+		})
+
+		require.ErrorContains(t, err, `failed to warm up "broken.tmpl" in layout "layouts/app.tmpl"`)
+		require.ErrorContains(t, err, `failed to warm up "missing.tmpl"`)
+		require.Less(t, strings.Index(err.Error(), "broken.tmpl"), strings.Index(err.Error(), "missing.tmpl"))
+		require.Equal(t, 1, pp.Stats().Templates.Entries, "expected the pages that loaded to be cached")
+	})
+}